@@ -8,4 +8,5 @@ import (
 //go:embed shared/static/*
 //go:embed projects/*/migrations/*.sql
 //go:embed projects/*/static/*
+//go:embed projects/*/templates/*
 var EmbeddedFiles embed.FS
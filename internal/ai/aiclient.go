@@ -12,26 +12,35 @@ import (
 func geminiAPIKey() (string, error) {
 	apiKey := config.GetEnv("GEMINI_AI_KEY")
 	if apiKey == "" {
-		return "", fmt.Errorf("AI 키 설정을 찾을 수 없습니다")
+		return "", ErrNotConfigured
 	}
 	return apiKey, nil
 }
 
-func Request(ctx context.Context, prompt string, model ...string) (string, error) {
-	modelStr := "gemini-3.1-flash-lite"
-	if len(model) > 0 {
-		modelStr = model[0]
-	}
+func newClient(ctx context.Context) (*genai.Client, error) {
 	apiKey, err := geminiAPIKey()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
 		APIKey:  apiKey,
 		Backend: genai.BackendGeminiAPI,
 	})
 	if err != nil {
-		return "", fmt.Errorf("AI 클라이언트 생성 실패: %w", err)
+		return nil, fmt.Errorf("%w: AI 클라이언트 생성 실패: %w", ErrNotConfigured, err)
+	}
+	return client, nil
+}
+
+// Request는 글로 답을 받는다. 요청한 모델이 실패하면 재시도한 뒤 더 가벼운 모델로 넘어간다.
+func Request(ctx context.Context, prompt string, model ...string) (string, error) {
+	modelStr := "gemini-3.1-flash-lite"
+	if len(model) > 0 {
+		modelStr = model[0]
+	}
+	client, err := newClient(ctx)
+	if err != nil {
+		return "", err
 	}
 
 	// gemini-3.5-flash
@@ -40,38 +49,34 @@ func Request(ctx context.Context, prompt string, model ...string) (string, error
 	// gemini-2.5-pro
 	// gemini-2.5-flash
 	// gemini-2.5-flash-lite
-	result, err := client.Models.GenerateContent(ctx, modelStr, genai.Text(prompt), nil)
+	result, err := generate(ctx, "request", modelChain(modelStr, options().Fallbacks), func(ctx context.Context, model string) (*genai.GenerateContentResponse, error) {
+		return client.Models.GenerateContent(ctx, model, genai.Text(prompt), nil)
+	})
 	if err != nil {
-		return "", fmt.Errorf("AI 요청 실패: %w", err)
+		return "", err
 	}
 
-	resultText := result.Candidates[0].Content.Parts[0].Text
-
-	return resultText, nil
+	return result.Text(), nil
 }
 
+// ImageRequest는 이미지를 base64로 받는다. 이미지 모델은 대신할 모델이 없어 재시도만 한다.
 func ImageRequest(ctx context.Context, prompt string, model ...string) (string, error) {
 	modelStr := "gemini-2.0-flash-preview-image-generation"
 	if len(model) > 0 {
 		modelStr = model[0]
 	}
-	apiKey, err := geminiAPIKey()
+	client, err := newClient(ctx)
 	if err != nil {
 		return "", err
 	}
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  apiKey,
-		Backend: genai.BackendGeminiAPI,
-	})
-	if err != nil {
-		return "", fmt.Errorf("AI 클라이언트 생성 실패: %w", err)
-	}
 
-	result, err := client.Models.GenerateContent(ctx, modelStr, genai.Text(prompt), &genai.GenerateContentConfig{
-		ResponseModalities: []string{"Text", "Image"},
+	result, err := generate(ctx, "image", []string{modelStr}, func(ctx context.Context, model string) (*genai.GenerateContentResponse, error) {
+		return client.Models.GenerateContent(ctx, model, genai.Text(prompt), &genai.GenerateContentConfig{
+			ResponseModalities: []string{"Text", "Image"},
+		})
 	})
 	if err != nil {
-		return "", fmt.Errorf("이미지 생성 요청 실패: %w", err)
+		return "", err
 	}
 
 	for _, part := range result.Candidates[0].Content.Parts {
@@ -80,5 +85,5 @@ func ImageRequest(ctx context.Context, prompt string, model ...string) (string,
 		}
 	}
 
-	return "", fmt.Errorf("이미지 생성 실패")
+	return "", fmt.Errorf("%w: 이미지가 없습니다", ErrEmptyResponse)
 }
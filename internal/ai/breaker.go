@@ -0,0 +1,86 @@
+package aiclient
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker는 모델별 회로 차단기다. 연속으로 threshold번 실패하면 cooldown 동안 요청을 보내지 않고 바로 실패한다.
+// cooldown이 지나면 요청 하나만 시험 삼아 보내고, 성공하면 닫고 실패하면 다시 연다.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow는 지금 요청을 보내도 되는지 알려준다.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+	b.probing = false
+}
+
+// release는 성공도 실패도 아닌 채로 끝난 시험 요청을 돌려놓는다.
+// 호출자가 요청을 취소했거나 거부된 응답처럼 모델 상태를 알 수 없는 경우다. 그대로 두면 다음 시험 요청을 보낼 수 없다.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// open은 차단 중인지 알려준다. 시험 요청을 보낼 수 있는 상태도 차단 중으로 본다.
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = make(map[string]*breaker)
+)
+
+func breakerFor(model string) *breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[model]
+	if !ok {
+		opts := options()
+		b = newBreaker(opts.BreakerThreshold, opts.BreakerCooldown)
+		breakers[model] = b
+	}
+	return b
+}
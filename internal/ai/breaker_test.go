@@ -0,0 +1,61 @@
+package aiclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	b := newBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	if !b.allow() {
+		t.Fatal("breaker should stay closed below threshold")
+	}
+	b.failure()
+	if b.allow() {
+		t.Fatal("breaker should open at threshold")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("breaker should allow one probe after cooldown")
+	}
+	if b.allow() {
+		t.Fatal("breaker should allow only one probe at a time")
+	}
+	b.failure()
+	if b.allow() {
+		t.Fatal("failed probe should reopen breaker")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("breaker should allow probe after second cooldown")
+	}
+	b.success()
+	if !b.allow() || b.open() {
+		t.Fatal("successful probe should close breaker")
+	}
+}
+
+func TestBreakerRelease(t *testing.T) {
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	b := newBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("breaker should allow one probe after cooldown")
+	}
+	b.release()
+	if !b.open() {
+		t.Fatal("released probe should keep breaker open")
+	}
+	if !b.allow() {
+		t.Fatal("released probe should let the next probe through")
+	}
+}
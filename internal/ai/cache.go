@@ -0,0 +1,214 @@
+package aiclient
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+const (
+	// DefaultCacheTTL은 캐시한 응답을 다시 쓰는 기간이다.
+	DefaultCacheTTL = 24 * time.Hour
+	// defaultCacheSize는 메모리 LRU에 들고 있는 응답 수다.
+	defaultCacheSize = 512
+)
+
+// CacheStore는 메모리 캐시 뒤에 두는 저장소다. 서버를 다시 띄워도 캐시를 이어 쓰고 싶을 때 붙인다.
+// Get은 만료된 항목을 없는 것으로 보고, 찾은 항목은 저장할 때 받은 만료 시각과 함께 반환한다.
+type CacheStore interface {
+	Get(ctx context.Context, key string, now time.Time) (string, time.Time, bool, error)
+	Set(ctx context.Context, key, value string, expires time.Time) error
+}
+
+// cacheStats는 /debug/vars의 ai_cache로 캐시 적중률을 보여준다.
+var cacheStats = expvar.NewMap("ai_cache")
+
+// Cache는 같은 모델, 프롬프트, 옵션의 응답을 TTL 동안 다시 쓴다. 메모리 LRU를 먼저 보고 없으면 store를 본다.
+type Cache struct {
+	ttl   time.Duration
+	size  int
+	store CacheStore
+	now   func() time.Time
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	value   string
+	expires time.Time
+}
+
+// NewCache는 size개까지 들고 있는 캐시를 만든다. store가 nil이면 메모리에만 둔다.
+func NewCache(size int, ttl time.Duration, store CacheStore) *Cache {
+	return &Cache{
+		ttl:   ttl,
+		size:  size,
+		store: store,
+		now:   time.Now,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// CacheKey는 모델, 프롬프트, 옵션으로 캐시 키를 만든다. 프롬프트 원문은 키에 남기지 않는다.
+func CacheKey(model, prompt string, params ...string) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	for _, s := range append([]string{prompt}, params...) {
+		h.Write([]byte{0})
+		h.Write([]byte(s))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get은 캐시한 응답을 반환한다. store에서 찾은 응답은 메모리에도 올린다.
+func (c *Cache) Get(ctx context.Context, key string) (string, bool) {
+	now := c.now()
+
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*cacheEntry)
+		if now.Before(entry.expires) {
+			c.order.MoveToFront(el)
+			c.mu.Unlock()
+			cacheStats.Add("memory_hits", 1)
+			return entry.value, true
+		}
+		c.removeElement(el)
+	}
+	c.mu.Unlock()
+
+	if c.store != nil {
+		value, expires, ok, err := c.store.Get(ctx, key, now)
+		if err != nil {
+			slog.Warn("AI 캐시 조회 실패", "error", err)
+		}
+		if ok {
+			cacheStats.Add("store_hits", 1)
+			// 메모리에는 store의 만료 시각까지만 둔다. 새로 TTL을 주면 만료 직전 항목이 TTL만큼 더 살아남는다.
+			c.put(key, value, expires)
+			return value, true
+		}
+	}
+	cacheStats.Add("misses", 1)
+	return "", false
+}
+
+// Set은 응답을 캐시한다.
+func (c *Cache) Set(ctx context.Context, key, value string) {
+	expires := c.now().Add(c.ttl)
+	c.put(key, value, expires)
+	if c.store != nil {
+		if err := c.store.Set(ctx, key, value, expires); err != nil {
+			slog.Warn("AI 캐시 저장 실패", "error", err)
+		}
+	}
+}
+
+func (c *Cache) put(key, value string, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value = &cacheEntry{key: key, value: value, expires: expires}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+		cacheStats.Add("evictions", 1)
+	}
+}
+
+func (c *Cache) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*cacheEntry).key)
+}
+
+// Len은 메모리에 들고 있는 응답 수다.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+var (
+	defaultCacheMu sync.Mutex
+	defaultCache   = NewCache(defaultCacheSize, DefaultCacheTTL, nil)
+)
+
+func init() {
+	cacheStats.Set("entries", expvar.Func(func() any { return sharedCache().Len() }))
+}
+
+// SetCacheStore는 공용 캐시 뒤에 store를 붙인다. 서버를 시작할 때 한 번 부른다.
+func SetCacheStore(store CacheStore) {
+	defaultCacheMu.Lock()
+	defer defaultCacheMu.Unlock()
+	defaultCache = NewCache(defaultCacheSize, DefaultCacheTTL, store)
+}
+
+func sharedCache() *Cache {
+	defaultCacheMu.Lock()
+	defer defaultCacheMu.Unlock()
+	return defaultCache
+}
+
+// CachedRequest는 Request와 같지만 같은 모델과 프롬프트의 응답을 캐시에서 꺼내 쓴다.
+// 같은 입력이면 같은 답이어도 되는 요청에만 쓴다.
+func CachedRequest(ctx context.Context, prompt string, model ...string) (string, error) {
+	modelStr := "gemini-3.1-flash-lite"
+	if len(model) > 0 {
+		modelStr = model[0]
+	}
+	cache := sharedCache()
+	key := CacheKey(modelStr, prompt, "request")
+	if value, ok := cache.Get(ctx, key); ok {
+		return value, nil
+	}
+	value, err := Request(ctx, prompt, modelStr)
+	if err != nil {
+		return "", err
+	}
+	cache.Set(ctx, key, value)
+	return value, nil
+}
+
+// CachedRequestJSON은 RequestJSON과 같지만 같은 모델, 프롬프트, 스키마의 응답을 캐시에서 꺼내 쓴다.
+func CachedRequestJSON(ctx context.Context, prompt string, schema *genai.Schema, out any, model ...string) error {
+	modelStr := "gemini-3.1-flash-lite"
+	if len(model) > 0 {
+		modelStr = model[0]
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	cache := sharedCache()
+	key := CacheKey(modelStr, prompt, "json", string(schemaJSON))
+	if value, ok := cache.Get(ctx, key); ok {
+		if err := decodeJSON(value, out); err == nil {
+			return nil
+		}
+	}
+	if err := RequestJSON(ctx, prompt, schema, out, modelStr); err != nil {
+		return err
+	}
+	value, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	cache.Set(ctx, key, string(value))
+	return nil
+}
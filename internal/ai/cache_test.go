@@ -0,0 +1,120 @@
+package aiclient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string, now time.Time) (string, time.Time, bool, error) {
+	v, ok := s.values[key]
+	if !ok || !now.Before(s.expires[key]) {
+		return "", time.Time{}, false, nil
+	}
+	return v, s.expires[key], true, nil
+}
+
+func (s *memoryStore) Set(ctx context.Context, key, value string, expires time.Time) error {
+	s.values[key] = value
+	s.expires[key] = expires
+	return nil
+}
+
+func TestCacheLRU(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(2, time.Hour, nil)
+	c.Set(ctx, "a", "1")
+	c.Set(ctx, "b", "2")
+	c.Get(ctx, "a")
+	c.Set(ctx, "c", "3")
+
+	if _, ok := c.Get(ctx, "b"); ok {
+		t.Error("least recently used entry should be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(ctx, key); !ok {
+			t.Errorf("Get(%q) should hit", key)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	c := NewCache(10, time.Hour, nil)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "a", "1")
+	now = now.Add(59 * time.Minute)
+	if v, ok := c.Get(ctx, "a"); !ok || v != "1" {
+		t.Errorf("Get() before TTL = %q, %v", v, ok)
+	}
+	now = now.Add(time.Minute)
+	if _, ok := c.Get(ctx, "a"); ok {
+		t.Error("Get() after TTL should miss")
+	}
+	if c.Len() != 0 {
+		t.Errorf("expired entry should be removed, Len() = %d", c.Len())
+	}
+}
+
+func TestCacheStore(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{values: map[string]string{}, expires: map[string]time.Time{}}
+
+	NewCache(10, time.Hour, store).Set(ctx, "a", "1")
+
+	// 메모리가 빈 새 캐시도 store에서 찾아 쓴다.
+	c := NewCache(10, time.Hour, store)
+	if v, ok := c.Get(ctx, "a"); !ok || v != "1" {
+		t.Errorf("Get() from store = %q, %v", v, ok)
+	}
+	if c.Len() != 1 {
+		t.Error("store hit should be loaded into memory")
+	}
+}
+
+func TestCacheStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	store := &memoryStore{values: map[string]string{}, expires: map[string]time.Time{}}
+	store.Set(ctx, "a", "1", now.Add(time.Minute))
+
+	c := NewCache(10, time.Hour, store)
+	c.now = func() time.Time { return now }
+	if _, ok := c.Get(ctx, "a"); !ok {
+		t.Fatal("Get() before store expiry should hit")
+	}
+
+	// 메모리에 올린 항목도 store의 만료 시각을 따른다.
+	now = now.Add(time.Minute)
+	if _, ok := c.Get(ctx, "a"); ok {
+		t.Error("Get() after store expiry should miss")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	base := CacheKey("m", "prompt", "json")
+	if base != CacheKey("m", "prompt", "json") {
+		t.Error("CacheKey should be deterministic")
+	}
+	for _, other := range []string{
+		CacheKey("m2", "prompt", "json"),
+		CacheKey("m", "prompt2", "json"),
+		CacheKey("m", "prompt", "request"),
+		CacheKey("m", "prompt", "json", "schema"),
+		CacheKey("m", "prom", "ptjson"),
+	} {
+		if other == base {
+			t.Error("CacheKey should differ for different model, prompt or params")
+		}
+	}
+}
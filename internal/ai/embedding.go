@@ -0,0 +1,198 @@
+package aiclient
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"simple-server/internal/config"
+
+	"google.golang.org/genai"
+)
+
+// EmbedTask는 임베딩을 어디에 쓸지 알려준다. 검색용 모델은 문서와 질의를 다르게 임베딩한다.
+type EmbedTask string
+
+const (
+	EmbedDocument EmbedTask = "RETRIEVAL_DOCUMENT"
+	EmbedQuery    EmbedTask = "RETRIEVAL_QUERY"
+)
+
+const (
+	geminiEmbeddingModel = "gemini-embedding-001"
+	geminiEmbeddingDim   = 768
+	localEmbeddingDim    = 256
+)
+
+// Embedder는 글을 벡터로 바꾸는 임베딩 제공자다.
+// 모델이 다르면 벡터끼리 비교할 수 없으므로 저장할 때 Model도 함께 남긴다.
+type Embedder interface {
+	Model() string
+	Embed(ctx context.Context, task EmbedTask, texts ...string) ([][]float32, error)
+}
+
+// DefaultEmbedder는 AI 키가 있으면 Gemini 임베딩을, 없거나 EMBEDDING_PROVIDER=local이면 로컬 임베딩을 쓴다.
+func DefaultEmbedder() Embedder {
+	if config.GetEnv("EMBEDDING_PROVIDER") == "local" || config.GetEnv("GEMINI_AI_KEY") == "" {
+		return LocalEmbedder{Dim: localEmbeddingDim}
+	}
+	return GeminiEmbedder{}
+}
+
+// GeminiEmbedder는 Gemini 임베딩 API를 쓴다.
+type GeminiEmbedder struct{}
+
+func (GeminiEmbedder) Model() string {
+	return fmt.Sprintf("%s-%d", geminiEmbeddingModel, geminiEmbeddingDim)
+}
+
+func (GeminiEmbedder) Embed(ctx context.Context, task EmbedTask, texts ...string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	apiKey, err := geminiAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("AI 클라이언트 생성 실패: %w", err)
+	}
+
+	contents := make([]*genai.Content, 0, len(texts))
+	for _, text := range texts {
+		contents = append(contents, genai.NewContentFromText(text, genai.RoleUser))
+	}
+	dim := int32(geminiEmbeddingDim)
+	result, err := client.Models.EmbedContent(ctx, geminiEmbeddingModel, contents, &genai.EmbedContentConfig{
+		TaskType:             string(task),
+		OutputDimensionality: &dim,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("임베딩 요청 실패: %w", err)
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("임베딩 응답 개수가 맞지 않습니다: %d/%d", len(result.Embeddings), len(texts))
+	}
+
+	vectors := make([][]float32, 0, len(texts))
+	for _, e := range result.Embeddings {
+		if e == nil || len(e.Values) == 0 {
+			return nil, fmt.Errorf("임베딩 응답이 비어 있습니다")
+		}
+		vectors = append(vectors, Normalize(e.Values))
+	}
+	return vectors, nil
+}
+
+// LocalEmbedder는 외부 API 없이 2글자 조각을 해시해 벡터를 만든다.
+// 의미보다는 겹치는 표현을 잡아내지만, 같은 글에는 항상 같은 벡터가 나와 키 없는 환경과 테스트에 쓴다.
+type LocalEmbedder struct {
+	Dim int
+}
+
+func (e LocalEmbedder) Model() string {
+	return fmt.Sprintf("local-bigram-%d", e.Dim)
+}
+
+func (e LocalEmbedder) Embed(_ context.Context, _ EmbedTask, texts ...string) ([][]float32, error) {
+	if e.Dim <= 0 {
+		return nil, fmt.Errorf("임베딩 차원이 올바르지 않습니다: %d", e.Dim)
+	}
+	vectors := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		v := make([]float32, e.Dim)
+		for _, gram := range localGrams(text) {
+			h := fnv.New64a()
+			h.Write([]byte(gram))
+			sum := h.Sum64()
+			sign := float32(1)
+			if sum>>63 == 1 {
+				sign = -1
+			}
+			v[sum%uint64(e.Dim)] += sign
+		}
+		vectors = append(vectors, Normalize(v))
+	}
+	return vectors, nil
+}
+
+// localGrams는 글자와 숫자로 이뤄진 낱말을 소문자 2글자 조각으로 나눈다. 한 글자 낱말은 그대로 쓴다.
+func localGrams(text string) []string {
+	var grams []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(word)
+		if len(runes) == 1 {
+			grams = append(grams, word)
+			continue
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			grams = append(grams, string(runes[i:i+2]))
+		}
+	}
+	return grams
+}
+
+// Normalize는 벡터를 길이 1로 맞춘다. 정규화한 벡터끼리는 내적이 코사인 유사도다.
+func Normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+// Cosine은 두 벡터의 코사인 유사도를 반환한다. 길이가 다르거나 영벡터면 0이다.
+func Cosine(a, b []float32) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
+
+// EncodeVector는 벡터를 리틀 엔디언 float32 바이트로 바꿔 BLOB으로 저장할 수 있게 한다.
+func EncodeVector(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(x))
+	}
+	return b
+}
+
+// DecodeVector는 EncodeVector로 저장한 BLOB을 벡터로 되돌린다.
+func DecodeVector(b []byte) ([]float32, error) {
+	if len(b)%4 != 0 {
+		return nil, fmt.Errorf("임베딩 길이가 올바르지 않습니다: %d", len(b))
+	}
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v, nil
+}
@@ -0,0 +1,66 @@
+package aiclient
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestLocalEmbedder(t *testing.T) {
+	e := LocalEmbedder{Dim: 64}
+	vectors, err := e.Embed(context.Background(), EmbedDocument, "회사 일로 너무 지쳤다", "회사 일로 너무 지쳤다", "바다에 놀러 갔다")
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if len(vectors) != 3 || len(vectors[0]) != 64 {
+		t.Fatalf("Embed() returned %d vectors of %d", len(vectors), len(vectors[0]))
+	}
+	if !reflect.DeepEqual(vectors[0], vectors[1]) {
+		t.Error("Embed() is not deterministic")
+	}
+	if got := Cosine(vectors[0], vectors[1]); math.Abs(float64(got)-1) > 1e-5 {
+		t.Errorf("Cosine(same) = %v", got)
+	}
+
+	query, _ := e.Embed(context.Background(), EmbedQuery, "회사 때문에 지쳤어")
+	if Cosine(query[0], vectors[0]) <= Cosine(query[0], vectors[2]) {
+		t.Error("similar text should score higher")
+	}
+
+	if _, err := (LocalEmbedder{}).Embed(context.Background(), EmbedDocument, "a"); err == nil {
+		t.Error("Embed() with zero dim expected error")
+	}
+}
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float32
+	}{
+		{name: "same", a: []float32{1, 0}, b: []float32{2, 0}, want: 1},
+		{name: "orthogonal", a: []float32{1, 0}, b: []float32{0, 1}, want: 0},
+		{name: "opposite", a: []float32{1, 0}, b: []float32{-1, 0}, want: -1},
+		{name: "zero", a: []float32{0, 0}, b: []float32{1, 0}, want: 0},
+		{name: "length mismatch", a: []float32{1}, b: []float32{1, 0}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Cosine(tt.a, tt.b); math.Abs(float64(got-tt.want)) > 1e-6 {
+				t.Errorf("Cosine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVectorEncoding(t *testing.T) {
+	v := []float32{0, 1.5, -2.25, float32(math.Pi)}
+	got, err := DecodeVector(EncodeVector(v))
+	if err != nil || !reflect.DeepEqual(got, v) {
+		t.Fatalf("DecodeVector(EncodeVector()) = %v, %v", got, err)
+	}
+	if _, err := DecodeVector([]byte{1, 2, 3}); err == nil {
+		t.Error("DecodeVector() with bad length expected error")
+	}
+}
@@ -0,0 +1,76 @@
+package aiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/genai"
+)
+
+// AI 요청이 실패한 이유다. 핸들러는 HTTPError로 사용자에게 보여줄 문구를 고른다.
+var (
+	ErrNotConfigured  = errors.New("AI 키 설정을 찾을 수 없습니다")
+	ErrUnavailable    = errors.New("AI 서비스를 사용할 수 없습니다")
+	ErrRateLimited    = errors.New("AI 요청 한도를 넘었습니다")
+	ErrTimeout        = errors.New("AI 응답 시간이 초과되었습니다")
+	ErrBlocked        = errors.New("AI가 응답을 거부했습니다")
+	ErrEmptyResponse  = errors.New("AI 응답을 읽을 수 없습니다")
+	ErrInvalidRequest = errors.New("잘못된 AI 요청입니다")
+)
+
+// classify는 genai 오류를 위 오류 중 하나로 감싼다. 모르는 오류는 ErrUnavailable로 본다.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, known := range []error{ErrNotConfigured, ErrUnavailable, ErrRateLimited, ErrTimeout, ErrBlocked, ErrEmptyResponse, ErrInvalidRequest} {
+		if errors.Is(err, known) {
+			return err
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == http.StatusTooManyRequests:
+			return fmt.Errorf("%w: %w", ErrRateLimited, err)
+		case apiErr.Code == http.StatusRequestTimeout || apiErr.Code == http.StatusGatewayTimeout:
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		case apiErr.Code >= 500:
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		case apiErr.Code >= 400:
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+	}
+	return fmt.Errorf("%w: %w", ErrUnavailable, err)
+}
+
+// retryable은 같은 모델로 다시 요청하거나 다른 모델로 넘어가 볼 만한 오류인지 알려준다.
+func retryable(err error) bool {
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, ErrRateLimited) || errors.Is(err, ErrTimeout)
+}
+
+// HTTPError는 AI 요청 오류를 사용자에게 보여줄 HTTP 오류로 바꾼다.
+func HTTPError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return err
+	case errors.Is(err, ErrNotConfigured):
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "AI 기능이 설정되어 있지 않습니다.")
+	case errors.Is(err, ErrRateLimited):
+		return echo.NewHTTPError(http.StatusTooManyRequests, "요청이 많아요. 잠시 후 다시 시도해주세요.")
+	case errors.Is(err, ErrTimeout):
+		return echo.NewHTTPError(http.StatusGatewayTimeout, "AI 응답이 늦어지고 있어요. 잠시 후 다시 시도해주세요.")
+	case errors.Is(err, ErrBlocked), errors.Is(err, ErrEmptyResponse):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "AI가 답변을 만들지 못했어요. 내용을 바꿔 다시 시도해주세요.")
+	case errors.Is(err, ErrInvalidRequest):
+		return echo.NewHTTPError(http.StatusBadRequest, "AI 요청을 처리할 수 없습니다.")
+	default:
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "AI 서비스가 잠시 불안정해요. 잠시 후 다시 시도해주세요.")
+	}
+}
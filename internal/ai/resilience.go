@@ -0,0 +1,257 @@
+package aiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"simple-server/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/genai"
+)
+
+// Options는 AI 요청의 재시도, 시간 제한, 회로 차단 설정이다.
+type Options struct {
+	// MaxRetries는 모델마다 처음 요청 뒤에 다시 보내는 횟수다.
+	MaxRetries int
+	// Timeout은 요청 한 번의 시간 제한이다.
+	Timeout time.Duration
+	// BaseBackoff는 첫 재시도 전 기다리는 시간이다. 재시도마다 두 배가 되고 0~50% 지터를 더한다.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// BreakerThreshold번 연속 실패한 모델은 BreakerCooldown 동안 건너뛴다.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// Fallbacks는 요청한 모델이 실패했을 때 차례로 시도할 모델이다. 요청한 모델보다 비싼 등급은 건너뛴다.
+	Fallbacks []string
+}
+
+var (
+	optionsOnce sync.Once
+	opts        Options
+)
+
+// options는 환경 변수에서 설정을 한 번 읽는다.
+// AI_MAX_RETRIES, AI_TIMEOUT, AI_BREAKER_THRESHOLD, AI_BREAKER_COOLDOWN, AI_FALLBACK_MODELS를 쓴다.
+func options() Options {
+	optionsOnce.Do(func() {
+		opts = Options{
+			MaxRetries:       envInt("AI_MAX_RETRIES", 2),
+			Timeout:          envDuration("AI_TIMEOUT", 90*time.Second),
+			BaseBackoff:      500 * time.Millisecond,
+			MaxBackoff:       8 * time.Second,
+			BreakerThreshold: envInt("AI_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  envDuration("AI_BREAKER_COOLDOWN", 30*time.Second),
+			Fallbacks:        []string{"gemini-2.5-pro", "gemini-2.5-flash", "gemini-2.5-flash-lite"},
+		}
+		if v := config.GetEnv("AI_FALLBACK_MODELS"); v != "" {
+			opts.Fallbacks = nil
+			for _, m := range strings.Split(v, ",") {
+				if m = strings.TrimSpace(m); m != "" {
+					opts.Fallbacks = append(opts.Fallbacks, m)
+				}
+			}
+		}
+	})
+	return opts
+}
+
+func envInt(key string, fallback int) int {
+	v, err := strconv.Atoi(config.GetEnv(key))
+	if err != nil || v < 0 {
+		return fallback
+	}
+	return v
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	v, err := time.ParseDuration(config.GetEnv(key))
+	if err != nil || v <= 0 {
+		return fallback
+	}
+	return v
+}
+
+// tier는 모델 등급이다. pro, flash, flash-lite 순으로 낮아진다.
+func tier(model string) int {
+	switch {
+	case strings.Contains(model, "flash-lite"):
+		return 1
+	case strings.Contains(model, "flash"):
+		return 2
+	case strings.Contains(model, "pro"):
+		return 3
+	default:
+		return 0
+	}
+}
+
+// modelChain은 model을 먼저 시도하고 실패하면 넘어갈 모델 목록이다.
+func modelChain(model string, fallbacks []string) []string {
+	chain := []string{model}
+	for _, m := range fallbacks {
+		if m != model && tier(m) <= tier(model) {
+			chain = append(chain, m)
+		}
+	}
+	return chain
+}
+
+// backoff는 attempt번째 재시도 전에 기다릴 시간이다.
+func backoff(o Options, attempt int) time.Duration {
+	d := o.BaseBackoff << attempt
+	if d > o.MaxBackoff || d <= 0 {
+		d = o.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d + rand.N(d/2+1)
+}
+
+var (
+	tracer = otel.Tracer("simple-server/internal/ai")
+	meter  = otel.Meter("simple-server/internal/ai")
+
+	requestCounter, _   = meter.Int64Counter("ai.requests", metric.WithDescription("AI 모델 요청 수"))
+	requestDuration, _  = meter.Float64Histogram("ai.request.duration", metric.WithUnit("s"), metric.WithDescription("AI 모델 요청 시간"))
+	tokenCounter, _     = meter.Int64Counter("ai.tokens", metric.WithDescription("AI 모델 토큰 사용량"))
+	breakerOpenCount, _ = meter.Int64Counter("ai.breaker.rejected", metric.WithDescription("회로 차단으로 건너뛴 요청 수"))
+)
+
+// generateFunc는 모델 하나에 요청을 한 번 보낸다.
+type generateFunc func(ctx context.Context, model string) (*genai.GenerateContentResponse, error)
+
+// generate는 chain의 모델을 차례로 시도한다. 모델마다 재시도할 만한 오류면 지터를 둔 백오프로 다시 보내고,
+// 그래도 실패하거나 회로가 열려 있으면 다음 모델로 넘어간다. 잘못된 요청이나 거부된 응답은 바로 반환한다.
+func generate(ctx context.Context, operation string, chain []string, fn generateFunc) (*genai.GenerateContentResponse, error) {
+	o := options()
+	var lastErr error
+	for _, model := range chain {
+		b := breakerFor(model)
+		for attempt := 0; attempt <= o.MaxRetries; attempt++ {
+			if attempt > 0 {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(backoff(o, attempt-1)):
+				}
+			}
+			if !b.allow() {
+				breakerOpenCount.Add(ctx, 1, metric.WithAttributes(attribute.String("ai.model", model)))
+				lastErr = fmt.Errorf("%w: %s 모델 회로 차단 중", ErrUnavailable, model)
+				break
+			}
+
+			result, err := attemptGenerate(ctx, operation, model, attempt, o.Timeout, fn)
+			if err == nil {
+				b.success()
+				return result, nil
+			}
+			if ctx.Err() != nil {
+				b.release()
+				return nil, ctx.Err()
+			}
+			if !retryable(err) {
+				b.release()
+				return nil, err
+			}
+			b.failure()
+			lastErr = err
+			slog.Warn("AI 요청 실패", "model", model, "operation", operation, "attempt", attempt, "error", err)
+		}
+	}
+	if lastErr == nil {
+		lastErr = ErrUnavailable
+	}
+	return nil, lastErr
+}
+
+func attemptGenerate(ctx context.Context, operation, model string, attempt int, timeout time.Duration, fn generateFunc) (*genai.GenerateContentResponse, error) {
+	ctx, span := tracer.Start(ctx, "ai."+operation)
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("gen_ai.system", "gemini"),
+		attribute.String("gen_ai.request.model", model),
+		attribute.Int("ai.attempt", attempt),
+	)
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result, err := fn(callCtx, model)
+	if err == nil {
+		err = checkResponse(result)
+	}
+	err = classify(err)
+
+	outcome := "ok"
+	if err != nil {
+		outcome = outcomeOf(err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, outcome)
+	}
+	attrs := metric.WithAttributes(
+		attribute.String("ai.model", model),
+		attribute.String("ai.operation", operation),
+		attribute.String("ai.outcome", outcome),
+	)
+	requestCounter.Add(ctx, 1, attrs)
+	requestDuration.Record(ctx, time.Since(start).Seconds(), attrs)
+
+	if err == nil && result.UsageMetadata != nil {
+		usage := result.UsageMetadata
+		span.SetAttributes(
+			attribute.Int("gen_ai.usage.input_tokens", int(usage.PromptTokenCount)),
+			attribute.Int("gen_ai.usage.output_tokens", int(usage.CandidatesTokenCount)),
+		)
+		tokenCounter.Add(ctx, int64(usage.PromptTokenCount), metric.WithAttributes(attribute.String("ai.model", model), attribute.String("ai.token_type", "input")))
+		tokenCounter.Add(ctx, int64(usage.CandidatesTokenCount), metric.WithAttributes(attribute.String("ai.model", model), attribute.String("ai.token_type", "output")))
+	}
+	return result, err
+}
+
+// checkResponse는 후보가 없거나 내용이 빈 응답을 오류로 바꾼다.
+func checkResponse(result *genai.GenerateContentResponse) error {
+	if result == nil {
+		return ErrEmptyResponse
+	}
+	if result.PromptFeedback != nil && result.PromptFeedback.BlockReason != "" {
+		return fmt.Errorf("%w: %s", ErrBlocked, result.PromptFeedback.BlockReason)
+	}
+	if len(result.Candidates) == 0 || result.Candidates[0].Content == nil || len(result.Candidates[0].Content.Parts) == 0 {
+		if len(result.Candidates) > 0 && result.Candidates[0].FinishReason == genai.FinishReasonSafety {
+			return fmt.Errorf("%w: %s", ErrBlocked, genai.FinishReasonSafety)
+		}
+		return ErrEmptyResponse
+	}
+	return nil
+}
+
+func outcomeOf(err error) string {
+	switch {
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrTimeout):
+		return "timeout"
+	case errors.Is(err, ErrBlocked):
+		return "blocked"
+	case errors.Is(err, ErrEmptyResponse):
+		return "empty"
+	case errors.Is(err, ErrInvalidRequest):
+		return "invalid"
+	default:
+		return "unavailable"
+	}
+}
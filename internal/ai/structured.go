@@ -0,0 +1,52 @@
+package aiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// RequestJSON은 schema 형식의 JSON으로만 답하게 요청하고 결과를 out에 채운다.
+// 응답에서 HTML이나 목록을 정규식으로 긁어내지 않고 필드 단위로 받을 때 쓴다.
+func RequestJSON(ctx context.Context, prompt string, schema *genai.Schema, out any, model ...string) error {
+	modelStr := "gemini-3.1-flash-lite"
+	if len(model) > 0 {
+		modelStr = model[0]
+	}
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := generate(ctx, "json", modelChain(modelStr, options().Fallbacks), func(ctx context.Context, model string) (*genai.GenerateContentResponse, error) {
+		return client.Models.GenerateContent(ctx, model, genai.Text(prompt), &genai.GenerateContentConfig{
+			ResponseMIMEType: "application/json",
+			ResponseSchema:   schema,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return decodeJSON(result.Text(), out)
+}
+
+// decodeJSON은 JSON 응답을 out에 채운다. 모델이 코드 블록으로 감싸 보내도 읽는다.
+func decodeJSON(text string, out any) error {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(text, "```")
+	}
+	dec := json.NewDecoder(strings.NewReader(text))
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("%w: 형식 오류: %w", ErrEmptyResponse, err)
+	}
+	if dec.More() {
+		return fmt.Errorf("%w: JSON 뒤에 다른 내용이 있습니다", ErrEmptyResponse)
+	}
+	return nil
+}
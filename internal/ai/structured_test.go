@@ -0,0 +1,34 @@
+package aiclient
+
+import "testing"
+
+func TestDecodeJSON(t *testing.T) {
+	type topics struct {
+		Topics []string `json:"topics"`
+	}
+	tests := []struct {
+		name    string
+		text    string
+		want    int
+		wantErr bool
+	}{
+		{name: "plain", text: `{"topics":["Go","SQL"]}`, want: 2},
+		{name: "fenced", text: "```json\n{\"topics\":[\"Go\"]}\n```", want: 1},
+		{name: "html", text: `<ol><li>Go</li></ol>`, wantErr: true},
+		{name: "trailing text", text: `{"topics":["Go"]} 그리고 <script>alert(1)</script>`, wantErr: true},
+		{name: "wrong type", text: `{"topics":"<script>alert(1)</script>"}`, wantErr: true},
+		{name: "empty", text: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out topics
+			err := decodeJSON(tt.text, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeJSON(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			}
+			if err == nil && len(out.Topics) != tt.want {
+				t.Errorf("decodeJSON(%q) = %v, want %d topics", tt.text, out.Topics, tt.want)
+			}
+		})
+	}
+}
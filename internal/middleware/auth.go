@@ -8,6 +8,7 @@ import (
 	"simple-server/internal/config"
 	"simple-server/internal/connection"
 	"simple-server/pkg/util/authutil"
+	"time"
 
 	resources "simple-server"
 
@@ -68,7 +69,8 @@ func RegisterFirebaseAuthMiddleware(e *echo.Echo, ensureUserFn func(ctx context.
 			return err
 		}
 
-		token, err := auth.VerifyIDToken(ctx, req.Token)
+		// 강제 로그아웃으로 토큰이 취소되었으면 취소 전에 받은 토큰으로는 세션을 다시 만들 수 없다.
+		token, err := auth.VerifyIDTokenAndCheckRevoked(ctx, req.Token)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusUnauthorized, "유효하지 않은 사용자입니다.")
 		}
@@ -86,6 +88,7 @@ func RegisterFirebaseAuthMiddleware(e *echo.Echo, ensureUserFn func(ctx context.
 			SameSite: http.SameSiteLaxMode,
 		}
 		sess.Values["uid"] = token.UID
+		sess.Values["iat"] = time.Now().Unix()
 
 		if err := sess.Save(c.Request(), c.Response()); err != nil {
 			return err
@@ -72,7 +72,11 @@ func RegisterCommonMiddleware(e *echo.Echo) error {
 	e.Use(middleware.BodyLimit("5M"))
 
 	// 6) CSRF는 본문 파싱/쿠키 세팅 이후
+	// /api/ 경로는 쿠키 대신 Authorization 헤더의 토큰으로만 인증하므로 CSRF 검사에서 제외한다.
 	e.Use(middleware.CSRFWithConfig(middleware.CSRFConfig{
+		Skipper: func(c echo.Context) bool {
+			return strings.HasPrefix(c.Request().URL.Path, "/api/")
+		},
 		CookieHTTPOnly: false,
 		CookieSecure:   config.IsProdEnv(),
 		CookieSameSite: http.SameSiteLaxMode,
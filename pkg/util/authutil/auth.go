@@ -29,3 +29,14 @@ func SessionUID(c echo.Context) (string, error) {
 
 	return strUID, nil
 }
+
+// SessionIssuedAt은 로그인 세션을 만든 시각(유닉스 초)을 반환한다. 기록이 없는 예전 세션은 0이다.
+func SessionIssuedAt(c echo.Context) int64 {
+	sess, err := session.Get("session_v2", c)
+	if err != nil || sess == nil || sess.Values == nil {
+		return 0
+	}
+
+	iat, _ := sess.Values["iat"].(int64)
+	return iat
+}
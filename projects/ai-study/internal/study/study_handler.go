@@ -2,42 +2,64 @@ package study
 
 import (
 	"fmt"
-	"net/http"
-	"regexp"
+	"math/rand/v2"
 	aiclient "simple-server/internal/ai"
+	"simple-server/projects/ai-study/views"
+	"strings"
 
 	"github.com/labstack/echo/v4"
+	"google.golang.org/genai"
 )
 
+// maxTopics는 한 번에 보여줄 공부 주제 수다.
+const maxTopics = 10
+
+var topicsSchema = &genai.Schema{
+	Type: genai.TypeObject,
+	Properties: map[string]*genai.Schema{
+		"topics": {
+			Type:        genai.TypeArray,
+			Description: "짧은 공부 주제 목록",
+			Items:       &genai.Schema{Type: genai.TypeString},
+		},
+	},
+	Required: []string{"topics"},
+}
+
+// randomSubjects는 랜덤 주제로 시작할 때 고르는 분야다.
+// 분야를 서버에서 정해 프롬프트에 넣어야 같은 분야끼리 캐시를 나눠 쓴다.
+var randomSubjects = []string{
+	"자료구조", "알고리즘", "운영체제", "컴퓨터 네트워크", "데이터베이스", "웹 보안", "클라우드", "분산 시스템",
+	"머신러닝", "통계", "선형대수", "경제학", "심리학", "세계사", "한국사", "철학",
+	"글쓰기", "영어 회화", "디자인 기초", "재무 관리",
+}
+
+type topicsResponse struct {
+	Topics []string `json:"topics"`
+}
+
 func AIStudy(c echo.Context, random bool) error {
 	ctx := c.Request().Context()
-	input := c.Request().FormValue("input")
+	input := strings.TrimSpace(c.Request().FormValue("input"))
 
 	if random {
-		input = "너가 정해줘"
+		input = randomSubjects[rand.N(len(randomSubjects))]
 	}
 
 	prompt := fmt.Sprintf(`
-	해당 주제로 공부할 주제를 짧게 10개 작성해줘
+	해당 주제로 공부할 주제를 짧게 %d개 작성해줘
 
 	주제 : %s
+	`, maxTopics, input)
 
-	output :
-	<ol>
-		<li>{주제}</li>
-		<li>{주제}</li>
-		<li>{주제}</li>
-		....
-	</ol>
-	`, input)
-
-	result, err := aiclient.Request(ctx, prompt)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	var result topicsResponse
+	if err := aiclient.CachedRequestJSON(ctx, prompt, topicsSchema, &result); err != nil {
+		return aiclient.HTTPError(err)
 	}
 
-	re := regexp.MustCompile(`(?s)<ol>.*?</ol>`)
-	result = re.FindString(result)
-
-	return c.HTML(http.StatusOK, result)
+	topics := result.Topics
+	if len(topics) > maxTopics {
+		topics = topics[:maxTopics]
+	}
+	return views.Topics(topics).Render(ctx, c.Response().Writer)
 }
@@ -7,16 +7,27 @@ import (
 	"os"
 	resources "simple-server"
 	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/account"
 	"simple-server/projects/deario/internal/ai"
+	"simple-server/projects/deario/internal/apitoken"
 	"simple-server/projects/deario/internal/applock"
 	"simple-server/projects/deario/internal/auth"
+	"simple-server/projects/deario/internal/chat"
 	"simple-server/projects/deario/internal/diary"
+	"simple-server/projects/deario/internal/diarysync"
+	"simple-server/projects/deario/internal/diarytemplate"
+	"simple-server/projects/deario/internal/embedding"
 	"simple-server/projects/deario/internal/notification"
 	"simple-server/projects/deario/internal/privacy"
+	"simple-server/projects/deario/internal/safety"
 	"simple-server/projects/deario/internal/settings"
+	"simple-server/projects/deario/internal/share"
+	"simple-server/projects/deario/internal/voice"
+	"simple-server/projects/deario/internal/webhook"
 
 	"github.com/robfig/cron/v3"
 
+	aiclient "simple-server/internal/ai"
 	"simple-server/internal/config"
 	"simple-server/internal/debug"
 	"simple-server/internal/middleware"
@@ -56,8 +67,18 @@ func main() {
 	}
 	/* DB 마이그레이션 */
 
+	/* AI 응답 캐시 */
+	cacheQueries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+	aiclient.SetCacheStore(ai.CacheStore{Queries: cacheQueries})
+	/* AI 응답 캐시 */
+
 	/* 디버그 지표 노출 */
 	debug.Init(os.Getenv("SERVICE_NAME"), database)
+	safety.PublishEventCounts()
 	/* 디버그 지표 노출 */
 
 	e := setUpServer()
@@ -106,6 +127,10 @@ func setUpServer() *echo.Echo {
 	e.POST("/app-lock/disable", applock.RequireUnlocked(applock.Disable))
 	e.GET("/diary", applock.RequireUnlocked(diary.GetDiary))
 	e.GET("/diary/list", applock.RequireUnlocked(diary.ListDiaries))
+	e.GET("/diary/calendar", applock.RequireUnlocked(diary.CalendarView))
+	e.GET("/habit", applock.RequireUnlocked(diary.HabitPanel))
+	e.GET("/share/:token", share.SharedDiaryPage)
+	e.POST("/share/:token", share.UnlockSharedDiary)
 	/* 공개 라우터 */
 
 	/* 권한 라우터 */
@@ -115,10 +140,18 @@ func setUpServer() *echo.Echo {
 		os.Exit(1)
 	}
 	authGroup.Use(applock.RequireUnlocked)
-	authGroup.GET("/diary/month", diary.MonthlyDiaryDates)
+	authGroup.GET("/diary/month", diary.MonthlyDiaryDays)
 	authGroup.GET("/diary/random", diary.RedirectToRandomDiary)
+	authGroup.GET("/diary/memories", diary.OnThisDayMemories)
+	authGroup.GET("/diary/related", diary.RelatedDiaries)
+	authGroup.GET("/safety/helplines", safety.HelplinesPanel)
 	authGroup.POST("/diary/save", diary.SaveDiary)
+	authGroup.POST("/diary/draft", diary.SaveDiaryDraft)
+	authGroup.GET("/diary/draft/merge", diary.DraftMerge)
+	authGroup.POST("/diary/merge", diary.MergeDiary)
 	authGroup.GET("/diary/search", diary.SearchDiaries)
+	authGroup.GET("/diary/tags/suggest", diary.SuggestTags)
+	authGroup.POST("/diary/tags/rename", diary.RenameTag)
 	authGroup.GET("/ai-feedback", ai.GetAIFeedback)
 	authGroup.POST("/ai-feedback", ai.GenerateAIFeedback)
 	authGroup.POST("/ai-feedback/save", ai.SaveAIFeedback)
@@ -130,12 +163,68 @@ func setUpServer() *echo.Echo {
 	authGroup.POST("/diary/mood", diary.UpdateDiaryMood)
 	authGroup.GET("/statistic", diary.StatsPage)
 	authGroup.GET("/statistic/data", diary.GetStatsData)
+	authGroup.GET("/statistic/habit", diary.HabitAchievements)
+	authGroup.GET("/statistic/heatmap", diary.StatsHeatmap)
+	authGroup.GET("/statistic/export.csv", diary.ExportStatsCSV)
+	authGroup.GET("/statistic/tags", diary.TagCloud)
+	authGroup.GET("/statistic/summaries", ai.AISummaries)
 	authGroup.GET("/diary/images", diary.DiaryImagesPage)
 	authGroup.POST("/diary/image", diary.UploadDiaryImage)
 	authGroup.DELETE("/diary/image", diary.DeleteDiaryImage)
-	authGroup.POST("/diary/transcribe", diary.TranscribeDiaryVoice)
+	authGroup.GET("/diary/voice", voice.VoiceNotesPanel)
+	authGroup.POST("/diary/voice/uploads", voice.CreateUpload)
+	authGroup.GET("/diary/voice/uploads/:id", voice.UploadStatus)
+	authGroup.PATCH("/diary/voice/uploads/:id", voice.AppendUpload)
+	authGroup.POST("/diary/voice/uploads/:id/complete", voice.CompleteUpload)
+	authGroup.GET("/diary/voice/:id/audio", voice.VoiceAudio)
+	authGroup.DELETE("/diary/voice/:id", voice.DeleteVoiceNote)
+	authGroup.GET("/diary/sync", diary.PullDiaryChanges)
+	authGroup.POST("/diary/sync", diary.PushDiaryChanges)
+	authGroup.GET("/setting/tokens", apitoken.TokensPanel)
+	authGroup.POST("/setting/tokens", apitoken.CreateToken)
+	authGroup.DELETE("/setting/tokens/:id", apitoken.RevokeToken)
+	authGroup.GET("/setting/webhooks", webhook.WebhooksPanel)
+	authGroup.POST("/setting/webhooks", webhook.CreateWebhook)
+	authGroup.DELETE("/setting/webhooks/:id", webhook.DeleteWebhook)
+	authGroup.POST("/setting/webhooks/:id/enable", webhook.EnableWebhook)
+	authGroup.POST("/setting/webhooks/:id/test", webhook.TestWebhook)
+	authGroup.GET("/setting/webhooks/:id/deliveries", webhook.WebhookDeliveries)
+	authGroup.POST("/setting/webhooks/deliveries/:id/replay", webhook.ReplayDelivery)
+	authGroup.GET("/diary/templates", diarytemplate.TemplatePicker)
+	authGroup.GET("/setting/templates", diarytemplate.TemplatesPanel)
+	authGroup.POST("/setting/templates", diarytemplate.CreateTemplate)
+	authGroup.POST("/setting/templates/auto", diarytemplate.UpdateAutoTemplate)
+	authGroup.PUT("/setting/templates/:id", diarytemplate.UpdateTemplate)
+	authGroup.DELETE("/setting/templates/:id", diarytemplate.DeleteTemplate)
+	authGroup.GET("/diary/share", share.ShareForm)
+	authGroup.POST("/diary/share", share.CreateShare)
+	authGroup.GET("/setting/shares", share.SharesPanel)
+	authGroup.DELETE("/setting/shares/:id", share.RevokeShare)
+	authGroup.GET("/chat", chat.ChatPage)
+	authGroup.GET("/chat/sessions", chat.ChatSessions)
+	authGroup.GET("/chat/thread", chat.ChatThread)
+	authGroup.POST("/chat/messages", chat.SendMessage)
+	authGroup.DELETE("/chat/sessions/:id", chat.DeleteSession)
+	authGroup.GET("/setting/account", account.AccountPanel)
+	authGroup.POST("/account/delete", account.RequestDeletion)
+	authGroup.POST("/account/delete/cancel", account.CancelDeletion)
 	/* 권한 라우터 */
 
+	/* API 라우터 */
+	e.FileFS("/api/v1/openapi.yaml", "projects/deario/static/openapi.yaml", resources.EmbeddedFiles)
+	apiGroup := e.Group("/api/v1", apitoken.Middleware())
+	apiGroup.GET("/diaries", diary.APIListDiaries)
+	apiGroup.GET("/diaries/:date", diary.APIGetDiary)
+	apiGroup.PUT("/diaries/:date", diary.APIPutDiary)
+	apiGroup.DELETE("/diaries/:date", diary.APIDeleteDiary)
+	apiGroup.PUT("/diaries/:date/mood", diary.APIUpdateDiaryMood)
+	apiGroup.GET("/search", diary.APISearchDiaries)
+	apiGroup.GET("/stats", diary.APIGetStats)
+	apiGroup.GET("/settings", settings.APIGetSettings)
+	apiGroup.PUT("/settings", settings.APIUpdateSettings)
+	apiGroup.PATCH("/settings", settings.APIUpdateSettings)
+	/* API 라우터 */
+
 	if err := notification.InitPushQueue(); err != nil {
 		slog.Error("푸시 큐 초기화 실패", "error", err)
 		os.Exit(1)
@@ -144,15 +233,44 @@ func setUpServer() *echo.Echo {
 		slog.Error("AI 리포트 큐 초기화 실패", "error", err)
 		os.Exit(1)
 	}
+	if err := notification.InitAISummaryQueue(); err != nil {
+		slog.Error("AI 요약 큐 초기화 실패", "error", err)
+		os.Exit(1)
+	}
+	if err := embedding.InitEmbeddingQueue(); err != nil {
+		slog.Error("임베딩 큐 초기화 실패", "error", err)
+		os.Exit(1)
+	}
+	if err := voice.InitVoiceQueue(); err != nil {
+		slog.Error("음성 일기 큐 초기화 실패", "error", err)
+		os.Exit(1)
+	}
 
 	/* 큐 리시버 */
-	go notification.PushSendJob()         // 알기 작성 알림 푸시 리시버
-	go notification.GenerateAIReportJob() // AI 리포트 생성 리시버
+	go notification.PushSendJob()          // 알기 작성 알림 푸시 리시버
+	go notification.GenerateAIReportJob()  // AI 리포트 생성 리시버
+	go notification.GenerateAISummaryJob() // 정기 AI 요약 생성 리시버
+	go webhook.WebhookDeliverJob()         // 웹훅 전송 리시버
+	go account.AccountPurgeJob()           // 계정 삭제 리시버
+	go embedding.DiaryEmbeddingJob()       // 일기 임베딩 리시버
+	go voice.VoiceTranscribeJob()          // 음성 일기 받아쓰기 리시버
 	/* 큐 리시버 */
 
 	/* 스케줄 */
 	c := cron.New()
-	notification.PushSendCron(c) // 일기 작성 알림 푸시
+	notification.PushSendCron(c)        // 일기 작성 알림 푸시
+	notification.StreakNudgeCron(c)     // 연속 기록 유지 알림 푸시
+	notification.MemoryPushCron(c)      // 지난 해 오늘 추억 알림 푸시
+	notification.AISummaryCron(c)       // 주간, 월간 AI 요약
+	diarysync.PruneChangeLogCron(c)     // 동기화 변경 기록 정리
+	webhook.PruneDeliveriesCron(c)      // 웹훅 전송 기록 정리
+	account.AccountPurgeCron(c)         // 유예 기간이 지난 계정 삭제
+	share.PruneSharesCron(c)            // 만료된 공유 링크 정리
+	embedding.BackfillEmbeddingsCron(c) // 빠진 일기 임베딩 보충
+	ai.PruneAICacheCron(c)              // 만료된 AI 응답 캐시 정리
+	voice.PruneVoiceNotesCron(c)        // 끊긴 음성 업로드와 녹음 기록 정리
+	diary.PruneQuarantineCron(c)        // 보관 기간이 지난 격리 이미지 정리
+	diary.StorageGCCron(c)              // 일기에 연결되지 않은 이미지 정리
 	c.Start()
 	/* 스케줄 */
 
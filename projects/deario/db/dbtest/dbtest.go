@@ -0,0 +1,46 @@
+// Package dbtest는 테스트에서 쓸 메모리 SQLite 데이터베이스를 만든다.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"testing"
+
+	resources "simple-server"
+	"simple-server/projects/deario/db"
+
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+)
+
+// Open은 마이그레이션을 모두 적용한 메모리 데이터베이스를 연다. 테스트가 끝나면 닫는다.
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+	migrations, err := fs.Sub(resources.EmbeddedFiles, "projects/deario/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	// 메모리 데이터베이스는 연결마다 따로 생기므로 연결을 하나만 쓴다.
+	conn.SetMaxOpenConns(1)
+
+	provider, err := goose.NewProvider(goose.DialectSQLite3, conn, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// New는 Open으로 연 데이터베이스의 쿼리를 반환한다.
+func New(t testing.TB) *db.Queries {
+	t.Helper()
+	return db.New(Open(t))
+}
@@ -8,6 +8,80 @@ import (
 	"database/sql"
 )
 
+type AccountAudit struct {
+	ID      string
+	UidHash string
+	Event   string
+	Detail  string
+	Created sql.NullString
+}
+
+type AccountDeletion struct {
+	Uid        string
+	Status     string
+	PurgeAfter string
+	Created    sql.NullString
+	Updated    sql.NullString
+}
+
+type AiCache struct {
+	Key     string
+	Value   string
+	Expires string
+	Created sql.NullString
+}
+
+type AiSummary struct {
+	ID         string
+	Uid        string
+	Period     string
+	StartDate  string
+	EndDate    string
+	Content    string
+	DiaryCount int64
+	Created    sql.NullString
+	Updated    sql.NullString
+}
+
+type ApiToken struct {
+	ID        string
+	Uid       string
+	Name      string
+	Prefix    string
+	TokenHash string
+	Scopes    string
+	RateLimit int64
+	LastUsed  string
+	Revoked   string
+	Created   sql.NullString
+}
+
+type AppLockAttempt struct {
+	Uid         string
+	Failures    int64
+	Lockouts    int64
+	LockedUntil string
+	Updated     sql.NullString
+}
+
+type ChatMessage struct {
+	ID        string
+	SessionID string
+	Uid       string
+	Role      string
+	Content   string
+	Citations string
+	Created   sql.NullString
+}
+
+type ChatSession struct {
+	ID      string
+	Uid     string
+	Title   string
+	Created sql.NullString
+	Updated sql.NullString
+}
+
 type Diary struct {
 	ID         string
 	Uid        string
@@ -21,6 +95,76 @@ type Diary struct {
 	ImageUrl1  string
 	ImageUrl2  string
 	ImageUrl3  string
+	MonthDay   sql.NullString
+	Version    int64
+}
+
+type DiaryChange struct {
+	Seq     int64
+	Uid     string
+	Date    string
+	Op      string
+	Updated sql.NullString
+}
+
+type DiaryDraft struct {
+	Uid         string
+	Date        string
+	Content     string
+	BaseVersion int64
+	Created     sql.NullString
+	Updated     sql.NullString
+}
+
+type DiaryEmbedding struct {
+	DiaryID     string
+	Uid         string
+	Date        string
+	Model       string
+	ContentHash string
+	Vector      []byte
+	Updated     sql.NullString
+}
+
+type DiaryShare struct {
+	ID            string
+	Uid           string
+	Date          string
+	PinHash       string
+	IncludeAi     int64
+	IncludeImages int64
+	Expires       string
+	Revoked       string
+	ViewCount     int64
+	LastViewed    string
+	PinFailures   int64
+	LockedUntil   string
+	Created       sql.NullString
+	Updated       sql.NullString
+	PinAttempts   int64
+}
+
+type DiaryTag struct {
+	DiaryID string
+	Uid     string
+	Tag     string
+	Created sql.NullString
+}
+
+type DiaryTemplate struct {
+	ID      string
+	Uid     string
+	Name    string
+	Content string
+	Created sql.NullString
+	Updated sql.NullString
+}
+
+type DiaryTombstone struct {
+	Uid     string
+	Date    string
+	Version int64
+	Updated sql.NullString
 }
 
 type Goqite struct {
@@ -34,6 +178,23 @@ type Goqite struct {
 	Priority int64
 }
 
+type ImageQuarantine struct {
+	ID      string
+	Uid     string
+	Source  string
+	Object  string
+	Reason  string
+	Size    int64
+	Created sql.NullString
+}
+
+type SafetyEvent struct {
+	Day    string
+	Source string
+	Level  string
+	Count  int64
+}
+
 type User struct {
 	Uid     string
 	Name    string
@@ -42,14 +203,76 @@ type User struct {
 	Updated sql.NullString
 }
 
+type UserAchievement struct {
+	ID           string
+	Uid          string
+	Code         string
+	AchievedDate string
+	Created      sql.NullString
+	Updated      sql.NullString
+}
+
 type UserSetting struct {
+	Uid                string
+	IsPush             int64
+	PushToken          string
+	PushTime           string
+	RandomRange        int64
+	Created            sql.NullString
+	Updated            sql.NullString
+	AppLockEnabled     int64
+	AppLockPinHash     string
+	MonthlyGoal        int64
+	Timezone           string
+	MemoryPush         int64
+	AutoTemplate       string
+	WeeklySummary      int64
+	MonthlySummary     int64
+	KeepVoiceAudio     int64
+	AppLockForceLogout int64
+	SessionsRevokedAt  int64
+}
+
+type VoiceNote struct {
+	ID         string
+	Uid        string
+	Date       string
+	MimeType   string
+	Size       int64
+	Status     string
+	Progress   int64
+	KeepAudio  int64
+	Object     string
+	Transcript string
+	Error      string
+	Created    sql.NullString
+	Updated    sql.NullString
+}
+
+type Webhook struct {
+	ID             string
+	Uid            string
+	Url            string
+	Secret         string
+	Events         string
+	Enabled        int64
+	FailureCount   int64
+	DisabledReason string
+	Created        sql.NullString
+	Updated        sql.NullString
+}
+
+type WebhookDelivery struct {
+	ID             string
+	WebhookID      string
 	Uid            string
-	IsPush         int64
-	PushToken      string
-	PushTime       string
-	RandomRange    int64
+	Event          string
+	Payload        string
+	Status         string
+	Attempts       int64
+	ResponseStatus int64
+	Error          string
+	NextAttempt    string
 	Created        sql.NullString
 	Updated        sql.NullString
-	AppLockEnabled int64
-	AppLockPinHash string
 }
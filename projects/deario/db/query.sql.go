@@ -10,55 +10,91 @@ import (
 	"database/sql"
 )
 
-const createUser = `-- name: CreateUser :exec
-INSERT INTO
-    user (uid, name, email)
-VALUES
-    (?, ?, ?)
+const appendVoiceNoteChunk = `-- name: AppendVoiceNoteChunk :execrows
+UPDATE voice_note
+SET
+    size = CAST(?1 AS INTEGER),
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?2
+    AND uid = ?3
+    AND status = 'uploading'
+    AND size = CAST(?4 AS INTEGER)
 `
 
-type CreateUserParams struct {
-	Uid   string
-	Name  string
-	Email string
+type AppendVoiceNoteChunkParams struct {
+	NewSize int64
+	ID      string
+	Uid     string
+	Offset  int64
 }
 
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
-	_, err := q.db.ExecContext(ctx, createUser, arg.Uid, arg.Name, arg.Email)
-	return err
+func (q *Queries) AppendVoiceNoteChunk(ctx context.Context, arg AppendVoiceNoteChunkParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, appendVoiceNoteChunk,
+		arg.NewSize,
+		arg.ID,
+		arg.Uid,
+		arg.Offset,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const deleteDiary = `-- name: DeleteDiary :exec
-DELETE FROM diary
+const cancelAccountDeletion = `-- name: CancelAccountDeletion :execrows
+DELETE FROM account_deletion
 WHERE
-    id = ?
+    uid = ?
+    AND status = 'scheduled'
 `
 
-func (q *Queries) DeleteDiary(ctx context.Context, id string) error {
-	_, err := q.db.ExecContext(ctx, deleteDiary, id)
+func (q *Queries) CancelAccountDeletion(ctx context.Context, uid string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelAccountDeletion, uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const clearAutoTemplate = `-- name: ClearAutoTemplate :exec
+UPDATE user_setting
+SET
+    auto_template = '',
+    updated = datetime ('now')
+WHERE
+    uid = ?
+    AND auto_template = ?
+`
+
+type ClearAutoTemplateParams struct {
+	Uid          string
+	AutoTemplate string
+}
+
+func (q *Queries) ClearAutoTemplate(ctx context.Context, arg ClearAutoTemplateParams) error {
+	_, err := q.db.ExecContext(ctx, clearAutoTemplate, arg.Uid, arg.AutoTemplate)
 	return err
 }
 
-const getDiary = `-- name: GetDiary :one
-SELECT
-    id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3
-FROM
-    diary
+const clearDiaryContentIfVersion = `-- name: ClearDiaryContentIfVersion :one
+UPDATE diary
+SET
+    content = '',
+    version = version + 1,
+    updated = datetime ('now')
 WHERE
-    date = ?
-    AND uid = ?
-LIMIT
-    1
+    id = ?
+    AND version = ? RETURNING id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3, month_day, version
 `
 
-type GetDiaryParams struct {
-	Date string
-	Uid  string
+type ClearDiaryContentIfVersionParams struct {
+	ID      string
+	Version int64
 }
 
-// sqlc generate -f ./projects/deario/sqlc.yaml
-func (q *Queries) GetDiary(ctx context.Context, arg GetDiaryParams) (Diary, error) {
-	row := q.db.QueryRowContext(ctx, getDiary, arg.Date, arg.Uid)
+func (q *Queries) ClearDiaryContentIfVersion(ctx context.Context, arg ClearDiaryContentIfVersionParams) (Diary, error) {
+	row := q.db.QueryRowContext(ctx, clearDiaryContentIfVersion, arg.ID, arg.Version)
 	var i Diary
 	err := row.Scan(
 		&i.ID,
@@ -73,287 +109,3706 @@ func (q *Queries) GetDiary(ctx context.Context, arg GetDiaryParams) (Diary, erro
 		&i.ImageUrl1,
 		&i.ImageUrl2,
 		&i.ImageUrl3,
+		&i.MonthDay,
+		&i.Version,
 	)
 	return i, err
 }
 
-const getDiaryRandom = `-- name: GetDiaryRandom :one
+const countAISummariesForPeriod = `-- name: CountAISummariesForPeriod :one
+SELECT
+    COUNT(*)
+FROM
+    ai_summary
+WHERE
+    uid = ?
+    AND period = ?
+    AND start_date = ?
+`
+
+type CountAISummariesForPeriodParams struct {
+	Uid       string
+	Period    string
+	StartDate string
+}
+
+func (q *Queries) CountAISummariesForPeriod(ctx context.Context, arg CountAISummariesForPeriodParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAISummariesForPeriod, arg.Uid, arg.Period, arg.StartDate)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countAPITokens = `-- name: CountAPITokens :one
+SELECT
+    COUNT(*)
+FROM
+    api_token
+WHERE
+    uid = ?
+    AND revoked = ''
+`
+
+func (q *Queries) CountAPITokens(ctx context.Context, uid string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAPITokens, uid)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countActiveDiaryShares = `-- name: CountActiveDiaryShares :one
+SELECT
+    COUNT(*)
+FROM
+    diary_share
+WHERE
+    uid = ?1
+    AND revoked = ''
+    AND expires > CAST(?2 AS TEXT)
+`
+
+type CountActiveDiarySharesParams struct {
+	Uid string
+	Now string
+}
+
+func (q *Queries) CountActiveDiaryShares(ctx context.Context, arg CountActiveDiarySharesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countActiveDiaryShares, arg.Uid, arg.Now)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countDiaryBetween = `-- name: CountDiaryBetween :one
 SELECT
-    id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3
+    COUNT(*)
 FROM
     diary
 WHERE
-    date IS NOT NULL
-    AND uid = ?
-    AND date >= ?
-ORDER BY
-    RANDOM ()
-LIMIT
-    1
+    uid = ?
+    AND content != ''
+    AND date >= ?2
+    AND date <= ?3
+`
+
+type CountDiaryBetweenParams struct {
+	Uid       string
+	StartDate string
+	EndDate   string
+}
+
+func (q *Queries) CountDiaryBetween(ctx context.Context, arg CountDiaryBetweenParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countDiaryBetween, arg.Uid, arg.StartDate, arg.EndDate)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countDiaryTemplates = `-- name: CountDiaryTemplates :one
+SELECT
+    COUNT(*)
+FROM
+    diary_template
+WHERE
+    uid = ?
+`
+
+func (q *Queries) CountDiaryTemplates(ctx context.Context, uid string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countDiaryTemplates, uid)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countWebhooks = `-- name: CountWebhooks :one
+SELECT
+    COUNT(*)
+FROM
+    webhook
+WHERE
+    uid = ?
+`
+
+func (q *Queries) CountWebhooks(ctx context.Context, uid string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWebhooks, uid)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAPIToken = `-- name: CreateAPIToken :one
+INSERT INTO
+    api_token (uid, name, prefix, token_hash, scopes, rate_limit)
+VALUES
+    (?, ?, ?, ?, ?, ?)
+RETURNING
+    id, uid, name, prefix, token_hash, scopes, rate_limit, last_used, revoked, created
+`
+
+type CreateAPITokenParams struct {
+	Uid       string
+	Name      string
+	Prefix    string
+	TokenHash string
+	Scopes    string
+	RateLimit int64
+}
+
+func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, createAPIToken,
+		arg.Uid,
+		arg.Name,
+		arg.Prefix,
+		arg.TokenHash,
+		arg.Scopes,
+		arg.RateLimit,
+	)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.RateLimit,
+		&i.LastUsed,
+		&i.Revoked,
+		&i.Created,
+	)
+	return i, err
+}
+
+const createAccountAudit = `-- name: CreateAccountAudit :exec
+INSERT INTO
+    account_audit (uid_hash, event, detail)
+VALUES
+    (?, ?, ?)
+`
+
+type CreateAccountAuditParams struct {
+	UidHash string
+	Event   string
+	Detail  string
+}
+
+func (q *Queries) CreateAccountAudit(ctx context.Context, arg CreateAccountAuditParams) error {
+	_, err := q.db.ExecContext(ctx, createAccountAudit, arg.UidHash, arg.Event, arg.Detail)
+	return err
+}
+
+const createAppLockAttempt = `-- name: CreateAppLockAttempt :exec
+INSERT INTO
+    app_lock_attempt (uid)
+VALUES
+    (?) ON CONFLICT (uid) DO NOTHING
+`
+
+func (q *Queries) CreateAppLockAttempt(ctx context.Context, uid string) error {
+	_, err := q.db.ExecContext(ctx, createAppLockAttempt, uid)
+	return err
+}
+
+const createChatMessage = `-- name: CreateChatMessage :one
+INSERT INTO
+    chat_message (session_id, uid, role, content, citations)
+VALUES
+    (?, ?, ?, ?, ?)
+RETURNING
+    id, session_id, uid, role, content, citations, created
+`
+
+type CreateChatMessageParams struct {
+	SessionID string
+	Uid       string
+	Role      string
+	Content   string
+	Citations string
+}
+
+func (q *Queries) CreateChatMessage(ctx context.Context, arg CreateChatMessageParams) (ChatMessage, error) {
+	row := q.db.QueryRowContext(ctx, createChatMessage,
+		arg.SessionID,
+		arg.Uid,
+		arg.Role,
+		arg.Content,
+		arg.Citations,
+	)
+	var i ChatMessage
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Uid,
+		&i.Role,
+		&i.Content,
+		&i.Citations,
+		&i.Created,
+	)
+	return i, err
+}
+
+const createChatSession = `-- name: CreateChatSession :one
+INSERT INTO
+    chat_session (uid, title)
+VALUES
+    (?, ?)
+RETURNING
+    id, uid, title, created, updated
+`
+
+type CreateChatSessionParams struct {
+	Uid   string
+	Title string
+}
+
+func (q *Queries) CreateChatSession(ctx context.Context, arg CreateChatSessionParams) (ChatSession, error) {
+	row := q.db.QueryRowContext(ctx, createChatSession, arg.Uid, arg.Title)
+	var i ChatSession
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Title,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const createDiaryShare = `-- name: CreateDiaryShare :one
+INSERT INTO
+    diary_share (
+        uid,
+        date,
+        pin_hash,
+        include_ai,
+        include_images,
+        expires
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?)
+RETURNING
+    id, uid, date, pin_hash, include_ai, include_images, expires, revoked, view_count, last_viewed, pin_failures, locked_until, created, updated, pin_attempts
+`
+
+type CreateDiaryShareParams struct {
+	Uid           string
+	Date          string
+	PinHash       string
+	IncludeAi     int64
+	IncludeImages int64
+	Expires       string
+}
+
+func (q *Queries) CreateDiaryShare(ctx context.Context, arg CreateDiaryShareParams) (DiaryShare, error) {
+	row := q.db.QueryRowContext(ctx, createDiaryShare,
+		arg.Uid,
+		arg.Date,
+		arg.PinHash,
+		arg.IncludeAi,
+		arg.IncludeImages,
+		arg.Expires,
+	)
+	var i DiaryShare
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Date,
+		&i.PinHash,
+		&i.IncludeAi,
+		&i.IncludeImages,
+		&i.Expires,
+		&i.Revoked,
+		&i.ViewCount,
+		&i.LastViewed,
+		&i.PinFailures,
+		&i.LockedUntil,
+		&i.Created,
+		&i.Updated,
+		&i.PinAttempts,
+	)
+	return i, err
+}
+
+const createDiaryTemplate = `-- name: CreateDiaryTemplate :one
+INSERT INTO
+    diary_template (uid, name, content)
+VALUES
+    (?, ?, ?)
+RETURNING
+    id, uid, name, content, created, updated
+`
+
+type CreateDiaryTemplateParams struct {
+	Uid     string
+	Name    string
+	Content string
+}
+
+func (q *Queries) CreateDiaryTemplate(ctx context.Context, arg CreateDiaryTemplateParams) (DiaryTemplate, error) {
+	row := q.db.QueryRowContext(ctx, createDiaryTemplate, arg.Uid, arg.Name, arg.Content)
+	var i DiaryTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Name,
+		&i.Content,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const createImageQuarantine = `-- name: CreateImageQuarantine :exec
+INSERT INTO
+    image_quarantine (uid, source, object, reason, size)
+VALUES
+    (?, ?, ?, ?, ?)
+`
+
+type CreateImageQuarantineParams struct {
+	Uid    string
+	Source string
+	Object string
+	Reason string
+	Size   int64
+}
+
+func (q *Queries) CreateImageQuarantine(ctx context.Context, arg CreateImageQuarantineParams) error {
+	_, err := q.db.ExecContext(ctx, createImageQuarantine,
+		arg.Uid,
+		arg.Source,
+		arg.Object,
+		arg.Reason,
+		arg.Size,
+	)
+	return err
+}
+
+const createUser = `-- name: CreateUser :exec
+INSERT INTO
+    user (uid, name, email)
+VALUES
+    (?, ?, ?)
+`
+
+type CreateUserParams struct {
+	Uid   string
+	Name  string
+	Email string
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) error {
+	_, err := q.db.ExecContext(ctx, createUser, arg.Uid, arg.Name, arg.Email)
+	return err
+}
+
+const createVoiceNote = `-- name: CreateVoiceNote :one
+INSERT INTO
+    voice_note (uid, date, mime_type, keep_audio)
+VALUES
+    (?, ?, ?, ?) RETURNING id, uid, date, mime_type, size, status, progress, keep_audio, object, transcript, error, created, updated
+`
+
+type CreateVoiceNoteParams struct {
+	Uid       string
+	Date      string
+	MimeType  string
+	KeepAudio int64
+}
+
+func (q *Queries) CreateVoiceNote(ctx context.Context, arg CreateVoiceNoteParams) (VoiceNote, error) {
+	row := q.db.QueryRowContext(ctx, createVoiceNote,
+		arg.Uid,
+		arg.Date,
+		arg.MimeType,
+		arg.KeepAudio,
+	)
+	var i VoiceNote
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Date,
+		&i.MimeType,
+		&i.Size,
+		&i.Status,
+		&i.Progress,
+		&i.KeepAudio,
+		&i.Object,
+		&i.Transcript,
+		&i.Error,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO
+    webhook (uid, url, secret, events)
+VALUES
+    (?, ?, ?, ?)
+RETURNING
+    id, uid, url, secret, events, enabled, failure_count, disabled_reason, created, updated
+`
+
+type CreateWebhookParams struct {
+	Uid    string
+	Url    string
+	Secret string
+	Events string
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, createWebhook,
+		arg.Uid,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Enabled,
+		&i.FailureCount,
+		&i.DisabledReason,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :one
+INSERT INTO
+    webhook_delivery (webhook_id, uid, event, payload)
+VALUES
+    (?, ?, ?, ?)
+RETURNING
+    id, webhook_id, uid, event, payload, status, attempts, response_status, error, next_attempt, created, updated
+`
+
+type CreateWebhookDeliveryParams struct {
+	WebhookID string
+	Uid       string
+	Event     string
+	Payload   string
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, createWebhookDelivery,
+		arg.WebhookID,
+		arg.Uid,
+		arg.Event,
+		arg.Payload,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.Uid,
+		&i.Event,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseStatus,
+		&i.Error,
+		&i.NextAttempt,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const deleteAppLockAttempt = `-- name: DeleteAppLockAttempt :exec
+DELETE FROM app_lock_attempt
+WHERE
+    uid = ?
+`
+
+func (q *Queries) DeleteAppLockAttempt(ctx context.Context, uid string) error {
+	_, err := q.db.ExecContext(ctx, deleteAppLockAttempt, uid)
+	return err
+}
+
+const deleteChatSession = `-- name: DeleteChatSession :execrows
+DELETE FROM chat_session
+WHERE
+    id = ?
+    AND uid = ?
+`
+
+type DeleteChatSessionParams struct {
+	ID  string
+	Uid string
+}
+
+func (q *Queries) DeleteChatSession(ctx context.Context, arg DeleteChatSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteChatSession, arg.ID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteDiary = `-- name: DeleteDiary :exec
+DELETE FROM diary
+WHERE
+    id = ?
+`
+
+func (q *Queries) DeleteDiary(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteDiary, id)
+	return err
+}
+
+const deleteDiaryDraft = `-- name: DeleteDiaryDraft :exec
+DELETE FROM diary_draft
+WHERE
+    uid = ?
+    AND date = ?
+`
+
+type DeleteDiaryDraftParams struct {
+	Uid  string
+	Date string
+}
+
+func (q *Queries) DeleteDiaryDraft(ctx context.Context, arg DeleteDiaryDraftParams) error {
+	_, err := q.db.ExecContext(ctx, deleteDiaryDraft, arg.Uid, arg.Date)
+	return err
+}
+
+const deleteDiaryEmbedding = `-- name: DeleteDiaryEmbedding :exec
+DELETE FROM diary_embedding
+WHERE
+    diary_id = ?
+`
+
+func (q *Queries) DeleteDiaryEmbedding(ctx context.Context, diaryID string) error {
+	_, err := q.db.ExecContext(ctx, deleteDiaryEmbedding, diaryID)
+	return err
+}
+
+const deleteDiaryIfVersion = `-- name: DeleteDiaryIfVersion :execrows
+DELETE FROM diary
+WHERE
+    id = ?
+    AND version = ?
+`
+
+type DeleteDiaryIfVersionParams struct {
+	ID      string
+	Version int64
+}
+
+func (q *Queries) DeleteDiaryIfVersion(ctx context.Context, arg DeleteDiaryIfVersionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDiaryIfVersion, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteDiaryTags = `-- name: DeleteDiaryTags :exec
+DELETE FROM diary_tag
+WHERE
+    diary_id = ?
+`
+
+func (q *Queries) DeleteDiaryTags(ctx context.Context, diaryID string) error {
+	_, err := q.db.ExecContext(ctx, deleteDiaryTags, diaryID)
+	return err
+}
+
+const deleteDiaryTemplate = `-- name: DeleteDiaryTemplate :execrows
+DELETE FROM diary_template
+WHERE
+    id = ?
+    AND uid = ?
+`
+
+type DeleteDiaryTemplateParams struct {
+	ID  string
+	Uid string
+}
+
+func (q *Queries) DeleteDiaryTemplate(ctx context.Context, arg DeleteDiaryTemplateParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDiaryTemplate, arg.ID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteImageQuarantine = `-- name: DeleteImageQuarantine :exec
+DELETE FROM image_quarantine
+WHERE
+    id = ?
+`
+
+func (q *Queries) DeleteImageQuarantine(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteImageQuarantine, id)
+	return err
+}
+
+const deleteStaleAppLockAttempt = `-- name: DeleteStaleAppLockAttempt :exec
+DELETE FROM app_lock_attempt
+WHERE
+    uid = ?
+    AND updated < CAST(?2 AS TEXT)
+`
+
+type DeleteStaleAppLockAttemptParams struct {
+	Uid    string
+	Before string
+}
+
+func (q *Queries) DeleteStaleAppLockAttempt(ctx context.Context, arg DeleteStaleAppLockAttemptParams) error {
+	_, err := q.db.ExecContext(ctx, deleteStaleAppLockAttempt, arg.Uid, arg.Before)
+	return err
+}
+
+const deleteVoiceNote = `-- name: DeleteVoiceNote :execrows
+DELETE FROM voice_note
+WHERE
+    id = ?
+    AND uid = ?
+`
+
+type DeleteVoiceNoteParams struct {
+	ID  string
+	Uid string
+}
+
+func (q *Queries) DeleteVoiceNote(ctx context.Context, arg DeleteVoiceNoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteVoiceNote, arg.ID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteVoiceNoteByID = `-- name: DeleteVoiceNoteByID :exec
+DELETE FROM voice_note
+WHERE
+    id = ?
+`
+
+func (q *Queries) DeleteVoiceNoteByID(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteVoiceNoteByID, id)
+	return err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :execrows
+DELETE FROM webhook
+WHERE
+    id = ?
+    AND uid = ?
+`
+
+type DeleteWebhookParams struct {
+	ID  string
+	Uid string
+}
+
+func (q *Queries) DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWebhook, arg.ID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const disableUserWebhooks = `-- name: DisableUserWebhooks :exec
+UPDATE webhook
+SET
+    enabled = 0,
+    disabled_reason = ?1,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    uid = ?2
+    AND enabled = 1
+`
+
+type DisableUserWebhooksParams struct {
+	Reason string
+	Uid    string
+}
+
+func (q *Queries) DisableUserWebhooks(ctx context.Context, arg DisableUserWebhooksParams) error {
+	_, err := q.db.ExecContext(ctx, disableUserWebhooks, arg.Reason, arg.Uid)
+	return err
+}
+
+const disableWebhook = `-- name: DisableWebhook :exec
+UPDATE webhook
+SET
+    enabled = 0,
+    disabled_reason = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+`
+
+type DisableWebhookParams struct {
+	DisabledReason string
+	ID             string
+}
+
+func (q *Queries) DisableWebhook(ctx context.Context, arg DisableWebhookParams) error {
+	_, err := q.db.ExecContext(ctx, disableWebhook, arg.DisabledReason, arg.ID)
+	return err
+}
+
+const enableUserWebhooks = `-- name: EnableUserWebhooks :exec
+UPDATE webhook
+SET
+    enabled = 1,
+    failure_count = 0,
+    disabled_reason = '',
+    updated = CURRENT_TIMESTAMP
+WHERE
+    uid = ?1
+    AND enabled = 0
+    AND disabled_reason = ?2
+`
+
+type EnableUserWebhooksParams struct {
+	Uid    string
+	Reason string
+}
+
+func (q *Queries) EnableUserWebhooks(ctx context.Context, arg EnableUserWebhooksParams) error {
+	_, err := q.db.ExecContext(ctx, enableUserWebhooks, arg.Uid, arg.Reason)
+	return err
+}
+
+const enableWebhook = `-- name: EnableWebhook :execrows
+UPDATE webhook
+SET
+    enabled = 1,
+    failure_count = 0,
+    disabled_reason = '',
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND uid = ?
+`
+
+type EnableWebhookParams struct {
+	ID  string
+	Uid string
+}
+
+func (q *Queries) EnableWebhook(ctx context.Context, arg EnableWebhookParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enableWebhook, arg.ID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const failVoiceNote = `-- name: FailVoiceNote :exec
+UPDATE voice_note
+SET
+    status = 'failed',
+    error = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+`
+
+type FailVoiceNoteParams struct {
+	Error string
+	ID    string
+}
+
+func (q *Queries) FailVoiceNote(ctx context.Context, arg FailVoiceNoteParams) error {
+	_, err := q.db.ExecContext(ctx, failVoiceNote, arg.Error, arg.ID)
+	return err
+}
+
+const finishAccountDeletion = `-- name: FinishAccountDeletion :exec
+DELETE FROM account_deletion
+WHERE
+    uid = ?
+`
+
+func (q *Queries) FinishAccountDeletion(ctx context.Context, uid string) error {
+	_, err := q.db.ExecContext(ctx, finishAccountDeletion, uid)
+	return err
+}
+
+const finishVoiceNote = `-- name: FinishVoiceNote :exec
+UPDATE voice_note
+SET
+    status = 'done',
+    progress = 100,
+    transcript = ?,
+    object = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+`
+
+type FinishVoiceNoteParams struct {
+	Transcript string
+	Object     string
+	ID         string
+}
+
+func (q *Queries) FinishVoiceNote(ctx context.Context, arg FinishVoiceNoteParams) error {
+	_, err := q.db.ExecContext(ctx, finishVoiceNote, arg.Transcript, arg.Object, arg.ID)
+	return err
+}
+
+const getAICache = `-- name: GetAICache :one
+SELECT
+    value,
+    expires
+FROM
+    ai_cache
+WHERE
+    key = ?
+    AND expires > CAST(?2 AS TEXT)
+`
+
+type GetAICacheParams struct {
+	Key string
+	Now string
+}
+
+type GetAICacheRow struct {
+	Value   string
+	Expires string
+}
+
+func (q *Queries) GetAICache(ctx context.Context, arg GetAICacheParams) (GetAICacheRow, error) {
+	row := q.db.QueryRowContext(ctx, getAICache, arg.Key, arg.Now)
+	var i GetAICacheRow
+	err := row.Scan(&i.Value, &i.Expires)
+	return i, err
+}
+
+const getAPITokenByHash = `-- name: GetAPITokenByHash :one
+SELECT
+    id, uid, name, prefix, token_hash, scopes, rate_limit, last_used, revoked, created
+FROM
+    api_token
+WHERE
+    token_hash = ?
+    AND revoked = ''
+`
+
+func (q *Queries) GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, getAPITokenByHash, tokenHash)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.Scopes,
+		&i.RateLimit,
+		&i.LastUsed,
+		&i.Revoked,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getAccountDeletion = `-- name: GetAccountDeletion :one
+SELECT
+    uid, status, purge_after, created, updated
+FROM
+    account_deletion
+WHERE
+    uid = ?
+`
+
+func (q *Queries) GetAccountDeletion(ctx context.Context, uid string) (AccountDeletion, error) {
+	row := q.db.QueryRowContext(ctx, getAccountDeletion, uid)
+	var i AccountDeletion
+	err := row.Scan(
+		&i.Uid,
+		&i.Status,
+		&i.PurgeAfter,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getAppLockAttempt = `-- name: GetAppLockAttempt :one
+SELECT
+    uid, failures, lockouts, locked_until, updated
+FROM
+    app_lock_attempt
+WHERE
+    uid = ?
+`
+
+func (q *Queries) GetAppLockAttempt(ctx context.Context, uid string) (AppLockAttempt, error) {
+	row := q.db.QueryRowContext(ctx, getAppLockAttempt, uid)
+	var i AppLockAttempt
+	err := row.Scan(
+		&i.Uid,
+		&i.Failures,
+		&i.Lockouts,
+		&i.LockedUntil,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getChatSession = `-- name: GetChatSession :one
+SELECT
+    id, uid, title, created, updated
+FROM
+    chat_session
+WHERE
+    id = ?
+    AND uid = ?
+`
+
+type GetChatSessionParams struct {
+	ID  string
+	Uid string
+}
+
+func (q *Queries) GetChatSession(ctx context.Context, arg GetChatSessionParams) (ChatSession, error) {
+	row := q.db.QueryRowContext(ctx, getChatSession, arg.ID, arg.Uid)
+	var i ChatSession
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Title,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getDiary = `-- name: GetDiary :one
+SELECT
+    id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3, month_day, version
+FROM
+    diary
+WHERE
+    date = ?
+    AND uid = ?
+LIMIT
+    1
+`
+
+type GetDiaryParams struct {
+	Date string
+	Uid  string
+}
+
+// sqlc generate -f ./projects/deario/sqlc.yaml
+func (q *Queries) GetDiary(ctx context.Context, arg GetDiaryParams) (Diary, error) {
+	row := q.db.QueryRowContext(ctx, getDiary, arg.Date, arg.Uid)
+	var i Diary
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Date,
+		&i.Content,
+		&i.AiFeedback,
+		&i.AiImage,
+		&i.Created,
+		&i.Updated,
+		&i.Mood,
+		&i.ImageUrl1,
+		&i.ImageUrl2,
+		&i.ImageUrl3,
+		&i.MonthDay,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getDiaryDraft = `-- name: GetDiaryDraft :one
+SELECT
+    uid, date, content, base_version, created, updated
+FROM
+    diary_draft
+WHERE
+    uid = ?
+    AND date = ?
+`
+
+type GetDiaryDraftParams struct {
+	Uid  string
+	Date string
+}
+
+func (q *Queries) GetDiaryDraft(ctx context.Context, arg GetDiaryDraftParams) (DiaryDraft, error) {
+	row := q.db.QueryRowContext(ctx, getDiaryDraft, arg.Uid, arg.Date)
+	var i DiaryDraft
+	err := row.Scan(
+		&i.Uid,
+		&i.Date,
+		&i.Content,
+		&i.BaseVersion,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getDiaryEmbedding = `-- name: GetDiaryEmbedding :one
+SELECT
+    diary_id, uid, date, model, content_hash, vector, updated
+FROM
+    diary_embedding
+WHERE
+    diary_id = ?
+`
+
+func (q *Queries) GetDiaryEmbedding(ctx context.Context, diaryID string) (DiaryEmbedding, error) {
+	row := q.db.QueryRowContext(ctx, getDiaryEmbedding, diaryID)
+	var i DiaryEmbedding
+	err := row.Scan(
+		&i.DiaryID,
+		&i.Uid,
+		&i.Date,
+		&i.Model,
+		&i.ContentHash,
+		&i.Vector,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getDiaryRandom = `-- name: GetDiaryRandom :one
+SELECT
+    id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3, month_day, version
+FROM
+    diary
+WHERE
+    date IS NOT NULL
+    AND uid = ?
+    AND date >= ?
+ORDER BY
+    RANDOM ()
+LIMIT
+    1
+`
+
+type GetDiaryRandomParams struct {
+	Uid  string
+	Date string
+}
+
+func (q *Queries) GetDiaryRandom(ctx context.Context, arg GetDiaryRandomParams) (Diary, error) {
+	row := q.db.QueryRowContext(ctx, getDiaryRandom, arg.Uid, arg.Date)
+	var i Diary
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Date,
+		&i.Content,
+		&i.AiFeedback,
+		&i.AiImage,
+		&i.Created,
+		&i.Updated,
+		&i.Mood,
+		&i.ImageUrl1,
+		&i.ImageUrl2,
+		&i.ImageUrl3,
+		&i.MonthDay,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getDiaryShare = `-- name: GetDiaryShare :one
+SELECT
+    id, uid, date, pin_hash, include_ai, include_images, expires, revoked, view_count, last_viewed, pin_failures, locked_until, created, updated, pin_attempts
+FROM
+    diary_share
+WHERE
+    id = ?
+`
+
+func (q *Queries) GetDiaryShare(ctx context.Context, id string) (DiaryShare, error) {
+	row := q.db.QueryRowContext(ctx, getDiaryShare, id)
+	var i DiaryShare
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Date,
+		&i.PinHash,
+		&i.IncludeAi,
+		&i.IncludeImages,
+		&i.Expires,
+		&i.Revoked,
+		&i.ViewCount,
+		&i.LastViewed,
+		&i.PinFailures,
+		&i.LockedUntil,
+		&i.Created,
+		&i.Updated,
+		&i.PinAttempts,
+	)
+	return i, err
+}
+
+const getDiaryTemplate = `-- name: GetDiaryTemplate :one
+SELECT
+    id, uid, name, content, created, updated
+FROM
+    diary_template
+WHERE
+    id = ?
+    AND uid = ?
+`
+
+type GetDiaryTemplateParams struct {
+	ID  string
+	Uid string
+}
+
+func (q *Queries) GetDiaryTemplate(ctx context.Context, arg GetDiaryTemplateParams) (DiaryTemplate, error) {
+	row := q.db.QueryRowContext(ctx, getDiaryTemplate, arg.ID, arg.Uid)
+	var i DiaryTemplate
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Name,
+		&i.Content,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getFirstDiaryDate = `-- name: GetFirstDiaryDate :one
+SELECT
+    CAST(COALESCE(MIN(date), '') AS TEXT) AS date
+FROM
+    diary
+WHERE
+    uid = ?
+    AND content != ''
+`
+
+func (q *Queries) GetFirstDiaryDate(ctx context.Context, uid string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getFirstDiaryDate, uid)
+	var date string
+	err := row.Scan(&date)
+	return date, err
+}
+
+const getLatestDiaryChangeSeq = `-- name: GetLatestDiaryChangeSeq :one
+SELECT
+    CAST(COALESCE(MAX(seq), 0) AS INTEGER) AS seq
+FROM
+    diary_change
+WHERE
+    uid = ?
+`
+
+func (q *Queries) GetLatestDiaryChangeSeq(ctx context.Context, uid string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getLatestDiaryChangeSeq, uid)
+	var seq int64
+	err := row.Scan(&seq)
+	return seq, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT
+    uid, name, email, created, updated
+FROM
+    user
+WHERE
+    uid = ?
+LIMIT
+    1
+`
+
+func (q *Queries) GetUser(ctx context.Context, uid string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, uid)
+	var i User
+	err := row.Scan(
+		&i.Uid,
+		&i.Name,
+		&i.Email,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getUserSetting = `-- name: GetUserSetting :one
+SELECT
+    uid, is_push, push_token, push_time, random_range, created, updated, app_lock_enabled, app_lock_pin_hash, monthly_goal, timezone, memory_push, auto_template, weekly_summary, monthly_summary, keep_voice_audio, app_lock_force_logout, sessions_revoked_at
+FROM
+    user_setting
+WHERE
+    uid = ?
+LIMIT
+    1
+`
+
+func (q *Queries) GetUserSetting(ctx context.Context, uid string) (UserSetting, error) {
+	row := q.db.QueryRowContext(ctx, getUserSetting, uid)
+	var i UserSetting
+	err := row.Scan(
+		&i.Uid,
+		&i.IsPush,
+		&i.PushToken,
+		&i.PushTime,
+		&i.RandomRange,
+		&i.Created,
+		&i.Updated,
+		&i.AppLockEnabled,
+		&i.AppLockPinHash,
+		&i.MonthlyGoal,
+		&i.Timezone,
+		&i.MemoryPush,
+		&i.AutoTemplate,
+		&i.WeeklySummary,
+		&i.MonthlySummary,
+		&i.KeepVoiceAudio,
+		&i.AppLockForceLogout,
+		&i.SessionsRevokedAt,
+	)
+	return i, err
+}
+
+const getVoiceNote = `-- name: GetVoiceNote :one
+SELECT
+    id, uid, date, mime_type, size, status, progress, keep_audio, object, transcript, error, created, updated
+FROM
+    voice_note
+WHERE
+    id = ?
+    AND uid = ?
+`
+
+type GetVoiceNoteParams struct {
+	ID  string
+	Uid string
+}
+
+func (q *Queries) GetVoiceNote(ctx context.Context, arg GetVoiceNoteParams) (VoiceNote, error) {
+	row := q.db.QueryRowContext(ctx, getVoiceNote, arg.ID, arg.Uid)
+	var i VoiceNote
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Date,
+		&i.MimeType,
+		&i.Size,
+		&i.Status,
+		&i.Progress,
+		&i.KeepAudio,
+		&i.Object,
+		&i.Transcript,
+		&i.Error,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getVoiceNoteByID = `-- name: GetVoiceNoteByID :one
+SELECT
+    id, uid, date, mime_type, size, status, progress, keep_audio, object, transcript, error, created, updated
+FROM
+    voice_note
+WHERE
+    id = ?
+`
+
+func (q *Queries) GetVoiceNoteByID(ctx context.Context, id string) (VoiceNote, error) {
+	row := q.db.QueryRowContext(ctx, getVoiceNoteByID, id)
+	var i VoiceNote
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Date,
+		&i.MimeType,
+		&i.Size,
+		&i.Status,
+		&i.Progress,
+		&i.KeepAudio,
+		&i.Object,
+		&i.Transcript,
+		&i.Error,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT
+    id, uid, url, secret, events, enabled, failure_count, disabled_reason, created, updated
+FROM
+    webhook
+WHERE
+    id = ?
+`
+
+func (q *Queries) GetWebhook(ctx context.Context, id string) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, getWebhook, id)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Enabled,
+		&i.FailureCount,
+		&i.DisabledReason,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT
+    id, webhook_id, uid, event, payload, status, attempts, response_status, error, next_attempt, created, updated
+FROM
+    webhook_delivery
+WHERE
+    id = ?
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id string) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.Uid,
+		&i.Event,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseStatus,
+		&i.Error,
+		&i.NextAttempt,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const incrementSafetyEvent = `-- name: IncrementSafetyEvent :exec
+INSERT INTO
+    safety_event (day, source, level, count)
+VALUES
+    (?, ?, ?, 1) ON CONFLICT (day, source, level) DO
+UPDATE
+SET
+    count = count + 1
+`
+
+type IncrementSafetyEventParams struct {
+	Day    string
+	Source string
+	Level  string
+}
+
+func (q *Queries) IncrementSafetyEvent(ctx context.Context, arg IncrementSafetyEventParams) error {
+	_, err := q.db.ExecContext(ctx, incrementSafetyEvent, arg.Day, arg.Source, arg.Level)
+	return err
+}
+
+const insertDiaryContent = `-- name: InsertDiaryContent :one
+INSERT INTO
+    diary (uid, content, date, version)
+VALUES
+    (
+        ?1,
+        ?2,
+        ?3,
+        COALESCE(
+            (
+                SELECT
+                    diary_tombstone.version
+                FROM
+                    diary_tombstone
+                WHERE
+                    diary_tombstone.uid = ?1
+                    AND diary_tombstone.date = ?3
+            ),
+            0
+        ) + 1
+    ) ON CONFLICT (uid, date) DO NOTHING RETURNING id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3, month_day, version
+`
+
+type InsertDiaryContentParams struct {
+	Uid     string
+	Content string
+	Date    string
+}
+
+func (q *Queries) InsertDiaryContent(ctx context.Context, arg InsertDiaryContentParams) (Diary, error) {
+	row := q.db.QueryRowContext(ctx, insertDiaryContent, arg.Uid, arg.Content, arg.Date)
+	var i Diary
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Date,
+		&i.Content,
+		&i.AiFeedback,
+		&i.AiImage,
+		&i.Created,
+		&i.Updated,
+		&i.Mood,
+		&i.ImageUrl1,
+		&i.ImageUrl2,
+		&i.ImageUrl3,
+		&i.MonthDay,
+		&i.Version,
+	)
+	return i, err
+}
+
+const insertDiaryTag = `-- name: InsertDiaryTag :exec
+INSERT INTO
+    diary_tag (diary_id, uid, tag)
+VALUES
+    (?, ?, ?) ON CONFLICT (diary_id, tag) DO NOTHING
+`
+
+type InsertDiaryTagParams struct {
+	DiaryID string
+	Uid     string
+	Tag     string
+}
+
+func (q *Queries) InsertDiaryTag(ctx context.Context, arg InsertDiaryTagParams) error {
+	_, err := q.db.ExecContext(ctx, insertDiaryTag, arg.DiaryID, arg.Uid, arg.Tag)
+	return err
+}
+
+const insertUserAchievement = `-- name: InsertUserAchievement :exec
+INSERT INTO
+    user_achievement (uid, code, achieved_date)
+VALUES
+    (?, ?, ?) ON CONFLICT (uid, code) DO NOTHING
+`
+
+type InsertUserAchievementParams struct {
+	Uid          string
+	Code         string
+	AchievedDate string
+}
+
+func (q *Queries) InsertUserAchievement(ctx context.Context, arg InsertUserAchievementParams) error {
+	_, err := q.db.ExecContext(ctx, insertUserAchievement, arg.Uid, arg.Code, arg.AchievedDate)
+	return err
+}
+
+const listAISummaries = `-- name: ListAISummaries :many
+SELECT
+    id, uid, period, start_date, end_date, content, diary_count, created, updated
+FROM
+    ai_summary
+WHERE
+    uid = ?
+ORDER BY
+    start_date DESC,
+    period
+LIMIT
+    ?
+`
+
+type ListAISummariesParams struct {
+	Uid   string
+	Limit int64
+}
+
+func (q *Queries) ListAISummaries(ctx context.Context, arg ListAISummariesParams) ([]AiSummary, error) {
+	rows, err := q.db.QueryContext(ctx, listAISummaries, arg.Uid, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AiSummary
+	for rows.Next() {
+		var i AiSummary
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Period,
+			&i.StartDate,
+			&i.EndDate,
+			&i.Content,
+			&i.DiaryCount,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAPITokens = `-- name: ListAPITokens :many
+SELECT
+    id, uid, name, prefix, token_hash, scopes, rate_limit, last_used, revoked, created
+FROM
+    api_token
+WHERE
+    uid = ?
+    AND revoked = ''
+ORDER BY
+    created DESC
+`
+
+func (q *Queries) ListAPITokens(ctx context.Context, uid string) ([]ApiToken, error) {
+	rows, err := q.db.QueryContext(ctx, listAPITokens, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiToken
+	for rows.Next() {
+		var i ApiToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Name,
+			&i.Prefix,
+			&i.TokenHash,
+			&i.Scopes,
+			&i.RateLimit,
+			&i.LastUsed,
+			&i.Revoked,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActiveDiaryShares = `-- name: ListActiveDiaryShares :many
+SELECT
+    id, uid, date, pin_hash, include_ai, include_images, expires, revoked, view_count, last_viewed, pin_failures, locked_until, created, updated, pin_attempts
+FROM
+    diary_share
+WHERE
+    uid = ?1
+    AND revoked = ''
+    AND expires > CAST(?2 AS TEXT)
+ORDER BY
+    created DESC
+`
+
+type ListActiveDiarySharesParams struct {
+	Uid string
+	Now string
+}
+
+func (q *Queries) ListActiveDiaryShares(ctx context.Context, arg ListActiveDiarySharesParams) ([]DiaryShare, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveDiaryShares, arg.Uid, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DiaryShare
+	for rows.Next() {
+		var i DiaryShare
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Date,
+			&i.PinHash,
+			&i.IncludeAi,
+			&i.IncludeImages,
+			&i.Expires,
+			&i.Revoked,
+			&i.ViewCount,
+			&i.LastViewed,
+			&i.PinFailures,
+			&i.LockedUntil,
+			&i.Created,
+			&i.Updated,
+			&i.PinAttempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllDiaryImageURLs = `-- name: ListAllDiaryImageURLs :many
+SELECT
+    image_url1,
+    image_url2,
+    image_url3
+FROM
+    diary
+WHERE
+    image_url1 != ''
+    OR image_url2 != ''
+    OR image_url3 != ''
+`
+
+type ListAllDiaryImageURLsRow struct {
+	ImageUrl1 string
+	ImageUrl2 string
+	ImageUrl3 string
+}
+
+func (q *Queries) ListAllDiaryImageURLs(ctx context.Context) ([]ListAllDiaryImageURLsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAllDiaryImageURLs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAllDiaryImageURLsRow
+	for rows.Next() {
+		var i ListAllDiaryImageURLsRow
+		if err := rows.Scan(&i.ImageUrl1, &i.ImageUrl2, &i.ImageUrl3); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChatMessages = `-- name: ListChatMessages :many
+SELECT
+    id, session_id, uid, role, content, citations, created
+FROM
+    chat_message
+WHERE
+    session_id = ?
+    AND uid = ?
+ORDER BY
+    created,
+    rowid
+`
+
+type ListChatMessagesParams struct {
+	SessionID string
+	Uid       string
+}
+
+func (q *Queries) ListChatMessages(ctx context.Context, arg ListChatMessagesParams) ([]ChatMessage, error) {
+	rows, err := q.db.QueryContext(ctx, listChatMessages, arg.SessionID, arg.Uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChatMessage
+	for rows.Next() {
+		var i ChatMessage
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.Uid,
+			&i.Role,
+			&i.Content,
+			&i.Citations,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChatSessions = `-- name: ListChatSessions :many
+SELECT
+    id, uid, title, created, updated
+FROM
+    chat_session
+WHERE
+    uid = ?
+ORDER BY
+    updated DESC
+LIMIT
+    ?
+`
+
+type ListChatSessionsParams struct {
+	Uid   string
+	Limit int64
+}
+
+func (q *Queries) ListChatSessions(ctx context.Context, arg ListChatSessionsParams) ([]ChatSession, error) {
+	rows, err := q.db.QueryContext(ctx, listChatSessions, arg.Uid, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChatSession
+	for rows.Next() {
+		var i ChatSession
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Title,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiariesInRange = `-- name: ListDiariesInRange :many
+SELECT
+    date,
+    content,
+    mood
+FROM
+    diary
+WHERE
+    uid = ?
+    AND content != ''
+    AND date >= ?2
+    AND date <= ?3
+ORDER BY
+    date
+`
+
+type ListDiariesInRangeParams struct {
+	Uid       string
+	StartDate string
+	EndDate   string
+}
+
+type ListDiariesInRangeRow struct {
+	Date    string
+	Content string
+	Mood    string
+}
+
+func (q *Queries) ListDiariesInRange(ctx context.Context, arg ListDiariesInRangeParams) ([]ListDiariesInRangeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDiariesInRange, arg.Uid, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDiariesInRangeRow
+	for rows.Next() {
+		var i ListDiariesInRangeRow
+		if err := rows.Scan(&i.Date, &i.Content, &i.Mood); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiariesMissingEmbedding = `-- name: ListDiariesMissingEmbedding :many
+SELECT
+    diary.uid,
+    diary.date
+FROM
+    diary
+    LEFT JOIN diary_embedding ON diary_embedding.diary_id = diary.id
+    AND diary_embedding.model = ?2
+WHERE
+    diary.content != ''
+    AND (
+        diary_embedding.diary_id IS NULL
+        OR diary_embedding.updated < diary.updated
+    )
+ORDER BY
+    diary.updated DESC
+LIMIT
+    ?
+`
+
+type ListDiariesMissingEmbeddingParams struct {
+	Model string
+	Limit int64
+}
+
+type ListDiariesMissingEmbeddingRow struct {
+	Uid  string
+	Date string
+}
+
+func (q *Queries) ListDiariesMissingEmbedding(ctx context.Context, arg ListDiariesMissingEmbeddingParams) ([]ListDiariesMissingEmbeddingRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDiariesMissingEmbedding, arg.Model, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDiariesMissingEmbeddingRow
+	for rows.Next() {
+		var i ListDiariesMissingEmbeddingRow
+		if err := rows.Scan(&i.Uid, &i.Date); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiariesOnThisDay = `-- name: ListDiariesOnThisDay :many
+SELECT
+    date,
+    content,
+    mood
+FROM
+    diary
+WHERE
+    uid = ?
+    AND month_day IN (?2, ?3)
+    AND date < ?4
+    AND content != ''
+ORDER BY
+    date DESC
+`
+
+type ListDiariesOnThisDayParams struct {
+	Uid           string
+	MonthDay      sql.NullString
+	ExtraMonthDay sql.NullString
+	BeforeDate    string
+}
+
+type ListDiariesOnThisDayRow struct {
+	Date    string
+	Content string
+	Mood    string
+}
+
+func (q *Queries) ListDiariesOnThisDay(ctx context.Context, arg ListDiariesOnThisDayParams) ([]ListDiariesOnThisDayRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDiariesOnThisDay,
+		arg.Uid,
+		arg.MonthDay,
+		arg.ExtraMonthDay,
+		arg.BeforeDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDiariesOnThisDayRow
+	for rows.Next() {
+		var i ListDiariesOnThisDayRow
+		if err := rows.Scan(&i.Date, &i.Content, &i.Mood); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiaryCalendarDays = `-- name: ListDiaryCalendarDays :many
+SELECT
+    date,
+    mood,
+    content,
+    CAST(
+        image_url1 != ''
+        OR image_url2 != ''
+        OR image_url3 != '' AS INTEGER
+    ) AS has_image,
+    CAST(
+        ai_feedback != ''
+        OR ai_image != '' AS INTEGER
+    ) AS has_ai_feedback
+FROM
+    diary
+WHERE
+    uid = ?
+    AND date >= ?2
+    AND date <= ?3
+ORDER BY
+    date
+`
+
+type ListDiaryCalendarDaysParams struct {
+	Uid       string
+	StartDate string
+	EndDate   string
+}
+
+type ListDiaryCalendarDaysRow struct {
+	Date          string
+	Mood          string
+	Content       string
+	HasImage      int64
+	HasAiFeedback int64
+}
+
+func (q *Queries) ListDiaryCalendarDays(ctx context.Context, arg ListDiaryCalendarDaysParams) ([]ListDiaryCalendarDaysRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDiaryCalendarDays, arg.Uid, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDiaryCalendarDaysRow
+	for rows.Next() {
+		var i ListDiaryCalendarDaysRow
+		if err := rows.Scan(
+			&i.Date,
+			&i.Mood,
+			&i.Content,
+			&i.HasImage,
+			&i.HasAiFeedback,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiaryChangesSince = `-- name: ListDiaryChangesSince :many
+SELECT
+    changes.date,
+    CAST(changes.seq AS INTEGER) AS seq,
+    CAST(diary.id IS NOT NULL AS INTEGER) AS present,
+    CAST(COALESCE(diary.content, '') AS TEXT) AS content,
+    CAST(COALESCE(diary.mood, '0') AS TEXT) AS mood,
+    CAST(COALESCE(diary.version, 0) AS INTEGER) AS version,
+    CAST(COALESCE(diary.updated, '') AS TEXT) AS updated
+FROM
+    (
+        SELECT
+            diary_change.date,
+            MAX(diary_change.seq) AS seq
+        FROM
+            diary_change
+        WHERE
+            diary_change.uid = ?1
+            AND diary_change.seq > ?2
+        GROUP BY
+            diary_change.date
+    ) AS changes
+    LEFT JOIN diary ON diary.uid = ?1
+    AND diary.date = changes.date
+ORDER BY
+    changes.seq
+LIMIT
+    ?3
+`
+
+type ListDiaryChangesSinceParams struct {
+	Uid      string
+	Since    int64
+	PageSize int64
+}
+
+type ListDiaryChangesSinceRow struct {
+	Date    string
+	Seq     int64
+	Present int64
+	Content string
+	Mood    string
+	Version int64
+	Updated string
+}
+
+func (q *Queries) ListDiaryChangesSince(ctx context.Context, arg ListDiaryChangesSinceParams) ([]ListDiaryChangesSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDiaryChangesSince, arg.Uid, arg.Since, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDiaryChangesSinceRow
+	for rows.Next() {
+		var i ListDiaryChangesSinceRow
+		if err := rows.Scan(
+			&i.Date,
+			&i.Seq,
+			&i.Present,
+			&i.Content,
+			&i.Mood,
+			&i.Version,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiaryEmbeddings = `-- name: ListDiaryEmbeddings :many
+SELECT
+    diary.date,
+    diary.content,
+    diary.mood,
+    diary_embedding.vector
+FROM
+    diary_embedding
+    JOIN diary ON diary.id = diary_embedding.diary_id
+WHERE
+    diary_embedding.uid = ?
+    AND diary_embedding.model = ?
+    AND diary.content != ''
+ORDER BY
+    diary.date DESC
+`
+
+type ListDiaryEmbeddingsParams struct {
+	Uid   string
+	Model string
+}
+
+type ListDiaryEmbeddingsRow struct {
+	Date    string
+	Content string
+	Mood    string
+	Vector  []byte
+}
+
+func (q *Queries) ListDiaryEmbeddings(ctx context.Context, arg ListDiaryEmbeddingsParams) ([]ListDiaryEmbeddingsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDiaryEmbeddings, arg.Uid, arg.Model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDiaryEmbeddingsRow
+	for rows.Next() {
+		var i ListDiaryEmbeddingsRow
+		if err := rows.Scan(
+			&i.Date,
+			&i.Content,
+			&i.Mood,
+			&i.Vector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiaryImageURLs = `-- name: ListDiaryImageURLs :many
+SELECT
+    image_url1,
+    image_url2,
+    image_url3
+FROM
+    diary
+WHERE
+    uid = ?
+    AND (
+        image_url1 != ''
+        OR image_url2 != ''
+        OR image_url3 != ''
+    )
+`
+
+type ListDiaryImageURLsRow struct {
+	ImageUrl1 string
+	ImageUrl2 string
+	ImageUrl3 string
+}
+
+func (q *Queries) ListDiaryImageURLs(ctx context.Context, uid string) ([]ListDiaryImageURLsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDiaryImageURLs, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDiaryImageURLsRow
+	for rows.Next() {
+		var i ListDiaryImageURLsRow
+		if err := rows.Scan(&i.ImageUrl1, &i.ImageUrl2, &i.ImageUrl3); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiaryStatRows = `-- name: ListDiaryStatRows :many
+SELECT
+    date,
+    content,
+    mood,
+    created
+FROM
+    diary
+WHERE
+    uid = ?
+    AND content != ''
+    AND date >= ?2
+    AND date <= ?3
+ORDER BY
+    date
+`
+
+type ListDiaryStatRowsParams struct {
+	Uid       string
+	StartDate string
+	EndDate   string
+}
+
+type ListDiaryStatRowsRow struct {
+	Date    string
+	Content string
+	Mood    string
+	Created sql.NullString
+}
+
+func (q *Queries) ListDiaryStatRows(ctx context.Context, arg ListDiaryStatRowsParams) ([]ListDiaryStatRowsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDiaryStatRows, arg.Uid, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDiaryStatRowsRow
+	for rows.Next() {
+		var i ListDiaryStatRowsRow
+		if err := rows.Scan(
+			&i.Date,
+			&i.Content,
+			&i.Mood,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiaryTemplates = `-- name: ListDiaryTemplates :many
+SELECT
+    id, uid, name, content, created, updated
+FROM
+    diary_template
+WHERE
+    uid = ?
+ORDER BY
+    created
+`
+
+func (q *Queries) ListDiaryTemplates(ctx context.Context, uid string) ([]DiaryTemplate, error) {
+	rows, err := q.db.QueryContext(ctx, listDiaryTemplates, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DiaryTemplate
+	for rows.Next() {
+		var i DiaryTemplate
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Name,
+			&i.Content,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiaryWrittenDates = `-- name: ListDiaryWrittenDates :many
+SELECT
+    date
+FROM
+    diary
+WHERE
+    uid = ?
+    AND content != ''
+ORDER BY
+    date DESC
+`
+
+func (q *Queries) ListDiaryWrittenDates(ctx context.Context, uid string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listDiaryWrittenDates, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		items = append(items, date)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiarys = `-- name: ListDiarys :many
+SELECT
+    id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3, month_day, version
+FROM
+    diary
+WHERE
+    uid = ?
+    AND (
+        date < ?2
+        OR (
+            date = ?2
+            AND id < ?3
+        )
+        OR ?2 = ''
+    )
+ORDER BY
+    date DESC,
+    id DESC
+LIMIT
+    ?4
+`
+
+type ListDiarysParams struct {
+	Uid        string
+	CursorDate string
+	CursorID   string
+	PageSize   int64
+}
+
+func (q *Queries) ListDiarys(ctx context.Context, arg ListDiarysParams) ([]Diary, error) {
+	rows, err := q.db.QueryContext(ctx, listDiarys,
+		arg.Uid,
+		arg.CursorDate,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Diary
+	for rows.Next() {
+		var i Diary
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Date,
+			&i.Content,
+			&i.AiFeedback,
+			&i.AiImage,
+			&i.Created,
+			&i.Updated,
+			&i.Mood,
+			&i.ImageUrl1,
+			&i.ImageUrl2,
+			&i.ImageUrl3,
+			&i.MonthDay,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiarysByTag = `-- name: ListDiarysByTag :many
+SELECT
+    diary.id, diary.uid, diary.date, diary.content, diary.ai_feedback, diary.ai_image, diary.created, diary.updated, diary.mood, diary.image_url1, diary.image_url2, diary.image_url3, diary.month_day, diary.version
+FROM
+    diary
+    JOIN diary_tag ON diary_tag.diary_id = diary.id
+WHERE
+    diary.uid = ?
+    AND diary_tag.tag = ?
+    AND (
+        diary.date < ?3
+        OR (
+            diary.date = ?3
+            AND diary.id < ?4
+        )
+        OR ?3 = ''
+    )
+ORDER BY
+    diary.date DESC,
+    diary.id DESC
+LIMIT
+    ?5
+`
+
+type ListDiarysByTagParams struct {
+	Uid        string
+	Tag        string
+	CursorDate string
+	CursorID   string
+	PageSize   int64
+}
+
+func (q *Queries) ListDiarysByTag(ctx context.Context, arg ListDiarysByTagParams) ([]Diary, error) {
+	rows, err := q.db.QueryContext(ctx, listDiarysByTag,
+		arg.Uid,
+		arg.Tag,
+		arg.CursorDate,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Diary
+	for rows.Next() {
+		var i Diary
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Date,
+			&i.Content,
+			&i.AiFeedback,
+			&i.AiImage,
+			&i.Created,
+			&i.Updated,
+			&i.Mood,
+			&i.ImageUrl1,
+			&i.ImageUrl2,
+			&i.ImageUrl3,
+			&i.MonthDay,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDiarysWithTag = `-- name: ListDiarysWithTag :many
+SELECT
+    diary.id, diary.uid, diary.date, diary.content, diary.ai_feedback, diary.ai_image, diary.created, diary.updated, diary.mood, diary.image_url1, diary.image_url2, diary.image_url3, diary.month_day, diary.version
+FROM
+    diary
+    JOIN diary_tag ON diary_tag.diary_id = diary.id
+WHERE
+    diary.uid = ?
+    AND diary_tag.tag = ?
+`
+
+type ListDiarysWithTagParams struct {
+	Uid string
+	Tag string
+}
+
+func (q *Queries) ListDiarysWithTag(ctx context.Context, arg ListDiarysWithTagParams) ([]Diary, error) {
+	rows, err := q.db.QueryContext(ctx, listDiarysWithTag, arg.Uid, arg.Tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Diary
+	for rows.Next() {
+		var i Diary
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Date,
+			&i.Content,
+			&i.AiFeedback,
+			&i.AiImage,
+			&i.Created,
+			&i.Updated,
+			&i.Mood,
+			&i.ImageUrl1,
+			&i.ImageUrl2,
+			&i.ImageUrl3,
+			&i.MonthDay,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueAccountDeletions = `-- name: ListDueAccountDeletions :many
+SELECT
+    uid
+FROM
+    account_deletion
+WHERE
+    status = 'scheduled'
+    AND purge_after <= CAST(?1 AS TEXT)
+ORDER BY
+    purge_after
+`
+
+func (q *Queries) ListDueAccountDeletions(ctx context.Context, now string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listDueAccountDeletions, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		items = append(items, uid)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredImageQuarantine = `-- name: ListExpiredImageQuarantine :many
+SELECT
+    id,
+    object
+FROM
+    image_quarantine
+WHERE
+    created < CAST(?1 AS TEXT)
+`
+
+type ListExpiredImageQuarantineRow struct {
+	ID     string
+	Object string
+}
+
+func (q *Queries) ListExpiredImageQuarantine(ctx context.Context, before string) ([]ListExpiredImageQuarantineRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredImageQuarantine, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListExpiredImageQuarantineRow
+	for rows.Next() {
+		var i ListExpiredImageQuarantineRow
+		if err := rows.Scan(&i.ID, &i.Object); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMemoryPushTargets = `-- name: ListMemoryPushTargets :many
+SELECT
+    uid,
+    push_token,
+    timezone
+FROM
+    user_setting
+WHERE
+    is_push = 1
+    AND memory_push = 1
+    AND push_token != ''
+    AND uid NOT IN (
+        SELECT
+            uid
+        FROM
+            account_deletion
+    )
+`
+
+type ListMemoryPushTargetsRow struct {
+	Uid       string
+	PushToken string
+	Timezone  string
+}
+
+func (q *Queries) ListMemoryPushTargets(ctx context.Context) ([]ListMemoryPushTargetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMemoryPushTargets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMemoryPushTargetsRow
+	for rows.Next() {
+		var i ListMemoryPushTargetsRow
+		if err := rows.Scan(&i.Uid, &i.PushToken, &i.Timezone); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPrunableVoiceNotes = `-- name: ListPrunableVoiceNotes :many
+SELECT
+    id,
+    object
+FROM
+    voice_note
+WHERE
+    updated < CAST(?1 AS TEXT)
+    AND (
+        status != 'done'
+        OR (
+            object = ''
+            AND transcript = ''
+        )
+    )
+`
+
+type ListPrunableVoiceNotesRow struct {
+	ID     string
+	Object string
+}
+
+func (q *Queries) ListPrunableVoiceNotes(ctx context.Context, before string) ([]ListPrunableVoiceNotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPrunableVoiceNotes, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPrunableVoiceNotesRow
+	for rows.Next() {
+		var i ListPrunableVoiceNotesRow
+		if err := rows.Scan(&i.ID, &i.Object); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPushTargets = `-- name: ListPushTargets :many
+SELECT
+    uid,
+    push_token,
+    push_time,
+    random_range
+FROM
+    user_setting
+WHERE
+    is_push = 1
+    AND push_token != ''
+    AND push_time != ''
+    AND uid NOT IN (
+        SELECT
+            uid
+        FROM
+            account_deletion
+    )
+`
+
+type ListPushTargetsRow struct {
+	Uid         string
+	PushToken   string
+	PushTime    string
+	RandomRange int64
+}
+
+func (q *Queries) ListPushTargets(ctx context.Context) ([]ListPushTargetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPushTargets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPushTargetsRow
+	for rows.Next() {
+		var i ListPushTargetsRow
+		if err := rows.Scan(
+			&i.Uid,
+			&i.PushToken,
+			&i.PushTime,
+			&i.RandomRange,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentChatDiaries = `-- name: ListRecentChatDiaries :many
+SELECT
+    date,
+    content,
+    mood
+FROM
+    diary
+WHERE
+    uid = ?
+    AND content != ''
+ORDER BY
+    date DESC
+LIMIT
+    ?
+`
+
+type ListRecentChatDiariesParams struct {
+	Uid   string
+	Limit int64
+}
+
+type ListRecentChatDiariesRow struct {
+	Date    string
+	Content string
+	Mood    string
+}
+
+func (q *Queries) ListRecentChatDiaries(ctx context.Context, arg ListRecentChatDiariesParams) ([]ListRecentChatDiariesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRecentChatDiaries, arg.Uid, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRecentChatDiariesRow
+	for rows.Next() {
+		var i ListRecentChatDiariesRow
+		if err := rows.Scan(&i.Date, &i.Content, &i.Mood); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSafetyEvents = `-- name: ListSafetyEvents :many
+SELECT
+    day, source, level, count
+FROM
+    safety_event
+WHERE
+    day >= ?
+ORDER BY
+    day DESC,
+    source,
+    level
+`
+
+func (q *Queries) ListSafetyEvents(ctx context.Context, day string) ([]SafetyEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listSafetyEvents, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SafetyEvent
+	for rows.Next() {
+		var i SafetyEvent
+		if err := rows.Scan(
+			&i.Day,
+			&i.Source,
+			&i.Level,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStreakNudgeTargets = `-- name: ListStreakNudgeTargets :many
+SELECT
+    uid,
+    push_token,
+    timezone
+FROM
+    user_setting
+WHERE
+    is_push = 1
+    AND push_token != ''
+    AND uid NOT IN (
+        SELECT
+            uid
+        FROM
+            account_deletion
+    )
+`
+
+type ListStreakNudgeTargetsRow struct {
+	Uid       string
+	PushToken string
+	Timezone  string
+}
+
+func (q *Queries) ListStreakNudgeTargets(ctx context.Context) ([]ListStreakNudgeTargetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStreakNudgeTargets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStreakNudgeTargetsRow
+	for rows.Next() {
+		var i ListStreakNudgeTargetsRow
+		if err := rows.Scan(&i.Uid, &i.PushToken, &i.Timezone); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSummaryTargets = `-- name: ListSummaryTargets :many
+SELECT
+    uid,
+    is_push,
+    push_token,
+    timezone,
+    weekly_summary,
+    monthly_summary
+FROM
+    user_setting
+WHERE
+    (
+        weekly_summary = 1
+        OR monthly_summary = 1
+    )
+    AND uid NOT IN (
+        SELECT
+            uid
+        FROM
+            account_deletion
+    )
+`
+
+type ListSummaryTargetsRow struct {
+	Uid            string
+	IsPush         int64
+	PushToken      string
+	Timezone       string
+	WeeklySummary  int64
+	MonthlySummary int64
+}
+
+func (q *Queries) ListSummaryTargets(ctx context.Context) ([]ListSummaryTargetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSummaryTargets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSummaryTargetsRow
+	for rows.Next() {
+		var i ListSummaryTargetsRow
+		if err := rows.Scan(
+			&i.Uid,
+			&i.IsPush,
+			&i.PushToken,
+			&i.Timezone,
+			&i.WeeklySummary,
+			&i.MonthlySummary,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserAchievements = `-- name: ListUserAchievements :many
+SELECT
+    id, uid, code, achieved_date, created, updated
+FROM
+    user_achievement
+WHERE
+    uid = ?
+ORDER BY
+    achieved_date,
+    created
+`
+
+func (q *Queries) ListUserAchievements(ctx context.Context, uid string) ([]UserAchievement, error) {
+	rows, err := q.db.QueryContext(ctx, listUserAchievements, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserAchievement
+	for rows.Next() {
+		var i UserAchievement
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Code,
+			&i.AchievedDate,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserTags = `-- name: ListUserTags :many
+SELECT
+    tag,
+    COUNT(*) AS count
+FROM
+    diary_tag
+WHERE
+    uid = ?
+GROUP BY
+    tag
+ORDER BY
+    count DESC,
+    tag
+`
+
+type ListUserTagsRow struct {
+	Tag   string
+	Count int64
+}
+
+func (q *Queries) ListUserTags(ctx context.Context, uid string) ([]ListUserTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserTags, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserTagsRow
+	for rows.Next() {
+		var i ListUserTagsRow
+		if err := rows.Scan(&i.Tag, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVoiceNotes = `-- name: ListVoiceNotes :many
+SELECT
+    id, uid, date, mime_type, size, status, progress, keep_audio, object, transcript, error, created, updated
+FROM
+    voice_note
+WHERE
+    uid = ?
+    AND date = ?
+    AND status = 'done'
+    AND (
+        object != ''
+        OR transcript != ''
+    )
+ORDER BY
+    created
+`
+
+type ListVoiceNotesParams struct {
+	Uid  string
+	Date string
+}
+
+func (q *Queries) ListVoiceNotes(ctx context.Context, arg ListVoiceNotesParams) ([]VoiceNote, error) {
+	rows, err := q.db.QueryContext(ctx, listVoiceNotes, arg.Uid, arg.Date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VoiceNote
+	for rows.Next() {
+		var i VoiceNote
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Date,
+			&i.MimeType,
+			&i.Size,
+			&i.Status,
+			&i.Progress,
+			&i.KeepAudio,
+			&i.Object,
+			&i.Transcript,
+			&i.Error,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT
+    id, webhook_id, uid, event, payload, status, attempts, response_status, error, next_attempt, created, updated
+FROM
+    webhook_delivery
+WHERE
+    webhook_id = ?
+    AND uid = ?
+ORDER BY
+    created DESC
+LIMIT
+    20
+`
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID string
+	Uid       string
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookDeliveries, arg.WebhookID, arg.Uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Uid,
+			&i.Event,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseStatus,
+			&i.Error,
+			&i.NextAttempt,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT
+    id, uid, url, secret, events, enabled, failure_count, disabled_reason, created, updated
+FROM
+    webhook
+WHERE
+    uid = ?
+ORDER BY
+    created
+`
+
+func (q *Queries) ListWebhooks(ctx context.Context, uid string) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhooks, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.Enabled,
+			&i.FailureCount,
+			&i.DisabledReason,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooksForEvent = `-- name: ListWebhooksForEvent :many
+SELECT
+    id, uid, url, secret, events, enabled, failure_count, disabled_reason, created, updated
+FROM
+    webhook
+WHERE
+    uid = ?1
+    AND enabled = 1
+    AND (' ' || events || ' ') LIKE '% ' || ?2 || ' %'
+`
+
+type ListWebhooksForEventParams struct {
+	Uid   string
+	Event sql.NullString
+}
+
+func (q *Queries) ListWebhooksForEvent(ctx context.Context, arg ListWebhooksForEventParams) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhooksForEvent, arg.Uid, arg.Event)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.Enabled,
+			&i.FailureCount,
+			&i.DisabledReason,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockAppLockAttempt = `-- name: LockAppLockAttempt :execrows
+UPDATE app_lock_attempt
+SET
+    failures = 0,
+    lockouts = lockouts + 1,
+    locked_until = CAST(?1 AS TEXT),
+    updated = datetime ('now')
+WHERE
+    uid = ?2
+    AND failures >= ?3
+`
+
+type LockAppLockAttemptParams struct {
+	LockedUntil string
+	Uid         string
+	MaxFailures int64
+}
+
+func (q *Queries) LockAppLockAttempt(ctx context.Context, arg LockAppLockAttemptParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, lockAppLockAttempt, arg.LockedUntil, arg.Uid, arg.MaxFailures)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markAccountDeletionPurging = `-- name: MarkAccountDeletionPurging :execrows
+UPDATE account_deletion
+SET
+    status = 'purging',
+    updated = CURRENT_TIMESTAMP
+WHERE
+    uid = ?
+    AND status = 'scheduled'
+`
+
+func (q *Queries) MarkAccountDeletionPurging(ctx context.Context, uid string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markAccountDeletionPurging, uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const pruneAICache = `-- name: PruneAICache :execrows
+DELETE FROM ai_cache
+WHERE
+    expires <= CAST(?1 AS TEXT)
+`
+
+func (q *Queries) PruneAICache(ctx context.Context, now string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pruneAICache, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const pruneDiaryChanges = `-- name: PruneDiaryChanges :execrows
+DELETE FROM diary_change
+WHERE
+    diary_change.updated < CAST(?1 AS TEXT)
+    AND diary_change.seq NOT IN (
+        SELECT
+            MAX(latest.seq)
+        FROM
+            diary_change AS latest
+        GROUP BY
+            latest.uid,
+            latest.date
+    )
+`
+
+func (q *Queries) PruneDiaryChanges(ctx context.Context, before string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pruneDiaryChanges, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const pruneDiaryShares = `-- name: PruneDiaryShares :execrows
+DELETE FROM diary_share
+WHERE
+    expires < CAST(?1 AS TEXT)
+    OR (
+        revoked != ''
+        AND revoked < CAST(?1 AS TEXT)
+    )
+`
+
+func (q *Queries) PruneDiaryShares(ctx context.Context, before string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pruneDiaryShares, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const pruneWebhookDeliveries = `-- name: PruneWebhookDeliveries :execrows
+DELETE FROM webhook_delivery
+WHERE
+    created < CAST(?1 AS TEXT)
+`
+
+func (q *Queries) PruneWebhookDeliveries(ctx context.Context, before string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pruneWebhookDeliveries, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const queueVoiceNote = `-- name: QueueVoiceNote :execrows
+UPDATE voice_note
+SET
+    status = 'queued',
+    progress = 0,
+    mime_type = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND uid = ?
+    AND status = 'uploading'
+    AND size > 0
+`
+
+type QueueVoiceNoteParams struct {
+	MimeType string
+	ID       string
+	Uid      string
+}
+
+func (q *Queries) QueueVoiceNote(ctx context.Context, arg QueueVoiceNoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, queueVoiceNote, arg.MimeType, arg.ID, arg.Uid)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recordDiaryShareView = `-- name: RecordDiaryShareView :exec
+UPDATE diary_share
+SET
+    view_count = view_count + 1,
+    last_viewed = CURRENT_TIMESTAMP,
+    pin_failures = 0,
+    pin_attempts = 0,
+    locked_until = ''
+WHERE
+    id = ?
+`
+
+func (q *Queries) RecordDiaryShareView(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, recordDiaryShareView, id)
+	return err
+}
+
+const recordWebhookFailure = `-- name: RecordWebhookFailure :one
+UPDATE webhook
+SET
+    failure_count = failure_count + 1,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+RETURNING
+    failure_count
+`
+
+func (q *Queries) RecordWebhookFailure(ctx context.Context, id string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, recordWebhookFailure, id)
+	var failure_count int64
+	err := row.Scan(&failure_count)
+	return failure_count, err
+}
+
+const rescheduleAccountDeletion = `-- name: RescheduleAccountDeletion :exec
+UPDATE account_deletion
+SET
+    status = 'scheduled',
+    updated = CURRENT_TIMESTAMP
+WHERE
+    uid = ?
+`
+
+func (q *Queries) RescheduleAccountDeletion(ctx context.Context, uid string) error {
+	_, err := q.db.ExecContext(ctx, rescheduleAccountDeletion, uid)
+	return err
+}
+
+const reserveAppLockAttempt = `-- name: ReserveAppLockAttempt :one
+UPDATE app_lock_attempt
+SET
+    failures = failures + 1,
+    updated = datetime ('now')
+WHERE
+    uid = ?1
+    AND locked_until <= CAST(?2 AS TEXT)
+    AND failures < ?3
+RETURNING
+    uid, failures, lockouts, locked_until, updated
 `
 
-type GetDiaryRandomParams struct {
-	Uid  string
-	Date string
+type ReserveAppLockAttemptParams struct {
+	Uid         string
+	Now         string
+	MaxFailures int64
 }
 
-func (q *Queries) GetDiaryRandom(ctx context.Context, arg GetDiaryRandomParams) (Diary, error) {
-	row := q.db.QueryRowContext(ctx, getDiaryRandom, arg.Uid, arg.Date)
-	var i Diary
+func (q *Queries) ReserveAppLockAttempt(ctx context.Context, arg ReserveAppLockAttemptParams) (AppLockAttempt, error) {
+	row := q.db.QueryRowContext(ctx, reserveAppLockAttempt, arg.Uid, arg.Now, arg.MaxFailures)
+	var i AppLockAttempt
 	err := row.Scan(
-		&i.ID,
 		&i.Uid,
-		&i.Date,
-		&i.Content,
-		&i.AiFeedback,
-		&i.AiImage,
-		&i.Created,
+		&i.Failures,
+		&i.Lockouts,
+		&i.LockedUntil,
 		&i.Updated,
-		&i.Mood,
-		&i.ImageUrl1,
-		&i.ImageUrl2,
-		&i.ImageUrl3,
 	)
 	return i, err
 }
 
-const getUser = `-- name: GetUser :one
-SELECT
-    uid, name, email, created, updated
-FROM
-    user
+const reserveDiarySharePIN = `-- name: ReserveDiarySharePIN :one
+UPDATE diary_share
+SET
+    pin_failures = CASE
+        WHEN pin_failures + 1 >= ?1 THEN 0
+        ELSE pin_failures + 1
+    END,
+    locked_until = CASE
+        WHEN pin_failures + 1 >= ?1 THEN CAST(?2 AS TEXT)
+        ELSE ''
+    END,
+    pin_attempts = pin_attempts + 1
 WHERE
-    uid = ?
-LIMIT
-    1
+    id = ?3
+    AND revoked = ''
+    AND locked_until <= CAST(?4 AS TEXT)
+    AND pin_attempts < ?5
+RETURNING
+    id, uid, date, pin_hash, include_ai, include_images, expires, revoked, view_count, last_viewed, pin_failures, locked_until, created, updated, pin_attempts
 `
 
-func (q *Queries) GetUser(ctx context.Context, uid string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUser, uid)
-	var i User
+type ReserveDiarySharePINParams struct {
+	MaxFailures int64
+	LockUntil   string
+	ID          string
+	Now         string
+	MaxAttempts int64
+}
+
+func (q *Queries) ReserveDiarySharePIN(ctx context.Context, arg ReserveDiarySharePINParams) (DiaryShare, error) {
+	row := q.db.QueryRowContext(ctx, reserveDiarySharePIN,
+		arg.MaxFailures,
+		arg.LockUntil,
+		arg.ID,
+		arg.Now,
+		arg.MaxAttempts,
+	)
+	var i DiaryShare
 	err := row.Scan(
+		&i.ID,
 		&i.Uid,
-		&i.Name,
-		&i.Email,
+		&i.Date,
+		&i.PinHash,
+		&i.IncludeAi,
+		&i.IncludeImages,
+		&i.Expires,
+		&i.Revoked,
+		&i.ViewCount,
+		&i.LastViewed,
+		&i.PinFailures,
+		&i.LockedUntil,
 		&i.Created,
 		&i.Updated,
+		&i.PinAttempts,
 	)
 	return i, err
 }
 
-const getUserSetting = `-- name: GetUserSetting :one
-SELECT
-    uid, is_push, push_token, push_time, random_range, created, updated, app_lock_enabled, app_lock_pin_hash
-FROM
-    user_setting
+const resetWebhookFailures = `-- name: ResetWebhookFailures :exec
+UPDATE webhook
+SET
+    failure_count = 0,
+    updated = CURRENT_TIMESTAMP
 WHERE
-    uid = ?
-LIMIT
-    1
+    id = ?
+    AND failure_count > 0
 `
 
-func (q *Queries) GetUserSetting(ctx context.Context, uid string) (UserSetting, error) {
-	row := q.db.QueryRowContext(ctx, getUserSetting, uid)
-	var i UserSetting
-	err := row.Scan(
-		&i.Uid,
-		&i.IsPush,
-		&i.PushToken,
-		&i.PushTime,
-		&i.RandomRange,
-		&i.Created,
-		&i.Updated,
-		&i.AppLockEnabled,
-		&i.AppLockPinHash,
-	)
-	return i, err
+func (q *Queries) ResetWebhookFailures(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, resetWebhookFailures, id)
+	return err
 }
 
-const listDiaryDatesByMonth = `-- name: ListDiaryDatesByMonth :many
-SELECT
-    date
-FROM
-    diary
+const revokeAPIToken = `-- name: RevokeAPIToken :execrows
+UPDATE api_token
+SET
+    revoked = CURRENT_TIMESTAMP
 WHERE
-    uid = ?
-    AND substr (date, 1, 6) = ?
-ORDER BY
-    date
+    id = ?
+    AND uid = ?
+    AND revoked = ''
 `
 
-type ListDiaryDatesByMonthParams struct {
-	Uid  string
-	Date string
+type RevokeAPITokenParams struct {
+	ID  string
+	Uid string
 }
 
-func (q *Queries) ListDiaryDatesByMonth(ctx context.Context, arg ListDiaryDatesByMonthParams) ([]string, error) {
-	rows, err := q.db.QueryContext(ctx, listDiaryDatesByMonth, arg.Uid, arg.Date)
+func (q *Queries) RevokeAPIToken(ctx context.Context, arg RevokeAPITokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAPIToken, arg.ID, arg.Uid)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []string
-	for rows.Next() {
-		var date string
-		if err := rows.Scan(&date); err != nil {
-			return nil, err
-		}
-		items = append(items, date)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const listDiarys = `-- name: ListDiarys :many
-SELECT
-    id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3
-FROM
-    diary
+const revokeDiaryShare = `-- name: RevokeDiaryShare :execrows
+UPDATE diary_share
+SET
+    revoked = CURRENT_TIMESTAMP,
+    updated = CURRENT_TIMESTAMP
 WHERE
-    uid = ?
-ORDER BY
-    created desc
-LIMIT
-    7
-OFFSET
-    ((? - 1) * 7)
+    id = ?
+    AND uid = ?
+    AND revoked = ''
 `
 
-type ListDiarysParams struct {
-	Uid     string
-	Column2 interface{}
+type RevokeDiaryShareParams struct {
+	ID  string
+	Uid string
 }
 
-func (q *Queries) ListDiarys(ctx context.Context, arg ListDiarysParams) ([]Diary, error) {
-	rows, err := q.db.QueryContext(ctx, listDiarys, arg.Uid, arg.Column2)
+func (q *Queries) RevokeDiaryShare(ctx context.Context, arg RevokeDiaryShareParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeDiaryShare, arg.ID, arg.Uid)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Diary
-	for rows.Next() {
-		var i Diary
-		if err := rows.Scan(
-			&i.ID,
-			&i.Uid,
-			&i.Date,
-			&i.Content,
-			&i.AiFeedback,
-			&i.AiImage,
-			&i.Created,
-			&i.Updated,
-			&i.Mood,
-			&i.ImageUrl1,
-			&i.ImageUrl2,
-			&i.ImageUrl3,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const revokeUserAPITokens = `-- name: RevokeUserAPITokens :execrows
+UPDATE api_token
+SET
+    revoked = CURRENT_TIMESTAMP
+WHERE
+    uid = ?
+    AND revoked = ''
+`
+
+func (q *Queries) RevokeUserAPITokens(ctx context.Context, uid string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeUserAPITokens, uid)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected()
 }
 
-const listPushTargets = `-- name: ListPushTargets :many
-SELECT
-    uid,
-    push_token,
-    push_time,
-    random_range
-FROM
-    user_setting
+const revokeUserSessions = `-- name: RevokeUserSessions :exec
+UPDATE user_setting
+SET
+    sessions_revoked_at = ?,
+    updated = datetime ('now')
 WHERE
-    is_push = 1
-    AND push_token != ''
-    AND push_time != ''
+    uid = ?
 `
 
-type ListPushTargetsRow struct {
-	Uid         string
-	PushToken   string
-	PushTime    string
-	RandomRange int64
+type RevokeUserSessionsParams struct {
+	SessionsRevokedAt int64
+	Uid               string
 }
 
-func (q *Queries) ListPushTargets(ctx context.Context) ([]ListPushTargetsRow, error) {
-	rows, err := q.db.QueryContext(ctx, listPushTargets)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ListPushTargetsRow
-	for rows.Next() {
-		var i ListPushTargetsRow
-		if err := rows.Scan(
-			&i.Uid,
-			&i.PushToken,
-			&i.PushTime,
-			&i.RandomRange,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) RevokeUserSessions(ctx context.Context, arg RevokeUserSessionsParams) error {
+	_, err := q.db.ExecContext(ctx, revokeUserSessions, arg.SessionsRevokedAt, arg.Uid)
+	return err
 }
 
-const monthlyDiaryCount = `-- name: MonthlyDiaryCount :many
-WITH
-    monthly AS (
-        SELECT
-            substr (date, 1, 6) AS month,
-            COUNT(*) AS count
-        FROM
-            diary
-        WHERE
-            uid = ?
-        GROUP BY
-            substr (date, 1, 6)
-        ORDER BY
-            month DESC
-        LIMIT
-            6
-    )
+const scheduleAccountDeletion = `-- name: ScheduleAccountDeletion :one
+INSERT INTO
+    account_deletion (uid, status, purge_after)
+VALUES
+    (?, 'scheduled', ?)
+ON CONFLICT (uid) DO UPDATE
+SET
+    status = 'scheduled',
+    purge_after = excluded.purge_after,
+    updated = CURRENT_TIMESTAMP
+RETURNING
+    uid, status, purge_after, created, updated
+`
+
+type ScheduleAccountDeletionParams struct {
+	Uid        string
+	PurgeAfter string
+}
+
+func (q *Queries) ScheduleAccountDeletion(ctx context.Context, arg ScheduleAccountDeletionParams) (AccountDeletion, error) {
+	row := q.db.QueryRowContext(ctx, scheduleAccountDeletion, arg.Uid, arg.PurgeAfter)
+	var i AccountDeletion
+	err := row.Scan(
+		&i.Uid,
+		&i.Status,
+		&i.PurgeAfter,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const searchDiarys = `-- name: SearchDiarys :many
 SELECT
-    month, count
+    date,
+    content
 FROM
-    monthly
+    diary
+WHERE
+    uid = ?
+    AND content LIKE '%' || ? || '%'
 ORDER BY
-    month
+    date DESC
+LIMIT
+    20
 `
 
-type MonthlyDiaryCountRow struct {
-	Month string
-	Count int64
+type SearchDiarysParams struct {
+	Uid     string
+	Column2 sql.NullString
+}
+
+type SearchDiarysRow struct {
+	Date    string
+	Content string
 }
 
-func (q *Queries) MonthlyDiaryCount(ctx context.Context, uid string) ([]MonthlyDiaryCountRow, error) {
-	rows, err := q.db.QueryContext(ctx, monthlyDiaryCount, uid)
+func (q *Queries) SearchDiarys(ctx context.Context, arg SearchDiarysParams) ([]SearchDiarysRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchDiarys, arg.Uid, arg.Column2)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []MonthlyDiaryCountRow
+	var items []SearchDiarysRow
 	for rows.Next() {
-		var i MonthlyDiaryCountRow
-		if err := rows.Scan(&i.Month, &i.Count); err != nil {
+		var i SearchDiarysRow
+		if err := rows.Scan(&i.Date, &i.Content); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -367,86 +3822,44 @@ func (q *Queries) MonthlyDiaryCount(ctx context.Context, uid string) ([]MonthlyD
 	return items, nil
 }
 
-const monthlyMoodCount = `-- name: MonthlyMoodCount :many
-WITH
-    monthly AS (
-        SELECT
-            substr (date, 1, 6) AS month,
-            sum(
-                CASE
-                    WHEN mood = '1' THEN 1
-                    ELSE 0
-                END
-            ) AS mood1,
-            sum(
-                CASE
-                    WHEN mood = '2' THEN 1
-                    ELSE 0
-                END
-            ) AS mood2,
-            sum(
-                CASE
-                    WHEN mood = '3' THEN 1
-                    ELSE 0
-                END
-            ) AS mood3,
-            sum(
-                CASE
-                    WHEN mood = '4' THEN 1
-                    ELSE 0
-                END
-            ) AS mood4,
-            sum(
-                CASE
-                    WHEN mood = '5' THEN 1
-                    ELSE 0
-                END
-            ) AS mood5
-        FROM
-            diary
-        WHERE
-            uid = ?
-        GROUP BY
-            substr (date, 1, 6)
-        ORDER BY
-            month DESC
-        LIMIT
-            6
-    )
+const searchDiarysByTag = `-- name: SearchDiarysByTag :many
 SELECT
-    month, mood1, mood2, mood3, mood4, mood5
+    diary.date,
+    diary.content
 FROM
-    monthly
+    diary
+    JOIN diary_tag ON diary_tag.diary_id = diary.id
+WHERE
+    diary.uid = ?
+    AND diary_tag.tag = ?
+    AND diary.content LIKE '%' || ?3 || '%'
 ORDER BY
-    month
+    diary.date DESC
+LIMIT
+    20
 `
 
-type MonthlyMoodCountRow struct {
-	Month string
-	Mood1 sql.NullFloat64
-	Mood2 sql.NullFloat64
-	Mood3 sql.NullFloat64
-	Mood4 sql.NullFloat64
-	Mood5 sql.NullFloat64
+type SearchDiarysByTagParams struct {
+	Uid     string
+	Tag     string
+	Keyword sql.NullString
+}
+
+type SearchDiarysByTagRow struct {
+	Date    string
+	Content string
 }
 
-func (q *Queries) MonthlyMoodCount(ctx context.Context, uid string) ([]MonthlyMoodCountRow, error) {
-	rows, err := q.db.QueryContext(ctx, monthlyMoodCount, uid)
+func (q *Queries) SearchDiarysByTag(ctx context.Context, arg SearchDiarysByTagParams) ([]SearchDiarysByTagRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchDiarysByTag, arg.Uid, arg.Tag, arg.Keyword)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []MonthlyMoodCountRow
-	for rows.Next() {
-		var i MonthlyMoodCountRow
-		if err := rows.Scan(
-			&i.Month,
-			&i.Mood1,
-			&i.Mood2,
-			&i.Mood3,
-			&i.Mood4,
-			&i.Mood5,
-		); err != nil {
+	var items []SearchDiarysByTagRow
+	for rows.Next() {
+		var i SearchDiarysByTagRow
+		if err := rows.Scan(&i.Date, &i.Content); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -460,44 +3873,41 @@ func (q *Queries) MonthlyMoodCount(ctx context.Context, uid string) ([]MonthlyMo
 	return items, nil
 }
 
-const searchDiarys = `-- name: SearchDiarys :many
+const suggestUserTags = `-- name: SuggestUserTags :many
 SELECT
-    date,
-    content
+    tag
 FROM
-    diary
+    diary_tag
 WHERE
     uid = ?
-    AND content LIKE '%' || ? || '%'
+    AND like(?2 || '%', tag, '\')
+GROUP BY
+    tag
 ORDER BY
-    date DESC
+    COUNT(*) DESC,
+    tag
 LIMIT
-    20
+    10
 `
 
-type SearchDiarysParams struct {
-	Uid     string
-	Column2 sql.NullString
-}
-
-type SearchDiarysRow struct {
-	Date    string
-	Content string
+type SuggestUserTagsParams struct {
+	Uid    string
+	Prefix sql.NullString
 }
 
-func (q *Queries) SearchDiarys(ctx context.Context, arg SearchDiarysParams) ([]SearchDiarysRow, error) {
-	rows, err := q.db.QueryContext(ctx, searchDiarys, arg.Uid, arg.Column2)
+func (q *Queries) SuggestUserTags(ctx context.Context, arg SuggestUserTagsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, suggestUserTags, arg.Uid, arg.Prefix)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []SearchDiarysRow
+	var items []string
 	for rows.Next() {
-		var i SearchDiarysRow
-		if err := rows.Scan(&i.Date, &i.Content); err != nil {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, tag)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -508,6 +3918,39 @@ func (q *Queries) SearchDiarys(ctx context.Context, arg SearchDiarysParams) ([]S
 	return items, nil
 }
 
+const touchAPIToken = `-- name: TouchAPIToken :exec
+UPDATE api_token
+SET
+    last_used = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND last_used < DATETIME('now', '-1 minute')
+`
+
+func (q *Queries) TouchAPIToken(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, touchAPIToken, id)
+	return err
+}
+
+const touchChatSession = `-- name: TouchChatSession :exec
+UPDATE chat_session
+SET
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND uid = ?
+`
+
+type TouchChatSessionParams struct {
+	ID  string
+	Uid string
+}
+
+func (q *Queries) TouchChatSession(ctx context.Context, arg TouchChatSessionParams) error {
+	_, err := q.db.ExecContext(ctx, touchChatSession, arg.ID, arg.Uid)
+	return err
+}
+
 const updateAppLock = `-- name: UpdateAppLock :exec
 UPDATE user_setting
 SET
@@ -529,13 +3972,54 @@ func (q *Queries) UpdateAppLock(ctx context.Context, arg UpdateAppLockParams) er
 	return err
 }
 
+const updateAppLockForceLogout = `-- name: UpdateAppLockForceLogout :exec
+UPDATE user_setting
+SET
+    app_lock_force_logout = ?,
+    updated = datetime ('now')
+WHERE
+    uid = ?
+`
+
+type UpdateAppLockForceLogoutParams struct {
+	AppLockForceLogout int64
+	Uid                string
+}
+
+func (q *Queries) UpdateAppLockForceLogout(ctx context.Context, arg UpdateAppLockForceLogoutParams) error {
+	_, err := q.db.ExecContext(ctx, updateAppLockForceLogout, arg.AppLockForceLogout, arg.Uid)
+	return err
+}
+
+const updateAutoTemplate = `-- name: UpdateAutoTemplate :exec
+INSERT INTO
+    user_setting (uid, auto_template)
+VALUES
+    (?, ?) ON CONFLICT (uid) DO
+UPDATE
+SET
+    auto_template = excluded.auto_template,
+    updated = datetime ('now')
+`
+
+type UpdateAutoTemplateParams struct {
+	Uid          string
+	AutoTemplate string
+}
+
+func (q *Queries) UpdateAutoTemplate(ctx context.Context, arg UpdateAutoTemplateParams) error {
+	_, err := q.db.ExecContext(ctx, updateAutoTemplate, arg.Uid, arg.AutoTemplate)
+	return err
+}
+
 const updateDiary = `-- name: UpdateDiary :one
 UPDATE diary
 SET
     content = ?,
+    version = version + 1,
     updated = datetime ('now')
 WHERE
-    id = ? RETURNING id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3
+    id = ? RETURNING id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3, month_day, version
 `
 
 type UpdateDiaryParams struct {
@@ -559,6 +4043,54 @@ func (q *Queries) UpdateDiary(ctx context.Context, arg UpdateDiaryParams) (Diary
 		&i.ImageUrl1,
 		&i.ImageUrl2,
 		&i.ImageUrl3,
+		&i.MonthDay,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updateDiaryContentIfVersion = `-- name: UpdateDiaryContentIfVersion :one
+UPDATE diary
+SET
+    content = ?,
+    version = version + 1,
+    updated = datetime ('now')
+WHERE
+    uid = ?
+    AND date = ?
+    AND version = ?4 RETURNING id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3, month_day, version
+`
+
+type UpdateDiaryContentIfVersionParams struct {
+	Content     string
+	Uid         string
+	Date        string
+	BaseVersion int64
+}
+
+func (q *Queries) UpdateDiaryContentIfVersion(ctx context.Context, arg UpdateDiaryContentIfVersionParams) (Diary, error) {
+	row := q.db.QueryRowContext(ctx, updateDiaryContentIfVersion,
+		arg.Content,
+		arg.Uid,
+		arg.Date,
+		arg.BaseVersion,
+	)
+	var i Diary
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Date,
+		&i.Content,
+		&i.AiFeedback,
+		&i.AiImage,
+		&i.Created,
+		&i.Updated,
+		&i.Mood,
+		&i.ImageUrl1,
+		&i.ImageUrl2,
+		&i.ImageUrl3,
+		&i.MonthDay,
+		&i.Version,
 	)
 	return i, err
 }
@@ -631,15 +4163,275 @@ func (q *Queries) UpdateDiaryOfMood(ctx context.Context, arg UpdateDiaryOfMoodPa
 	return err
 }
 
+const updateDiaryTemplate = `-- name: UpdateDiaryTemplate :execrows
+UPDATE diary_template
+SET
+    name = ?,
+    content = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND uid = ?
+`
+
+type UpdateDiaryTemplateParams struct {
+	Name    string
+	Content string
+	ID      string
+	Uid     string
+}
+
+func (q *Queries) UpdateDiaryTemplate(ctx context.Context, arg UpdateDiaryTemplateParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateDiaryTemplate,
+		arg.Name,
+		arg.Content,
+		arg.ID,
+		arg.Uid,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateMemoryPush = `-- name: UpdateMemoryPush :exec
+UPDATE user_setting
+SET
+    memory_push = ?,
+    updated = datetime ('now')
+WHERE
+    uid = ?
+`
+
+type UpdateMemoryPushParams struct {
+	MemoryPush int64
+	Uid        string
+}
+
+func (q *Queries) UpdateMemoryPush(ctx context.Context, arg UpdateMemoryPushParams) error {
+	_, err := q.db.ExecContext(ctx, updateMemoryPush, arg.MemoryPush, arg.Uid)
+	return err
+}
+
+const updateSummarySettings = `-- name: UpdateSummarySettings :exec
+UPDATE user_setting
+SET
+    weekly_summary = ?,
+    monthly_summary = ?,
+    updated = datetime ('now')
+WHERE
+    uid = ?
+`
+
+type UpdateSummarySettingsParams struct {
+	WeeklySummary  int64
+	MonthlySummary int64
+	Uid            string
+}
+
+func (q *Queries) UpdateSummarySettings(ctx context.Context, arg UpdateSummarySettingsParams) error {
+	_, err := q.db.ExecContext(ctx, updateSummarySettings, arg.WeeklySummary, arg.MonthlySummary, arg.Uid)
+	return err
+}
+
+const updateVoiceNoteProgress = `-- name: UpdateVoiceNoteProgress :exec
+UPDATE voice_note
+SET
+    status = ?,
+    progress = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+`
+
+type UpdateVoiceNoteProgressParams struct {
+	Status   string
+	Progress int64
+	ID       string
+}
+
+func (q *Queries) UpdateVoiceNoteProgress(ctx context.Context, arg UpdateVoiceNoteProgressParams) error {
+	_, err := q.db.ExecContext(ctx, updateVoiceNoteProgress, arg.Status, arg.Progress, arg.ID)
+	return err
+}
+
+const updateVoiceSettings = `-- name: UpdateVoiceSettings :exec
+UPDATE user_setting
+SET
+    keep_voice_audio = ?,
+    updated = datetime ('now')
+WHERE
+    uid = ?
+`
+
+type UpdateVoiceSettingsParams struct {
+	KeepVoiceAudio int64
+	Uid            string
+}
+
+func (q *Queries) UpdateVoiceSettings(ctx context.Context, arg UpdateVoiceSettingsParams) error {
+	_, err := q.db.ExecContext(ctx, updateVoiceSettings, arg.KeepVoiceAudio, arg.Uid)
+	return err
+}
+
+const updateWebhookDelivery = `-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_delivery
+SET
+    status = ?,
+    attempts = ?,
+    response_status = ?,
+    error = ?,
+    next_attempt = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+`
+
+type UpdateWebhookDeliveryParams struct {
+	Status         string
+	Attempts       int64
+	ResponseStatus int64
+	Error          string
+	NextAttempt    string
+	ID             string
+}
+
+func (q *Queries) UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebhookDelivery,
+		arg.Status,
+		arg.Attempts,
+		arg.ResponseStatus,
+		arg.Error,
+		arg.NextAttempt,
+		arg.ID,
+	)
+	return err
+}
+
+const updateWritingGoal = `-- name: UpdateWritingGoal :exec
+UPDATE user_setting
+SET
+    monthly_goal = ?,
+    timezone = ?,
+    updated = datetime ('now')
+WHERE
+    uid = ?
+`
+
+type UpdateWritingGoalParams struct {
+	MonthlyGoal int64
+	Timezone    string
+	Uid         string
+}
+
+func (q *Queries) UpdateWritingGoal(ctx context.Context, arg UpdateWritingGoalParams) error {
+	_, err := q.db.ExecContext(ctx, updateWritingGoal, arg.MonthlyGoal, arg.Timezone, arg.Uid)
+	return err
+}
+
+const upsertAICache = `-- name: UpsertAICache :exec
+INSERT INTO
+    ai_cache (key, value, expires)
+VALUES
+    (?, ?, ?) ON CONFLICT (key) DO
+UPDATE
+SET
+    value = excluded.value,
+    expires = excluded.expires
+`
+
+type UpsertAICacheParams struct {
+	Key     string
+	Value   string
+	Expires string
+}
+
+func (q *Queries) UpsertAICache(ctx context.Context, arg UpsertAICacheParams) error {
+	_, err := q.db.ExecContext(ctx, upsertAICache, arg.Key, arg.Value, arg.Expires)
+	return err
+}
+
+const upsertAISummary = `-- name: UpsertAISummary :one
+INSERT INTO
+    ai_summary (
+        uid,
+        period,
+        start_date,
+        end_date,
+        content,
+        diary_count
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?) ON CONFLICT (uid, period, start_date) DO
+UPDATE
+SET
+    end_date = excluded.end_date,
+    content = excluded.content,
+    diary_count = excluded.diary_count,
+    updated = CURRENT_TIMESTAMP
+RETURNING
+    id, uid, period, start_date, end_date, content, diary_count, created, updated
+`
+
+type UpsertAISummaryParams struct {
+	Uid        string
+	Period     string
+	StartDate  string
+	EndDate    string
+	Content    string
+	DiaryCount int64
+}
+
+func (q *Queries) UpsertAISummary(ctx context.Context, arg UpsertAISummaryParams) (AiSummary, error) {
+	row := q.db.QueryRowContext(ctx, upsertAISummary,
+		arg.Uid,
+		arg.Period,
+		arg.StartDate,
+		arg.EndDate,
+		arg.Content,
+		arg.DiaryCount,
+	)
+	var i AiSummary
+	err := row.Scan(
+		&i.ID,
+		&i.Uid,
+		&i.Period,
+		&i.StartDate,
+		&i.EndDate,
+		&i.Content,
+		&i.DiaryCount,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
 const upsertDiaryContent = `-- name: UpsertDiaryContent :one
 INSERT INTO
-    diary (uid, content, date)
+    diary (uid, content, date, version)
 VALUES
-    (?, ?, ?) ON CONFLICT (uid, date) DO
+    (
+        ?1,
+        ?2,
+        ?3,
+        COALESCE(
+            (
+                SELECT
+                    diary_tombstone.version
+                FROM
+                    diary_tombstone
+                WHERE
+                    diary_tombstone.uid = ?1
+                    AND diary_tombstone.date = ?3
+            ),
+            0
+        ) + 1
+    ) ON CONFLICT (uid, date) DO
 UPDATE
 SET
     content = excluded.content,
-    updated = datetime ('now') RETURNING id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3
+    version = diary.version + 1,
+    updated = datetime ('now') RETURNING id, uid, date, content, ai_feedback, ai_image, created, updated, mood, image_url1, image_url2, image_url3, month_day, version
 `
 
 type UpsertDiaryContentParams struct {
@@ -664,10 +4456,83 @@ func (q *Queries) UpsertDiaryContent(ctx context.Context, arg UpsertDiaryContent
 		&i.ImageUrl1,
 		&i.ImageUrl2,
 		&i.ImageUrl3,
+		&i.MonthDay,
+		&i.Version,
 	)
 	return i, err
 }
 
+const upsertDiaryDraft = `-- name: UpsertDiaryDraft :exec
+INSERT INTO
+    diary_draft (uid, date, content, base_version)
+VALUES
+    (?, ?, ?, ?) ON CONFLICT (uid, date) DO
+UPDATE
+SET
+    content = excluded.content,
+    base_version = excluded.base_version,
+    updated = datetime ('now')
+`
+
+type UpsertDiaryDraftParams struct {
+	Uid         string
+	Date        string
+	Content     string
+	BaseVersion int64
+}
+
+func (q *Queries) UpsertDiaryDraft(ctx context.Context, arg UpsertDiaryDraftParams) error {
+	_, err := q.db.ExecContext(ctx, upsertDiaryDraft,
+		arg.Uid,
+		arg.Date,
+		arg.Content,
+		arg.BaseVersion,
+	)
+	return err
+}
+
+const upsertDiaryEmbedding = `-- name: UpsertDiaryEmbedding :exec
+INSERT INTO
+    diary_embedding (
+        diary_id,
+        uid,
+        date,
+        model,
+        content_hash,
+        vector
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?) ON CONFLICT (diary_id) DO
+UPDATE
+SET
+    date = excluded.date,
+    model = excluded.model,
+    content_hash = excluded.content_hash,
+    vector = excluded.vector,
+    updated = datetime ('now')
+`
+
+type UpsertDiaryEmbeddingParams struct {
+	DiaryID     string
+	Uid         string
+	Date        string
+	Model       string
+	ContentHash string
+	Vector      []byte
+}
+
+func (q *Queries) UpsertDiaryEmbedding(ctx context.Context, arg UpsertDiaryEmbeddingParams) error {
+	_, err := q.db.ExecContext(ctx, upsertDiaryEmbedding,
+		arg.DiaryID,
+		arg.Uid,
+		arg.Date,
+		arg.Model,
+		arg.ContentHash,
+		arg.Vector,
+	)
+	return err
+}
+
 const upsertPushKey = `-- name: UpsertPushKey :exec
 INSERT INTO
     user_setting (uid, push_token)
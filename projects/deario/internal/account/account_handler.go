@@ -0,0 +1,146 @@
+package account
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"simple-server/internal/middleware"
+	"simple-server/internal/validate"
+	"simple-server/pkg/util/authutil"
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/auth"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+type deleteAccountDTO struct {
+	Token string `json:"token" validate:"required" message:"다시 로그인한 뒤 시도해주세요."`
+}
+
+type deleteAccountResponse struct {
+	PurgeAfter string `json:"purgeAfter"`
+}
+
+// AccountPanel은 설정 화면의 계정 삭제 영역을 렌더링한다.
+func AccountPanel(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	return renderAccountPanel(c, queries, uid)
+}
+
+// RequestDeletion은 방금 다시 로그인한 토큰을 확인한 뒤 계정 삭제를 예약하고 로그아웃한다.
+// 예약과 함께 API 토큰을 폐기하고 웹훅을 끈다.
+// 데이터는 GracePeriod가 지난 뒤 AccountPurgeJob이 지우며, 그 전에 다시 로그인하면 취소할 수 있다.
+func RequestDeletion(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto deleteAccountDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	ctx := c.Request().Context()
+	client, err := middleware.App.Auth(ctx)
+	if err != nil {
+		return err
+	}
+	token, err := client.VerifyIDToken(ctx, dto.Token)
+	if err != nil || token.UID != uid {
+		return echo.NewHTTPError(http.StatusForbidden, "로그인한 계정을 확인하지 못했습니다.")
+	}
+	if !RecentlyAuthenticated(token.AuthTime, time.Now()) {
+		return echo.NewHTTPError(http.StatusForbidden, "보안을 위해 로그아웃 후 다시 로그인하고 5분 안에 시도해주세요.")
+	}
+
+	conn, err := db.GetDB()
+	if err != nil {
+		return err
+	}
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	purgeTime := PurgeAfter(time.Now())
+	purgeAfter := purgeTime.Format(dateutil.DateFormatISOTime)
+	if err := scheduleDeletion(ctx, conn, queries, uid, purgeAfter); err != nil {
+		slog.Error("계정 삭제 요청 실패", "uid_hash", HashUID(uid), "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "계정 삭제 요청에 실패했습니다.")
+	}
+	audit(ctx, queries, uid, auditRequested, purgeAfter)
+	slog.Info("계정 삭제 요청", "uid_hash", HashUID(uid), "purge_after", purgeAfter)
+
+	if err := auth.ClearSession(c); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, deleteAccountResponse{PurgeAfter: purgeTime.Format(time.RFC3339)})
+}
+
+// CancelDeletion은 유예 기간 안의 계정 삭제 요청을 취소하고 탈퇴 요청으로 끈 웹훅을 다시 켠다.
+func CancelDeletion(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.GetDB()
+	if err != nil {
+		return err
+	}
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	cancelled, err := cancelDeletion(ctx, conn, queries, uid)
+	if err != nil {
+		slog.Error("계정 삭제 취소 실패", "uid_hash", HashUID(uid), "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "계정 삭제 취소에 실패했습니다.")
+	}
+	if !cancelled {
+		return echo.NewHTTPError(http.StatusConflict, "취소할 계정 삭제 요청이 없거나 이미 삭제가 진행 중입니다.")
+	}
+	audit(ctx, queries, uid, auditCancelled, "")
+
+	return renderAccountPanel(c, queries, uid)
+}
+
+func renderAccountPanel(c echo.Context, queries *db.Queries, uid string) error {
+	ctx := c.Request().Context()
+	panel := components.AccountPanel{GraceDays: int(GracePeriod / (24 * time.Hour))}
+
+	deletion, err := queries.GetAccountDeletion(ctx, uid)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusInternalServerError, "계정 정보를 가져오지 못했습니다.")
+	}
+	if err == nil {
+		setting, _ := queries.GetUserSetting(ctx, uid)
+		panel.Scheduled = true
+		panel.Purging = deletion.Status == "purging"
+		panel.PurgeAfter = deariodate.LocalTime(deletion.PurgeAfter, deariodate.Location(setting.Timezone))
+	}
+
+	return components.Account(panel).Render(ctx, c.Response().Writer)
+}
@@ -0,0 +1,146 @@
+package account
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"simple-server/projects/deario/db"
+)
+
+const (
+	// GracePeriod는 탈퇴를 요청한 뒤 실제로 데이터를 지우기 전까지 취소할 수 있는 기간이다.
+	GracePeriod = 7 * 24 * time.Hour
+	// ReauthWindow는 탈퇴 요청에 쓸 수 있는 로그인의 유효 시간이다.
+	// 오래 열어 둔 세션으로는 탈퇴할 수 없도록 방금 다시 로그인한 토큰만 받는다.
+	ReauthWindow = 5 * time.Minute
+)
+
+// deletionWebhookReason은 탈퇴 요청으로 끈 웹훅에 남기는 이유다. 탈퇴를 취소하면 이 이유로 꺼진 웹훅만 다시 켠다.
+const deletionWebhookReason = "계정 삭제를 요청해 껐습니다."
+
+// 탈퇴 기록의 이벤트다.
+const (
+	auditRequested = "requested"
+	auditCancelled = "cancelled"
+	auditPurged    = "purged"
+	auditFailed    = "failed"
+)
+
+// purgeTables는 탈퇴한 사용자의 행을 uid로 지우는 테이블이다.
+// diary를 지우면 트리거가 diary_change와 diary_tombstone에 기록을 남기므로 두 테이블은 diary 뒤에 지운다.
+// account_deletion은 파이어베이스 계정까지 지운 뒤 마지막에 따로 지운다.
+var purgeTables = []string{
+	"diary_tag",
+	"diary_draft",
+	"diary_embedding",
+	"diary",
+	"diary_change",
+	"diary_tombstone",
+	"user_achievement",
+	"api_token",
+	"webhook_delivery",
+	"webhook",
+	"diary_share",
+	"diary_template",
+	"ai_summary",
+	"chat_message",
+	"chat_session",
+	"voice_note",
+	"image_quarantine",
+	"app_lock_attempt",
+	"user_setting",
+	"user",
+}
+
+// PurgeResult는 탈퇴 처리에서 지운 데이터의 양이다. 탈퇴 기록에 그대로 남긴다.
+type PurgeResult struct {
+	Rows         map[string]int64 `json:"rows"`
+	Images       int              `json:"images"`
+	Audio        int              `json:"audio"`
+	FirebaseUser bool             `json:"firebaseUser"`
+}
+
+// Detail은 탈퇴 기록에 남길 JSON 문자열을 반환한다.
+func (r PurgeResult) Detail() string {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// HashUID는 탈퇴 기록에 남길 uid 해시를 반환한다.
+// 데이터를 지운 뒤에도 같은 사용자의 요청과 처리 기록을 이어 볼 수 있지만 uid를 되돌릴 수는 없다.
+func HashUID(uid string) string {
+	sum := sha256.Sum256([]byte("deario-account:" + uid))
+	return hex.EncodeToString(sum[:])
+}
+
+// RecentlyAuthenticated는 토큰의 로그인 시각(auth_time)이 ReauthWindow 안인지 확인한다.
+func RecentlyAuthenticated(authTime int64, now time.Time) bool {
+	t := time.Unix(authTime, 0)
+	if t.After(now.Add(time.Minute)) {
+		return false
+	}
+	return now.Sub(t) <= ReauthWindow
+}
+
+// PurgeAfter는 지금 요청한 탈퇴를 처리할 시각이다.
+func PurgeAfter(now time.Time) time.Time {
+	return now.UTC().Add(GracePeriod).Truncate(time.Second)
+}
+
+// scheduleDeletion은 계정 삭제를 예약하고, 유예 기간 동안 바깥에서 데이터에 닿지 못하도록
+// API 토큰을 폐기하고 웹훅을 끈다. 알림과 요약은 예약이 남아 있는 동안 대상 조회에서 빠진다.
+func scheduleDeletion(ctx context.Context, conn *sql.DB, queries *db.Queries, uid, purgeAfter string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("트랜잭션 시작 실패: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	q := queries.WithTx(tx)
+
+	if _, err := q.ScheduleAccountDeletion(ctx, db.ScheduleAccountDeletionParams{
+		Uid:        uid,
+		PurgeAfter: purgeAfter,
+	}); err != nil {
+		return fmt.Errorf("계정 삭제 예약 실패: %w", err)
+	}
+	if _, err := q.RevokeUserAPITokens(ctx, uid); err != nil {
+		return fmt.Errorf("API 토큰 폐기 실패: %w", err)
+	}
+	if err := q.DisableUserWebhooks(ctx, db.DisableUserWebhooksParams{Uid: uid, Reason: deletionWebhookReason}); err != nil {
+		return fmt.Errorf("웹훅 끄기 실패: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// cancelDeletion은 유예 기간 안의 계정 삭제 예약을 지우고 탈퇴 요청으로 끈 웹훅을 다시 켠다.
+// 폐기한 API 토큰은 되살리지 않는다. 취소한 예약이 없으면 false를 반환한다.
+func cancelDeletion(ctx context.Context, conn *sql.DB, queries *db.Queries, uid string) (bool, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("트랜잭션 시작 실패: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	q := queries.WithTx(tx)
+
+	count, err := q.CancelAccountDeletion(ctx, uid)
+	if err != nil {
+		return false, fmt.Errorf("계정 삭제 취소 실패: %w", err)
+	}
+	if count == 0 {
+		return false, nil
+	}
+	if err := q.EnableUserWebhooks(ctx, db.EnableUserWebhooksParams{Uid: uid, Reason: deletionWebhookReason}); err != nil {
+		return false, fmt.Errorf("웹훅 켜기 실패: %w", err)
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,168 @@
+package account
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/db/dbtest"
+)
+
+func TestHashUID(t *testing.T) {
+	h := HashUID("user-1")
+	if len(h) != 64 || h == "user-1" {
+		t.Errorf("HashUID() = %q", h)
+	}
+	if HashUID("user-1") != h {
+		t.Error("HashUID() is not stable")
+	}
+	if HashUID("user-2") == h {
+		t.Error("HashUID() collided for different uids")
+	}
+}
+
+func TestRecentlyAuthenticated(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		authTime time.Time
+		want     bool
+	}{
+		{name: "just now", authTime: now, want: true},
+		{name: "within window", authTime: now.Add(-4 * time.Minute), want: true},
+		{name: "expired", authTime: now.Add(-ReauthWindow - time.Second), want: false},
+		{name: "small clock skew", authTime: now.Add(30 * time.Second), want: true},
+		{name: "future", authTime: now.Add(10 * time.Minute), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RecentlyAuthenticated(tt.authTime.Unix(), now); got != tt.want {
+				t.Errorf("RecentlyAuthenticated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPurgeAfter(t *testing.T) {
+	now := time.Date(2026, 10, 19, 21, 30, 15, 500, time.FixedZone("KST", 9*60*60))
+	want := time.Date(2026, 10, 26, 12, 30, 15, 0, time.UTC)
+	if got := PurgeAfter(now); !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("PurgeAfter() = %v, want %v", got, want)
+	}
+}
+
+// TestPurgeTablesCoverSchema는 uid 컬럼이 있는 테이블이 모두 탈퇴 처리 대상인지 확인한다.
+// 새 테이블을 추가하고 purgeTables에 넣지 않으면 실패한다.
+func TestPurgeTablesCoverSchema(t *testing.T) {
+	conn := dbtest.Open(t)
+
+	rows, err := conn.Query(`
+		SELECT m.name
+		FROM sqlite_master AS m, pragma_table_info(m.name) AS c
+		WHERE m.type = 'table' AND c.name = 'uid'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	// account_deletion은 purgeAccount 마지막에 따로 지운다.
+	handled := append(slices.Clone(purgeTables), "account_deletion")
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(handled, table) {
+			t.Errorf("table %q has a uid column but is not purged", table)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScheduleAndCancelDeletion(t *testing.T) {
+	ctx := context.Background()
+	conn := dbtest.Open(t)
+	queries := db.New(conn)
+
+	if _, err := conn.Exec(`INSERT INTO user_setting (uid, is_push, push_token, push_time) VALUES ('user-1', 1, 'token', '21:00')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queries.CreateAPIToken(ctx, db.CreateAPITokenParams{Uid: "user-1", Name: "cli", TokenHash: "h1"}); err != nil {
+		t.Fatal(err)
+	}
+	active, err := queries.CreateWebhook(ctx, db.CreateWebhookParams{Uid: "user-1", Url: "https://example.com/a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed, err := queries.CreateWebhook(ctx, db.CreateWebhookParams{Uid: "user-1", Url: "https://example.com/b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queries.DisableWebhook(ctx, db.DisableWebhookParams{ID: failed.ID, DisabledReason: "전송 실패"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := scheduleDeletion(ctx, conn, queries, "user-1", "2026-10-26T12:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := queries.ListAPITokens(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range tokens {
+		if token.Revoked == "" {
+			t.Errorf("token %s was not revoked", token.ID)
+		}
+	}
+	if enabled := enabledWebhooks(t, queries); len(enabled) != 0 {
+		t.Errorf("enabled webhooks after scheduling = %v", enabled)
+	}
+	targets, err := queries.ListPushTargets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 0 {
+		t.Errorf("push targets during grace period = %v", targets)
+	}
+
+	cancelled, err := cancelDeletion(ctx, conn, queries, "user-1")
+	if err != nil || !cancelled {
+		t.Fatalf("cancelDeletion() = %v, %v", cancelled, err)
+	}
+	// 탈퇴 요청으로 끈 웹훅만 다시 켜고, 전송 실패로 꺼진 웹훅은 그대로 둔다.
+	if enabled := enabledWebhooks(t, queries); !slices.Equal(enabled, []string{active.ID}) {
+		t.Errorf("enabled webhooks after cancel = %v, want [%s]", enabled, active.ID)
+	}
+	targets, err = queries.ListPushTargets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 {
+		t.Errorf("push targets after cancel = %v", targets)
+	}
+
+	if cancelled, err := cancelDeletion(ctx, conn, queries, "user-1"); err != nil || cancelled {
+		t.Errorf("second cancelDeletion() = %v, %v", cancelled, err)
+	}
+}
+
+func enabledWebhooks(t *testing.T, queries *db.Queries) []string {
+	t.Helper()
+	hooks, err := queries.ListWebhooks(context.Background(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, h := range hooks {
+		if h.Enabled == 1 {
+			ids = append(ids, h.ID)
+		}
+	}
+	return ids
+}
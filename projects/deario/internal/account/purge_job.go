@@ -0,0 +1,208 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"simple-server/internal/middleware"
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/diary"
+	"simple-server/projects/deario/internal/voice"
+
+	firebaseauth "firebase.google.com/go/v4/auth"
+	"github.com/robfig/cron/v3"
+	"maragu.dev/goqite"
+	"maragu.dev/goqite/jobs"
+)
+
+var purgeQ *goqite.Queue
+var purgeQOnce sync.Once
+var errPurgeQ error
+
+func InitPurgeQueue() error {
+	purgeQOnce.Do(func() {
+		purgeDB, err := db.GetDB(false)
+		if err != nil {
+			errPurgeQ = fmt.Errorf("탈퇴 처리 큐 데이터베이스 연결 실패: %w", err)
+			return
+		}
+
+		purgeQ = goqite.New(goqite.NewOpts{
+			DB:      purgeDB,
+			Name:    "account-purge",
+			Timeout: 5 * time.Minute,
+		})
+	})
+
+	return errPurgeQ
+}
+
+// AccountPurgeCron은 매시간 유예 기간이 지난 탈퇴 요청을 처리 큐에 넣는다.
+func AccountPurgeCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@hourly", func() {
+		if err := InitPurgeQueue(); err != nil {
+			slog.Error("탈퇴 처리 큐 초기화 실패", "error", err)
+			return
+		}
+
+		ctx := context.Background()
+		now := time.Now().UTC().Format(dateutil.DateFormatISOTime)
+		uids, err := queries.ListDueAccountDeletions(ctx, now)
+		if err != nil {
+			slog.Error("탈퇴 요청 조회 실패", "error", err)
+			return
+		}
+		for _, uid := range uids {
+			// 이전 처리가 아직 진행 중이면 purging 상태라 다시 넣지 않는다.
+			count, err := queries.MarkAccountDeletionPurging(ctx, uid)
+			if err != nil || count == 0 {
+				continue
+			}
+			if _, err := jobs.Create(ctx, purgeQ, "purge", goqite.Message{Body: []byte(uid)}); err != nil {
+				slog.Error("탈퇴 처리 등록 실패", "error", err)
+				_ = queries.RescheduleAccountDeletion(ctx, uid)
+			}
+		}
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
+
+// AccountPurgeJob은 탈퇴한 사용자의 이미지, DB 데이터, 권한, 파이어베이스 계정을 차례로 지운다.
+// 중간에 실패하면 요청을 다시 scheduled로 돌려 다음 스케줄에서 처음부터 다시 처리한다.
+func AccountPurgeJob() {
+	if err := InitPurgeQueue(); err != nil {
+		slog.Error("탈퇴 처리 큐 초기화 실패", "error", err)
+		return
+	}
+
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	r := jobs.NewRunner(jobs.NewRunnerOpts{
+		Limit:        1,
+		Log:          slog.Default(),
+		PollInterval: 1 * time.Second,
+		Queue:        purgeQ,
+	})
+
+	r.Register("purge", func(ctx context.Context, m []byte) error {
+		uid := string(m)
+		result, err := purgeAccount(ctx, queries, uid)
+		if err == nil {
+			slog.Info("탈퇴 처리 완료", "uid_hash", HashUID(uid))
+			audit(ctx, queries, uid, auditPurged, result.Detail())
+			return nil
+		}
+		if errors.Is(err, errDeletionCancelled) {
+			return nil
+		}
+
+		slog.Error("탈퇴 처리 실패", "uid_hash", HashUID(uid), "error", err)
+		audit(ctx, queries, uid, auditFailed, err.Error())
+		if err := queries.RescheduleAccountDeletion(ctx, uid); err != nil {
+			slog.Error("탈퇴 요청 복구 실패", "uid_hash", HashUID(uid), "error", err)
+		}
+		return nil
+	})
+
+	r.Start(context.Background())
+}
+
+var errDeletionCancelled = errors.New("탈퇴 요청이 취소되었습니다")
+
+func purgeAccount(ctx context.Context, queries *db.Queries, uid string) (PurgeResult, error) {
+	deletion, err := queries.GetAccountDeletion(ctx, uid)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && deletion.Status != "purging") {
+		return PurgeResult{}, errDeletionCancelled
+	}
+	if err != nil {
+		return PurgeResult{}, fmt.Errorf("탈퇴 요청 조회 실패: %w", err)
+	}
+
+	result := PurgeResult{Rows: make(map[string]int64, len(purgeTables))}
+
+	// 이미지 주소를 DB에서 읽어야 하므로 스토리지를 먼저 지운다.
+	result.Images, err = diary.DeleteUserImages(ctx, queries, uid)
+	if err != nil {
+		return result, err
+	}
+	result.Audio, err = voice.DeleteUserAudio(ctx, uid)
+	if err != nil {
+		return result, err
+	}
+
+	if err := purgeRows(ctx, uid, result.Rows); err != nil {
+		return result, err
+	}
+
+	if _, err := middleware.Enforcer.DeleteUser(uid); err != nil {
+		return result, fmt.Errorf("권한 삭제 실패: %w", err)
+	}
+
+	client, err := middleware.App.Auth(ctx)
+	if err != nil {
+		return result, fmt.Errorf("인증 클라이언트 생성 실패: %w", err)
+	}
+	if err := client.DeleteUser(ctx, uid); err != nil && !firebaseauth.IsUserNotFound(err) {
+		return result, fmt.Errorf("파이어베이스 사용자 삭제 실패: %w", err)
+	}
+	result.FirebaseUser = true
+
+	if err := queries.FinishAccountDeletion(ctx, uid); err != nil {
+		return result, fmt.Errorf("탈퇴 요청 삭제 실패: %w", err)
+	}
+	return result, nil
+}
+
+// purgeRows는 purgeTables에서 사용자의 행을 한 트랜잭션으로 지운다.
+func purgeRows(ctx context.Context, uid string, rows map[string]int64) error {
+	conn, err := db.GetDB(false)
+	if err != nil {
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("트랜잭션 시작 실패: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range purgeTables {
+		res, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE uid = ?", uid)
+		if err != nil {
+			return fmt.Errorf("%s 삭제 실패: %w", table, err)
+		}
+		rows[table], _ = res.RowsAffected()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("트랜잭션 커밋 실패: %w", err)
+	}
+	return nil
+}
+
+func audit(ctx context.Context, queries *db.Queries, uid, event, detail string) {
+	if err := queries.CreateAccountAudit(ctx, db.CreateAccountAuditParams{
+		UidHash: HashUID(uid),
+		Event:   event,
+		Detail:  detail,
+	}); err != nil {
+		slog.Error("탈퇴 기록 저장 실패", "event", event, "error", err)
+	}
+}
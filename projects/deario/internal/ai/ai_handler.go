@@ -12,6 +12,8 @@ import (
 	"simple-server/projects/deario/db"
 	"simple-server/projects/deario/internal/deariodate"
 	"simple-server/projects/deario/internal/notification"
+	"simple-server/projects/deario/internal/safety"
+	"simple-server/projects/deario/internal/webhook"
 	"simple-server/projects/deario/views/components"
 
 	"github.com/labstack/echo/v4"
@@ -38,6 +40,24 @@ func GenerateAIFeedback(c echo.Context) error {
 		return err
 	}
 
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	// 위기 표현이 보이면 프롬프트에 안내를 붙이고 답변 아래에 상담 연락처를 보여준다. 그림일기는 위로 답변으로 바꾼다.
+	ctx := c.Request().Context()
+	level := safety.Classify(ctx, content)
+	safety.Record(ctx, queries, safety.SourceFeedback, level, uid)
+	var helplines []safety.Helpline
+	if level == safety.LevelCrisis {
+		helplines = safety.UserHelplines(ctx, queries, uid)
+		if typeValue == "4" {
+			typeValue = "2"
+			typeStr, _ = aiFeedbackInstruction(typeValue)
+		}
+	}
+
 	if typeValue == "4" {
 		prompt := fmt.Sprintf(`
                 %s
@@ -46,7 +66,8 @@ func GenerateAIFeedback(c echo.Context) error {
                 `, typeStr, content)
 		result, err := aiclient.ImageRequest(c.Request().Context(), prompt)
 		if err != nil {
-			return err
+			slog.Error("AI 이미지 생성 실패", "error", err)
+			return aiclient.HTTPError(err)
 		}
 		return components.AiImageResult(result).Render(c.Request().Context(), c.Response().Writer)
 	}
@@ -68,7 +89,7 @@ func GenerateAIFeedback(c echo.Context) error {
 - 마지막 문장은 짧은 여운이나 질문 하나로 끝내줘
 - Markdown 형식으로, 제목 없이 2~3개의 짧은 문단으로 작성해줘
 - 답변은 300자에서 500자 사이로 해줘
-`, content, typeStr)
+%s`, content, typeStr, safety.PromptGuidance(level, helplines))
 	// 상황별 감정 해석을 더 강화하고 싶을 때 교체할 프롬프트:
 	//
 	// 너는 사용자의 일기를 읽고 다정하게 답장해주는 "일기요정"이야.
@@ -89,12 +110,13 @@ func GenerateAIFeedback(c echo.Context) error {
 	// - 충고가 필요한 경우에도 단정하지 말고, 사용자가 오늘 바로 해볼 수 있는 작은 제안으로 말해줘.
 	// - 답변 길이는 350자에서 600자 사이로 해줘.
 	// - Markdown 형식으로 작성하되, 제목은 쓰지 말고 짧은 문단과 필요한 경우 목록 1개만 사용해줘.
-	result, err := aiclient.Request(c.Request().Context(), prompt)
+	result, err := aiclient.Request(ctx, prompt)
 	if err != nil {
-		return err
+		slog.Error("AI 피드백 생성 실패", "error", err)
+		return aiclient.HTTPError(err)
 	}
 
-	return components.AiFeedbackResult(result).Render(c.Request().Context(), c.Response().Writer)
+	return components.AiFeedbackResult(result, safety.HelplineItems(helplines)).Render(ctx, c.Response().Writer)
 }
 
 // SaveAIFeedback는 생성된 AI 피드백과 이미지를 저장한다.
@@ -132,6 +154,9 @@ func SaveAIFeedback(c echo.Context) error {
 	}); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "일기요정 저장에 실패하였습니다.")
 	}
+	if aiFeedback != "" {
+		webhook.Emit(c.Request().Context(), queries, uid, webhook.EventAIFeedbackSaved, webhook.AIFeedbackData{Date: date, AIFeedback: aiFeedback})
+	}
 
 	return c.NoContent(http.StatusNoContent)
 }
@@ -218,7 +243,7 @@ func GetAIFeedback(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "저장된 일기요정이 없습니다.")
 	}
 
-	return components.AiFeedbackResult(diary.AiFeedback).Render(c.Request().Context(), c.Response().Writer)
+	return components.AiFeedbackResult(diary.AiFeedback, nil).Render(c.Request().Context(), c.Response().Writer)
 }
 
 func hasDiaryImageData(d db.Diary) bool {
@@ -267,3 +292,40 @@ func GenerateAIReport(c echo.Context) error {
 
 	return c.NoContent(http.StatusAccepted)
 }
+
+// summaryListLimit는 통계 화면에 보여줄 정기 요약 수다.
+const summaryListLimit = 12
+
+// AISummaries는 저장된 주간, 월간 AI 요약 목록을 렌더링한다.
+func AISummaries(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	summaries, err := queries.ListAISummaries(c.Request().Context(), db.ListAISummariesParams{
+		Uid:   uid,
+		Limit: summaryListLimit,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "AI 요약을 가져오지 못했습니다.")
+	}
+
+	items := make([]components.AISummaryItem, 0, len(summaries))
+	for _, s := range summaries {
+		items = append(items, components.AISummaryItem{
+			Period:     notification.SummaryPeriod(s.Period).Label(),
+			StartDate:  s.StartDate,
+			EndDate:    s.EndDate,
+			DiaryCount: s.DiaryCount,
+			Content:    s.Content,
+		})
+	}
+
+	return components.AISummaries(items).Render(c.Request().Context(), c.Response().Writer)
+}
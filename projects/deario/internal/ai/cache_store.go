@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+
+	"github.com/robfig/cron/v3"
+)
+
+// CacheStore는 AI 응답 캐시를 ai_cache 테이블에 남긴다.
+type CacheStore struct {
+	Queries *db.Queries
+}
+
+func (s CacheStore) Get(ctx context.Context, key string, now time.Time) (string, time.Time, bool, error) {
+	row, err := s.Queries.GetAICache(ctx, db.GetAICacheParams{
+		Key: key,
+		Now: now.UTC().Format(dateutil.DateFormatISOTime),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", time.Time{}, false, nil
+	}
+	if err != nil {
+		return "", time.Time{}, false, err
+	}
+	expires, err := time.ParseInLocation(dateutil.DateFormatISOTime, row.Expires, time.UTC)
+	if err != nil {
+		return "", time.Time{}, false, err
+	}
+	return row.Value, expires, true, nil
+}
+
+func (s CacheStore) Set(ctx context.Context, key, value string, expires time.Time) error {
+	return s.Queries.UpsertAICache(ctx, db.UpsertAICacheParams{
+		Key:     key,
+		Value:   value,
+		Expires: expires.UTC().Format(dateutil.DateFormatISOTime),
+	})
+}
+
+// PruneAICacheCron은 만료된 AI 응답 캐시를 매일 지운다.
+func PruneAICacheCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@daily", func() {
+		now := time.Now().UTC().Format(dateutil.DateFormatISOTime)
+		count, err := queries.PruneAICache(context.Background(), now)
+		if err != nil {
+			slog.Error("AI 캐시 정리 실패", "error", err)
+			return
+		}
+		slog.Info("AI 캐시 정리", "count", count)
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
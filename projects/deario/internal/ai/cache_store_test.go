@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	aiclient "simple-server/internal/ai"
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db/dbtest"
+)
+
+func TestCacheStore(t *testing.T) {
+	ctx := context.Background()
+	queries := dbtest.New(t)
+	store := CacheStore{Queries: queries}
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	if _, _, ok, err := store.Get(ctx, "k", now); ok || err != nil {
+		t.Fatalf("Get() on empty store = %v, %v", ok, err)
+	}
+	if err := store.Set(ctx, "k", "v1", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Set(ctx, "k", "v2", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if v, expires, ok, err := store.Get(ctx, "k", now); !ok || err != nil || v != "v2" || !expires.Equal(now.Add(time.Hour)) {
+		t.Errorf("Get() = %q, %s, %v, %v", v, expires, ok, err)
+	}
+	if _, _, ok, _ := store.Get(ctx, "k", now.Add(time.Hour)); ok {
+		t.Error("Get() after expiry should miss")
+	}
+
+	count, err := queries.PruneAICache(ctx, now.Add(2*time.Hour).Format(dateutil.DateFormatISOTime))
+	if err != nil || count != 1 {
+		t.Errorf("PruneAICache() = %d, %v, want 1", count, err)
+	}
+
+	// 메모리 캐시가 비어도 store에 남은 응답을 쓴다.
+	cache := aiclient.NewCache(10, time.Hour, store)
+	cache.Set(ctx, "other", "answer")
+	if v, ok := aiclient.NewCache(10, time.Hour, store).Get(ctx, "other"); !ok || v != "answer" {
+		t.Errorf("Cache.Get() through store = %q, %v", v, ok)
+	}
+}
@@ -0,0 +1,163 @@
+package apitoken
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"simple-server/projects/deario/db"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/time/rate"
+)
+
+const contextKey = "apitoken"
+
+const (
+	// limiterIdleTTL 동안 쓰이지 않은 토큰의 한도 기록은 지운다.
+	// 분당 한도는 1분이면 다시 가득 차므로 그보다 오래 쉰 기록은 지워도 결과가 같다.
+	limiterIdleTTL = 10 * time.Minute
+	// touchInterval은 토큰 사용 시각을 DB에 다시 쓰기까지의 최소 간격이다.
+	touchInterval = time.Minute
+)
+
+// limiters는 토큰 ID별 요청 한도와 마지막 사용 시각 기록을 메모리에 보관한다.
+var limiters = newLimiterStore()
+
+type limiterEntry struct {
+	limiter *rate.Limiter
+	seen    time.Time
+	touched time.Time
+}
+
+type limiterStore struct {
+	mu        sync.Mutex
+	entries   map[string]*limiterEntry
+	lastSweep time.Time
+}
+
+func newLimiterStore() *limiterStore {
+	return &limiterStore{entries: map[string]*limiterEntry{}}
+}
+
+// entry는 토큰의 기록을 찾거나 만들고, 가끔 오래 쓰이지 않은 기록을 지운다. s.mu를 잡고 불러야 한다.
+func (s *limiterStore) entry(id string, perMinute int64, now time.Time) *limiterEntry {
+	if now.Sub(s.lastSweep) >= limiterIdleTTL {
+		for key, e := range s.entries {
+			if now.Sub(e.seen) >= limiterIdleTTL {
+				delete(s.entries, key)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	e, ok := s.entries[id]
+	if !ok {
+		e = &limiterEntry{limiter: rate.NewLimiter(rate.Limit(float64(perMinute)/60), int(perMinute))}
+		s.entries[id] = e
+	}
+	e.seen = now
+	return e
+}
+
+// allow는 분당 perMinute회 한도 안이면 요청을 허용하고, 아니면 다시 시도할 때까지 기다릴 시간을 반환한다.
+func (s *limiterStore) allow(id string, perMinute int64, now time.Time) (bool, time.Duration) {
+	s.mu.Lock()
+	l := s.entry(id, perMinute, now).limiter
+	s.mu.Unlock()
+
+	r := l.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// shouldTouch는 토큰 사용 시각을 마지막으로 기록한 지 touchInterval이 지났으면 true를 반환하고 지금으로 표시한다.
+// 요청마다 DB에 쓰지 않도록 allow 뒤에 부른다.
+func (s *limiterStore) shouldTouch(id string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return true
+	}
+	if !e.touched.IsZero() && now.Sub(e.touched) < touchInterval {
+		return false
+	}
+	e.touched = now
+	return true
+}
+
+func (s *limiterStore) forget(id string) {
+	s.mu.Lock()
+	delete(s.entries, id)
+	s.mu.Unlock()
+}
+
+// Middleware는 Authorization: Bearer 토큰으로 사용자를 확인하고,
+// 토큰 권한을 검사한 뒤 토큰별 요청 한도를 적용한다.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			plain, ok := BearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+			if !ok {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="deario"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, "액세스 토큰이 필요합니다.")
+			}
+
+			queries, err := db.GetQueries()
+			if err != nil {
+				return err
+			}
+
+			ctx := c.Request().Context()
+			token, err := queries.GetAPITokenByHash(ctx, Hash(plain))
+			if errors.Is(err, sql.ErrNoRows) {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="deario", error="invalid_token"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, "유효하지 않은 액세스 토큰입니다.")
+			}
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "토큰 확인에 실패했습니다.")
+			}
+
+			if !Allowed(SplitScopes(token.Scopes), c.Path(), c.Request().Method) {
+				return echo.NewHTTPError(http.StatusForbidden, "토큰에 이 요청 권한이 없습니다.")
+			}
+
+			header := c.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.FormatInt(token.RateLimit, 10))
+			now := time.Now()
+			if ok, wait := limiters.allow(token.ID, token.RateLimit, now); !ok {
+				header.Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "요청 한도를 넘었습니다. 잠시 후 다시 시도해주세요.")
+			}
+
+			if limiters.shouldTouch(token.ID, now) {
+				if err := queries.TouchAPIToken(context.WithoutCancel(ctx), token.ID); err != nil {
+					slog.Error("토큰 사용 시각 갱신 실패", "id", token.ID, "error", err)
+				}
+			}
+
+			c.Set(contextKey, token)
+			return next(c)
+		}
+	}
+}
+
+// UID는 토큰 미들웨어가 확인한 토큰 소유자의 uid를 반환한다.
+func UID(c echo.Context) (string, error) {
+	token, ok := c.Get(contextKey).(db.ApiToken)
+	if !ok || token.Uid == "" {
+		return "", echo.NewHTTPError(http.StatusUnauthorized, "유효하지 않은 액세스 토큰입니다.")
+	}
+	return token.Uid, nil
+}
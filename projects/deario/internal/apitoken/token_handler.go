@@ -0,0 +1,154 @@
+package apitoken
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"simple-server/internal/validate"
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+type createTokenDTO struct {
+	Name      string   `form:"name" validate:"required,max=50" message:"토큰 이름은 1~50자로 입력해주세요."`
+	Scopes    []string `form:"scopes" validate:"required,min=1" message:"권한을 하나 이상 골라주세요."`
+	RateLimit int64    `form:"rate_limit" validate:"required" message:"요청 한도를 골라주세요."`
+}
+
+// TokensPanel은 설정 화면의 개인 액세스 토큰 목록을 렌더링한다.
+func TokensPanel(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	return renderTokens(c, queries, uid, "")
+}
+
+// CreateToken은 새 토큰을 발급하고 원문을 한 번만 보여준다.
+func CreateToken(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto createTokenDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	scopes, err := ParseScopes(dto.Scopes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "권한 값이 올바르지 않습니다.")
+	}
+	if !slices.Contains(RateLimits, dto.RateLimit) {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 한도 값이 올바르지 않습니다.")
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	count, err := queries.CountAPITokens(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "토큰 목록을 가져오지 못했습니다.")
+	}
+	if count >= MaxTokensPerUser {
+		return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("토큰은 최대 %d개까지 발급할 수 있습니다.", MaxTokensPerUser))
+	}
+
+	plain, prefix, hash, err := Generate()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "토큰 발급에 실패했습니다.")
+	}
+	if _, err := queries.CreateAPIToken(c.Request().Context(), db.CreateAPITokenParams{
+		Uid:       uid,
+		Name:      strings.TrimSpace(dto.Name),
+		Prefix:    prefix,
+		TokenHash: hash,
+		Scopes:    JoinScopes(scopes),
+		RateLimit: dto.RateLimit,
+	}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "토큰 발급에 실패했습니다.")
+	}
+
+	return renderTokens(c, queries, uid, plain)
+}
+
+// RevokeToken은 토큰을 폐기한다. 폐기한 토큰은 즉시 인증에 실패한다.
+func RevokeToken(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	id := c.Param("id")
+	count, err := queries.RevokeAPIToken(c.Request().Context(), db.RevokeAPITokenParams{ID: id, Uid: uid})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "토큰 폐기에 실패했습니다.")
+	}
+	if count == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "토큰을 찾을 수 없습니다.")
+	}
+	limiters.forget(id)
+
+	return renderTokens(c, queries, uid, "")
+}
+
+func renderTokens(c echo.Context, queries *db.Queries, uid, created string) error {
+	ctx := c.Request().Context()
+	tokens, err := queries.ListAPITokens(ctx, uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "토큰 목록을 가져오지 못했습니다.")
+	}
+
+	setting, _ := queries.GetUserSetting(ctx, uid)
+	loc := deariodate.Location(setting.Timezone)
+
+	panel := components.APITokenPanel{
+		Tokens:           make([]components.APITokenItem, 0, len(tokens)),
+		RateLimits:       RateLimits,
+		DefaultRateLimit: DefaultRateLimit,
+		Created:          created,
+		CanIssue:         len(tokens) < MaxTokensPerUser,
+	}
+	for _, info := range AllScopes {
+		panel.Scopes = append(panel.Scopes, components.APITokenScopeOption{Value: string(info.Scope), Label: info.Label})
+	}
+	for _, t := range tokens {
+		item := components.APITokenItem{
+			ID:        t.ID,
+			Name:      t.Name,
+			Prefix:    t.Prefix,
+			RateLimit: t.RateLimit,
+			Created:   deariodate.LocalTime(t.Created.String, loc),
+			LastUsed:  deariodate.LocalTime(t.LastUsed, loc),
+		}
+		for _, s := range SplitScopes(t.Scopes) {
+			item.Scopes = append(item.Scopes, s.Label())
+		}
+		panel.Tokens = append(panel.Tokens, item)
+	}
+
+	return components.APITokens(panel).Render(ctx, c.Response().Writer)
+}
@@ -0,0 +1,163 @@
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// tokenPrefix는 토큰이 유출되었을 때 출처를 알아보기 쉽도록 붙이는 접두어다.
+	tokenPrefix = "dea_"
+	// displayPrefixLen은 목록에서 토큰을 구분하도록 보여줄 앞부분 길이다.
+	displayPrefixLen = 12
+	// MaxTokensPerUser는 사용자당 유효한 토큰 수의 상한이다.
+	MaxTokensPerUser = 10
+	// DefaultRateLimit는 토큰별 분당 요청 수의 기본값이다.
+	DefaultRateLimit = 60
+)
+
+// RateLimits는 토큰을 만들 때 고를 수 있는 분당 요청 수다.
+var RateLimits = []int64{30, 60, 120}
+
+// Scope는 토큰에 부여하는 권한 단위다.
+type Scope string
+
+const (
+	ScopeDiaryRead     Scope = "diary:read"
+	ScopeDiaryWrite    Scope = "diary:write"
+	ScopeStatsRead     Scope = "stats:read"
+	ScopeSettingsRead  Scope = "settings:read"
+	ScopeSettingsWrite Scope = "settings:write"
+)
+
+// ScopeInfo는 설정 화면에 보여줄 권한 설명이다.
+type ScopeInfo struct {
+	Scope Scope
+	Label string
+}
+
+// AllScopes는 부여할 수 있는 권한을 화면에 보여줄 순서대로 나열한다.
+var AllScopes = []ScopeInfo{
+	{Scope: ScopeDiaryRead, Label: "일기 읽기"},
+	{Scope: ScopeDiaryWrite, Label: "일기 쓰기"},
+	{Scope: ScopeStatsRead, Label: "통계 읽기"},
+	{Scope: ScopeSettingsRead, Label: "설정 읽기"},
+	{Scope: ScopeSettingsWrite, Label: "설정 변경"},
+}
+
+// scopeRoutes는 권한별로 허용하는 API 경로와 메서드다.
+var scopeRoutes = map[Scope][][2]string{
+	ScopeDiaryRead: {
+		{"/api/v1/diaries", "GET"},
+		{"/api/v1/diaries/:date", "GET"},
+		{"/api/v1/search", "GET"},
+	},
+	ScopeDiaryWrite: {
+		{"/api/v1/diaries/:date", "PUT"},
+		{"/api/v1/diaries/:date", "DELETE"},
+		{"/api/v1/diaries/:date/mood", "PUT"},
+	},
+	ScopeStatsRead: {
+		{"/api/v1/stats", "GET"},
+	},
+	ScopeSettingsRead: {
+		{"/api/v1/settings", "GET"},
+	},
+	ScopeSettingsWrite: {
+		{"/api/v1/settings", "PUT"},
+		{"/api/v1/settings", "PATCH"},
+	},
+}
+
+// Label은 권한의 한국어 이름을 반환한다.
+func (s Scope) Label() string {
+	for _, info := range AllScopes {
+		if info.Scope == s {
+			return info.Label
+		}
+	}
+	return string(s)
+}
+
+// Generate는 새 토큰 원문과 목록용 접두어, 저장용 해시를 만든다.
+// 원문은 만들 때 한 번만 보여주고 저장하지 않는다.
+func Generate() (token, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("토큰 생성 실패: %w", err)
+	}
+	token = tokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, token[:displayPrefixLen], Hash(token), nil
+}
+
+// Hash는 토큰 원문의 SHA-256 해시를 16진수로 반환한다.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// BearerToken은 Authorization 헤더에서 토큰을 꺼낸다.
+func BearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, strings.HasPrefix(token, tokenPrefix)
+}
+
+// ParseScopes는 입력된 권한을 검증하고 중복을 없애 정해진 순서로 정렬한다.
+func ParseScopes(values []string) ([]Scope, error) {
+	var scopes []Scope
+	for _, info := range AllScopes {
+		if slices.Contains(values, string(info.Scope)) {
+			scopes = append(scopes, info.Scope)
+		}
+	}
+	for _, v := range values {
+		if _, ok := scopeRoutes[Scope(v)]; !ok {
+			return nil, fmt.Errorf("알 수 없는 권한: %s", v)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("권한을 하나 이상 골라야 합니다")
+	}
+	return scopes, nil
+}
+
+// JoinScopes는 권한 목록을 저장용 문자열로 만든다.
+func JoinScopes(scopes []Scope) string {
+	parts := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		parts = append(parts, string(s))
+	}
+	return strings.Join(parts, " ")
+}
+
+// SplitScopes는 저장된 권한 문자열을 목록으로 되돌린다.
+func SplitScopes(v string) []Scope {
+	fields := strings.Fields(v)
+	scopes := make([]Scope, 0, len(fields))
+	for _, f := range fields {
+		scopes = append(scopes, Scope(f))
+	}
+	return scopes
+}
+
+// Allowed는 토큰 권한 중 하나라도 요청 경로와 메서드를 허용하는지 확인한다.
+// 토큰 권한은 api_token 행에만 있으므로 사용자 권한을 담는 Casbin 정책과 섞지 않고 코드에서 바로 검사한다.
+func Allowed(scopes []Scope, path, method string) bool {
+	for _, s := range scopes {
+		for _, route := range scopeRoutes[s] {
+			if route[0] == path && route[1] == method {
+				return true
+			}
+		}
+	}
+	return false
+}
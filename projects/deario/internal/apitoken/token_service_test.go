@@ -0,0 +1,143 @@
+package apitoken
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	token, prefix, hash, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !strings.HasPrefix(token, tokenPrefix) || !strings.HasPrefix(token, prefix) {
+		t.Errorf("Generate() token = %q, prefix = %q", token, prefix)
+	}
+	if hash != Hash(token) || strings.Contains(hash, token) {
+		t.Errorf("Generate() hash = %q, want Hash(token)", hash)
+	}
+
+	other, _, _, _ := Generate()
+	if other == token {
+		t.Error("Generate() returned the same token twice")
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{header: "Bearer dea_abc", want: "dea_abc", ok: true},
+		{header: "bearer  dea_abc ", want: "dea_abc", ok: true},
+		{header: "Basic dea_abc", ok: false},
+		{header: "Bearer other", want: "other", ok: false},
+		{header: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := BearerToken(tt.header)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("BearerToken(%q) = %q, %v, want %q, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	got, err := ParseScopes([]string{"stats:read", "diary:read", "stats:read"})
+	if err != nil {
+		t.Fatalf("ParseScopes() error = %v", err)
+	}
+	if want := []Scope{ScopeDiaryRead, ScopeStatsRead}; !slices.Equal(got, want) {
+		t.Errorf("ParseScopes() = %v, want %v", got, want)
+	}
+	if s := SplitScopes(JoinScopes(got)); !slices.Equal(s, got) {
+		t.Errorf("SplitScopes(JoinScopes()) = %v, want %v", s, got)
+	}
+
+	for _, values := range [][]string{nil, {"admin"}, {"diary:read", "diary:*"}} {
+		if _, err := ParseScopes(values); err == nil {
+			t.Errorf("ParseScopes(%v) error = nil", values)
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []Scope
+		path   string
+		method string
+		want   bool
+	}{
+		{name: "read diary", scopes: []Scope{ScopeDiaryRead}, path: "/api/v1/diaries/:date", method: "GET", want: true},
+		{name: "read cannot write", scopes: []Scope{ScopeDiaryRead}, path: "/api/v1/diaries/:date", method: "PUT", want: false},
+		{name: "write diary", scopes: []Scope{ScopeDiaryRead, ScopeDiaryWrite}, path: "/api/v1/diaries/:date/mood", method: "PUT", want: true},
+		{name: "stats only", scopes: []Scope{ScopeStatsRead}, path: "/api/v1/search", method: "GET", want: false},
+		{name: "settings write", scopes: []Scope{ScopeSettingsWrite}, path: "/api/v1/settings", method: "PUT", want: true},
+		{name: "no scopes", scopes: nil, path: "/api/v1/stats", method: "GET", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Allowed(tt.scopes, tt.path, tt.method); got != tt.want {
+				t.Errorf("Allowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterStore(t *testing.T) {
+	store := newLimiterStore()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	for i := range 3 {
+		if ok, _ := store.allow("t1", 3, now); !ok {
+			t.Fatalf("request %d rejected within limit", i+1)
+		}
+	}
+	ok, wait := store.allow("t1", 3, now)
+	if ok || wait <= 0 || wait > 20*time.Second {
+		t.Errorf("allow() over limit = %v, %v", ok, wait)
+	}
+	if ok, _ := store.allow("t2", 3, now); !ok {
+		t.Error("allow() limited another token")
+	}
+	if ok, _ := store.allow("t1", 3, now.Add(20*time.Second)); !ok {
+		t.Error("allow() did not refill after 20s")
+	}
+
+	store.forget("t1")
+	if _, exists := store.entries["t1"]; exists {
+		t.Error("forget() kept the limiter")
+	}
+
+	// 오래 쓰이지 않은 토큰의 기록은 다음 요청 때 지운다.
+	later := now.Add(limiterIdleTTL)
+	store.allow("t3", 3, later)
+	if _, exists := store.entries["t2"]; exists {
+		t.Error("idle limiter was not evicted")
+	}
+	if _, exists := store.entries["t3"]; !exists {
+		t.Error("active limiter was evicted")
+	}
+}
+
+func TestLimiterStoreShouldTouch(t *testing.T) {
+	store := newLimiterStore()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	store.allow("t1", 60, now)
+	if !store.shouldTouch("t1", now) {
+		t.Fatal("first request was not touched")
+	}
+	if store.shouldTouch("t1", now.Add(30*time.Second)) {
+		t.Error("touched again within a minute")
+	}
+	if !store.shouldTouch("t1", now.Add(touchInterval)) {
+		t.Error("not touched after a minute")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"simple-server/internal/validate"
 	"simple-server/pkg/util/authutil"
@@ -40,6 +42,9 @@ func LockPage(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	if isSessionRevoked(c, setting) {
+		return loggedOutResponse(c)
+	}
 	if setting.AppLockEnabled != 1 {
 		return c.Redirect(http.StatusSeeOther, "/")
 	}
@@ -61,14 +66,13 @@ func Unlock(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	if isSessionRevoked(c, setting) {
+		return loggedOutResponse(c)
+	}
 	if setting.AppLockEnabled != 1 {
 		return redirectAfterUnlock(c)
 	}
 
-	if remaining := unlockLockRemaining(uid); remaining > 0 {
-		return echo.NewHTTPError(http.StatusTooManyRequests, fmt.Sprintf("PIN을 여러 번 틀렸습니다. %d초 후 다시 시도해주세요.", remaining))
-	}
-
 	var dto unlockDTO
 	if err := c.Bind(&dto); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
@@ -77,13 +81,10 @@ func Unlock(c echo.Context) error {
 		return validate.HTTPError(err, &dto)
 	}
 
-	if !isPINValid(setting.AppLockPinHash, dto.PIN) {
-		recordUnlockFailure(uid)
-		return echo.NewHTTPError(http.StatusUnauthorized, "PIN이 올바르지 않습니다.")
+	if err := checkPIN(c, setting, dto.PIN, "PIN이 올바르지 않습니다."); err != nil {
+		return err
 	}
 
-	resetUnlockFailure(uid)
-
 	if err := SaveUnlockSession(c, uid); err != nil {
 		return err
 	}
@@ -132,8 +133,10 @@ func UpdatePIN(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	if setting.AppLockEnabled == 1 && !isPINValid(setting.AppLockPinHash, dto.CurrentPIN) {
-		return echo.NewHTTPError(http.StatusUnauthorized, "현재 PIN이 올바르지 않습니다.")
+	if setting.AppLockEnabled == 1 {
+		if err := checkPIN(c, setting, dto.CurrentPIN, "현재 PIN이 올바르지 않습니다."); err != nil {
+			return err
+		}
 	}
 
 	pinHash, err := hashPIN(dto.PIN)
@@ -180,8 +183,10 @@ func Disable(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	if setting.AppLockEnabled == 1 && !isPINValid(setting.AppLockPinHash, dto.CurrentPIN) {
-		return echo.NewHTTPError(http.StatusUnauthorized, "현재 PIN이 올바르지 않습니다.")
+	if setting.AppLockEnabled == 1 {
+		if err := checkPIN(c, setting, dto.CurrentPIN, "현재 PIN이 올바르지 않습니다."); err != nil {
+			return err
+		}
 	}
 
 	queries, err := db.GetQueries()
@@ -236,6 +241,57 @@ func userSetting(c echo.Context, uid string) (db.UserSetting, error) {
 	return setting, nil
 }
 
+// checkPIN은 시도를 실패 기록에 먼저 예약한 뒤 PIN을 확인한다.
+// 잠금 중이면 PIN을 확인하지 않고, 잠금이 거듭 걸리면 사용자에게 알리거나 설정에 따라 모든 기기를 로그아웃시킨다.
+func checkPIN(c echo.Context, setting db.UserSetting, pin string, message string) error {
+	ctx := c.Request().Context()
+	conn, err := db.GetDB()
+	if err != nil {
+		return err
+	}
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	attempt, err := reserveUnlockAttempt(ctx, conn, queries, setting.Uid, time.Now())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "PIN 실패 기록 저장에 실패했습니다.")
+	}
+	if !attempt.allowed {
+		return echo.NewHTTPError(http.StatusTooManyRequests, fmt.Sprintf("PIN을 여러 번 틀렸습니다. %s 후 다시 시도해주세요.", waitText(attempt.remaining)))
+	}
+
+	if isPINValid(setting.AppLockPinHash, pin) {
+		if err := resetUnlockFailure(ctx, queries, setting.Uid); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "PIN 실패 기록 초기화에 실패했습니다.")
+		}
+		return nil
+	}
+
+	failure := attempt.failure
+	if failure.lockedFor == 0 {
+		return echo.NewHTTPError(http.StatusUnauthorized, message)
+	}
+
+	if setting.AppLockForceLogout == 1 && failure.lockouts >= forceLogoutLockouts {
+		if err := forceLogout(ctx, queries, setting.Uid, time.Now()); err != nil {
+			slog.Error("강제 로그아웃 실패", "uid", setting.Uid, "error", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "로그아웃 처리에 실패했습니다.")
+		}
+		notifyLockout(ctx, setting, failure, true)
+		if err := clearLoginSession(c); err != nil {
+			return err
+		}
+		c.Response().Header().Set("Hx-Redirect", "/login")
+		return echo.NewHTTPError(http.StatusUnauthorized, "PIN을 여러 번 틀려 모든 기기에서 로그아웃했습니다. 다시 로그인해주세요.")
+	}
+	if failure.lockouts >= notifyLockouts {
+		notifyLockout(ctx, setting, failure, false)
+	}
+	return echo.NewHTTPError(http.StatusTooManyRequests, fmt.Sprintf("PIN을 여러 번 틀렸습니다. %s 후 다시 시도해주세요.", waitText(int(failure.lockedFor.Seconds()))))
+}
+
 func isPINValid(pinHash string, pin string) bool {
 	if pinHash == "" || pin == "" {
 		return false
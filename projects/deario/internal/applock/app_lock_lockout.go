@@ -1,63 +1,221 @@
 package applock
 
 import (
-	"sync"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
 	"time"
+
+	"simple-server/internal/middleware"
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/notification"
 )
 
 const (
-	maxUnlockFailures = 5                // 잠금까지 허용하는 연속 실패 횟수
-	unlockLockWindow  = 30 * time.Second // 실패 초과 시 잠금 유지 시간
+	maxUnlockFailures   = 5                // 잠금까지 허용하는 연속 실패 횟수
+	unlockLockWindow    = 30 * time.Second // 첫 잠금 유지 시간. 잠금이 걸릴 때마다 두 배로 늘어난다.
+	maxUnlockLockWindow = time.Hour        // 잠금 유지 시간 상한
+	unlockFailureReset  = 24 * time.Hour   // 마지막 실패 후 이 시간이 지나면 실패 기록을 처음부터 센다.
+	notifyLockouts      = 2                // 이 횟수째 잠금부터 사용자에게 푸시로 알린다.
+	forceLogoutLockouts = 4                // 강제 로그아웃을 켠 사용자는 이 횟수째 잠금에서 모든 기기를 로그아웃시킨다.
 )
 
-// unlockAttempt는 사용자별 PIN 실패 상태를 보관한다.
-type unlockAttempt struct {
-	failures    int
-	lockedUntil time.Time
+// unlockFailure는 PIN 실패를 기록한 결과다. 이번 실패로 잠금이 걸리지 않았으면 lockedFor가 0이다.
+type unlockFailure struct {
+	lockedFor time.Duration
+	lockouts  int64
 }
 
-var (
-	unlockAttempts   = make(map[string]*unlockAttempt)
-	unlockAttemptsMu sync.Mutex
-)
-
-// unlockLockRemaining는 현재 잠금 중이면 남은 초를 반환한다.
-func unlockLockRemaining(uid string) int {
-	unlockAttemptsMu.Lock()
-	defer unlockAttemptsMu.Unlock()
+// lockoutDuration은 n번째 잠금의 유지 시간이다.
+func lockoutDuration(lockouts int64) time.Duration {
+	if lockouts < 1 {
+		return 0
+	}
+	d := unlockLockWindow
+	for i := int64(1); i < lockouts && d < maxUnlockLockWindow; i++ {
+		d *= 2
+	}
+	return min(d, maxUnlockLockWindow)
+}
 
-	attempt, ok := unlockAttempts[uid]
-	if !ok {
+// lockRemaining은 locked_until까지 남은 초를 올림해 반환한다.
+func lockRemaining(lockedUntil string, now time.Time) int {
+	if lockedUntil == "" {
 		return 0
 	}
-	remaining := time.Until(attempt.lockedUntil)
+	until, err := time.ParseInLocation(dateutil.DateFormatISOTime, lockedUntil, time.UTC)
+	if err != nil {
+		return 0
+	}
+	remaining := until.Sub(now)
 	if remaining <= 0 {
 		return 0
 	}
-	return int(remaining.Seconds()) + 1
+	return int((remaining + time.Second - 1) / time.Second)
 }
 
-// recordUnlockFailure는 실패를 누적하고, 임계치 초과 시 잠금을 건다.
-func recordUnlockFailure(uid string) {
-	unlockAttemptsMu.Lock()
-	defer unlockAttemptsMu.Unlock()
+// unlockLockRemaining는 현재 잠금 중이면 남은 초를 반환한다.
+func unlockLockRemaining(ctx context.Context, queries *db.Queries, uid string, now time.Time) (int, error) {
+	attempt, err := queries.GetAppLockAttempt(ctx, uid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return lockRemaining(attempt.LockedUntil, now), nil
+}
+
+// unlockAttempt는 PIN을 비교하기 전에 예약한 시도다.
+type unlockAttempt struct {
+	allowed   bool          // PIN을 비교해도 되는지. 잠겨 있으면 false다.
+	remaining int           // allowed가 false일 때 남은 잠금 초
+	failure   unlockFailure // 이번 시도로 걸린 잠금. PIN이 틀렸을 때만 쓴다.
+}
+
+// reserveUnlockAttempt는 PIN을 비교하기 전에 시도 한 번을 실패로 먼저 기록한다.
+// 잠금 여부를 먼저 읽고 비교한 뒤에 실패를 쓰면 동시에 들어온 요청이 모두 검사를 통과하므로,
+// 잠기지 않았을 때만 횟수를 올리는 문장으로 시도를 예약하고 임계치에 닿은 시도가 곧바로 잠금을 건다.
+// 예약과 잠금을 한 트랜잭션에서 처리해 임계치에 닿은 기록이 잠금 없이 남지 않고, 잠금은 그 시도만 보고한다.
+// PIN이 맞으면 resetUnlockFailure가 기록을 지워 잠금도 풀린다.
+func reserveUnlockAttempt(ctx context.Context, conn *sql.DB, queries *db.Queries, uid string, now time.Time) (unlockAttempt, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return unlockAttempt{}, fmt.Errorf("트랜잭션 시작 실패: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	q := queries.WithTx(tx)
+
+	if err := q.DeleteStaleAppLockAttempt(ctx, db.DeleteStaleAppLockAttemptParams{
+		Uid:    uid,
+		Before: now.UTC().Add(-unlockFailureReset).Format(dateutil.DateFormatISOTime),
+	}); err != nil {
+		return unlockAttempt{}, err
+	}
+	if err := q.CreateAppLockAttempt(ctx, uid); err != nil {
+		return unlockAttempt{}, err
+	}
+
+	attempt, err := q.ReserveAppLockAttempt(ctx, db.ReserveAppLockAttemptParams{
+		Uid:         uid,
+		Now:         now.UTC().Format(dateutil.DateFormatISOTime),
+		MaxFailures: maxUnlockFailures,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// 잠겨 있다. 예약과 잠금을 따로 쓰던 때 남은, 임계치에 닿고도 잠기지 않은 기록이면 지금 잠근다.
+		attempt, err := q.GetAppLockAttempt(ctx, uid)
+		if err != nil {
+			return unlockAttempt{}, err
+		}
+		if lockRemaining(attempt.LockedUntil, now) == 0 {
+			if _, err := lockUnlockAttempt(ctx, q, attempt, now); err != nil {
+				return unlockAttempt{}, err
+			}
+		}
+		remaining, err := unlockLockRemaining(ctx, q, uid, now)
+		if err != nil {
+			return unlockAttempt{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return unlockAttempt{}, err
+		}
+		return unlockAttempt{remaining: max(remaining, 1)}, nil
+	}
+	if err != nil {
+		return unlockAttempt{}, err
+	}
+
+	var failure unlockFailure
+	if attempt.Failures >= maxUnlockFailures {
+		if failure, err = lockUnlockAttempt(ctx, q, attempt, now); err != nil {
+			return unlockAttempt{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return unlockAttempt{}, err
+	}
+	return unlockAttempt{allowed: true, failure: failure}, nil
+}
 
-	attempt, ok := unlockAttempts[uid]
-	if !ok {
-		attempt = &unlockAttempt{}
-		unlockAttempts[uid] = attempt
+// lockUnlockAttempt는 실패가 임계치에 닿은 기록에 지금까지 걸린 잠금 횟수만큼 늘어난 잠금을 건다.
+func lockUnlockAttempt(ctx context.Context, queries *db.Queries, attempt db.AppLockAttempt, now time.Time) (unlockFailure, error) {
+	lockouts := attempt.Lockouts + 1
+	d := lockoutDuration(lockouts)
+	n, err := queries.LockAppLockAttempt(ctx, db.LockAppLockAttemptParams{
+		LockedUntil: now.UTC().Add(d).Format(dateutil.DateFormatISOTime),
+		Uid:         attempt.Uid,
+		MaxFailures: maxUnlockFailures,
+	})
+	if err != nil {
+		return unlockFailure{}, err
 	}
-	attempt.failures++
-	if attempt.failures >= maxUnlockFailures {
-		attempt.lockedUntil = time.Now().Add(unlockLockWindow)
-		attempt.failures = 0
+	// 동시에 들어온 다른 요청이 먼저 잠금을 걸었으면 잠금 횟수를 두 번 올리지 않는다.
+	if n == 0 {
+		return unlockFailure{}, nil
 	}
+	return unlockFailure{lockedFor: d, lockouts: lockouts}, nil
 }
 
 // resetUnlockFailure는 성공 시 실패 상태를 초기화한다.
-func resetUnlockFailure(uid string) {
-	unlockAttemptsMu.Lock()
-	defer unlockAttemptsMu.Unlock()
+func resetUnlockFailure(ctx context.Context, queries *db.Queries, uid string) error {
+	return queries.DeleteAppLockAttempt(ctx, uid)
+}
+
+// waitText는 다시 시도할 수 있을 때까지 남은 시간을 화면에 보여줄 말로 바꾼다.
+func waitText(seconds int) string {
+	if seconds < 60 {
+		return fmt.Sprintf("%d초", seconds)
+	}
+	return fmt.Sprintf("%d분", (seconds+59)/60)
+}
 
-	delete(unlockAttempts, uid)
+// notifyLockout은 잠금이 거듭 걸린 사용자에게 푸시로 알린다. 푸시 토큰이 없으면 건너뛴다.
+func notifyLockout(ctx context.Context, setting db.UserSetting, failure unlockFailure, loggedOut bool) {
+	if setting.PushToken == "" {
+		return
+	}
+
+	body := fmt.Sprintf("PIN을 여러 번 틀려 앱 잠금이 %s 동안 걸렸습니다. 본인이 아니라면 PIN을 바꿔주세요.", waitText(int(failure.lockedFor.Seconds())))
+	if loggedOut {
+		body = "PIN을 여러 번 틀려 모든 기기에서 로그아웃했습니다. 다시 로그인해주세요."
+	}
+	if err := notification.EnqueuePush(ctx, notification.Payload{
+		Title: "앱 잠금 경고",
+		Body:  body,
+		Token: setting.PushToken,
+	}); err != nil {
+		slog.Error("앱 잠금 경고 푸시 발송 실패", "uid", setting.Uid, "error", err)
+	}
+}
+
+// forceLogout은 모든 기기의 로그인을 끊는다.
+// 파이어베이스 갱신 토큰을 취소해 다시 로그인해야 세션을 만들 수 있게 하고,
+// 쿠키에 남은 세션은 sessions_revoked_at보다 먼저 만들어졌으므로 다음 요청에서 거절한다.
+// API 액세스 토큰도 로그인과 같은 권한이므로 모두 폐기한다.
+func forceLogout(ctx context.Context, queries *db.Queries, uid string, now time.Time) error {
+	client, err := middleware.App.Auth(ctx)
+	if err != nil {
+		return fmt.Errorf("인증 클라이언트 생성 실패: %w", err)
+	}
+	if err := client.RevokeRefreshTokens(ctx, uid); err != nil {
+		return fmt.Errorf("파이어베이스 토큰 취소 실패: %w", err)
+	}
+
+	if err := queries.RevokeUserSessions(ctx, db.RevokeUserSessionsParams{
+		SessionsRevokedAt: now.Unix(),
+		Uid:               uid,
+	}); err != nil {
+		return fmt.Errorf("세션 취소 실패: %w", err)
+	}
+	if _, err := queries.RevokeUserAPITokens(ctx, uid); err != nil {
+		return fmt.Errorf("API 토큰 폐기 실패: %w", err)
+	}
+	// 다시 로그인하면 본인 확인이 된 것이므로 잠금을 풀어 준다.
+	if err := queries.DeleteAppLockAttempt(ctx, uid); err != nil {
+		return fmt.Errorf("PIN 실패 기록 삭제 실패: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,172 @@
+package applock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/db/dbtest"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		lockouts int64
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := lockoutDuration(tt.lockouts); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %s, want %s", tt.lockouts, got, tt.want)
+		}
+	}
+}
+
+func TestLockRemaining(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		lockedUntil string
+		want        int
+	}{
+		{"", 0},
+		{"2026-10-19 11:59:00", 0},
+		{"2026-10-19 12:00:30", 30},
+		{"2026-10-19 13:00:00", 3600},
+		{"잘못된 값", 0},
+	}
+	for _, tt := range tests {
+		if got := lockRemaining(tt.lockedUntil, now); got != tt.want {
+			t.Errorf("lockRemaining(%q) = %d, want %d", tt.lockedUntil, got, tt.want)
+		}
+	}
+}
+
+func TestReserveUnlockAttempt(t *testing.T) {
+	ctx := context.Background()
+	conn := dbtest.Open(t)
+	queries := db.New(conn)
+	now := time.Now()
+
+	// fail은 잠금이 걸릴 때까지 시도를 예약하고 몇 번째 시도에서 걸렸는지 반환한다.
+	fail := func(now time.Time) (int, unlockFailure) {
+		t.Helper()
+		for i := 1; i <= maxUnlockFailures; i++ {
+			attempt, err := reserveUnlockAttempt(ctx, conn, queries, "user-1", now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !attempt.allowed {
+				t.Fatalf("%d번째 시도가 거절됨", i)
+			}
+			if attempt.failure.lockedFor > 0 {
+				return i, attempt.failure
+			}
+		}
+		return 0, unlockFailure{}
+	}
+
+	n, failure := fail(now)
+	if n != maxUnlockFailures || failure != (unlockFailure{lockedFor: 30 * time.Second, lockouts: 1}) {
+		t.Fatalf("첫 잠금 = %d번째 %+v", n, failure)
+	}
+	attempt, err := reserveUnlockAttempt(ctx, conn, queries, "user-1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt.allowed || attempt.remaining != 30 {
+		t.Errorf("잠긴 뒤 시도 = %+v, want remaining 30", attempt)
+	}
+
+	// 잠금이 풀린 뒤 다시 틀리면 잠금 시간이 두 배가 된다.
+	now = now.Add(30 * time.Second)
+	_, failure = fail(now)
+	if failure != (unlockFailure{lockedFor: time.Minute, lockouts: 2}) {
+		t.Errorf("두 번째 잠금 = %+v", failure)
+	}
+
+	// 다른 사용자의 기록과는 섞이지 않는다.
+	if remaining, err := unlockLockRemaining(ctx, queries, "user-2", now); err != nil || remaining != 0 {
+		t.Errorf("user-2 remaining = %d, %v", remaining, err)
+	}
+
+	// 마지막 실패 후 하루가 지나면 처음부터 센다.
+	later := now.Add(unlockFailureReset + time.Hour)
+	if _, err := reserveUnlockAttempt(ctx, conn, queries, "user-1", later); err != nil {
+		t.Fatal(err)
+	}
+	got, err := queries.GetAppLockAttempt(ctx, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Failures != 1 || got.Lockouts != 0 || got.LockedUntil != "" {
+		t.Errorf("하루 뒤 기록 = %+v", got)
+	}
+
+	if err := resetUnlockFailure(ctx, queries, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if remaining, err := unlockLockRemaining(ctx, queries, "user-1", later); err != nil || remaining != 0 {
+		t.Errorf("초기화 뒤 remaining = %d, %v", remaining, err)
+	}
+}
+
+func TestReserveUnlockAttemptConcurrent(t *testing.T) {
+	ctx := context.Background()
+	conn := dbtest.Open(t)
+	queries := db.New(conn)
+	now := time.Now()
+
+	// 한꺼번에 들어온 요청도 잠금까지 maxUnlockFailures번만 PIN을 비교할 수 있고, 잠금은 한 번만 걸린다.
+	const workers = 20
+	attempts := make([]unlockAttempt, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if attempts[i], err = reserveUnlockAttempt(ctx, conn, queries, "user-1", now); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	allowed, locks := 0, 0
+	for _, a := range attempts {
+		if a.allowed {
+			allowed++
+		}
+		if a.failure.lockedFor > 0 {
+			locks++
+		}
+	}
+	if allowed != maxUnlockFailures || locks != 1 {
+		t.Errorf("허용된 시도 = %d, 잠금 = %d", allowed, locks)
+	}
+	if remaining, err := unlockLockRemaining(ctx, queries, "user-1", now); err != nil || remaining != 30 {
+		t.Errorf("remaining = %d, %v", remaining, err)
+	}
+}
+
+func TestWaitText(t *testing.T) {
+	tests := map[int]string{
+		1:    "1초",
+		59:   "59초",
+		60:   "1분",
+		61:   "2분",
+		3600: "60분",
+	}
+	for seconds, want := range tests {
+		if got := waitText(seconds); got != want {
+			t.Errorf("waitText(%d) = %q, want %q", seconds, got, want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
 	"simple-server/projects/deario/views/pages"
 
 	"github.com/labstack/echo/v4"
@@ -17,11 +18,14 @@ func GuardIndex(next echo.HandlerFunc) echo.HandlerFunc {
 			return next(c)
 		}
 
-		locked, err := isLocked(c, uid)
+		setting, err := userSetting(c, uid)
 		if err != nil {
 			return err
 		}
-		if locked {
+		if isSessionRevoked(c, setting) {
+			return loggedOutResponse(c)
+		}
+		if isLocked(c, setting) {
 			return pages.AppLock().Render(c.Request().Context(), c.Response().Writer)
 		}
 
@@ -37,11 +41,14 @@ func RequireUnlocked(next echo.HandlerFunc) echo.HandlerFunc {
 			return err
 		}
 
-		locked, err := isLocked(c, uid)
+		setting, err := userSetting(c, uid)
 		if err != nil {
 			return err
 		}
-		if locked {
+		if isSessionRevoked(c, setting) {
+			return loggedOutResponse(c)
+		}
+		if isLocked(c, setting) {
 			return lockedResponse(c)
 		}
 
@@ -49,16 +56,12 @@ func RequireUnlocked(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
-func isLocked(c echo.Context, uid string) (bool, error) {
-	setting, err := userSetting(c, uid)
-	if err != nil {
-		return false, err
-	}
+func isLocked(c echo.Context, setting db.UserSetting) bool {
 	if setting.AppLockEnabled != 1 {
-		return false, nil
+		return false
 	}
 
-	return !isUnlockSessionValid(c, uid), nil
+	return !isUnlockSessionValid(c, setting.Uid)
 }
 
 func lockedResponse(c echo.Context) error {
@@ -72,3 +75,19 @@ func lockedResponse(c echo.Context) error {
 
 	return echo.NewHTTPError(http.StatusLocked, "PIN 잠금 해제가 필요합니다.")
 }
+
+// loggedOutResponse는 취소된 로그인 세션을 지우고 로그인 화면으로 보낸다.
+func loggedOutResponse(c echo.Context) error {
+	if err := clearLoginSession(c); err != nil {
+		return err
+	}
+	if c.Request().Header.Get("Hx-Request") == "true" {
+		c.Response().Header().Set("Hx-Redirect", "/login")
+		return c.NoContent(http.StatusNoContent)
+	}
+	if c.Request().Method == http.MethodGet {
+		return c.Redirect(http.StatusSeeOther, "/login")
+	}
+
+	return echo.NewHTTPError(http.StatusUnauthorized, "다시 로그인해주세요.")
+}
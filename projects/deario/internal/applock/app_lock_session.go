@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"simple-server/internal/config"
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
 
 	"github.com/gorilla/sessions"
 	"github.com/labstack/echo-contrib/session"
@@ -39,6 +41,28 @@ func ClearUnlockSession(c echo.Context) error {
 	return sess.Save(c.Request(), c.Response())
 }
 
+// clearLoginSession은 로그인 세션과 앱 잠금 해제 세션을 지운다.
+// auth 패키지가 이 패키지를 쓰므로 auth.ClearSession을 부르지 않고 직접 지운다.
+func clearLoginSession(c echo.Context) error {
+	sess, err := session.Get("session_v2", c)
+	if err != nil {
+		return err
+	}
+
+	sess.Options = &sessions.Options{Path: "/", MaxAge: -1}
+	if err := sess.Save(c.Request(), c.Response()); err != nil {
+		return err
+	}
+
+	return ClearUnlockSession(c)
+}
+
+// isSessionRevoked는 강제 로그아웃 전에 만든 로그인 세션인지 확인한다.
+// 쿠키 세션은 서버에서 지울 수 없으므로 세션을 만든 시각으로 거른다.
+func isSessionRevoked(c echo.Context, setting db.UserSetting) bool {
+	return setting.SessionsRevokedAt > 0 && authutil.SessionIssuedAt(c) <= setting.SessionsRevokedAt
+}
+
 func isUnlockSessionValid(c echo.Context, uid string) bool {
 	sess, err := session.Get(unlockSessionName, c)
 	if err != nil || sess == nil || sess.Values == nil {
@@ -18,6 +18,15 @@ func LoginPage(c echo.Context) error {
 
 // Logout은 사용자 세션을 종료한다.
 func Logout(c echo.Context) error {
+	if err := ClearSession(c); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ClearSession은 로그인 세션과 앱 잠금 해제 세션을 지운다.
+func ClearSession(c echo.Context) error {
 	sess, err := session.Get("session_v2", c)
 	if err != nil {
 		return err
@@ -28,9 +37,5 @@ func Logout(c echo.Context) error {
 		return err
 	}
 
-	if err := applock.ClearUnlockSession(c); err != nil {
-		return err
-	}
-
-	return c.NoContent(http.StatusNoContent)
+	return applock.ClearUnlockSession(c)
 }
@@ -0,0 +1,135 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"simple-server/pkg/util/dateutil"
+	"simple-server/pkg/util/stringutil"
+	"simple-server/projects/deario/db"
+)
+
+const monthFormat = "200601"
+
+// Day는 달력 하루 칸에 표시할 일기 요약이다.
+type Day struct {
+	Date          string `json:"date"`
+	Mood          string `json:"mood"`
+	HasImage      bool   `json:"hasImage"`
+	HasAiFeedback bool   `json:"hasAiFeedback"`
+	WordCount     int    `json:"wordCount"`
+}
+
+// Cell은 달력 격자의 한 칸이다. Written이 false면 Day는 비어 있다.
+type Cell struct {
+	Date    string
+	Day     Day
+	Written bool
+	// InRange는 칸이 조회한 달(또는 주)에 속하는지 나타낸다.
+	InRange bool
+}
+
+// DayOfMonth는 칸의 일자를 숫자로 반환한다.
+func (c Cell) DayOfMonth() int {
+	t, err := time.Parse(dateutil.DateFormatYYYYMMDD, c.Date)
+	if err != nil {
+		return 0
+	}
+	return t.Day()
+}
+
+// MonthRange는 YYYYMM 달의 첫날과 마지막 날을 반환한다.
+func MonthRange(month string) (string, string, error) {
+	t, err := time.Parse(monthFormat, month)
+	if err != nil {
+		return "", "", fmt.Errorf("월 형식 오류: %w", err)
+	}
+	return t.Format(dateutil.DateFormatYYYYMMDD), t.AddDate(0, 1, -1).Format(dateutil.DateFormatYYYYMMDD), nil
+}
+
+// WeekRange는 날짜가 속한 주의 일요일과 토요일을 반환한다.
+func WeekRange(date string) (string, string, error) {
+	t, err := time.Parse(dateutil.DateFormatYYYYMMDD, date)
+	if err != nil {
+		return "", "", fmt.Errorf("날짜 형식 오류: %w", err)
+	}
+	sunday := t.AddDate(0, 0, -int(t.Weekday()))
+	return sunday.Format(dateutil.DateFormatYYYYMMDD), sunday.AddDate(0, 0, 6).Format(dateutil.DateFormatYYYYMMDD), nil
+}
+
+// LoadDays는 기간 내 작성한 일기 요약을 (uid, date) 인덱스 범위 조회로 가져온다.
+func LoadDays(ctx context.Context, queries *db.Queries, uid, start, end string) ([]Day, error) {
+	rows, err := queries.ListDiaryCalendarDays(ctx, db.ListDiaryCalendarDaysParams{
+		Uid:       uid,
+		StartDate: start,
+		EndDate:   end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("달력 조회 실패: %w", err)
+	}
+
+	days := make([]Day, 0, len(rows))
+	for _, row := range rows {
+		days = append(days, Day{
+			Date:          row.Date,
+			Mood:          row.Mood,
+			HasImage:      row.HasImage != 0,
+			HasAiFeedback: row.HasAiFeedback != 0,
+			WordCount:     stringutil.CountWords(row.Content),
+		})
+	}
+	return days, nil
+}
+
+// Index는 날짜로 일기 요약을 찾을 수 있도록 맵으로 바꾼다.
+func Index(days []Day) map[string]Day {
+	m := make(map[string]Day, len(days))
+	for _, d := range days {
+		m[d.Date] = d
+	}
+	return m
+}
+
+// MonthWeeks는 YYYYMM 달을 일요일부터 시작하는 주 단위 격자로 만든다.
+func MonthWeeks(month string, days map[string]Day) [][]Cell {
+	first, err := time.Parse(monthFormat, month)
+	if err != nil {
+		return nil
+	}
+	last := first.AddDate(0, 1, -1)
+
+	var weeks [][]Cell
+	day := first.AddDate(0, 0, -int(first.Weekday()))
+	for !day.After(last) {
+		week := make([]Cell, 0, 7)
+		for range 7 {
+			week = append(week, newCell(day, days, day.Month() == first.Month()))
+			day = day.AddDate(0, 0, 1)
+		}
+		weeks = append(weeks, week)
+	}
+	return weeks
+}
+
+// WeekCells는 날짜가 속한 주의 일요일~토요일 칸을 만든다.
+func WeekCells(date string, days map[string]Day) []Cell {
+	start, _, err := WeekRange(date)
+	if err != nil {
+		return nil
+	}
+	day, _ := time.Parse(dateutil.DateFormatYYYYMMDD, start)
+
+	cells := make([]Cell, 0, 7)
+	for range 7 {
+		cells = append(cells, newCell(day, days, true))
+		day = day.AddDate(0, 0, 1)
+	}
+	return cells
+}
+
+func newCell(day time.Time, days map[string]Day, inRange bool) Cell {
+	date := day.Format(dateutil.DateFormatYYYYMMDD)
+	d, ok := days[date]
+	return Cell{Date: date, Day: d, Written: ok && inRange, InRange: inRange}
+}
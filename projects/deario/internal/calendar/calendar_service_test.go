@@ -0,0 +1,46 @@
+package calendar
+
+import "testing"
+
+func TestMonthWeeks(t *testing.T) {
+	days := Index([]Day{{Date: "20250201", Mood: "2"}, {Date: "20250131", Mood: "1"}})
+	weeks := MonthWeeks("202502", days)
+
+	// 2025년 2월 1일은 토요일이고 28일은 금요일이므로 5주가 된다.
+	if len(weeks) != 5 {
+		t.Fatalf("len(weeks) = %d, want 5", len(weeks))
+	}
+	first := weeks[0]
+	if first[0].Date != "20250126" || first[0].InRange {
+		t.Errorf("first cell = %+v, want out-of-range 20250126", first[0])
+	}
+	if first[5].Written {
+		t.Errorf("20250131 belongs to the previous month but was marked written")
+	}
+	if !first[6].Written || first[6].Day.Mood != "2" || first[6].DayOfMonth() != 1 {
+		t.Errorf("20250201 cell = %+v", first[6])
+	}
+}
+
+func TestWeekRange(t *testing.T) {
+	start, end, err := WeekRange("20250101")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != "20241229" || end != "20250104" {
+		t.Errorf("WeekRange() = %s~%s, want 20241229~20250104", start, end)
+	}
+}
+
+func TestMonthRange(t *testing.T) {
+	start, end, err := MonthRange("202402")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != "20240201" || end != "20240229" {
+		t.Errorf("MonthRange() = %s~%s, want 20240201~20240229", start, end)
+	}
+	if _, _, err := MonthRange("2024-02"); err == nil {
+		t.Error("MonthRange() with invalid month should fail")
+	}
+}
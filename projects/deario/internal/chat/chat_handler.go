@@ -0,0 +1,274 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	aiclient "simple-server/internal/ai"
+	"simple-server/internal/validate"
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/embedding"
+	"simple-server/projects/deario/internal/safety"
+	"simple-server/projects/deario/views/components"
+	"simple-server/projects/deario/views/pages"
+
+	"github.com/labstack/echo/v4"
+)
+
+type questionDTO struct {
+	Session  string `form:"session"`
+	Question string `form:"question" validate:"required,max=500" message:"질문은 1~500자로 입력해주세요."`
+}
+
+// ChatPage는 일기요정과 대화하는 페이지를 렌더링한다. 대화 목록과 내용은 페이지에서 따로 불러온다.
+func ChatPage(c echo.Context) error {
+	if _, err := authutil.SessionUID(c); err != nil {
+		return err
+	}
+	return pages.Chat(c.QueryParam("session")).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// ChatSessions는 최근 대화 목록을 렌더링한다.
+func ChatSessions(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := queries.ListChatSessions(c.Request().Context(), db.ListChatSessionsParams{
+		Uid:   uid,
+		Limit: SessionListLimit,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "대화 목록을 가져오지 못했습니다.")
+	}
+
+	current := c.QueryParam("session")
+	items := make([]components.ChatSessionItem, 0, len(sessions))
+	for _, s := range sessions {
+		items = append(items, components.ChatSessionItem{ID: s.ID, Title: s.Title, Current: s.ID == current})
+	}
+	return components.ChatSessions(items).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// ChatThread는 대화 하나의 메시지를 렌더링한다. session이 없으면 빈 새 대화를 보여준다.
+func ChatThread(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	sessionID := c.QueryParam("session")
+	if sessionID == "" {
+		return renderThread(c, "", nil, nil)
+	}
+
+	ctx := c.Request().Context()
+	if err := checkSession(ctx, queries, uid, sessionID); err != nil {
+		return err
+	}
+	messages, err := queries.ListChatMessages(ctx, db.ListChatMessagesParams{SessionID: sessionID, Uid: uid})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "대화를 가져오지 못했습니다.")
+	}
+	return renderThread(c, sessionID, messages, nil)
+}
+
+// SendMessage는 질문과 뜻이 비슷한 지난 일기를 의미 검색으로 찾아 일기요정의 답을 만들고 대화에 저장한다.
+// session이 없으면 첫 질문을 제목으로 새 대화를 만든다.
+func SendMessage(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto questionDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	var history []db.ChatMessage
+	if dto.Session != "" {
+		if err := checkSession(ctx, queries, uid, dto.Session); err != nil {
+			return err
+		}
+		history, err = queries.ListChatMessages(ctx, db.ListChatMessagesParams{SessionID: dto.Session, Uid: uid})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "대화를 가져오지 못했습니다.")
+		}
+		if len(history)+2 > MaxMessagesPerSession {
+			return echo.NewHTTPError(http.StatusConflict, "대화가 너무 길어졌어요. 새 대화에서 이어서 물어봐주세요.")
+		}
+	}
+
+	query := SearchQuery(dto.Question, PreviousQuestions(history, retrievalTurns))
+	matches, err := embedding.Search(ctx, queries, aiclient.DefaultEmbedder(), uid, query, sourceLimit)
+	if err != nil {
+		slog.Error("일기요정 일기 검색 실패", "uid", uid, "error", err)
+		return aiclient.HTTPError(err)
+	}
+	recent, err := queries.ListRecentChatDiaries(ctx, db.ListRecentChatDiariesParams{Uid: uid, Limit: recentLimit})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기를 가져오지 못했습니다.")
+	}
+	sources := Retrieve(matches, recent)
+
+	level := safety.Classify(ctx, dto.Question)
+	safety.Record(ctx, queries, safety.SourceChat, level, uid)
+	var helplines []safety.Helpline
+	if level == safety.LevelCrisis {
+		helplines = safety.UserHelplines(ctx, queries, uid)
+	}
+
+	answer, err := aiclient.Request(ctx, BuildPrompt(history, dto.Question, sources)+safety.PromptGuidance(level, helplines))
+	if err != nil {
+		slog.Error("일기요정 대화 답변 실패", "uid", uid, "error", err)
+		return aiclient.HTTPError(err)
+	}
+
+	sessionID, question, reply, err := saveExchange(ctx, uid, dto.Session, dto.Question, answer, JoinCitations(Citations(answer, sources)))
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("HX-Push-Url", "/chat?session="+sessionID)
+	c.Response().Header().Set("HX-Trigger", "deario-chat-updated")
+	return renderThread(c, sessionID, append(history, question, reply), safety.HelplineItems(helplines))
+}
+
+// saveExchange는 질문과 답변을 한 트랜잭션으로 저장한다. sessionID가 비었으면 첫 질문을 제목으로 새 대화를 만든다.
+// 중간에 실패하면 답 없는 질문이나 메시지 없는 대화가 남지 않는다.
+func saveExchange(ctx context.Context, uid, sessionID, question, answer, citations string) (string, db.ChatMessage, db.ChatMessage, error) {
+	var q, a db.ChatMessage
+
+	conn, err := db.GetDB()
+	if err != nil {
+		return "", q, a, err
+	}
+	queries, err := db.GetQueries()
+	if err != nil {
+		return "", q, a, err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return "", q, a, echo.NewHTTPError(http.StatusInternalServerError, "대화를 저장하지 못했습니다.")
+	}
+	defer func() { _ = tx.Rollback() }()
+	queries = queries.WithTx(tx)
+
+	if sessionID == "" {
+		session, err := queries.CreateChatSession(ctx, db.CreateChatSessionParams{Uid: uid, Title: Title(question)})
+		if err != nil {
+			return "", q, a, echo.NewHTTPError(http.StatusInternalServerError, "대화를 만들지 못했습니다.")
+		}
+		sessionID = session.ID
+	}
+
+	q, err = queries.CreateChatMessage(ctx, db.CreateChatMessageParams{
+		SessionID: sessionID,
+		Uid:       uid,
+		Role:      RoleUser,
+		Content:   question,
+	})
+	if err != nil {
+		return "", q, a, echo.NewHTTPError(http.StatusInternalServerError, "질문을 저장하지 못했습니다.")
+	}
+	a, err = queries.CreateChatMessage(ctx, db.CreateChatMessageParams{
+		SessionID: sessionID,
+		Uid:       uid,
+		Role:      RoleModel,
+		Content:   answer,
+		Citations: citations,
+	})
+	if err != nil {
+		return "", q, a, echo.NewHTTPError(http.StatusInternalServerError, "답변을 저장하지 못했습니다.")
+	}
+	if err := queries.TouchChatSession(ctx, db.TouchChatSessionParams{ID: sessionID, Uid: uid}); err != nil {
+		return "", q, a, echo.NewHTTPError(http.StatusInternalServerError, "대화를 저장하지 못했습니다.")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", q, a, echo.NewHTTPError(http.StatusInternalServerError, "대화를 저장하지 못했습니다.")
+	}
+	return sessionID, q, a, nil
+}
+
+// DeleteSession은 대화를 삭제하고 새 대화 화면으로 보낸다. 메시지는 외래 키로 함께 지워진다.
+func DeleteSession(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	count, err := queries.DeleteChatSession(c.Request().Context(), db.DeleteChatSessionParams{ID: c.Param("id"), Uid: uid})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "대화 삭제에 실패했습니다.")
+	}
+	if count == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "대화를 찾을 수 없습니다.")
+	}
+
+	c.Response().Header().Set("HX-Redirect", "/chat")
+	return c.NoContent(http.StatusOK)
+}
+
+// checkSession은 대화가 사용자의 것인지 확인한다.
+func checkSession(ctx context.Context, queries *db.Queries, uid, id string) error {
+	_, err := queries.GetChatSession(ctx, db.GetChatSessionParams{ID: id, Uid: uid})
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "대화를 찾을 수 없습니다.")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "대화를 가져오지 못했습니다.")
+	}
+	return nil
+}
+
+func renderThread(c echo.Context, sessionID string, messages []db.ChatMessage, helplines []components.SafetyHelpline) error {
+	thread := components.ChatThreadView{
+		SessionID: sessionID,
+		Helplines: helplines,
+		CanAsk:    len(messages)+2 <= MaxMessagesPerSession,
+	}
+	for _, m := range messages {
+		item := components.ChatMessageItem{FromUser: m.Role == RoleUser}
+		if item.FromUser {
+			item.Segments = []components.ChatSegment{{Text: m.Content}}
+		} else {
+			for _, s := range Segments(m.Content, SplitCitations(m.Citations)) {
+				item.Segments = append(item.Segments, components.ChatSegment(s))
+			}
+		}
+		thread.Messages = append(thread.Messages, item)
+	}
+	return components.ChatThread(thread).Render(c.Request().Context(), c.Response().Writer)
+}
@@ -0,0 +1,202 @@
+package chat
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/embedding"
+)
+
+const (
+	// MaxMessagesPerSession은 대화 하나에 쌓을 수 있는 메시지 수다. 질문과 답을 각각 센다.
+	MaxMessagesPerSession = 100
+	// SessionListLimit은 채팅 화면에 보여줄 최근 대화 수다.
+	SessionListLimit = 20
+
+	RoleUser  = "user"
+	RoleModel = "model"
+
+	// sourceLimit은 질문과 비슷해서 프롬프트에 넣을 일기 수다.
+	sourceLimit = 8
+	// recentLimit은 질문과 상관없이 함께 넣는 최근 일기 수다. "요즘", "최근" 같은 질문에 쓰인다.
+	recentLimit = 3
+	// historyLimit은 프롬프트에 넣을 이전 대화 메시지 수다.
+	historyLimit = 10
+	// retrievalTurns는 검색어에 함께 쓰는 이전 질문 수다.
+	retrievalTurns = 2
+	// sourceRuneLimit은 프롬프트에 넣을 일기 하나의 최대 글자 수다.
+	sourceRuneLimit = 800
+	titleRuneLimit  = 40
+)
+
+// citationPattern은 답변 속 [YYYYMMDD] 형식의 일기 인용이다.
+var citationPattern = regexp.MustCompile(`\[(\d{8})\]`)
+
+// Source는 답변의 근거로 프롬프트에 넣는 일기다.
+type Source struct {
+	Date    string
+	Content string
+	Mood    string
+}
+
+// SearchQuery는 일기 검색에 쓸 글을 만든다. 이전 질문을 앞에 붙여 "그때", "이런 기분"처럼 앞 질문을 가리키는 말을 이어준다.
+func SearchQuery(question string, previous []string) string {
+	return strings.Join(append(previous, question), "\n")
+}
+
+// Retrieve는 의미 검색으로 고른 일기에 최근 일기를 덧붙여 날짜 내림차순으로 반환한다.
+// matches는 유사도 순이어야 하며 앞에서부터 sourceLimit개까지 쓴다.
+func Retrieve(matches []embedding.Match, recent []db.ListRecentChatDiariesRow) []Source {
+	seen := make(map[string]bool)
+	var sources []Source
+	add := func(s Source) {
+		if seen[s.Date] {
+			return
+		}
+		seen[s.Date] = true
+		sources = append(sources, s)
+	}
+	for i := 0; i < len(matches) && i < sourceLimit; i++ {
+		add(Source{Date: matches[i].Date, Content: matches[i].Content, Mood: matches[i].Mood})
+	}
+	for i := 0; i < len(recent) && i < recentLimit; i++ {
+		add(Source{Date: recent[i].Date, Content: recent[i].Content, Mood: recent[i].Mood})
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool { return sources[i].Date > sources[j].Date })
+	return sources
+}
+
+// PreviousQuestions는 대화 기록에서 마지막 n개의 사용자 질문을 반환한다.
+func PreviousQuestions(history []db.ChatMessage, n int) []string {
+	var out []string
+	for i := len(history) - 1; i >= 0 && len(out) < n; i-- {
+		if history[i].Role == RoleUser {
+			out = append([]string{history[i].Content}, out...)
+		}
+	}
+	return out
+}
+
+// BuildPrompt는 이전 대화와 고른 일기로 일기요정의 답변 프롬프트를 만든다.
+func BuildPrompt(history []db.ChatMessage, question string, sources []Source) string {
+	var entries strings.Builder
+	for _, s := range sources {
+		fmt.Fprintf(&entries, "[%s] %s, 기분: %s\n%s\n---\n", s.Date, dateutil.MustFormatDateKorWithWeekDay(s.Date), s.Mood, truncate(s.Content, sourceRuneLimit))
+	}
+	if entries.Len() == 0 {
+		entries.WriteString("(참고할 일기가 없습니다)\n")
+	}
+
+	if len(history) > historyLimit {
+		history = history[len(history)-historyLimit:]
+	}
+	var turns strings.Builder
+	for _, m := range history {
+		speaker := "사용자"
+		if m.Role == RoleModel {
+			speaker = "일기요정"
+		}
+		fmt.Fprintf(&turns, "%s: %s\n", speaker, m.Content)
+	}
+	if turns.Len() == 0 {
+		turns.WriteString("(첫 질문입니다)\n")
+	}
+
+	return fmt.Sprintf(`너는 사용자의 지난 일기를 함께 읽어주는 "일기요정"이야.
+아래 일기만 근거로 사용자의 질문에 답해줘. 일기에 없는 내용은 지어내지 말고, 찾을 수 없으면 찾지 못했다고 솔직하게 말해줘.
+기분은 1(아주 좋음)부터 5(아주 나쁨)까지의 값이며 0은 기록하지 않은 날이야.
+
+규칙:
+- 근거로 삼은 일기는 문장 끝에 [YYYYMMDD] 형식으로 날짜를 표시해줘. 아래 일기에 있는 날짜만 쓸 수 있어.
+- 진단하거나 평가하지 말고 친구처럼 담백한 반말로 답해줘.
+- 마크다운 없이 3~5문장의 짧은 글로 답해줘.
+
+일기:
+---
+%s
+이전 대화:
+%s
+질문: %s`, entries.String(), turns.String(), question)
+}
+
+// Citations는 답변에서 인용한 날짜 중 실제로 프롬프트에 넣은 일기의 날짜만 처음 나온 순서대로 반환한다.
+func Citations(answer string, sources []Source) []string {
+	allowed := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		allowed[s.Date] = true
+	}
+
+	var out []string
+	seen := make(map[string]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		date := m[1]
+		if allowed[date] && !seen[date] {
+			seen[date] = true
+			out = append(out, date)
+		}
+	}
+	return out
+}
+
+// JoinCitations는 인용 날짜를 chat_message.citations에 저장할 문자열로 바꾼다.
+func JoinCitations(dates []string) string {
+	return strings.Join(dates, ",")
+}
+
+// SplitCitations는 chat_message.citations에 저장한 인용 날짜를 되돌린다.
+func SplitCitations(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// Segment는 답변을 글과 인용으로 나눈 조각이다. Date가 있으면 인용이다.
+type Segment struct {
+	Text string
+	Date string
+}
+
+// Segments는 답변을 글 조각과 인용 조각으로 나눈다. citations에 없는 [YYYYMMDD]는 글로 남긴다.
+func Segments(content string, citations []string) []Segment {
+	allowed := make(map[string]bool, len(citations))
+	for _, date := range citations {
+		allowed[date] = true
+	}
+
+	var out []Segment
+	last := 0
+	for _, loc := range citationPattern.FindAllStringSubmatchIndex(content, -1) {
+		date := content[loc[2]:loc[3]]
+		if !allowed[date] {
+			continue
+		}
+		if loc[0] > last {
+			out = append(out, Segment{Text: content[last:loc[0]]})
+		}
+		out = append(out, Segment{Date: date})
+		last = loc[1]
+	}
+	if last < len(content) {
+		out = append(out, Segment{Text: content[last:]})
+	}
+	return out
+}
+
+// Title은 첫 질문으로 대화 제목을 만든다.
+func Title(question string) string {
+	return truncate(strings.Join(strings.Fields(question), " "), titleRuneLimit)
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
@@ -0,0 +1,109 @@
+package chat
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/embedding"
+)
+
+func TestRetrieve(t *testing.T) {
+	matches := []embedding.Match{
+		{Date: "20260905", Content: "회사 일로 너무 지쳤다. 번아웃이 온 것 같다", Mood: "5", Score: 0.8},
+		{Date: "20261017", Content: "점심을 먹고 지쳐서 쉬었다", Mood: "3", Score: 0.5},
+		{Date: "20260310", Content: "발표 때문에 지쳤다", Mood: "4", Score: 0.4},
+	}
+	recent := []db.ListRecentChatDiariesRow{
+		{Date: "20261018", Content: "오늘은 평범한 하루", Mood: "3"},
+		{Date: "20261017", Content: "점심을 먹고 지쳐서 쉬었다", Mood: "3"},
+		{Date: "20261016", Content: "산책을 했다", Mood: "2"},
+	}
+
+	// 검색 결과와 최근 일기가 겹치면 한 번만 넣고 날짜 내림차순으로 정렬한다.
+	got := dates(Retrieve(matches, recent))
+	want := []string{"20261018", "20261017", "20261016", "20260905", "20260310"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Retrieve() = %v, want %v", got, want)
+	}
+
+	if got := Retrieve(nil, nil); len(got) != 0 {
+		t.Errorf("Retrieve() without diaries = %v", got)
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	if got := SearchQuery("그때 무슨 일이 있었어?", []string{"번아웃"}); got != "번아웃\n그때 무슨 일이 있었어?" {
+		t.Errorf("SearchQuery() = %q", got)
+	}
+}
+
+func TestPreviousQuestions(t *testing.T) {
+	history := []db.ChatMessage{
+		{Role: RoleUser, Content: "a"},
+		{Role: RoleModel, Content: "b"},
+		{Role: RoleUser, Content: "c"},
+		{Role: RoleModel, Content: "d"},
+		{Role: RoleUser, Content: "e"},
+		{Role: RoleModel, Content: "f"},
+	}
+	if got := PreviousQuestions(history, 2); !reflect.DeepEqual(got, []string{"c", "e"}) {
+		t.Errorf("PreviousQuestions() = %v", got)
+	}
+}
+
+func TestBuildPrompt(t *testing.T) {
+	history := []db.ChatMessage{{Role: RoleUser, Content: "지난 질문"}, {Role: RoleModel, Content: "지난 답"}}
+	prompt := BuildPrompt(history, "새 질문", []Source{{Date: "20261018", Content: "일기 내용", Mood: "2"}})
+	for _, want := range []string{"[20261018]", "일기 내용", "사용자: 지난 질문", "일기요정: 지난 답", "질문: 새 질문"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("BuildPrompt() missing %q", want)
+		}
+	}
+}
+
+func TestCitations(t *testing.T) {
+	sources := []Source{{Date: "20261018"}, {Date: "20260905"}}
+	answer := "9월에 지쳤다고 했어 [20260905]. 어제도 [20261018] 그랬고 [20260905], 없는 날짜 [20200101]도 있어."
+
+	got := Citations(answer, sources)
+	if !reflect.DeepEqual(got, []string{"20260905", "20261018"}) {
+		t.Errorf("Citations() = %v", got)
+	}
+	if joined := JoinCitations(got); !reflect.DeepEqual(SplitCitations(joined), got) {
+		t.Errorf("SplitCitations(JoinCitations()) = %v", SplitCitations(joined))
+	}
+	if SplitCitations("") != nil {
+		t.Error("SplitCitations(\"\") should be nil")
+	}
+}
+
+func TestSegments(t *testing.T) {
+	got := Segments("그날 [20260905] 힘들었어. [20200101]", []string{"20260905"})
+	want := []Segment{
+		{Text: "그날 "},
+		{Date: "20260905"},
+		{Text: " 힘들었어. [20200101]"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Segments() = %#v, want %#v", got, want)
+	}
+}
+
+func TestTitle(t *testing.T) {
+	if got := Title("  요즘   어때? "); got != "요즘 어때?" {
+		t.Errorf("Title() = %q", got)
+	}
+	if got := Title(strings.Repeat("가", 50)); got != strings.Repeat("가", titleRuneLimit)+"..." {
+		t.Errorf("Title() = %q", got)
+	}
+}
+
+func dates(sources []Source) []string {
+	var out []string
+	for _, s := range sources {
+		out = append(out, s.Date)
+	}
+	return out
+}
@@ -2,7 +2,9 @@ package deariodate
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"simple-server/pkg/util/dateutil"
 
@@ -28,3 +30,47 @@ func NormalizeRequired(value string) (string, error) {
 	}
 	return normalized, nil
 }
+
+const defaultTimezone = "Asia/Seoul"
+
+// Location은 사용자 시간대를 불러오고, 실패하면 서울 시간대를 사용한다.
+func Location(timezone string) *time.Location {
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc, err = time.LoadLocation(defaultTimezone)
+		if err != nil {
+			return time.FixedZone("KST", 9*60*60)
+		}
+	}
+	return loc
+}
+
+// TodayIn은 사용자 시간대 기준 오늘 날짜를 YYYYMMDD 형식으로 반환한다.
+func TodayIn(timezone string) string {
+	return time.Now().In(Location(timezone)).Format(dateutil.DateFormatYYYYMMDD)
+}
+
+// MonthDayKeys는 YYYYMMDD 날짜의 MMDD 조회 키를 반환한다.
+// 윤년이 아닌 해의 2월 28일에는 2월 29일 기록도 함께 볼 수 있도록 보조 키로 0229를 반환한다.
+func MonthDayKeys(date string) (string, string) {
+	monthDay := date[4:]
+	if monthDay == "0228" {
+		if year, err := strconv.Atoi(date[:4]); err == nil && !dateutil.IsLeapYear(year) {
+			return monthDay, "0229"
+		}
+	}
+	return monthDay, monthDay
+}
+
+// LocalTime은 DB에 UTC로 저장된 시각을 사용자 시간대의 "2006-01-02 15:04"로 바꾼다.
+// 값이 비어 있거나 형식이 다르면 빈 문자열을 반환한다.
+func LocalTime(v string, loc *time.Location) string {
+	t, err := time.ParseInLocation(dateutil.DateFormatISOTime, v, time.UTC)
+	if err != nil {
+		return ""
+	}
+	return t.In(loc).Format("2006-01-02 15:04")
+}
@@ -0,0 +1,360 @@
+package diary
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"simple-server/internal/validate"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/apitoken"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/internal/stats"
+	"simple-server/projects/deario/internal/tags"
+	"simple-server/projects/deario/internal/webhook"
+
+	"github.com/labstack/echo/v4"
+)
+
+type apiDiary struct {
+	Date       string   `json:"date"`
+	Content    string   `json:"content"`
+	Mood       string   `json:"mood"`
+	Version    int64    `json:"version"`
+	AiFeedback string   `json:"aiFeedback"`
+	Images     []string `json:"images"`
+	Created    string   `json:"created"`
+	Updated    string   `json:"updated"`
+}
+
+type apiDiaryListItem struct {
+	Date    string `json:"date"`
+	Preview string `json:"preview"`
+	Mood    string `json:"mood"`
+	Version int64  `json:"version"`
+}
+
+type apiDiaryList struct {
+	Items      []apiDiaryListItem `json:"items"`
+	NextCursor string             `json:"nextCursor"`
+}
+
+type apiSearchItem struct {
+	Date    string `json:"date"`
+	Snippet string `json:"snippet"`
+}
+
+type apiSearchResult struct {
+	Items []apiSearchItem `json:"items"`
+}
+
+type apiConflict struct {
+	Message string `json:"message"`
+	Version int64  `json:"version"`
+	Content string `json:"content"`
+}
+
+type apiPutDiaryDTO struct {
+	Content string `json:"content"`
+	// Version이 있으면 해당 버전일 때만 수정하고, 0이면 새로 작성할 때만 저장한다.
+	Version *int64 `json:"version"`
+}
+
+type apiMoodDTO struct {
+	Mood string `json:"mood" validate:"required,oneof=0 1 2 3 4 5" message:"기분 값이 올바르지 않습니다."`
+}
+
+// APIListDiaries는 최신순 일기 목록을 커서 기반으로 반환한다.
+func APIListDiaries(c echo.Context) error {
+	uid, err := apitoken.UID(c)
+	if err != nil {
+		return err
+	}
+
+	size, cursor, err := parseListPage(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	tag, _ := tags.Normalize(c.QueryParam("tag"))
+	diarys, next, err := loadDiaryPage(c.Request().Context(), queries, uid, tag, cursor, size)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "목록을 가져오지 못했습니다.")
+	}
+
+	res := apiDiaryList{Items: make([]apiDiaryListItem, 0, len(diarys)), NextCursor: next}
+	for _, d := range diarys {
+		res.Items = append(res.Items, apiDiaryListItem{
+			Date:    d.Date,
+			Preview: contentPreview(d.Content),
+			Mood:    d.Mood,
+			Version: d.Version,
+		})
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// APIGetDiary는 날짜의 일기를 반환한다.
+func APIGetDiary(c echo.Context) error {
+	uid, err := apitoken.UID(c)
+	if err != nil {
+		return err
+	}
+
+	date, err := deariodate.NormalizeRequired(c.Param("date"))
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	diary, err := queries.GetDiary(c.Request().Context(), db.GetDiaryParams{Uid: uid, Date: date})
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "일기가 없습니다.")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기 조회에 실패했습니다.")
+	}
+
+	return c.JSON(http.StatusOK, toAPIDiary(diary))
+}
+
+// APIPutDiary는 날짜의 일기 본문을 저장한다.
+// version이 저장된 버전과 다르면 409와 함께 서버에 남은 내용을 돌려준다.
+func APIPutDiary(c echo.Context) error {
+	uid, err := apitoken.UID(c)
+	if err != nil {
+		return err
+	}
+
+	date, err := deariodate.NormalizeRequired(c.Param("date"))
+	if err != nil {
+		return err
+	}
+
+	var dto apiPutDiaryDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	content := strings.TrimSpace(dto.Content)
+	if content == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "내용이 비어 있습니다. 일기를 지우려면 DELETE를 사용해주세요.")
+	}
+	if dto.Version != nil && *dto.Version < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "버전이 올바르지 않습니다.")
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	saved, err := saveDiaryContent(ctx, queries, uid, date, content, dto.Version)
+	if errors.Is(err, errDiaryConflict) {
+		return apiDiaryConflict(c, queries, uid, date)
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기 저장에 실패했습니다.")
+	}
+	afterDiaryContentSaved(ctx, queries, uid, saved)
+	if err := queries.DeleteDiaryDraft(ctx, db.DeleteDiaryDraftParams{Uid: uid, Date: date}); err != nil {
+		slog.Error("초안 삭제 실패", "uid", uid, "error", err)
+	}
+
+	return c.JSON(http.StatusOK, toAPIDiary(saved))
+}
+
+// APIDeleteDiary는 일기 본문을 지운다. 이미지나 일기요정 답변이 있으면 본문만 비운다.
+func APIDeleteDiary(c echo.Context) error {
+	uid, err := apitoken.UID(c)
+	if err != nil {
+		return err
+	}
+
+	date, err := deariodate.NormalizeRequired(c.Param("date"))
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	diary, err := queries.GetDiary(ctx, db.GetDiaryParams{Uid: uid, Date: date})
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "일기가 없습니다.")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기 조회에 실패했습니다.")
+	}
+	if v := c.QueryParam("version"); v != "" {
+		version, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "버전이 올바르지 않습니다.")
+		}
+		if version != diary.Version {
+			return apiDiaryConflict(c, queries, uid, date)
+		}
+	}
+
+	_, err = clearDiaryContent(ctx, queries, diary)
+	if errors.Is(err, errDiaryConflict) {
+		return apiDiaryConflict(c, queries, uid, date)
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기 삭제에 실패했습니다.")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// APIUpdateDiaryMood는 일기의 기분을 바꾼다.
+func APIUpdateDiaryMood(c echo.Context) error {
+	uid, err := apitoken.UID(c)
+	if err != nil {
+		return err
+	}
+
+	date, err := deariodate.NormalizeRequired(c.Param("date"))
+	if err != nil {
+		return err
+	}
+
+	var dto apiMoodDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	diary, err := queries.GetDiary(ctx, db.GetDiaryParams{Uid: uid, Date: date})
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "일기를 먼저 작성해주세요.")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기 조회에 실패했습니다.")
+	}
+
+	if err := queries.UpdateDiaryOfMood(ctx, db.UpdateDiaryOfMoodParams{ID: diary.ID, Mood: dto.Mood}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "기분 저장에 실패했습니다.")
+	}
+	diary.Mood = dto.Mood
+	webhook.Emit(ctx, queries, uid, webhook.EventMoodUpdated, webhook.MoodData{Date: diary.Date, Mood: diary.Mood})
+
+	return c.JSON(http.StatusOK, toAPIDiary(diary))
+}
+
+// APISearchDiaries는 키워드나 태그로 일기를 검색한다.
+func APISearchDiaries(c echo.Context) error {
+	uid, err := apitoken.UID(c)
+	if err != nil {
+		return err
+	}
+
+	q, tag := parseSearchQuery(c.QueryParam("q"), c.QueryParam("tag"))
+	if q == "" && tag == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "검색어나 태그를 입력해주세요.")
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	diarys, err := searchDiaryRows(c.Request().Context(), queries, uid, q, tag)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "검색에 실패했습니다.")
+	}
+
+	keyword := searchKeyword(q, tag)
+	res := apiSearchResult{Items: make([]apiSearchItem, 0, len(diarys))}
+	for _, d := range diarys {
+		snippet := snippetNodes(d.Content, keyword)
+		res.Items = append(res.Items, apiSearchItem{
+			Date:    d.Date,
+			Snippet: snippet.Prefix + snippet.Match + snippet.Suffix,
+		})
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// APIGetStats는 범위별 통계를 반환한다. 응답 형식은 통계 화면의 데이터와 같다.
+func APIGetStats(c echo.Context) error {
+	uid, err := apitoken.UID(c)
+	if err != nil {
+		return err
+	}
+
+	r, ok := stats.ParseRange(c.QueryParam("range"))
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "조회 범위가 올바르지 않습니다.")
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	data, err := loadStats(c.Request().Context(), queries, uid, r)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "통계를 가져오지 못했습니다.")
+	}
+
+	return c.JSON(http.StatusOK, data)
+}
+
+// apiDiaryConflict는 409와 함께 서버에 저장된 최신 내용을 돌려준다.
+func apiDiaryConflict(c echo.Context, queries *db.Queries, uid, date string) error {
+	saved, err := queries.GetDiary(c.Request().Context(), db.GetDiaryParams{Uid: uid, Date: date})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기 조회에 실패했습니다.")
+	}
+
+	return c.JSON(http.StatusConflict, apiConflict{
+		Message: errDiaryConflict.Error(),
+		Version: saved.Version,
+		Content: saved.Content,
+	})
+}
+
+func toAPIDiary(d db.Diary) apiDiary {
+	images := make([]string, 0, 3)
+	for _, url := range []string{d.ImageUrl1, d.ImageUrl2, d.ImageUrl3} {
+		if url != "" {
+			images = append(images, url)
+		}
+	}
+	return apiDiary{
+		Date:       d.Date,
+		Content:    d.Content,
+		Mood:       d.Mood,
+		Version:    d.Version,
+		AiFeedback: d.AiFeedback,
+		Images:     images,
+		Created:    formatUTCTime(d.Created.String),
+		Updated:    formatUTCTime(d.Updated.String),
+	}
+}
@@ -0,0 +1,149 @@
+package diary
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"simple-server/pkg/util/authutil"
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/calendar"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+type monthDaysResponse struct {
+	Month string         `json:"month"`
+	Days  []calendar.Day `json:"days"`
+}
+
+// MonthlyDiaryDays는 특정 월의 날짜별 기분, 이미지/일기요정 여부, 단어 수를 반환한다.
+func MonthlyDiaryDays(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	month := c.QueryParam("month")
+	if month == "" {
+		setting, err := queries.GetUserSetting(c.Request().Context(), uid)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		month = deariodate.TodayIn(setting.Timezone)[:6]
+	}
+
+	start, end, err := calendar.MonthRange(month)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "월 형식이 올바르지 않습니다.")
+	}
+
+	days, err := calendar.LoadDays(c.Request().Context(), queries, uid, start, end)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "목록을 가져오지 못했습니다.")
+	}
+
+	return c.JSON(http.StatusOK, monthDaysResponse{Month: month, Days: days})
+}
+
+// CalendarView는 월/주/연 단위 달력을 렌더링한다. 로그인하지 않았으면 빈 달력을 보여준다.
+func CalendarView(c echo.Context) error {
+	view := c.QueryParam("view")
+	switch view {
+	case "":
+		view = "month"
+	case "month", "week", "year":
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "달력 보기 형식이 올바르지 않습니다.")
+	}
+
+	uid, _ := authutil.SessionUID(c)
+
+	var queries *db.Queries
+	today := dateutil.Today()
+	if uid != "" {
+		var err error
+		queries, err = db.GetQueries()
+		if err != nil {
+			return err
+		}
+		setting, err := queries.GetUserSetting(c.Request().Context(), uid)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		today = deariodate.TodayIn(setting.Timezone)
+	}
+
+	date := today
+	if v := c.QueryParam("date"); v != "" {
+		var err error
+		if date, err = deariodate.NormalizeRequired(v); err != nil {
+			return err
+		}
+	}
+
+	cal := components.Calendar{View: view, Date: date, Today: today}
+	var start, end string
+	switch view {
+	case "week":
+		start, end, _ = calendar.WeekRange(date)
+		cal.Title = fmt.Sprintf("%s ~ %s", dateutil.MustFormatDateKorSimpleWithWeekDay(start), dateutil.MustFormatDateKorSimpleWithWeekDay(end))
+		cal.Prev = dateutil.MustAddDaysToDate(date, -7)
+		cal.Next = dateutil.MustAddDaysToDate(date, 7)
+	case "year":
+		start, end = date[:4]+"0101", date[:4]+"1231"
+		cal.Title = date[:4] + "년"
+		cal.Prev, _ = dateutil.AddYearsToDate(date[:4]+"0101", -1)
+		cal.Next, _ = dateutil.AddYearsToDate(date[:4]+"0101", 1)
+	default:
+		start, end, _ = calendar.MonthRange(date[:6])
+		cal.Title, _ = dateutil.FormatDate(start, "2006년 1월")
+		cal.Prev, _ = dateutil.AddMonthsToDate(start, -1)
+		cal.Next, _ = dateutil.AddMonthsToDate(start, 1)
+	}
+
+	var days []calendar.Day
+	if queries != nil {
+		var err error
+		days, err = calendar.LoadDays(c.Request().Context(), queries, uid, start, end)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "달력을 가져오지 못했습니다.")
+		}
+	}
+	index := calendar.Index(days)
+
+	switch view {
+	case "week":
+		cal.Week = calendar.WeekCells(date, index)
+	case "year":
+		for m := 1; m <= 12; m++ {
+			month := fmt.Sprintf("%s%02d", date[:4], m)
+			count := 0
+			for _, d := range days {
+				if strings.HasPrefix(d.Date, month) {
+					count++
+				}
+			}
+			cal.Months = append(cal.Months, components.CalendarMonth{
+				Month: month,
+				Label: fmt.Sprintf("%d월", m),
+				Count: count,
+				Weeks: calendar.MonthWeeks(month, index),
+			})
+		}
+	default:
+		cal.Weeks = calendar.MonthWeeks(date[:6], index)
+	}
+
+	return components.CalendarView(cal).Render(c.Request().Context(), c.Response().Writer)
+}
@@ -3,8 +3,11 @@ package diary
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,6 +15,9 @@ import (
 	"simple-server/pkg/util/authutil"
 	"simple-server/projects/deario/db"
 	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/internal/diarytemplate"
+	"simple-server/projects/deario/internal/safety"
+	"simple-server/projects/deario/internal/tags"
 	"simple-server/projects/deario/views/components"
 	"simple-server/projects/deario/views/pages"
 
@@ -73,56 +79,41 @@ func GetDiary(c echo.Context) error {
 		Uid:  uid,
 		Date: date,
 	})
-
-	if err != nil {
-		return components.DiaryContentForm(date, "").Render(c.Request().Context(), c.Response().Writer)
-	}
-	return components.DiaryContentForm(diary.Date, diary.Content).Render(c.Request().Context(), c.Response().Writer)
-}
-
-// ListDiaries는 일기 날짜 목록을 반환한다.
-func ListDiaries(c echo.Context) error {
-	uid, err := authutil.SessionUID(c)
-	if err != nil {
-		return err
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기 조회에 실패했습니다.")
 	}
 
-	page := c.QueryParam("page")
-	if page == "" {
-		page = "1"
-	}
+	isNew := errors.Is(err, sql.ErrNoRows)
 
-	queries, err := db.GetQueries()
-	if err != nil {
-		return err
+	editor := components.DiaryEditor{
+		Date:      date,
+		Content:   diary.Content,
+		Version:   diary.Version,
+		Versioned: true,
+		Prompt:    diarytemplate.DailyPrompt(date),
 	}
-
-	diarys, err := queries.ListDiarys(c.Request().Context(), db.ListDiarysParams{
-		Uid:     uid,
-		Column2: page,
-	})
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "목록을 가져오지 못했습니다.")
+	draft, err := queries.GetDiaryDraft(c.Request().Context(), db.GetDiaryDraftParams{Uid: uid, Date: date})
+	if err == nil && draft.Content != diary.Content {
+		editor.HasDraft = true
 	}
-
-	items := make([]components.DiaryListItem, 0, len(diarys))
-	for _, diary := range diarys {
-		items = append(items, components.DiaryListItem{Date: diary.Date})
+	if isNew && !editor.HasDraft {
+		editor.Content = autoTemplateContent(c.Request().Context(), queries, uid, date)
 	}
 
-	return components.DiaryListItems(items).Render(c.Request().Context(), c.Response().Writer)
+	return components.DiaryContentForm(editor).Render(c.Request().Context(), c.Response().Writer)
 }
 
-// MonthlyDiaryDates는 특정 월에 작성한 일기 날짜 목록을 반환한다.
-func MonthlyDiaryDates(c echo.Context) error {
+// ListDiaries는 일기 날짜 순으로 목록을 반환한다. tag가 있으면 해당 태그의 일기만 반환한다.
+// cursor 이후부터 size개씩 가져오며, 다음 페이지가 있으면 무한 스크롤용 요청 주소를 함께 렌더링한다.
+func ListDiaries(c echo.Context) error {
 	uid, err := authutil.SessionUID(c)
 	if err != nil {
 		return err
 	}
 
-	month := c.QueryParam("month")
-	if month == "" {
-		month = time.Now().Format("200601")
+	size, cursor, err := parseListPage(c)
+	if err != nil {
+		return err
 	}
 
 	queries, err := db.GetQueries()
@@ -130,15 +121,34 @@ func MonthlyDiaryDates(c echo.Context) error {
 		return err
 	}
 
-	dates, err := queries.ListDiaryDatesByMonth(c.Request().Context(), db.ListDiaryDatesByMonthParams{
-		Uid:  uid,
-		Date: month,
-	})
+	tag, _ := tags.Normalize(c.QueryParam("tag"))
+	diarys, next, err := loadDiaryPage(c.Request().Context(), queries, uid, tag, cursor, size)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "목록을 가져오지 못했습니다.")
 	}
 
-	return c.JSON(http.StatusOK, dates)
+	var nextURL string
+	if next != "" {
+		params := url.Values{}
+		params.Set("cursor", next)
+		params.Set("size", strconv.Itoa(size))
+		if tag != "" {
+			params.Set("tag", tag)
+		}
+		nextURL = "/diary/list?" + params.Encode()
+	}
+
+	items := make([]components.DiaryListItem, 0, len(diarys))
+	for _, diary := range diarys {
+		items = append(items, components.DiaryListItem{
+			Date:      diary.Date,
+			Preview:   contentPreview(diary.Content),
+			Mood:      diary.Mood,
+			Thumbnail: firstNonEmpty(diary.ImageUrl1, diary.ImageUrl2, diary.ImageUrl3),
+		})
+	}
+
+	return components.DiaryListItems(items, nextURL).Render(c.Request().Context(), c.Response().Writer)
 }
 
 // RedirectToRandomDiary는 무작위 일기 날짜로 이동한다.
@@ -174,6 +184,8 @@ func RedirectToRandomDiary(c echo.Context) error {
 }
 
 // SaveDiary는 일기를 저장하거나 수정한다.
+// version이 함께 오면 편집을 시작한 버전과 저장된 버전이 같을 때만 저장하고,
+// 다르면 409와 함께 내용 합치기 화면을 내려준다.
 func SaveDiary(c echo.Context) error {
 	uid, err := authutil.SessionUID(c)
 	if err != nil {
@@ -183,6 +195,7 @@ func SaveDiary(c echo.Context) error {
 	type saveDiaryDTO struct {
 		Date    string `form:"date" validate:"required" message:"날짜가 필요합니다."`
 		Content string `form:"content"`
+		Version *int64 `form:"version"`
 	}
 	var dto saveDiaryDTO
 	if err := c.Bind(&dto); err != nil {
@@ -209,35 +222,37 @@ func SaveDiary(c echo.Context) error {
 			Date: date,
 		})
 		if errors.Is(err, sql.ErrNoRows) {
-			return c.NoContent(http.StatusNoContent)
+			return diarySaved(c, queries, uid, date, 0)
 		}
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "일기 조회에 실패했습니다.")
 		}
-		if hasDiaryLinkedData(diary) {
-			if _, err := queries.UpdateDiary(c.Request().Context(), db.UpdateDiaryParams{
-				Content: "",
-				ID:      diary.ID,
-			}); err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, "수정 실패")
-			}
-
-			return c.NoContent(http.StatusNoContent)
+		if dto.Version != nil && diary.Version != *dto.Version {
+			return renderDiaryConflict(c, queries, uid, date, content, *dto.Version)
 		}
-		if err := queries.DeleteDiary(c.Request().Context(), diary.ID); err != nil {
+		version, err := clearDiaryContent(c.Request().Context(), queries, diary)
+		if errors.Is(err, errDiaryConflict) {
+			return renderDiaryConflict(c, queries, uid, date, content, diary.Version)
+		}
+		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "수정 실패")
 		}
 
-		return c.NoContent(http.StatusNoContent)
+		return diarySaved(c, queries, uid, date, version)
 	}
 
-	if _, err := queries.UpsertDiaryContent(c.Request().Context(), db.UpsertDiaryContentParams{
-		Uid:     uid,
-		Content: content,
-		Date:    date,
-	}); err != nil {
+	saved, err := saveDiaryContent(c.Request().Context(), queries, uid, date, content, dto.Version)
+	if errors.Is(err, errDiaryConflict) {
+		return renderDiaryConflict(c, queries, uid, date, content, *dto.Version)
+	}
+	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "일기 저장에 실패했습니다. 다시 시도해주세요.")
 	}
 
-	return c.NoContent(http.StatusNoContent)
+	// 위기 표현이 보이면 저장은 그대로 하고 상담 연락처 안내만 띄운다.
+	if afterDiaryContentSaved(c.Request().Context(), queries, uid, saved) == safety.LevelCrisis {
+		c.Response().Header().Set("HX-Trigger", fmt.Sprintf(`{%q:{"date":%q}}`, safety.EventName, date))
+	}
+
+	return diarySaved(c, queries, uid, date, saved.Version)
 }
@@ -3,24 +3,38 @@ package diary
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"simple-server/internal/config"
 	"simple-server/internal/middleware"
+	"simple-server/pkg/util/dateutil"
 	"simple-server/pkg/util/firebaseutil"
+	"simple-server/pkg/util/stringutil"
 	"simple-server/projects/deario/db"
 	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/internal/diarytemplate"
 
 	"cloud.google.com/go/storage"
 	"github.com/labstack/echo/v4"
+	"google.golang.org/api/iterator"
 )
 
 const defaultDiaryImageBucket = "warm-braid-383411.firebasestorage.app"
 
+const contentPreviewLen = 60
+
+const (
+	defaultListPageSize = 10
+	maxListPageSize     = 50
+)
+
 var diaryImageFileNamePattern = regexp.MustCompile(`^\d+\.[A-Za-z0-9]+$`)
 
 // diaryMood는 일기에서 기분 값을 추출한다.
@@ -41,6 +55,23 @@ func hasDiaryImageData(d db.Diary) bool {
 	return d.ImageUrl1 != "" || d.ImageUrl2 != "" || d.ImageUrl3 != ""
 }
 
+// autoTemplateContent는 아직 쓰지 않은 날에 미리 채울 자동 적용 템플릿 내용이다.
+// 설정이 없거나 템플릿이 지워졌으면 빈 문자열을 반환한다.
+func autoTemplateContent(ctx context.Context, queries *db.Queries, uid, date string) string {
+	setting, err := queries.GetUserSetting(ctx, uid)
+	if err != nil || setting.AutoTemplate == "" {
+		return ""
+	}
+	t, err := diarytemplate.Resolve(ctx, queries, uid, setting.AutoTemplate)
+	if err != nil {
+		if !errors.Is(err, diarytemplate.ErrNotFound) {
+			slog.Error("자동 적용 템플릿 조회 실패", "error", err)
+		}
+		return ""
+	}
+	return diarytemplate.Expand(t.Content, date)
+}
+
 // hasDiaryLinkedData는 본문 외에 보존할 연결 데이터가 있는지 확인한다.
 func hasDiaryLinkedData(d db.Diary) bool {
 	return hasDiaryAIData(d) || hasDiaryImageData(d)
@@ -57,14 +88,14 @@ func firstEmptyImageSlot(d db.Diary) (int, bool) {
 	return -1, false
 }
 
-// validateDiaryImageURL은 클라이언트가 전달한 이미지 URL이 현재 사용자의 일기 이미지인지 확인한다.
-func validateDiaryImageURL(ctx context.Context, raw, uid, date string) error {
+// validateDiaryImageURL은 클라이언트가 전달한 이미지 URL이 현재 사용자의 일기 이미지인지 확인하고 내용을 검사한다.
+func validateDiaryImageURL(ctx context.Context, queries *db.Queries, raw, uid, date string) error {
 	bucket, object, err := firebaseutil.ParseFirebaseURL(raw)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "이미지 URL이 올바르지 않습니다.")
 	}
 
-	expectedBucket := config.GetEnvOrDefault("FIREBASE_STORAGE_BUCKET", defaultDiaryImageBucket)
+	expectedBucket := StorageBucket()
 	if bucket != expectedBucket {
 		return echo.NewHTTPError(http.StatusBadRequest, "허용되지 않은 이미지 저장소입니다.")
 	}
@@ -73,14 +104,9 @@ func validateDiaryImageURL(ctx context.Context, raw, uid, date string) error {
 		return err
 	}
 
-	client, err := middleware.App.Storage(ctx)
-	if err != nil {
-		return fmt.Errorf("스토리지 클라이언트 생성 실패: %w", err)
-	}
-
-	b, err := client.Bucket(bucket)
+	b, err := storageBucket(ctx)
 	if err != nil {
-		return fmt.Errorf("버킷 가져오기 실패: %w", err)
+		return err
 	}
 
 	attrs, err := b.Object(object).Attrs(ctx)
@@ -90,11 +116,8 @@ func validateDiaryImageURL(ctx context.Context, raw, uid, date string) error {
 		}
 		return fmt.Errorf("이미지 파일 확인 실패: %w", err)
 	}
-	if !strings.HasPrefix(attrs.ContentType, "image/") {
-		return echo.NewHTTPError(http.StatusBadRequest, "이미지 파일만 업로드할 수 있습니다.")
-	}
 
-	return nil
+	return checkDiaryImage(ctx, queries, b, object, uid, attrs)
 }
 
 func validateDiaryImageObjectPath(object, uid, date string) error {
@@ -230,19 +253,166 @@ func deleteFirebaseImage(ctx context.Context, raw string) error {
 	return nil
 }
 
-// buildMoodMap은 월별 기분 데이터를 맵으로 변환한다.
-func buildMoodMap(rows []db.MonthlyMoodCountRow) map[string]db.MonthlyMoodCountRow {
-	m := make(map[string]db.MonthlyMoodCountRow)
-	for _, r := range rows {
-		m[r.Month] = r
+// StorageBucket은 일기 이미지와 음성 같은 첨부 파일을 두는 스토리지 버킷이다.
+func StorageBucket() string {
+	return config.GetEnvOrDefault("FIREBASE_STORAGE_BUCKET", defaultDiaryImageBucket)
+}
+
+// storageBucket은 첨부 파일 버킷 핸들을 가져온다.
+func storageBucket(ctx context.Context) (*storage.BucketHandle, error) {
+	client, err := middleware.App.Storage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("스토리지 클라이언트 생성 실패: %w", err)
+	}
+	b, err := client.Bucket(StorageBucket())
+	if err != nil {
+		return nil, fmt.Errorf("버킷 가져오기 실패: %w", err)
 	}
-	return m
+	return b, nil
 }
 
-// nullFloat64ToInt64는 sql.NullFloat64를 int64로 변환한다.
-func nullFloat64ToInt64(v sql.NullFloat64) int64 {
-	if v.Valid {
-		return int64(v.Float64)
+// DeleteUserImages는 사용자가 올린 일기 이미지를 모두 지우고 지운 개수를 반환한다.
+// 일기에 연결된 이미지와 함께, 연결이 끊겨 남아 있는 diary/*/<uid>/ 경로의 파일과 격리한 이미지도 지운다.
+func DeleteUserImages(ctx context.Context, queries *db.Queries, uid string) (int, error) {
+	rows, err := queries.ListDiaryImageURLs(ctx, uid)
+	if err != nil {
+		return 0, fmt.Errorf("이미지 목록 조회 실패: %w", err)
+	}
+
+	deleted := 0
+	for _, row := range rows {
+		for _, url := range []string{row.ImageUrl1, row.ImageUrl2, row.ImageUrl3} {
+			if url == "" {
+				continue
+			}
+			if err := deleteFirebaseImage(ctx, url); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+
+	b, err := storageBucket(ctx)
+	if err != nil {
+		return deleted, err
+	}
+
+	for _, q := range []*storage.Query{
+		{MatchGlob: "diary/*/" + uid + "/**"},
+		{Prefix: quarantineObjectName(uid, "")},
+	} {
+		it := b.Objects(ctx, q)
+		for {
+			attrs, err := it.Next()
+			if errors.Is(err, iterator.Done) {
+				break
+			}
+			if err != nil {
+				return deleted, fmt.Errorf("이미지 파일 조회 실패: %w", err)
+			}
+			if err := b.Object(attrs.Name).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+				return deleted, fmt.Errorf("파일 삭제 실패: %w", err)
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// contentPreview는 일기 첫 줄을 미리보기 길이로 자른다.
+func contentPreview(content string) string {
+	firstLine, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	return stringutil.TruncateWithSuffix(firstLine, contentPreviewLen, "...")
+}
+
+// firstNonEmpty는 비어 있지 않은 첫 값을 반환한다.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// listCursor는 일기 목록 키셋 페이지네이션의 마지막 위치(date, id)다.
+type listCursor struct {
+	Date string
+	ID   string
+}
+
+// encode는 커서를 URL에 그대로 실을 수 있는 불투명한 문자열로 만든다.
+func (c listCursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.Date + ":" + c.ID))
+}
+
+// decodeListCursor는 encode로 만든 커서를 해석한다. 빈 문자열은 첫 페이지를 뜻한다.
+func decodeListCursor(value string) (listCursor, error) {
+	if value == "" {
+		return listCursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return listCursor{}, fmt.Errorf("커서 디코딩 실패: %w", err)
+	}
+	date, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" || !dateutil.IsValidDate(date, dateutil.DateFormatYYYYMMDD) {
+		return listCursor{}, errors.New("커서 형식 오류")
+	}
+	return listCursor{Date: date, ID: id}, nil
+}
+
+// parseListPage는 size, cursor 쿼리 파라미터를 검증한다.
+func parseListPage(c echo.Context) (int, listCursor, error) {
+	size := defaultListPageSize
+	if v := c.QueryParam("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListPageSize {
+			return 0, listCursor{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("목록 크기는 1~%d 사이여야 합니다.", maxListPageSize))
+		}
+		size = n
+	}
+
+	cursor, err := decodeListCursor(c.QueryParam("cursor"))
+	if err != nil {
+		return 0, listCursor{}, echo.NewHTTPError(http.StatusBadRequest, "목록 위치가 올바르지 않습니다.")
+	}
+	return size, cursor, nil
+}
+
+// loadDiaryPage는 커서 다음 일기를 size개까지 조회하고 다음 페이지 커서를 함께 반환한다.
+// tag가 비어 있지 않으면 해당 태그가 달린 일기만 조회하며, 마지막 페이지면 커서는 빈 문자열이다.
+func loadDiaryPage(ctx context.Context, queries *db.Queries, uid, tag string, cursor listCursor, size int) ([]db.Diary, string, error) {
+	// 다음 페이지 존재 여부를 알기 위해 한 건 더 조회한다.
+	limit := int64(size + 1)
+	var (
+		diarys []db.Diary
+		err    error
+	)
+	if tag != "" {
+		diarys, err = queries.ListDiarysByTag(ctx, db.ListDiarysByTagParams{
+			Uid:        uid,
+			Tag:        tag,
+			CursorDate: cursor.Date,
+			CursorID:   cursor.ID,
+			PageSize:   limit,
+		})
+	} else {
+		diarys, err = queries.ListDiarys(ctx, db.ListDiarysParams{
+			Uid:        uid,
+			CursorDate: cursor.Date,
+			CursorID:   cursor.ID,
+			PageSize:   limit,
+		})
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(diarys) <= size {
+		return diarys, "", nil
 	}
-	return 0
+	diarys = diarys[:size]
+	last := diarys[len(diarys)-1]
+	return diarys, listCursor{Date: last.Date, ID: last.ID}.encode(), nil
 }
@@ -0,0 +1,321 @@
+package diary
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"simple-server/internal/validate"
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/internal/draft"
+	"simple-server/projects/deario/internal/embedding"
+	"simple-server/projects/deario/internal/habit"
+	"simple-server/projects/deario/internal/safety"
+	"simple-server/projects/deario/internal/tags"
+	"simple-server/projects/deario/internal/webhook"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+// diaryVersionHeader는 저장 후 새 일기 버전을 편집기에 알려주는 응답 헤더다.
+const diaryVersionHeader = "X-Diary-Version"
+
+var errDiaryConflict = errors.New("다른 기기에서 먼저 수정된 일기입니다")
+
+type saveDraftDTO struct {
+	Date    string `form:"date" validate:"required" message:"날짜가 필요합니다."`
+	Content string `form:"content"`
+	Version int64  `form:"version" validate:"min=0" message:"버전이 올바르지 않습니다."`
+}
+
+type mergeDiaryDTO struct {
+	Date    string `form:"date" validate:"required" message:"날짜가 필요합니다."`
+	Version int64  `form:"version" validate:"min=0" message:"버전이 올바르지 않습니다."`
+	Choice  string `form:"choice" validate:"required,oneof=saved mine merged" message:"합치기 방식을 선택해주세요."`
+	Content string `form:"content"`
+}
+
+// SaveDiaryDraft는 저장되지 않은 편집 내용을 (uid, date)별 초안으로 보관한다.
+func SaveDiaryDraft(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto saveDraftDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	date, err := deariodate.NormalizeRequired(dto.Date)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	if err := queries.UpsertDiaryDraft(c.Request().Context(), db.UpsertDiaryDraftParams{
+		Uid:         uid,
+		Date:        date,
+		Content:     strings.TrimSpace(dto.Content),
+		BaseVersion: dto.Version,
+	}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "초안 저장에 실패했습니다.")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DraftMerge는 보관된 초안과 저장된 일기를 비교하는 합치기 화면을 렌더링한다.
+func DraftMerge(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	date, err := deariodate.NormalizeRequired(c.QueryParam("date"))
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	pending, err := queries.GetDiaryDraft(c.Request().Context(), db.GetDiaryDraftParams{Uid: uid, Date: date})
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "초안이 없습니다.")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "초안 조회에 실패했습니다.")
+	}
+
+	saved, err := queries.GetDiary(c.Request().Context(), db.GetDiaryParams{Uid: uid, Date: date})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기 조회에 실패했습니다.")
+	}
+
+	return components.DiaryMerge(date, saved.Content, saved.Version, pending.Content, draft.Merge(saved.Content, pending.Content)).
+		Render(c.Request().Context(), c.Response().Writer)
+}
+
+// MergeDiary는 합치기 화면에서 고른 내용으로 일기를 저장하고 초안을 지운다.
+func MergeDiary(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto mergeDiaryDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	date, err := deariodate.NormalizeRequired(dto.Date)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	var content string
+	switch dto.Choice {
+	case "saved":
+		return diaryMerged(c, queries, uid, date)
+	case "mine":
+		pending, err := queries.GetDiaryDraft(c.Request().Context(), db.GetDiaryDraftParams{Uid: uid, Date: date})
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "초안이 없습니다.")
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "초안 조회에 실패했습니다.")
+		}
+		content = pending.Content
+	default:
+		content = strings.TrimSpace(dto.Content)
+	}
+	if content == "" && dto.Choice != "mine" {
+		return echo.NewHTTPError(http.StatusBadRequest, "저장할 내용이 비어 있습니다.")
+	}
+	if content == "" {
+		// 내 쪽에서 일기를 모두 지운 경우다. 저장된 일기가 그사이 바뀌지 않았을 때만 비운다.
+		diary, err := queries.GetDiary(c.Request().Context(), db.GetDiaryParams{Uid: uid, Date: date})
+		if errors.Is(err, sql.ErrNoRows) {
+			return diaryMerged(c, queries, uid, date)
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "일기 조회에 실패했습니다.")
+		}
+		if diary.Version != dto.Version {
+			return renderDiaryConflict(c, queries, uid, date, content, dto.Version)
+		}
+		_, err = clearDiaryContent(c.Request().Context(), queries, diary)
+		if errors.Is(err, errDiaryConflict) {
+			return renderDiaryConflict(c, queries, uid, date, content, dto.Version)
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "수정 실패")
+		}
+		return diaryMerged(c, queries, uid, date)
+	}
+
+	saved, err := saveDiaryContent(c.Request().Context(), queries, uid, date, content, &dto.Version)
+	if errors.Is(err, errDiaryConflict) {
+		return renderDiaryConflict(c, queries, uid, date, content, dto.Version)
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기 저장에 실패했습니다. 다시 시도해주세요.")
+	}
+	afterDiaryContentSaved(c.Request().Context(), queries, uid, saved)
+
+	return diaryMerged(c, queries, uid, date)
+}
+
+// saveDiaryContent는 일기 본문을 저장한다.
+// baseVersion이 nil이면 버전 검사 없이 덮어쓰고, 0이면 새 일기로, 그 외에는 해당 버전일 때만 수정한다.
+func saveDiaryContent(ctx context.Context, queries *db.Queries, uid, date, content string, baseVersion *int64) (db.Diary, error) {
+	if baseVersion == nil {
+		return queries.UpsertDiaryContent(ctx, db.UpsertDiaryContentParams{
+			Uid:     uid,
+			Content: content,
+			Date:    date,
+		})
+	}
+
+	var (
+		saved db.Diary
+		err   error
+	)
+	if *baseVersion == 0 {
+		saved, err = queries.InsertDiaryContent(ctx, db.InsertDiaryContentParams{
+			Uid:     uid,
+			Content: content,
+			Date:    date,
+		})
+	} else {
+		saved, err = queries.UpdateDiaryContentIfVersion(ctx, db.UpdateDiaryContentIfVersionParams{
+			Content:     content,
+			Uid:         uid,
+			Date:        date,
+			BaseVersion: *baseVersion,
+		})
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Diary{}, errDiaryConflict
+	}
+	return saved, err
+}
+
+// clearDiaryContent는 본문을 비운다. 이미지나 일기요정 답변이 남아 있으면 본문만 지우고,
+// 없으면 일기를 삭제한다. 남은 일기의 새 버전을 반환하며 삭제했으면 0이다.
+// 삭제해도 diary_tombstone에 마지막 버전이 남으므로 같은 날짜에 새로 만든 일기는 그 다음 버전부터 센다.
+// diary를 읽은 뒤 다른 저장이 끼어들어 버전이 바뀌었으면 아무것도 지우지 않고 errDiaryConflict를 반환한다.
+func clearDiaryContent(ctx context.Context, queries *db.Queries, diary db.Diary) (int64, error) {
+	if !hasDiaryLinkedData(diary) {
+		n, err := queries.DeleteDiaryIfVersion(ctx, db.DeleteDiaryIfVersionParams{ID: diary.ID, Version: diary.Version})
+		if err != nil {
+			return 0, err
+		}
+		if n == 0 {
+			return 0, errDiaryConflict
+		}
+		return 0, nil
+	}
+
+	cleared, err := queries.ClearDiaryContentIfVersion(ctx, db.ClearDiaryContentIfVersionParams{ID: diary.ID, Version: diary.Version})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errDiaryConflict
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := queries.DeleteDiaryTags(ctx, diary.ID); err != nil {
+		slog.Error("태그 삭제 실패", "uid", diary.Uid, "error", err)
+	}
+	embedding.Enqueue(ctx, diary.Uid, diary.Date)
+	return cleared.Version, nil
+}
+
+// afterDiaryContentSaved는 본문 저장 뒤 태그와 배지를 갱신하고 임베딩 작업을 넣는다. 실패해도 저장은 유지한다.
+// 본문에서 감지한 위기 표현 단계를 반환한다.
+func afterDiaryContentSaved(ctx context.Context, queries *db.Queries, uid string, saved db.Diary) safety.Level {
+	if err := tags.SyncDiary(ctx, queries, saved.ID, uid, saved.Content); err != nil {
+		slog.Error("태그 갱신 실패", "uid", uid, "error", err)
+	}
+	if _, err := habit.RefreshAchievements(ctx, queries, uid); err != nil {
+		slog.Error("배지 갱신 실패", "uid", uid, "error", err)
+	}
+	embedding.Enqueue(ctx, uid, saved.Date)
+	webhook.Emit(ctx, queries, uid, webhook.EventDiarySaved, webhook.DiaryData{
+		Date:    saved.Date,
+		Content: saved.Content,
+		Mood:    saved.Mood,
+		Version: saved.Version,
+	})
+
+	level := safety.Assess(saved.Content)
+	safety.Record(ctx, queries, safety.SourceSave, level, uid)
+	return level
+}
+
+// diarySaved는 초안을 정리하고 새 버전을 헤더로 알린다.
+func diarySaved(c echo.Context, queries *db.Queries, uid, date string, version int64) error {
+	if err := queries.DeleteDiaryDraft(c.Request().Context(), db.DeleteDiaryDraftParams{Uid: uid, Date: date}); err != nil {
+		slog.Error("초안 삭제 실패", "uid", uid, "error", err)
+	}
+	c.Response().Header().Set(diaryVersionHeader, strconv.FormatInt(version, 10))
+	return c.NoContent(http.StatusNoContent)
+}
+
+// diaryMerged는 초안을 정리하고 최신 내용으로 편집기를 다시 연다.
+func diaryMerged(c echo.Context, queries *db.Queries, uid, date string) error {
+	if err := queries.DeleteDiaryDraft(c.Request().Context(), db.DeleteDiaryDraftParams{Uid: uid, Date: date}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "초안 삭제에 실패했습니다.")
+	}
+	c.Response().Header().Set("HX-Redirect", "/?date="+date)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// renderDiaryConflict는 내 변경분을 초안으로 보관한 뒤 409와 함께 합치기 화면을 내려준다.
+func renderDiaryConflict(c echo.Context, queries *db.Queries, uid, date, mine string, baseVersion int64) error {
+	ctx := c.Request().Context()
+	if err := queries.UpsertDiaryDraft(ctx, db.UpsertDiaryDraftParams{
+		Uid:         uid,
+		Date:        date,
+		Content:     mine,
+		BaseVersion: baseVersion,
+	}); err != nil {
+		slog.Error("충돌 초안 저장 실패", "uid", uid, "error", err)
+	}
+
+	saved, err := queries.GetDiary(ctx, db.GetDiaryParams{Uid: uid, Date: date})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기 조회에 실패했습니다.")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().Header().Set("HX-Retarget", "#diary-merge-content")
+	c.Response().Header().Set("HX-Reswap", "innerHTML")
+	c.Response().WriteHeader(http.StatusConflict)
+	return components.DiaryMerge(date, saved.Content, saved.Version, mine, draft.Merge(saved.Content, mine)).Render(ctx, c.Response().Writer)
+}
@@ -0,0 +1,81 @@
+package diary
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/db/dbtest"
+)
+
+func TestSaveDiaryContentAfterDelete(t *testing.T) {
+	ctx := context.Background()
+	queries := dbtest.New(t)
+	version := func(v int64) *int64 { return &v }
+
+	first, err := saveDiaryContent(ctx, queries, "user-1", "20261019", "아침", version(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := queries.DeleteDiary(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 지운 뒤 다시 만든 일기는 지우기 전 버전 다음부터 센다.
+	second, err := saveDiaryContent(ctx, queries, "user-1", "20261019", "저녁", version(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Version != first.Version+1 {
+		t.Fatalf("다시 만든 일기 버전 = %d, want %d", second.Version, first.Version+1)
+	}
+
+	// 지우기 전 버전을 들고 있는 기기는 새 일기를 덮어쓰지 못한다.
+	if _, err := saveDiaryContent(ctx, queries, "user-1", "20261019", "점심", version(first.Version)); !errors.Is(err, errDiaryConflict) {
+		t.Fatalf("옛 버전으로 저장 err = %v, want errDiaryConflict", err)
+	}
+
+	// 버전 검사 없이 저장해도 같은 규칙을 따른다.
+	if err := queries.DeleteDiary(ctx, second.ID); err != nil {
+		t.Fatal(err)
+	}
+	third, err := saveDiaryContent(ctx, queries, "user-1", "20261019", "밤", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Version != second.Version+1 {
+		t.Errorf("덮어쓴 일기 버전 = %d, want %d", third.Version, second.Version+1)
+	}
+}
+
+func TestClearDiaryContentConflict(t *testing.T) {
+	ctx := context.Background()
+	queries := dbtest.New(t)
+	version := func(v int64) *int64 { return &v }
+
+	stale, err := saveDiaryContent(ctx, queries, "user-1", "20261019", "아침", version(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 지우려고 읽은 뒤 다른 기기의 저장이 먼저 반영되었다.
+	saved, err := saveDiaryContent(ctx, queries, "user-1", "20261019", "아침과 저녁", version(stale.Version))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := clearDiaryContent(ctx, queries, stale); !errors.Is(err, errDiaryConflict) {
+		t.Fatalf("옛 버전으로 지우기 err = %v, want errDiaryConflict", err)
+	}
+	got, err := queries.GetDiary(ctx, db.GetDiaryParams{Uid: "user-1", Date: "20261019"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != saved.Content || got.Version != saved.Version {
+		t.Errorf("남은 일기 = %q v%d, want %q v%d", got.Content, got.Version, saved.Content, saved.Version)
+	}
+
+	if _, err := clearDiaryContent(ctx, queries, got); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,50 @@
+package diary
+
+import (
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/habit"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HabitPanel은 연속 작성 기록과 월간 목표 진행률을 렌더링한다.
+func HabitPanel(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	summary, err := habit.LoadSummary(c.Request().Context(), queries, uid)
+	if err != nil {
+		return err
+	}
+
+	return components.HabitPanel(summary).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// HabitAchievements는 작성 습관 통계와 배지 목록을 렌더링한다.
+func HabitAchievements(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	summary, err := habit.RefreshAchievements(c.Request().Context(), queries, uid)
+	if err != nil {
+		return err
+	}
+
+	return components.HabitAchievements(summary).Render(c.Request().Context(), c.Response().Writer)
+}
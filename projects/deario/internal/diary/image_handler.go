@@ -62,12 +62,12 @@ func UploadDiaryImage(c echo.Context) error {
 	if url == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "URL이 필요합니다.")
 	}
-	if err := validateDiaryImageURL(c.Request().Context(), url, uid, date); err != nil {
+	queries, err := db.GetQueries()
+	if err != nil {
 		return err
 	}
 
-	queries, err := db.GetQueries()
-	if err != nil {
+	if err := validateDiaryImageURL(c.Request().Context(), queries, url, uid, date); err != nil {
 		return err
 	}
 
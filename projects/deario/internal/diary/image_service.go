@@ -0,0 +1,480 @@
+package diary
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+
+	"cloud.google.com/go/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/robfig/cron/v3"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// MaxImageSize는 일기 이미지 파일 하나의 최대 크기다.
+	MaxImageSize = 10 << 20
+	// MaxImageDimension은 이미지 가로, 세로 한 변의 최대 픽셀 수다.
+	MaxImageDimension = 8192
+	// MaxImagePixels는 이미지 전체 픽셀 수 상한이다. 작은 파일이 메모리를 크게 잡아먹는 압축 폭탄을 막는다.
+	MaxImagePixels = 40_000_000
+
+	// quarantineRetention은 격리한 이미지를 지우기 전까지 남겨 두는 기간이다.
+	quarantineRetention = 30 * 24 * time.Hour
+)
+
+var (
+	errImageEmpty       = echo.NewHTTPError(http.StatusBadRequest, "이미지 파일이 비어 있습니다.")
+	errImageTooBig      = echo.NewHTTPError(http.StatusRequestEntityTooLarge, "이미지는 10MB까지 올릴 수 있습니다.")
+	errImageUnsupported = echo.NewHTTPError(http.StatusUnsupportedMediaType, "JPEG, PNG, GIF, WebP 이미지만 올릴 수 있습니다.")
+	errImageMarkup      = echo.NewHTTPError(http.StatusUnsupportedMediaType, "SVG나 HTML 파일은 이미지로 올릴 수 없습니다.")
+	errImageCorrupt     = echo.NewHTTPError(http.StatusUnprocessableEntity, "이미지 파일이 손상되어 읽을 수 없습니다.")
+	errImageDimension   = echo.NewHTTPError(http.StatusUnprocessableEntity, "이미지 해상도가 너무 큽니다.")
+	errImagePolyglot    = echo.NewHTTPError(http.StatusUnprocessableEntity, "이미지에 허용되지 않는 데이터가 들어 있습니다.")
+)
+
+// imageInfo는 이미지 내용을 직접 읽어 알아낸 형식과 크기다.
+type imageInfo struct {
+	MIMEType string
+	Width    int
+	Height   int
+}
+
+// imageSignatures는 받는 이미지 형식의 시그니처와 image 패키지의 형식 이름이다.
+var imageSignatures = []struct {
+	mimeType string
+	format   string
+	match    func([]byte) bool
+}{
+	{"image/jpeg", "jpeg", func(b []byte) bool { return bytes.HasPrefix(b, []byte{0xFF, 0xD8, 0xFF}) }},
+	{"image/png", "png", func(b []byte) bool { return bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")) }},
+	{"image/gif", "gif", func(b []byte) bool {
+		return bytes.HasPrefix(b, []byte("GIF87a")) || bytes.HasPrefix(b, []byte("GIF89a"))
+	}},
+	{"image/webp", "webp", func(b []byte) bool {
+		return len(b) >= 12 && string(b[0:4]) == "RIFF" && string(b[8:12]) == "WEBP"
+	}},
+}
+
+// markupTags는 이미지 안에 있으면 브라우저가 문서로 해석할 수 있는 표시다.
+// XMP 메타데이터(<?xpacket, <x:xmpmeta)는 사진에 흔하므로 넣지 않는다.
+var markupTags = [][]byte{
+	[]byte("<script"),
+	[]byte("<html"),
+	[]byte("<svg"),
+	[]byte("<iframe"),
+	[]byte("<object"),
+	[]byte("<embed"),
+	[]byte("<body"),
+	[]byte("<!doctype"),
+	[]byte("<?php"),
+	[]byte("javascript:"),
+}
+
+// inspectImage는 업로드된 파일을 직접 읽어 받을 수 있는 이미지인지 확인한다.
+// 저장소에 적힌 ContentType은 클라이언트가 정하므로 믿지 않는다.
+func inspectImage(data []byte) (imageInfo, error) {
+	if len(data) == 0 {
+		return imageInfo{}, errImageEmpty
+	}
+	if len(data) > MaxImageSize {
+		return imageInfo{}, errImageTooBig
+	}
+
+	var info imageInfo
+	format := ""
+	for _, sig := range imageSignatures {
+		if sig.match(data) {
+			info.MIMEType, format = sig.mimeType, sig.format
+			break
+		}
+	}
+	if format == "" {
+		if looksLikeMarkup(data) {
+			return info, errImageMarkup
+		}
+		return info, errImageUnsupported
+	}
+
+	// 픽셀을 풀기 전에 헤더만 읽어 크기를 확인한다.
+	config, decoded, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decoded != format || config.Width <= 0 || config.Height <= 0 {
+		return info, errImageCorrupt
+	}
+	info.Width, info.Height = config.Width, config.Height
+	if info.Width > MaxImageDimension || info.Height > MaxImageDimension || info.Width*info.Height > MaxImagePixels {
+		return info, errImageDimension
+	}
+
+	if isPolyglot(data, format) {
+		return info, errImagePolyglot
+	}
+	return info, nil
+}
+
+// looksLikeMarkup은 BOM과 공백을 건너뛴 첫 글자가 '<'인지 본다. SVG, HTML, XML 파일이 여기에 걸린다.
+func looksLikeMarkup(data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] == '<'
+}
+
+// isPolyglot은 이미지이면서 다른 형식으로도 해석되는 파일인지 확인한다.
+// 메타데이터와 이미지 끝 뒤에 문서 태그가 들어 있거나, PNG의 IEND 뒤에 데이터가 붙었거나, 파일 끝에 ZIP 디렉터리가 있으면 거절한다.
+// 압축된 픽셀 데이터에는 태그와 같은 바이트가 우연히 나올 수 있으므로 보지 않는다.
+// JPEG 뒤에 붙는 데이터는 모션 포토처럼 정상적인 경우가 많아 태그만 확인한다.
+func isPolyglot(data []byte, format string) bool {
+	for _, section := range textSections(data, format) {
+		if containsMarkupTag(section) {
+			return true
+		}
+	}
+	if format == "png" && pngTrailer(data) {
+		return true
+	}
+
+	tail := data[max(0, len(data)-(64<<10+22)):]
+	return bytes.Contains(tail, []byte("PK\x05\x06"))
+}
+
+// textSections는 이미지에서 글을 담을 수 있는 부분을 돌려준다.
+// 메타데이터 세그먼트와 청크, 그리고 이미지 끝 표시 뒤에 붙은 데이터다.
+// 구조가 어긋나 더 읽을 수 없으면 남은 데이터를 모두 넣는다.
+func textSections(data []byte, format string) [][]byte {
+	switch format {
+	case "jpeg":
+		return jpegSections(data)
+	case "png":
+		return pngSections(data)
+	case "gif":
+		return gifSections(data)
+	case "webp":
+		return webpSections(data)
+	}
+	return [][]byte{data}
+}
+
+// jpegSections는 COM, APPn 세그먼트와 EOI 뒤의 데이터를 모은다.
+func jpegSections(data []byte) [][]byte {
+	var sections [][]byte
+	pos := 2 // SOI
+	for pos+1 < len(data) {
+		if data[pos] != 0xFF {
+			return append(sections, data[pos:])
+		}
+		marker := data[pos+1]
+		switch {
+		case marker == 0xFF:
+			// 마커 앞의 채움 바이트
+			pos++
+			continue
+		case marker == 0xD9: // EOI
+			return append(sections, data[pos+2:])
+		case marker == 0x01 || 0xD0 <= marker && marker <= 0xD7:
+			// 길이가 없는 마커
+			pos += 2
+			continue
+		}
+		if pos+4 > len(data) {
+			return append(sections, data[pos:])
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if end > len(data) || end < pos+4 {
+			return append(sections, data[pos:])
+		}
+		if marker == 0xFE || 0xE0 <= marker && marker <= 0xEF {
+			sections = append(sections, data[pos+4:end])
+		}
+		pos = end
+		if marker == 0xDA { // SOS
+			// 압축 데이터는 0xFF 뒤에 0x00이나 RSTn이 아닌 바이트가 오는 곳에서 끝난다.
+			for pos+1 < len(data) && (data[pos] != 0xFF || data[pos+1] == 0x00 || 0xD0 <= data[pos+1] && data[pos+1] <= 0xD7) {
+				pos++
+			}
+		}
+	}
+	return sections
+}
+
+// pngSections는 tEXt, iTXt, zTXt 청크와 IEND 뒤의 데이터를 모은다.
+func pngSections(data []byte) [][]byte {
+	var sections [][]byte
+	pos := 8 // 시그니처
+	for pos+8 <= len(data) {
+		size := int(binary.BigEndian.Uint32(data[pos:]))
+		kind := string(data[pos+4 : pos+8])
+		end := pos + 8 + size + 4 // CRC
+		if end > len(data) {
+			return append(sections, data[pos:])
+		}
+		switch kind {
+		case "tEXt", "iTXt", "zTXt":
+			sections = append(sections, data[pos+8:end-4])
+		case "IEND":
+			return append(sections, data[end:])
+		}
+		pos = end
+	}
+	return append(sections, data[pos:])
+}
+
+// gifSections는 주석과 애플리케이션 확장, 트레일러 뒤의 데이터를 모은다.
+// 확장의 데이터는 255바이트 이하 블록으로 나뉘어 있으므로 이어 붙여 태그가 잘리지 않게 한다.
+func gifSections(data []byte) [][]byte {
+	if len(data) < 13 {
+		return [][]byte{data}
+	}
+	var sections [][]byte
+	pos := 13 // 헤더와 논리 화면 기술자
+	if data[10]&0x80 != 0 {
+		pos += 3 << (data[10]&0x07 + 1)
+	}
+	// subBlocks는 pos부터 이어지는 데이터 블록을 읽어 합친 내용과 다음 위치를 돌려준다.
+	subBlocks := func(pos int) ([]byte, int, bool) {
+		var b []byte
+		for pos < len(data) {
+			n := int(data[pos])
+			pos++
+			if n == 0 {
+				return b, pos, true
+			}
+			if pos+n > len(data) {
+				return b, pos, false
+			}
+			b = append(b, data[pos:pos+n]...)
+			pos += n
+		}
+		return b, pos, false
+	}
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x3B: // 트레일러
+			return append(sections, data[pos+1:])
+		case 0x21: // 확장
+			if pos+2 > len(data) {
+				return append(sections, data[pos:])
+			}
+			label := data[pos+1]
+			b, next, ok := subBlocks(pos + 2)
+			if !ok {
+				return append(sections, data[pos:])
+			}
+			if label == 0xFE || label == 0xFF {
+				sections = append(sections, b)
+			}
+			pos = next
+		case 0x2C: // 이미지 기술자
+			if pos+10 > len(data) {
+				return append(sections, data[pos:])
+			}
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			// LZW 최소 코드 크기 뒤에 압축 데이터가 온다.
+			_, next, ok := subBlocks(pos + 1)
+			if !ok {
+				return append(sections, data[min(pos, len(data)):])
+			}
+			pos = next
+		default:
+			return append(sections, data[pos:])
+		}
+	}
+	return sections
+}
+
+// webpSections는 EXIF, XMP 청크와 RIFF 끝 뒤의 데이터를 모은다.
+func webpSections(data []byte) [][]byte {
+	var sections [][]byte
+	riffEnd := min(len(data), 8+int(binary.LittleEndian.Uint32(data[4:8])))
+	pos := 12 // RIFF 헤더와 WEBP
+	for pos+8 <= riffEnd {
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size
+		if end > riffEnd {
+			return append(sections, data[pos:])
+		}
+		switch string(data[pos : pos+4]) {
+		case "EXIF", "XMP ":
+			sections = append(sections, data[pos+8:end])
+		}
+		// 청크는 짝수 바이트로 맞춘다.
+		pos = end + size&1
+	}
+	return append(sections, data[min(pos, len(data)):])
+}
+
+func containsMarkupTag(data []byte) bool {
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if c != '<' && c != 'j' && c != 'J' {
+			continue
+		}
+		for _, tag := range markupTags {
+			if tag[0] == lower(c) && hasPrefixFold(data[i:], tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasPrefixFold(b, prefix []byte) bool {
+	if len(b) < len(prefix) {
+		return false
+	}
+	for i, c := range prefix {
+		if lower(b[i]) != c {
+			return false
+		}
+	}
+	return true
+}
+
+func lower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// pngTrailer는 IEND 청크 뒤에 데이터가 더 있는지 확인한다.
+func pngTrailer(data []byte) bool {
+	i := bytes.LastIndex(data, []byte("IEND"))
+	if i < 0 {
+		return true
+	}
+	// IEND 뒤에는 4바이트 CRC만 온다.
+	return len(data) > i+8
+}
+
+// checkDiaryImage는 저장소에 올라온 이미지를 내려받아 검사한다.
+// 통과하면 저장소의 ContentType을 실제 형식으로 고치고, 통과하지 못하면 격리한 뒤 사용자에게 보여줄 오류를 반환한다.
+func checkDiaryImage(ctx context.Context, queries *db.Queries, b *storage.BucketHandle, object, uid string, attrs *storage.ObjectAttrs) error {
+	var info imageInfo
+	var err error
+	if attrs.Size > MaxImageSize {
+		err = errImageTooBig
+	} else {
+		var data []byte
+		if data, err = readObject(ctx, b, object); err != nil {
+			return err
+		}
+		info, err = inspectImage(data)
+	}
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		if qerr := quarantineImage(ctx, queries, b, object, uid, fmt.Sprint(he.Message), attrs.Size); qerr != nil {
+			slog.Error("이미지 격리 실패", "object", object, "error", qerr)
+		}
+		return he
+	}
+	if err != nil {
+		return err
+	}
+
+	if attrs.ContentType != info.MIMEType {
+		if _, err := b.Object(object).Update(ctx, storage.ObjectAttrsToUpdate{ContentType: info.MIMEType}); err != nil {
+			return fmt.Errorf("이미지 형식 갱신 실패: %w", err)
+		}
+	}
+	return nil
+}
+
+func readObject(ctx context.Context, b *storage.BucketHandle, object string) ([]byte, error) {
+	r, err := b.Object(object).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("이미지 파일 읽기 실패: %w", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, MaxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("이미지 파일 읽기 실패: %w", err)
+	}
+	return data, nil
+}
+
+// quarantineObjectName은 격리한 이미지를 옮겨 둘 경로다. 계정 삭제 때 uid로 한꺼번에 지울 수 있게 uid를 앞에 둔다.
+func quarantineObjectName(uid, object string) string {
+	return fmt.Sprintf("quarantine/%s/%s", uid, object)
+}
+
+// quarantineImage는 검사를 통과하지 못한 이미지를 격리 경로로 옮기고 기록을 남긴다.
+// 원래 경로의 공개 다운로드 주소로는 더 이상 받을 수 없다.
+func quarantineImage(ctx context.Context, queries *db.Queries, b *storage.BucketHandle, object, uid, reason string, size int64) error {
+	target := quarantineObjectName(uid, object)
+	copier := b.Object(target).CopierFrom(b.Object(object))
+	copier.ContentType = "application/octet-stream"
+	copier.Metadata = map[string]string{"reason": reason}
+	if _, err := copier.Run(ctx); err != nil {
+		return fmt.Errorf("격리 경로로 복사 실패: %w", err)
+	}
+	if err := b.Object(object).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("원본 삭제 실패: %w", err)
+	}
+
+	slog.Warn("이미지 격리", "uid", uid, "object", object, "reason", reason)
+	return queries.CreateImageQuarantine(ctx, db.CreateImageQuarantineParams{
+		Uid:    uid,
+		Source: object,
+		Object: target,
+		Reason: reason,
+		Size:   size,
+	})
+}
+
+// PruneQuarantineCron은 보관 기간이 지난 격리 이미지를 지운다.
+func PruneQuarantineCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@daily", func() {
+		ctx := context.Background()
+		before := time.Now().UTC().Add(-quarantineRetention).Format(dateutil.DateFormatISOTime)
+		rows, err := queries.ListExpiredImageQuarantine(ctx, before)
+		if err != nil {
+			slog.Error("격리 이미지 조회 실패", "error", err)
+			return
+		}
+		if len(rows) == 0 {
+			return
+		}
+
+		b, err := storageBucket(ctx)
+		if err != nil {
+			slog.Error("격리 이미지 정리 실패", "error", err)
+			return
+		}
+		for _, row := range rows {
+			if err := b.Object(row.Object).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+				slog.Error("격리 이미지 삭제 실패", "object", row.Object, "error", err)
+				continue
+			}
+			if err := queries.DeleteImageQuarantine(ctx, row.ID); err != nil {
+				slog.Error("격리 기록 삭제 실패", "id", row.ID, "error", err)
+			}
+		}
+		slog.Info("격리 이미지 정리", "count", len(rows))
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
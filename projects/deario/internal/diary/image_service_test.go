@@ -0,0 +1,178 @@
+package diary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand/v2"
+	"testing"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 3)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeGIF(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	img := image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.Black, color.White})
+	if err := gif.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// webpVP8X는 캔버스 크기만 적힌 확장 WebP 헤더를 만든다.
+func webpVP8X(w, h int) []byte {
+	chunk := []byte{0x10, 0, 0, 0}
+	chunk = append(chunk, byte(w-1), byte((w-1)>>8), byte((w-1)>>16))
+	chunk = append(chunk, byte(h-1), byte((h-1)>>8), byte((h-1)>>16))
+	b := []byte("RIFF")
+	b = binary.LittleEndian.AppendUint32(b, uint32(4+8+len(chunk)))
+	b = append(b, "WEBPVP8X"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(chunk)))
+	return append(b, chunk...)
+}
+
+// pngBomb는 IHDR의 크기만 바꾼 PNG를 만든다. 파일은 작지만 풀면 w*h 픽셀이 된다.
+func pngBomb(t *testing.T, w, h int) []byte {
+	t.Helper()
+	b := encodePNG(t, 1, 1)
+	binary.BigEndian.PutUint32(b[16:20], uint32(w))
+	binary.BigEndian.PutUint32(b[20:24], uint32(h))
+	binary.BigEndian.PutUint32(b[29:33], crc32.ChecksumIEEE(b[12:29]))
+	return b
+}
+
+// pngWithText는 IHDR 바로 뒤에 tEXt 청크를 넣는다.
+func pngWithText(t *testing.T, text string) []byte {
+	t.Helper()
+	b := encodePNG(t, 1, 1)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)))
+	chunk = append(chunk, "tEXt"...)
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	ihdrEnd := 8 + 8 + 13 + 4
+	return append(append(append([]byte{}, b[:ihdrEnd]...), chunk...), b[ihdrEnd:]...)
+}
+
+// jpegWithComment는 JPEG의 SOI 바로 뒤에 COM 세그먼트를 넣는다.
+func jpegWithComment(t *testing.T, comment string) []byte {
+	t.Helper()
+	b := encodeJPEG(t)
+	com := []byte{0xFF, 0xFE}
+	com = binary.BigEndian.AppendUint16(com, uint16(len(comment)+2))
+	com = append(com, comment...)
+	return append(append(append([]byte{}, b[:2]...), com...), b[2:]...)
+}
+
+// noiseJPEG는 압축이 거의 안 되는 무작위 픽셀로 큰 JPEG를 만들고, 압축 데이터 한가운데에 태그와 같은 바이트를 넣는다.
+// 헤더만 읽어 검사하므로 픽셀 데이터가 조금 바뀌어도 이미지로 읽힌다.
+func noiseJPEG(t *testing.T) []byte {
+	t.Helper()
+	rng := rand.New(rand.NewPCG(1, 2))
+	img := image.NewRGBA(image.Rect(0, 0, 1024, 768))
+	for i := range img.Pix {
+		img.Pix[i] = byte(rng.Uint32())
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	copy(b[len(b)/2:], "<svg onload=")
+	return b
+}
+
+func TestInspectImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		mimeType string
+		width    int
+		height   int
+	}{
+		{"png", encodePNG(t, 3, 2), "image/png", 3, 2},
+		{"jpeg", encodeJPEG(t), "image/jpeg", 4, 3},
+		{"gif", encodeGIF(t), "image/gif", 2, 2},
+		{"webp", webpVP8X(100, 50), "image/webp", 100, 50},
+		{"xmp 메타데이터", jpegWithComment(t, `<?xpacket begin=""?><x:xmpmeta xmlns:x="adobe:ns:meta/"></x:xmpmeta>`), "image/jpeg", 4, 3},
+		{"모션 포토처럼 뒤에 붙은 데이터", append(encodeJPEG(t), make([]byte, 1024)...), "image/jpeg", 4, 3},
+		{"무작위 픽셀로 채운 큰 jpeg", noiseJPEG(t), "image/jpeg", 1024, 768},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := inspectImage(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info != (imageInfo{MIMEType: tt.mimeType, Width: tt.width, Height: tt.height}) {
+				t.Errorf("inspectImage = %+v", info)
+			}
+		})
+	}
+}
+
+func TestInspectImageRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"빈 파일", nil, errImageEmpty},
+		{"너무 큰 파일", append(encodePNG(t, 1, 1), make([]byte, MaxImageSize)...), errImageTooBig},
+		{"svg", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"/>`), errImageMarkup},
+		{"BOM 붙은 svg", []byte("\xEF\xBB\xBF \n<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), errImageMarkup},
+		{"html", []byte("<!DOCTYPE html><script>alert(1)</script>"), errImageMarkup},
+		{"pdf", []byte("%PDF-1.7\n"), errImageUnsupported},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), errImageUnsupported},
+		{"잘린 png", []byte("\x89PNG\r\n\x1a\n\x00\x00"), errImageCorrupt},
+		{"png 시그니처의 gif", append([]byte("\x89PNG\r\n\x1a\n"), encodeGIF(t)...), errImageCorrupt},
+		{"압축 폭탄 png", pngBomb(t, 50000, 50000), errImageDimension},
+		{"긴 변이 너무 긴 png", pngBomb(t, MaxImageDimension+1, 10), errImageDimension},
+		{"픽셀 수가 너무 많은 png", pngBomb(t, 8000, 8000), errImageDimension},
+		{"큰 webp", webpVP8X(16000, 16000), errImageDimension},
+		{"스크립트가 든 jpeg", jpegWithComment(t, "<ScRiPt>alert(1)</script>"), errImagePolyglot},
+		{"html을 붙인 gif", append(encodeGIF(t), "<html><body>hi</body></html>"...), errImagePolyglot},
+		{"IEND 뒤에 데이터가 붙은 png", append(encodePNG(t, 1, 1), "payload"...), errImagePolyglot},
+		{"EOI 뒤에 html을 붙인 jpeg", append(encodeJPEG(t), "<html><script>alert(1)</script>"...), errImagePolyglot},
+		{"tEXt 청크에 스크립트가 든 png", pngWithText(t, "Comment\x00<script>alert(1)</script>"), errImagePolyglot},
+		{"zip을 붙인 jpeg", append(encodeJPEG(t), "PK\x03\x04payloadPK\x05\x06\x00\x00\x00\x00\x01\x00\x01\x00"...), errImagePolyglot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := inspectImage(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("inspectImage err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuarantineObjectName(t *testing.T) {
+	got := quarantineObjectName("user-1", "diary/20261019/user-1/20261019/1.png")
+	if got != "quarantine/user-1/diary/20261019/user-1/20261019/1.png" {
+		t.Errorf("quarantineObjectName = %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+package diary
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+// OnThisDayMemories는 지난 해 같은 날짜에 작성한 일기 목록을 렌더링한다.
+func OnThisDayMemories(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	date, err := deariodate.NormalizeWithDefault(c.QueryParam("date"))
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	items, err := listMemories(c.Request().Context(), queries, uid, date)
+	if err != nil {
+		return err
+	}
+
+	return components.MemoriesPanel(items).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// listMemories는 기준 날짜와 같은 월일에 작성된 지난 일기를 조회한다.
+func listMemories(ctx context.Context, queries *db.Queries, uid, date string) ([]components.MemoryItem, error) {
+	monthDay, extraMonthDay := deariodate.MonthDayKeys(date)
+	rows, err := queries.ListDiariesOnThisDay(ctx, db.ListDiariesOnThisDayParams{
+		Uid:           uid,
+		MonthDay:      sql.NullString{String: monthDay, Valid: true},
+		ExtraMonthDay: sql.NullString{String: extraMonthDay, Valid: true},
+		BeforeDate:    date[:4] + "0101",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	year, _ := strconv.Atoi(date[:4])
+	items := make([]components.MemoryItem, 0, len(rows))
+	for _, row := range rows {
+		rowYear, _ := strconv.Atoi(row.Date[:4])
+		items = append(items, components.MemoryItem{
+			Date:     row.Date,
+			YearsAgo: year - rowYear,
+			Preview:  contentPreview(row.Content),
+			Mood:     row.Mood,
+		})
+	}
+	return items, nil
+}
@@ -6,6 +6,7 @@ import (
 	"simple-server/internal/validate"
 	"simple-server/pkg/util/authutil"
 	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/webhook"
 
 	"github.com/labstack/echo/v4"
 )
@@ -50,6 +51,7 @@ func UpdateDiaryMood(c echo.Context) error {
 	}); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "일기요정 저장 실패")
 	}
+	webhook.Emit(c.Request().Context(), queries, uid, webhook.EventMoodUpdated, webhook.MoodData{Date: diary.Date, Mood: dto.Mood})
 
 	return c.NoContent(http.StatusNoContent)
 }
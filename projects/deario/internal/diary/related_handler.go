@@ -0,0 +1,49 @@
+package diary
+
+import (
+	"log/slog"
+
+	aiclient "simple-server/internal/ai"
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/internal/embedding"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RelatedDiaries는 기준 날짜 일기와 내용이 비슷한 지난 일기를 보여준다.
+// 임베딩이 아직 없거나 조회에 실패하면 빈 패널을 내려 일기 화면을 가리지 않는다.
+func RelatedDiaries(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	date, err := deariodate.NormalizeWithDefault(c.QueryParam("date"))
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	matches, err := embedding.Related(c.Request().Context(), queries, aiclient.DefaultEmbedder(), uid, date, embedding.RelatedLimit)
+	if err != nil {
+		slog.Error("비슷한 일기 조회 실패", "uid", uid, "error", err)
+	}
+
+	items := make([]components.RelatedDiaryItem, 0, len(matches))
+	for _, m := range matches {
+		items = append(items, components.RelatedDiaryItem{
+			Date:    m.Date,
+			Preview: contentPreview(m.Content),
+			Mood:    m.Mood,
+		})
+	}
+
+	return components.RelatedDiariesPanel(items).Render(c.Request().Context(), c.Response().Writer)
+}
@@ -1,13 +1,18 @@
 package diary
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
 	"net/http"
 	"strings"
 	"unicode/utf8"
 
+	aiclient "simple-server/internal/ai"
 	"simple-server/pkg/util/authutil"
 	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/embedding"
+	"simple-server/projects/deario/internal/tags"
 	"simple-server/projects/deario/views/components"
 
 	"github.com/labstack/echo/v4"
@@ -17,17 +22,21 @@ const (
 	searchSnippetPrefixLen   = 3
 	searchSnippetSuffixLen   = 4
 	searchSnippetFallbackLen = 15
+
+	searchModeSemantic = "semantic"
 )
 
 // SearchDiaries는 내용에서 키워드를 검색해 일기 목록을 반환한다.
+// tag 파라미터나 "#태그 키워드" 형식의 검색어로 태그 필터를 함께 걸 수 있다.
+// mode=semantic이면 임베딩으로 뜻이 비슷한 일기를 찾는다. 이때 태그 필터는 쓰지 않는다.
 func SearchDiaries(c echo.Context) error {
 	uid, err := authutil.SessionUID(c)
 	if err != nil {
 		return err
 	}
 
-	q := strings.TrimSpace(c.QueryParam("q"))
-	if q == "" {
+	q, tag := parseSearchQuery(c.QueryParam("q"), c.QueryParam("tag"))
+	if q == "" && tag == "" {
 		return c.String(http.StatusOK, "")
 	}
 
@@ -36,25 +45,90 @@ func SearchDiaries(c echo.Context) error {
 		return err
 	}
 
-	diarys, err := queries.SearchDiarys(c.Request().Context(), db.SearchDiarysParams{
-		Uid:     uid,
-		Column2: sql.NullString{String: q, Valid: true},
-	})
+	if c.QueryParam("mode") == searchModeSemantic && q != "" {
+		return semanticSearch(c, queries, uid, q)
+	}
+
+	diarys, err := searchDiaryRows(c.Request().Context(), queries, uid, q, tag)
 	if err != nil {
 		return err
 	}
 
+	keyword := searchKeyword(q, tag)
 	items := make([]components.SearchResultItem, 0, len(diarys))
 	for _, d := range diarys {
 		items = append(items, components.SearchResultItem{
 			Date:    d.Date,
-			Snippet: snippetNodes(d.Content, q),
+			Snippet: snippetNodes(d.Content, keyword),
 		})
 	}
 
 	return components.SearchResults(items).Render(c.Request().Context(), c.Response().Writer)
 }
 
+// semanticSearch는 검색어와 뜻이 비슷한 일기를 유사도 순으로 렌더링한다.
+func semanticSearch(c echo.Context, queries *db.Queries, uid, q string) error {
+	matches, err := embedding.Search(c.Request().Context(), queries, aiclient.DefaultEmbedder(), uid, q, embedding.SearchLimit)
+	if err != nil {
+		slog.Error("의미 검색 실패", "uid", uid, "error", err)
+		return echo.NewHTTPError(http.StatusBadGateway, "의미 검색에 실패했습니다. 잠시 후 다시 시도해주세요.")
+	}
+
+	items := make([]components.SearchResultItem, 0, len(matches))
+	for _, m := range matches {
+		items = append(items, components.SearchResultItem{
+			Date:    m.Date,
+			Snippet: snippetNodes(m.Content, q),
+		})
+	}
+
+	return components.SearchResults(items).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// parseSearchQuery는 검색어와 태그를 정리한다. tag가 없고 검색어가 "#태그"로 시작하면 태그로 쓴다.
+// 올바르지 않은 태그는 빈 문자열로 돌려준다.
+func parseSearchQuery(q, tagParam string) (string, string) {
+	q = strings.TrimSpace(q)
+	if tagParam == "" && strings.HasPrefix(q, "#") {
+		tagParam, q, _ = strings.Cut(q, " ")
+		q = strings.TrimSpace(q)
+	}
+	tag, _ := tags.Normalize(tagParam)
+	return q, tag
+}
+
+// searchDiaryRows는 키워드로 일기를 검색한다. tag가 있으면 해당 태그가 달린 일기로 좁힌다.
+func searchDiaryRows(ctx context.Context, queries *db.Queries, uid, q, tag string) ([]db.SearchDiarysRow, error) {
+	if tag == "" {
+		return queries.SearchDiarys(ctx, db.SearchDiarysParams{
+			Uid:     uid,
+			Column2: sql.NullString{String: q, Valid: true},
+		})
+	}
+
+	rows, err := queries.SearchDiarysByTag(ctx, db.SearchDiarysByTagParams{
+		Uid:     uid,
+		Tag:     tag,
+		Keyword: sql.NullString{String: q, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	diarys := make([]db.SearchDiarysRow, 0, len(rows))
+	for _, r := range rows {
+		diarys = append(diarys, db.SearchDiarysRow(r))
+	}
+	return diarys, nil
+}
+
+// searchKeyword는 스니펫에서 강조할 문자열이다. 키워드 없이 태그로만 찾았으면 태그를 강조한다.
+func searchKeyword(q, tag string) string {
+	if q == "" {
+		return "#" + tag
+	}
+	return q
+}
+
 func snippetNodes(content, keyword string) components.SearchResultSnippet {
 	lowerContent := strings.ToLower(content)
 	lowerKeyword := strings.ToLower(keyword)
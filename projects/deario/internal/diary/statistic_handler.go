@@ -1,12 +1,21 @@
 package diary
 
 import (
+	"context"
 	"database/sql"
+	"encoding/csv"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"simple-server/pkg/util/authutil"
+	"simple-server/pkg/util/dateutil"
 	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/internal/stats"
+	"simple-server/projects/deario/views/components"
 	"simple-server/projects/deario/views/pages"
 
 	"github.com/labstack/echo/v4"
@@ -31,56 +40,162 @@ func StatsPage(c echo.Context) error {
 	return pages.Statistic().Render(c.Request().Context(), c.Response().Writer)
 }
 
-// GetStatsData는 통계 데이터를 JSON으로 반환한다.
+// GetStatsData는 선택한 범위의 통계 데이터를 JSON으로 반환한다.
 func GetStatsData(c echo.Context) error {
 	uid, err := authutil.SessionUID(c)
 	if err != nil {
 		return err
 	}
 
+	r, ok := stats.ParseRange(c.QueryParam("range"))
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "조회 범위가 올바르지 않습니다.")
+	}
+
 	queries, err := db.GetQueries()
 	if err != nil {
 		return err
 	}
 
-	counts, err := queries.MonthlyDiaryCount(c.Request().Context(), uid)
+	data, err := loadStats(c.Request().Context(), queries, uid, r)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, data)
+}
+
+// ExportStatsCSV는 선택한 범위의 구간별 통계를 CSV 파일로 내려준다.
+func ExportStatsCSV(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
 	if err != nil {
 		return err
 	}
 
-	moods, err := queries.MonthlyMoodCount(c.Request().Context(), uid)
+	r, ok := stats.ParseRange(c.QueryParam("range"))
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "조회 범위가 올바르지 않습니다.")
+	}
+
+	queries, err := db.GetQueries()
 	if err != nil {
 		return err
 	}
 
-	moodMap := buildMoodMap(moods)
+	data, err := loadStats(c.Request().Context(), queries, uid, r)
+	if err != nil {
+		return err
+	}
 
-	var months []string
-	var diaryCount []int64
-	var mood1, mood2, mood3, mood4, mood5 []int64
+	filename := fmt.Sprintf("deario-stats-%s-%s.csv", data.Range, data.EndDate)
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	c.Response().WriteHeader(http.StatusOK)
 
-	for _, cRow := range counts {
-		month := cRow.Month
-		months = append(months, month)
-		diaryCount = append(diaryCount, cRow.Count)
+	// 엑셀에서 한글이 깨지지 않도록 UTF-8 BOM을 먼저 쓴다.
+	if _, err := c.Response().Write([]byte("\ufeff")); err != nil {
+		return err
+	}
 
-		mm := moodMap[month]
-		mood1 = append(mood1, nullFloat64ToInt64(mm.Mood1))
-		mood2 = append(mood2, nullFloat64ToInt64(mm.Mood2))
-		mood3 = append(mood3, nullFloat64ToInt64(mm.Mood3))
-		mood4 = append(mood4, nullFloat64ToInt64(mm.Mood4))
-		mood5 = append(mood5, nullFloat64ToInt64(mm.Mood5))
+	w := csv.NewWriter(c.Response())
+	if err := w.Write([]string{"구간", "작성 수", "단어 수", "😁", "🙂", "😐", "😣", "😭"}); err != nil {
+		return err
 	}
+	for _, b := range data.Buckets {
+		record := []string{b.Key, strconv.Itoa(b.DiaryCount), strconv.Itoa(b.WordCount)}
+		for _, n := range b.Moods {
+			record = append(record, strconv.Itoa(n))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
 
-	result := map[string]interface{}{
-		"months":     months,
-		"diaryCount": diaryCount,
-		"mood1":      mood1,
-		"mood2":      mood2,
-		"mood3":      mood3,
-		"mood4":      mood4,
-		"mood5":      mood5,
+// StatsHeatmap은 연간 작성 히트맵을 렌더링한다.
+func StatsHeatmap(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(http.StatusOK, result)
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	setting, err := queries.GetUserSetting(c.Request().Context(), uid)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	today := deariodate.TodayIn(setting.Timezone)
+	currentYear, _ := strconv.Atoi(today[:4])
+
+	year := currentYear
+	if v := c.QueryParam("year"); v != "" {
+		year, err = strconv.Atoi(v)
+		if err != nil || year < 1900 || year > currentYear {
+			return echo.NewHTTPError(http.StatusBadRequest, "연도가 올바르지 않습니다.")
+		}
+	}
+
+	rows, err := queries.ListDiaryStatRows(c.Request().Context(), db.ListDiaryStatRowsParams{
+		Uid:       uid,
+		StartDate: fmt.Sprintf("%04d0101", year),
+		EndDate:   fmt.Sprintf("%04d1231", year),
+	})
+	if err != nil {
+		return err
+	}
+
+	weeks := stats.Heatmap(year, toStatEntries(rows, nil))
+	return components.StatsHeatmap(year, year < currentYear, len(rows), weeks).
+		Render(c.Request().Context(), c.Response().Writer)
+}
+
+// loadStats는 사용자 시간대 기준으로 조회 범위를 계산해 통계를 만든다.
+func loadStats(ctx context.Context, queries *db.Queries, uid string, r stats.Range) (stats.Data, error) {
+	setting, err := queries.GetUserSetting(ctx, uid)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return stats.Data{}, err
+	}
+	today := deariodate.TodayIn(setting.Timezone)
+
+	var firstDate string
+	if r == stats.RangeAll {
+		firstDate, err = queries.GetFirstDiaryDate(ctx, uid)
+		if err != nil {
+			return stats.Data{}, err
+		}
+	}
+
+	start, end := stats.Period(r, today, firstDate)
+	rows, err := queries.ListDiaryStatRows(ctx, db.ListDiaryStatRowsParams{
+		Uid:       uid,
+		StartDate: start,
+		EndDate:   end,
+	})
+	if err != nil {
+		return stats.Data{}, err
+	}
+
+	return stats.Build(r, start, end, toStatEntries(rows, deariodate.Location(setting.Timezone))), nil
+}
+
+// toStatEntries는 조회 결과를 통계 입력으로 바꾼다.
+// created는 UTC로 저장되므로 loc이 주어지면 사용자 시간대로 변환한다.
+func toStatEntries(rows []db.ListDiaryStatRowsRow, loc *time.Location) []stats.Entry {
+	entries := make([]stats.Entry, 0, len(rows))
+	for _, row := range rows {
+		entry := stats.Entry{Date: row.Date, Content: row.Content, Mood: row.Mood}
+		if loc != nil && row.Created.Valid {
+			if t, err := time.ParseInLocation(dateutil.DateFormatISOTime, row.Created.String, time.UTC); err == nil {
+				entry.Created = t.In(loc)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
 }
@@ -0,0 +1,202 @@
+package diary
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"simple-server/pkg/util/firebaseutil"
+	"simple-server/projects/deario/db"
+
+	"cloud.google.com/go/storage"
+	"github.com/robfig/cron/v3"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	// orphanGracePeriod보다 오래된 파일만 고아로 본다.
+	// 클라이언트는 스토리지에 먼저 올리고 주소를 저장하므로 막 올린 파일은 아직 연결 전일 수 있다.
+	orphanGracePeriod = 72 * time.Hour
+	// orphanDeleteRatio는 한 번에 지울 수 있는 고아 파일 비율이다.
+	// 참조 조회가 잘못되어 멀쩡한 파일을 모두 고아로 판단하는 사고를 막는다.
+	orphanDeleteRatio = 0.5
+	// orphanLogLimit은 시험 실행에서 하나씩 남기는 고아 파일 수다.
+	orphanLogLimit = 100
+)
+
+// StorageGCReport는 고아 파일 정리 결과다. /debug/vars의 storage_gc로 마지막 결과를 볼 수 있다.
+type StorageGCReport struct {
+	DryRun     bool      `json:"dryRun"`
+	Scanned    int       `json:"scanned"`
+	Referenced int       `json:"referenced"`
+	Young      int       `json:"young"`
+	Orphans    int       `json:"orphans"`
+	Bytes      int64     `json:"bytes"`
+	Deleted    int       `json:"deleted"`
+	Skipped    string    `json:"skipped,omitempty"`
+	Finished   time.Time `json:"finished"`
+}
+
+type gcObject struct {
+	Name    string
+	Size    int64
+	Created time.Time
+}
+
+var (
+	lastGCMu     sync.Mutex
+	lastGCReport StorageGCReport
+)
+
+func init() {
+	expvar.Publish("storage_gc", expvar.Func(func() any {
+		lastGCMu.Lock()
+		defer lastGCMu.Unlock()
+		return lastGCReport
+	}))
+}
+
+// storageGCDryRun은 고아 파일을 지우지 않고 보고만 할지 여부다. STORAGE_GC_DELETE=true일 때만 지운다.
+func storageGCDryRun() bool {
+	return os.Getenv("STORAGE_GC_DELETE") != "true"
+}
+
+// referencedObjects는 일기에 연결된 이미지 주소를 같은 버킷의 파일 경로로 바꾼다.
+func referencedObjects(rows []db.ListAllDiaryImageURLsRow, bucket string) map[string]bool {
+	refs := make(map[string]bool, len(rows)*3)
+	for _, row := range rows {
+		for _, raw := range []string{row.ImageUrl1, row.ImageUrl2, row.ImageUrl3} {
+			if raw == "" {
+				continue
+			}
+			b, object, err := firebaseutil.ParseFirebaseURL(raw)
+			if err != nil || b != bucket {
+				continue
+			}
+			refs[object] = true
+		}
+	}
+	return refs
+}
+
+// classifyOrphans는 참조되지 않은 파일 중 유예 기간이 지난 것을 고른다. 유예 기간 안의 파일 수도 함께 반환한다.
+func classifyOrphans(objects []gcObject, refs map[string]bool, now time.Time) ([]gcObject, int) {
+	var orphans []gcObject
+	young := 0
+	for _, o := range objects {
+		if refs[o.Name] {
+			continue
+		}
+		if now.Sub(o.Created) < orphanGracePeriod {
+			young++
+			continue
+		}
+		orphans = append(orphans, o)
+	}
+	return orphans, young
+}
+
+// tooManyOrphans는 지우려는 파일이 비정상적으로 많은지 확인한다. 파일이 적을 때는 비율을 보지 않는다.
+func tooManyOrphans(orphans, scanned int) bool {
+	return scanned >= 20 && float64(orphans) > float64(scanned)*orphanDeleteRatio
+}
+
+// CollectOrphanImages는 diary/ 아래 파일을 일기의 이미지 주소와 맞춰 보고 연결되지 않은 파일을 정리한다.
+// 일기를 지우거나 이미지 저장이 실패해 스토리지에만 남은 파일이 대상이다. dryRun이면 보고만 한다.
+func CollectOrphanImages(ctx context.Context, queries *db.Queries, dryRun bool) (StorageGCReport, error) {
+	report := StorageGCReport{DryRun: dryRun}
+
+	b, err := storageBucket(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	// 파일 목록을 먼저 읽고 참조를 나중에 읽어야 그 사이에 연결된 이미지를 고아로 잘못 보지 않는다.
+	var objects []gcObject
+	it := b.Objects(ctx, &storage.Query{Prefix: "diary/"})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("스토리지 파일 조회 실패: %w", err)
+		}
+		objects = append(objects, gcObject{Name: attrs.Name, Size: attrs.Size, Created: attrs.Created})
+	}
+
+	rows, err := queries.ListAllDiaryImageURLs(ctx)
+	if err != nil {
+		return report, fmt.Errorf("이미지 참조 조회 실패: %w", err)
+	}
+	refs := referencedObjects(rows, StorageBucket())
+
+	orphans, young := classifyOrphans(objects, refs, time.Now())
+	report.Scanned = len(objects)
+	report.Referenced = len(objects) - young - len(orphans)
+	report.Young = young
+	report.Orphans = len(orphans)
+	for _, o := range orphans {
+		report.Bytes += o.Size
+	}
+
+	switch {
+	case dryRun:
+		for i, o := range orphans {
+			if i == orphanLogLimit {
+				slog.Info("고아 이미지 기록 생략", "rest", len(orphans)-i)
+				break
+			}
+			slog.Info("고아 이미지", "object", o.Name, "size", o.Size, "created", o.Created)
+		}
+	case tooManyOrphans(len(orphans), len(objects)):
+		report.Skipped = "고아 파일 비율이 너무 높아 삭제하지 않았습니다."
+		slog.Error("고아 이미지 삭제 중단", "orphans", len(orphans), "scanned", len(objects))
+	default:
+		for _, o := range orphans {
+			if err := b.Object(o.Name).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+				slog.Error("고아 이미지 삭제 실패", "object", o.Name, "error", err)
+				continue
+			}
+			report.Deleted++
+		}
+	}
+
+	report.Finished = time.Now()
+	return report, nil
+}
+
+// StorageGCCron은 매일 고아 이미지를 정리하고 결과를 남긴다.
+func StorageGCCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@daily", func() {
+		report, err := CollectOrphanImages(context.Background(), queries, storageGCDryRun())
+		if err != nil {
+			slog.Error("고아 이미지 정리 실패", "error", err)
+			return
+		}
+
+		lastGCMu.Lock()
+		lastGCReport = report
+		lastGCMu.Unlock()
+		slog.Info("고아 이미지 정리",
+			"dryRun", report.DryRun,
+			"scanned", report.Scanned,
+			"orphans", report.Orphans,
+			"bytes", report.Bytes,
+			"deleted", report.Deleted,
+		)
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
@@ -0,0 +1,68 @@
+package diary
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"simple-server/projects/deario/db"
+)
+
+func TestReferencedObjects(t *testing.T) {
+	const bucket = "test-bucket"
+	rows := []db.ListAllDiaryImageURLsRow{
+		{
+			ImageUrl1: "https://firebasestorage.googleapis.com/v0/b/test-bucket/o/diary%2F20261019%2Fuser-1%2F20261019%2F1.png?alt=media&token=abc",
+			ImageUrl2: "gs://test-bucket/diary/20261019/user-1/20261019/2.jpg",
+		},
+		{
+			ImageUrl1: "gs://other-bucket/diary/20261019/user-2/20261019/3.jpg",
+			ImageUrl3: "not a url",
+		},
+	}
+
+	got := referencedObjects(rows, bucket)
+	want := map[string]bool{
+		"diary/20261019/user-1/20261019/1.png": true,
+		"diary/20261019/user-1/20261019/2.jpg": true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("referencedObjects = %v, want %v", got, want)
+	}
+}
+
+func TestClassifyOrphans(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-orphanGracePeriod - time.Hour)
+	objects := []gcObject{
+		{Name: "diary/a/linked.png", Created: old},
+		{Name: "diary/a/orphan.png", Size: 10, Created: old},
+		{Name: "diary/a/just-uploaded.png", Created: now.Add(-time.Minute)},
+		{Name: "diary/a/edge.png", Created: now.Add(-orphanGracePeriod)},
+	}
+	refs := map[string]bool{"diary/a/linked.png": true}
+
+	orphans, young := classifyOrphans(objects, refs, now)
+	want := []gcObject{objects[1], objects[3]}
+	if !reflect.DeepEqual(orphans, want) || young != 1 {
+		t.Errorf("classifyOrphans = %v, %d", orphans, young)
+	}
+}
+
+func TestTooManyOrphans(t *testing.T) {
+	tests := []struct {
+		orphans, scanned int
+		want             bool
+	}{
+		{0, 0, false},
+		{5, 5, false},
+		{10, 20, false},
+		{11, 20, true},
+		{1000, 1000, true},
+	}
+	for _, tt := range tests {
+		if got := tooManyOrphans(tt.orphans, tt.scanned); got != tt.want {
+			t.Errorf("tooManyOrphans(%d, %d) = %v, want %v", tt.orphans, tt.scanned, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package diary
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"simple-server/pkg/util/authutil"
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/internal/diarysync"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultSyncPageSize = 100
+	maxSyncPageSize     = 500
+)
+
+type syncChange struct {
+	Date    string `json:"date"`
+	Deleted bool   `json:"deleted"`
+	Content string `json:"content"`
+	Mood    string `json:"mood"`
+	Version int64  `json:"version"`
+	Updated string `json:"updated"`
+}
+
+type syncPullResponse struct {
+	Changes []syncChange `json:"changes"`
+	Token   string       `json:"token"`
+	HasMore bool         `json:"hasMore"`
+}
+
+type syncPushRequest struct {
+	Changes []diarysync.Change `json:"changes"`
+}
+
+type syncPushResult struct {
+	Date    string           `json:"date"`
+	Status  diarysync.Status `json:"status"`
+	Version int64            `json:"version"`
+	// Content는 충돌일 때 서버에 남은 내용이다.
+	Content string `json:"content,omitempty"`
+}
+
+type syncPushResponse struct {
+	Results []syncPushResult `json:"results"`
+}
+
+// PullDiaryChanges는 토큰 이후 바뀐 일기를 날짜별 최신 상태로 내려준다.
+// 토큰이 서버 기록보다 앞서 있으면 410을 돌려 클라이언트가 처음부터 다시 받게 한다.
+func PullDiaryChanges(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	since, err := diarysync.DecodeToken(c.QueryParam("since"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "동기화 토큰이 올바르지 않습니다.")
+	}
+	limit := defaultSyncPageSize
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit 값이 올바르지 않습니다.")
+		}
+		limit = min(n, maxSyncPageSize)
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	latest, err := queries.GetLatestDiaryChangeSeq(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "동기화 정보를 가져오지 못했습니다.")
+	}
+	if since > latest {
+		return echo.NewHTTPError(http.StatusGone, "동기화 토큰이 만료되었습니다. 처음부터 다시 받아주세요.")
+	}
+
+	rows, err := queries.ListDiaryChangesSince(c.Request().Context(), db.ListDiaryChangesSinceParams{
+		Uid:      uid,
+		Since:    since,
+		PageSize: int64(limit + 1),
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "변경 내역을 가져오지 못했습니다.")
+	}
+
+	res := syncPullResponse{Changes: make([]syncChange, 0, len(rows)), Token: diarysync.EncodeToken(since)}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		res.HasMore = true
+	}
+	for _, row := range rows {
+		res.Changes = append(res.Changes, syncChange{
+			Date:    row.Date,
+			Deleted: row.Present == 0,
+			Content: row.Content,
+			Mood:    row.Mood,
+			Version: row.Version,
+			Updated: formatUTCTime(row.Updated),
+		})
+		res.Token = diarysync.EncodeToken(row.Seq)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// PushDiaryChanges는 오프라인에서 쌓인 변경을 한꺼번에 반영한다.
+// 같은 날짜는 마지막 변경만 처리하며, 충돌한 내용은 초안으로 보관해 합치기 화면에서 고를 수 있게 한다.
+func PushDiaryChanges(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var req syncPushRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if len(req.Changes) > diarysync.MaxBatchSize {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("한 번에 %d개까지 올릴 수 있습니다.", diarysync.MaxBatchSize))
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	results := make([]syncPushResult, 0, len(req.Changes))
+	valid := make([]diarysync.Change, 0, len(req.Changes))
+	for _, ch := range req.Changes {
+		date, err := deariodate.NormalizeRequired(ch.Date)
+		if err != nil {
+			results = append(results, syncPushResult{Date: ch.Date, Status: diarysync.StatusInvalid})
+			continue
+		}
+		ch.Date = date
+		valid = append(valid, ch)
+	}
+
+	ctx := c.Request().Context()
+	for _, ch := range diarysync.Dedupe(valid, time.Now()) {
+		result, err := applySyncChange(ctx, queries, uid, ch)
+		if err != nil {
+			slog.Error("동기화 반영 실패", "uid", uid, "date", ch.Date, "error", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "동기화에 실패했습니다. 다시 시도해주세요.")
+		}
+		results = append(results, result)
+	}
+
+	return c.JSON(http.StatusOK, syncPushResponse{Results: results})
+}
+
+// applySyncChange는 변경 하나를 충돌 규칙에 따라 반영한다.
+func applySyncChange(ctx context.Context, queries *db.Queries, uid string, ch diarysync.Change) (syncPushResult, error) {
+	saved, err := queries.GetDiary(ctx, db.GetDiaryParams{Uid: uid, Date: ch.Date})
+	exists := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return syncPushResult{}, err
+	}
+
+	server := diarysync.Server{Exists: exists, Content: saved.Content, Version: saved.Version}
+	if t, err := time.ParseInLocation(dateutil.DateFormatISOTime, saved.Updated.String, time.UTC); err == nil {
+		server.Updated = t
+	}
+
+	result := syncPushResult{Date: ch.Date, Status: diarysync.Resolve(server, ch), Version: saved.Version}
+	switch result.Status {
+	case diarysync.StatusApplied:
+		if ch.Content == "" {
+			result.Version, err = clearDiaryContent(ctx, queries, saved)
+			if errors.Is(err, errDiaryConflict) {
+				return conflictSyncChange(ctx, queries, uid, ch, result)
+			}
+			if err != nil {
+				return syncPushResult{}, err
+			}
+			break
+		}
+
+		base := saved.Version
+		updated, err := saveDiaryContent(ctx, queries, uid, ch.Date, ch.Content, &base)
+		if errors.Is(err, errDiaryConflict) {
+			return conflictSyncChange(ctx, queries, uid, ch, result)
+		}
+		if err != nil {
+			return syncPushResult{}, err
+		}
+		afterDiaryContentSaved(ctx, queries, uid, updated)
+		result.Version = updated.Version
+	case diarysync.StatusConflict:
+		result.Content = saved.Content
+		return conflictSyncChange(ctx, queries, uid, ch, result)
+	default:
+		return result, nil
+	}
+
+	if err := queries.DeleteDiaryDraft(ctx, db.DeleteDiaryDraftParams{Uid: uid, Date: ch.Date}); err != nil {
+		slog.Error("초안 삭제 실패", "uid", uid, "error", err)
+	}
+	return result, nil
+}
+
+// conflictSyncChange는 서버 내용을 유지하고 올라온 내용을 초안으로 보관한다.
+func conflictSyncChange(ctx context.Context, queries *db.Queries, uid string, ch diarysync.Change, result syncPushResult) (syncPushResult, error) {
+	result.Status = diarysync.StatusConflict
+	if result.Content == "" {
+		saved, err := queries.GetDiary(ctx, db.GetDiaryParams{Uid: uid, Date: ch.Date})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return syncPushResult{}, err
+		}
+		result.Content, result.Version = saved.Content, saved.Version
+	}
+	if ch.Content == "" {
+		return result, nil
+	}
+
+	base := result.Version
+	if ch.BaseVersion != nil {
+		base = *ch.BaseVersion
+	}
+	if err := queries.UpsertDiaryDraft(ctx, db.UpsertDiaryDraftParams{
+		Uid:         uid,
+		Date:        ch.Date,
+		Content:     ch.Content,
+		BaseVersion: base,
+	}); err != nil {
+		return syncPushResult{}, err
+	}
+	return result, nil
+}
+
+// formatUTCTime은 DB에 UTC로 저장된 시각을 RFC 3339로 바꾼다.
+func formatUTCTime(v string) string {
+	t, err := time.ParseInLocation(dateutil.DateFormatISOTime, v, time.UTC)
+	if err != nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,184 @@
+package diary
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"simple-server/internal/validate"
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/tags"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+type renameTagDTO struct {
+	From string `form:"from" validate:"required" message:"바꿀 태그를 입력해주세요."`
+	To   string `form:"to" validate:"required" message:"새 태그를 입력해주세요."`
+}
+
+// SuggestTags는 입력 중인 접두어로 시작하는 태그를 자주 쓴 순서로 반환한다.
+func SuggestTags(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	prefix, ok := tags.Normalize(c.QueryParam("q"))
+	if !ok {
+		return c.JSON(http.StatusOK, []string{})
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	names, err := queries.SuggestUserTags(c.Request().Context(), db.SuggestUserTagsParams{
+		Uid:    uid,
+		Prefix: sql.NullString{String: tags.EscapeLike(prefix), Valid: true},
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "태그를 가져오지 못했습니다.")
+	}
+	if names == nil {
+		names = []string{}
+	}
+
+	return c.JSON(http.StatusOK, names)
+}
+
+// TagCloud는 통계 페이지의 태그 구름을 렌더링한다.
+func TagCloud(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	return renderTagCloud(c, queries, uid)
+}
+
+// RenameTag는 태그 이름을 바꾸거나 기존 태그로 합친다.
+func RenameTag(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto renameTagDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	from, ok := tags.Normalize(dto.From)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "바꿀 태그가 올바르지 않습니다.")
+	}
+	to, ok := tags.Normalize(dto.To)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "새 태그는 30자 이내의 글자, 숫자, _만 사용할 수 있습니다.")
+	}
+	if from == to {
+		return echo.NewHTTPError(http.StatusBadRequest, "같은 태그로는 바꿀 수 없습니다.")
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	count, err := renameTag(ctx, queries, uid, from, to, func(saved db.Diary) {
+		afterDiaryContentSaved(ctx, queries, uid, saved)
+	})
+	if err != nil {
+		slog.Error("태그 변경 실패", "uid", uid, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "태그 변경에 실패했습니다.")
+	}
+	if count == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "해당 태그가 달린 일기가 없습니다.")
+	}
+
+	return renderTagCloud(c, queries, uid)
+}
+
+// renameTag는 from 태그가 달린 일기의 본문 해시태그를 to로 바꿔 저장한다. to가 이미 있으면 두 태그가 합쳐진다.
+// 일기마다 편집기 저장과 같이 읽은 버전을 확인해 저장하고, 저장한 일기로 after를 불러 태그, 임베딩, 웹훅을 갱신한다.
+// 읽은 뒤 다른 기기에서 고친 일기는 다시 읽어 한 번 더 바꾼다. 바꾼 일기 수를 반환한다.
+func renameTag(ctx context.Context, queries *db.Queries, uid, from, to string, after func(db.Diary)) (int, error) {
+	diarys, err := queries.ListDiarysWithTag(ctx, db.ListDiarysWithTagParams{Uid: uid, Tag: from})
+	if err != nil {
+		return 0, fmt.Errorf("태그 일기 조회 실패: %w", err)
+	}
+
+	for _, d := range diarys {
+		if err := renameTagInDiary(ctx, queries, uid, d, from, to, after); err != nil {
+			return 0, fmt.Errorf("%s 일기 수정 실패: %w", d.Date, err)
+		}
+	}
+	return len(diarys), nil
+}
+
+func renameTagInDiary(ctx context.Context, queries *db.Queries, uid string, d db.Diary, from, to string, after func(db.Diary)) error {
+	for retried := false; ; retried = true {
+		content := tags.ReplaceInContent(d.Content, from, to)
+		if content == d.Content {
+			// 본문에 해시태그가 없으면 저장하지 않고 태그 목록만 본문에 맞춘다.
+			return tags.SyncDiary(ctx, queries, d.ID, uid, d.Content)
+		}
+
+		saved, err := saveDiaryContent(ctx, queries, uid, d.Date, content, &d.Version)
+		if errors.Is(err, errDiaryConflict) && !retried {
+			d, err = queries.GetDiary(ctx, db.GetDiaryParams{Uid: uid, Date: d.Date})
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		after(saved)
+		return nil
+	}
+}
+
+// renderTagCloud는 사용 횟수에 따라 1~4단계 크기를 매겨 태그 구름을 그린다.
+func renderTagCloud(c echo.Context, queries *db.Queries, uid string) error {
+	rows, err := queries.ListUserTags(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "태그를 가져오지 못했습니다.")
+	}
+
+	var maxCount int64
+	for _, r := range rows {
+		maxCount = max(maxCount, r.Count)
+	}
+
+	items := make([]components.TagCloudItem, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, components.TagCloudItem{
+			Tag:    r.Tag,
+			Count:  r.Count,
+			Weight: int(1 + r.Count*3/maxCount),
+		})
+	}
+
+	return components.TagCloud(items).Render(c.Request().Context(), c.Response().Writer)
+}
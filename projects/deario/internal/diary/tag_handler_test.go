@@ -0,0 +1,64 @@
+package diary
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/db/dbtest"
+	"simple-server/projects/deario/internal/tags"
+)
+
+func TestRenameTag(t *testing.T) {
+	ctx := context.Background()
+	queries := dbtest.New(t)
+	version := func(v int64) *int64 { return &v }
+
+	var before []db.Diary
+	for _, d := range []struct{ date, content string }{
+		{"20261018", "아침 #운동 했다"},
+		{"20261019", "#운동 #헬스 같이"},
+	} {
+		saved, err := saveDiaryContent(ctx, queries, "user-1", d.date, d.content, version(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tags.SyncDiary(ctx, queries, saved.ID, "user-1", saved.Content); err != nil {
+			t.Fatal(err)
+		}
+		before = append(before, saved)
+	}
+
+	var after []string
+	count, err := renameTag(ctx, queries, "user-1", "운동", "헬스", func(saved db.Diary) {
+		// 일반 저장과 같이 저장 뒤 처리에서 태그를 다시 맞춘다.
+		if err := tags.SyncDiary(ctx, queries, saved.ID, "user-1", saved.Content); err != nil {
+			t.Fatal(err)
+		}
+		after = append(after, saved.Date)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("renameTag() = %d, want 2", count)
+	}
+	slices.Sort(after)
+	if !slices.Equal(after, []string{"20261018", "20261019"}) {
+		t.Errorf("저장 뒤 처리한 일기 = %v", after)
+	}
+
+	for i, want := range []string{"아침 #헬스 했다", "#헬스 #헬스 같이"} {
+		got, err := queries.GetDiary(ctx, db.GetDiaryParams{Uid: "user-1", Date: before[i].Date})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Content != want || got.Version != before[i].Version+1 {
+			t.Errorf("%s = %q v%d, want %q v%d", got.Date, got.Content, got.Version, want, before[i].Version+1)
+		}
+	}
+	if rows, err := queries.ListDiarysWithTag(ctx, db.ListDiarysWithTagParams{Uid: "user-1", Tag: "운동"}); err != nil || len(rows) != 0 {
+		t.Errorf("남은 #운동 일기 = %d, %v", len(rows), err)
+	}
+}
@@ -0,0 +1,37 @@
+package diarysync
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+
+	"github.com/robfig/cron/v3"
+)
+
+// changeRetention은 변경 기록을 남겨 두는 기간이다.
+// 날짜별 마지막 기록은 지우지 않으므로 오래 접속하지 않은 기기도 이어서 동기화할 수 있다.
+const changeRetention = 90 * 24 * time.Hour
+
+// PruneChangeLogCron은 매일 보존 기간이 지난 중간 변경 기록을 지운다.
+func PruneChangeLogCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@daily", func() {
+		before := time.Now().UTC().Add(-changeRetention).Format(dateutil.DateFormatISOTime)
+		count, err := queries.PruneDiaryChanges(context.Background(), before)
+		if err != nil {
+			slog.Error("변경 기록 정리 실패", "error", err)
+			return
+		}
+		slog.Info("변경 기록 정리", "count", count)
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
@@ -0,0 +1,126 @@
+package diarysync
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tokenPrefix = "v1:"
+
+// MaxBatchSize는 한 번에 올릴 수 있는 변경 수의 상한이다.
+const MaxBatchSize = 100
+
+var ErrInvalidToken = errors.New("동기화 토큰이 올바르지 않습니다")
+
+// Status는 올라온 변경 하나를 처리한 결과다.
+type Status string
+
+const (
+	StatusApplied   Status = "applied"
+	StatusUnchanged Status = "unchanged"
+	StatusConflict  Status = "conflict"
+	StatusInvalid   Status = "invalid"
+)
+
+// Change는 오프라인에서 쌓인 일기 변경 하나다.
+// BaseVersion은 편집을 시작한 서버 버전이며, 모르면 비워 둔다.
+type Change struct {
+	Date          string    `json:"date"`
+	Content       string    `json:"content"`
+	BaseVersion   *int64    `json:"baseVersion"`
+	ClientUpdated time.Time `json:"clientUpdated"`
+	Deleted       bool      `json:"deleted"`
+}
+
+// Server는 변경을 적용하기 직전 서버에 저장된 일기 상태다.
+type Server struct {
+	Exists  bool
+	Content string
+	Version int64
+	Updated time.Time
+}
+
+// EncodeToken은 변경 기록 순번을 클라이언트에 넘길 불투명 토큰으로 만든다.
+func EncodeToken(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(tokenPrefix + strconv.FormatInt(seq, 10)))
+}
+
+// DecodeToken은 토큰에서 순번을 꺼낸다. 빈 토큰은 처음부터 받는다는 뜻이다.
+func DecodeToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	raw, ok := strings.CutPrefix(string(b), tokenPrefix)
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	seq, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, ErrInvalidToken
+	}
+	return seq, nil
+}
+
+// Dedupe는 같은 날짜의 변경 중 클라이언트 시각이 가장 늦은 것만 남기고 시각순으로 정렬한다.
+// 미래 시각은 now로 당기고, 시각이 같으면 나중에 쌓인 변경을 택한다.
+func Dedupe(changes []Change, now time.Time) []Change {
+	latest := make(map[string]int, len(changes))
+	out := make([]Change, 0, len(changes))
+	for _, ch := range changes {
+		if ch.ClientUpdated.IsZero() || ch.ClientUpdated.After(now) {
+			ch.ClientUpdated = now
+		}
+		ch.Content = strings.TrimSpace(ch.Content)
+		if ch.Deleted {
+			ch.Content = ""
+		}
+
+		if i, ok := latest[ch.Date]; ok {
+			if ch.ClientUpdated.Before(out[i].ClientUpdated) {
+				continue
+			}
+			out[i] = ch
+			continue
+		}
+		latest[ch.Date] = len(out)
+		out = append(out, ch)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].ClientUpdated.Before(out[j].ClientUpdated)
+	})
+	return out
+}
+
+// Resolve는 변경을 서버에 적용할지 정한다.
+//   - 내용이 같으면 그대로 둔다.
+//   - 서버가 비어 있으면 적용한다.
+//   - 편집을 시작한 버전이 서버 버전과 같으면 적용한다.
+//   - 버전을 모르면 서버보다 늦게 고친 경우에만 적용한다.
+//   - 그 외에는 충돌로 보고 서버 내용을 유지한다.
+func Resolve(server Server, ch Change) Status {
+	if ch.Content == strings.TrimSpace(server.Content) {
+		return StatusUnchanged
+	}
+	if !server.Exists || strings.TrimSpace(server.Content) == "" {
+		return StatusApplied
+	}
+	if ch.BaseVersion != nil {
+		if *ch.BaseVersion == server.Version {
+			return StatusApplied
+		}
+		return StatusConflict
+	}
+	if ch.ClientUpdated.After(server.Updated) {
+		return StatusApplied
+	}
+	return StatusConflict
+}
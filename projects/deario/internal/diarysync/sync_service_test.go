@@ -0,0 +1,84 @@
+package diarysync
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestToken(t *testing.T) {
+	for _, seq := range []int64{0, 1, 42, 1 << 40} {
+		got, err := DecodeToken(EncodeToken(seq))
+		if err != nil || got != seq {
+			t.Errorf("DecodeToken(EncodeToken(%d)) = %d, %v", seq, got, err)
+		}
+	}
+
+	if got, err := DecodeToken(""); err != nil || got != 0 {
+		t.Errorf("DecodeToken(\"\") = %d, %v", got, err)
+	}
+	for _, token := range []string{"!!!", "djI6MQ", "djE6LTE", "djE6YQ"} {
+		if _, err := DecodeToken(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("DecodeToken(%q) err = %v, want ErrInvalidToken", token, err)
+		}
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	at := func(minute int) time.Time { return now.Add(time.Duration(minute) * time.Minute) }
+
+	got := Dedupe([]Change{
+		{Date: "20261018", Content: "나중", ClientUpdated: at(-5)},
+		{Date: "20261019", Content: " 첫 ", ClientUpdated: at(-30)},
+		{Date: "20261018", Content: "먼저", ClientUpdated: at(-10)},
+		{Date: "20261019", Content: "미래", ClientUpdated: at(60)},
+		{Date: "20261017", Content: "무시", Deleted: true, ClientUpdated: at(-20)},
+	}, now)
+
+	want := []Change{
+		{Date: "20261017", Content: "", Deleted: true, ClientUpdated: at(-20)},
+		{Date: "20261018", Content: "나중", ClientUpdated: at(-5)},
+		{Date: "20261019", Content: "미래", ClientUpdated: now},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Dedupe() len = %d, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Date != want[i].Date || got[i].Content != want[i].Content ||
+			got[i].Deleted != want[i].Deleted || !got[i].ClientUpdated.Equal(want[i].ClientUpdated) {
+			t.Errorf("Dedupe()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	serverTime := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	version := func(v int64) *int64 { return &v }
+	saved := Server{Exists: true, Content: "저장된 일기", Version: 3, Updated: serverTime}
+
+	tests := []struct {
+		name   string
+		server Server
+		change Change
+		want   Status
+	}{
+		{name: "same content", server: saved, change: Change{Content: "저장된 일기", BaseVersion: version(1)}, want: StatusUnchanged},
+		{name: "delete missing", server: Server{}, change: Change{Deleted: true}, want: StatusUnchanged},
+		{name: "new diary", server: Server{}, change: Change{Content: "새 일기"}, want: StatusApplied},
+		{name: "empty server", server: Server{Exists: true, Version: 2}, change: Change{Content: "채움", BaseVersion: version(1)}, want: StatusApplied},
+		{name: "version match", server: saved, change: Change{Content: "고침", BaseVersion: version(3)}, want: StatusApplied},
+		{name: "version stale", server: saved, change: Change{Content: "고침", BaseVersion: version(2)}, want: StatusConflict},
+		{name: "delete stale", server: saved, change: Change{Deleted: true, BaseVersion: version(2)}, want: StatusConflict},
+		{name: "no version newer", server: saved, change: Change{Content: "고침", ClientUpdated: serverTime.Add(time.Minute)}, want: StatusApplied},
+		{name: "no version older", server: saved, change: Change{Content: "고침", ClientUpdated: serverTime.Add(-time.Minute)}, want: StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Resolve(tt.server, tt.change); got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
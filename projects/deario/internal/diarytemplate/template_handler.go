@@ -0,0 +1,242 @@
+package diarytemplate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"simple-server/internal/validate"
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+type templateDTO struct {
+	Name    string `form:"name" validate:"required,max=50" message:"이름은 1~50자로 입력해주세요."`
+	Content string `form:"content" validate:"required,max=5000" message:"내용은 1~5000자로 입력해주세요."`
+}
+
+type autoTemplateDTO struct {
+	AutoTemplate string `form:"auto_template"`
+}
+
+// TemplatePicker는 일기 화면의 템플릿 대화상자에 들어갈 목록을 렌더링한다.
+func TemplatePicker(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	date, err := deariodate.NormalizeWithDefault(c.QueryParam("date"))
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	templates, err := All(c.Request().Context(), queries, uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "템플릿을 가져오지 못했습니다.")
+	}
+
+	items := make([]components.DiaryTemplateItem, 0, len(templates))
+	for _, t := range templates {
+		items = append(items, components.DiaryTemplateItem{
+			ID:      t.ID,
+			Name:    t.Name,
+			Content: Expand(t.Content, date),
+			Builtin: t.Builtin,
+		})
+	}
+
+	return components.DiaryTemplateList(items).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// TemplatesPanel은 설정 화면의 템플릿 관리 영역을 렌더링한다.
+func TemplatesPanel(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	return renderTemplates(c, queries, uid)
+}
+
+// CreateTemplate은 사용자 템플릿을 만든다.
+func CreateTemplate(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto templateDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	count, err := queries.CountDiaryTemplates(ctx, uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "템플릿을 가져오지 못했습니다.")
+	}
+	if count >= MaxTemplatesPerUser {
+		return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("템플릿은 최대 %d개까지 만들 수 있습니다.", MaxTemplatesPerUser))
+	}
+
+	if _, err := queries.CreateDiaryTemplate(ctx, db.CreateDiaryTemplateParams{
+		Uid:     uid,
+		Name:    dto.Name,
+		Content: dto.Content,
+	}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "템플릿을 만들지 못했습니다.")
+	}
+
+	return renderTemplates(c, queries, uid)
+}
+
+// UpdateTemplate은 사용자 템플릿의 이름과 내용을 바꾼다. 기본 템플릿은 바꿀 수 없다.
+func UpdateTemplate(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto templateDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	count, err := queries.UpdateDiaryTemplate(c.Request().Context(), db.UpdateDiaryTemplateParams{
+		Name:    dto.Name,
+		Content: dto.Content,
+		ID:      c.Param("id"),
+		Uid:     uid,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "템플릿 저장에 실패했습니다.")
+	}
+	if count == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "템플릿을 찾을 수 없습니다.")
+	}
+
+	return renderTemplates(c, queries, uid)
+}
+
+// DeleteTemplate은 사용자 템플릿을 삭제한다. 자동 적용 중이던 템플릿이면 자동 적용도 끈다.
+func DeleteTemplate(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	count, err := queries.DeleteDiaryTemplate(ctx, db.DeleteDiaryTemplateParams{ID: id, Uid: uid})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "템플릿 삭제에 실패했습니다.")
+	}
+	if count == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "템플릿을 찾을 수 없습니다.")
+	}
+	if err := queries.ClearAutoTemplate(ctx, db.ClearAutoTemplateParams{Uid: uid, AutoTemplate: id}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "자동 적용 설정을 정리하지 못했습니다.")
+	}
+
+	return renderTemplates(c, queries, uid)
+}
+
+// UpdateAutoTemplate은 새 일기에 자동으로 넣을 템플릿을 정한다. 빈 값이면 자동 적용을 끈다.
+func UpdateAutoTemplate(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto autoTemplateDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if dto.AutoTemplate != "" {
+		if _, err := Resolve(ctx, queries, uid, dto.AutoTemplate); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return echo.NewHTTPError(http.StatusBadRequest, "템플릿을 찾을 수 없습니다.")
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, "템플릿을 가져오지 못했습니다.")
+		}
+	}
+	if err := queries.UpdateAutoTemplate(ctx, db.UpdateAutoTemplateParams{
+		Uid:          uid,
+		AutoTemplate: dto.AutoTemplate,
+	}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "자동 적용 설정 저장에 실패했습니다.")
+	}
+
+	return renderTemplates(c, queries, uid)
+}
+
+func renderTemplates(c echo.Context, queries *db.Queries, uid string) error {
+	ctx := c.Request().Context()
+	templates, err := All(ctx, queries, uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "템플릿을 가져오지 못했습니다.")
+	}
+
+	var panel components.DiaryTemplatePanel
+	userCount := 0
+	for _, t := range templates {
+		if !t.Builtin {
+			userCount++
+		}
+		panel.Templates = append(panel.Templates, components.DiaryTemplateItem{
+			ID:      t.ID,
+			Name:    t.Name,
+			Content: t.Content,
+			Builtin: t.Builtin,
+		})
+	}
+	panel.CanCreate = userCount < MaxTemplatesPerUser
+
+	setting, _ := queries.GetUserSetting(ctx, uid)
+	panel.AutoTemplate = setting.AutoTemplate
+
+	return components.DiaryTemplates(panel).Render(ctx, c.Response().Writer)
+}
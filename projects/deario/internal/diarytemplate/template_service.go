@@ -0,0 +1,179 @@
+package diarytemplate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	resources "simple-server"
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+)
+
+const (
+	// BuiltinPrefix는 기본 제공 템플릿 id 앞에 붙는 값이다. 사용자 템플릿 id와 겹치지 않는다.
+	BuiltinPrefix = "builtin:"
+	// MaxTemplatesPerUser는 사용자가 만들 수 있는 템플릿 수다.
+	MaxTemplatesPerUser = 20
+
+	libraryDir  = "projects/deario/templates"
+	promptsFile = "prompts.txt"
+)
+
+var ErrNotFound = errors.New("템플릿을 찾을 수 없습니다")
+
+// Template은 일기 작성에 쓰는 템플릿이다. Content의 {{date}}는 적용할 때 날짜로 바뀐다.
+type Template struct {
+	ID      string
+	Name    string
+	Content string
+	Builtin bool
+}
+
+var (
+	libraryOnce sync.Once
+	library     []Template
+	prompts     []string
+	errLibrary  error
+)
+
+// Library는 임베드한 기본 템플릿 목록을 파일 이름 순서로 반환한다.
+func Library() ([]Template, error) {
+	libraryOnce.Do(loadLibrary)
+	return library, errLibrary
+}
+
+// DailyPrompt는 날짜마다 정해진 오늘의 질문을 반환한다. 같은 날짜에는 항상 같은 질문이 나온다.
+func DailyPrompt(date string) string {
+	libraryOnce.Do(loadLibrary)
+	if len(prompts) == 0 {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(date))
+	return prompts[h.Sum32()%uint32(len(prompts))]
+}
+
+// Expand는 템플릿 내용의 {{date}}를 일기 날짜로 바꾼다.
+func Expand(content, date string) string {
+	if !strings.Contains(content, "{{date}}") {
+		return content
+	}
+	if !dateutil.IsValidDate(date, dateutil.DateFormatYYYYMMDD) {
+		return strings.ReplaceAll(content, "{{date}}", "")
+	}
+	return strings.ReplaceAll(content, "{{date}}", dateutil.MustFormatDateKorWithWeekDay(date))
+}
+
+// Resolve는 기본 템플릿이나 사용자 템플릿을 id로 찾는다.
+func Resolve(ctx context.Context, queries *db.Queries, uid, id string) (Template, error) {
+	if strings.HasPrefix(id, BuiltinPrefix) {
+		templates, err := Library()
+		if err != nil {
+			return Template{}, err
+		}
+		for _, t := range templates {
+			if t.ID == id {
+				return t, nil
+			}
+		}
+		return Template{}, ErrNotFound
+	}
+
+	t, err := queries.GetDiaryTemplate(ctx, db.GetDiaryTemplateParams{ID: id, Uid: uid})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Template{}, ErrNotFound
+	}
+	if err != nil {
+		return Template{}, err
+	}
+	return fromRow(t), nil
+}
+
+// All은 기본 템플릿 뒤에 사용자 템플릿을 이어 붙인 목록이다.
+func All(ctx context.Context, queries *db.Queries, uid string) ([]Template, error) {
+	templates, err := Library()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := queries.ListDiaryTemplates(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]Template, 0, len(templates)+len(rows))
+	all = append(all, templates...)
+	for _, row := range rows {
+		all = append(all, fromRow(row))
+	}
+	return all, nil
+}
+
+func fromRow(row db.DiaryTemplate) Template {
+	return Template{ID: row.ID, Name: row.Name, Content: row.Content}
+}
+
+func loadLibrary() {
+	library, prompts, errLibrary = parseLibrary(resources.EmbeddedFiles, libraryDir)
+}
+
+// parseLibrary는 dir의 *.md 파일을 템플릿으로, prompts.txt를 질문 목록으로 읽는다.
+func parseLibrary(fsys fs.FS, dir string) ([]Template, []string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var templates []Template
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".md" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, nil, err
+		}
+		templates = append(templates, parseTemplate(strings.TrimSuffix(name, ".md"), data))
+	}
+
+	data, err := fs.ReadFile(fsys, path.Join(dir, promptsFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, err
+	}
+	return templates, parsePrompts(data), nil
+}
+
+// parseTemplate은 첫 줄의 "# 이름"을 템플릿 이름으로, 그 뒤를 내용으로 읽는다.
+// 제목 줄이 없으면 파일 이름을 이름으로 쓴다.
+func parseTemplate(slug string, data []byte) Template {
+	t := Template{ID: BuiltinPrefix + slug, Name: slug, Builtin: true}
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if first, rest, ok := strings.Cut(content, "\n"); ok && strings.HasPrefix(first, "# ") {
+		t.Name = strings.TrimSpace(strings.TrimPrefix(first, "# "))
+		content = rest
+	}
+	t.Content = strings.TrimLeft(content, "\n")
+	return t
+}
+
+// parsePrompts는 빈 줄과 #으로 시작하는 줄을 뺀 나머지를 질문으로 읽는다.
+func parsePrompts(data []byte) []string {
+	var out []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package diarytemplate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		slug string
+		data string
+		want Template
+	}{
+		{
+			name: "title line",
+			slug: "gratitude",
+			data: "# 감사 일기\n\n{{date}}\n1. \n",
+			want: Template{ID: "builtin:gratitude", Name: "감사 일기", Content: "{{date}}\n1. \n", Builtin: true},
+		},
+		{
+			name: "crlf",
+			slug: "crlf",
+			data: "# 제목\r\n\r\n본문\r\n",
+			want: Template{ID: "builtin:crlf", Name: "제목", Content: "본문\n", Builtin: true},
+		},
+		{
+			name: "no title",
+			slug: "plain",
+			data: "본문만 있음",
+			want: Template{ID: "builtin:plain", Name: "plain", Content: "본문만 있음", Builtin: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTemplate(tt.slug, []byte(tt.data)); got != tt.want {
+				t.Errorf("parseTemplate() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLibrary(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib/b.md":        {Data: []byte("# 둘\n내용")},
+		"lib/a.md":        {Data: []byte("# 하나\n내용")},
+		"lib/readme.txt":  {Data: []byte("무시")},
+		"lib/prompts.txt": {Data: []byte("# 주석\n\n질문 하나\n  질문 둘  \n")},
+	}
+
+	templates, prompts, err := parseLibrary(fsys, "lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 2 || templates[0].ID != "builtin:a" || templates[1].Name != "둘" {
+		t.Errorf("templates = %#v", templates)
+	}
+	if len(prompts) != 2 || prompts[0] != "질문 하나" || prompts[1] != "질문 둘" {
+		t.Errorf("prompts = %#v", prompts)
+	}
+}
+
+func TestEmbeddedLibrary(t *testing.T) {
+	templates, err := Library()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) == 0 {
+		t.Fatal("Library() returned no templates")
+	}
+	seen := map[string]bool{}
+	for _, tmpl := range templates {
+		if !strings.HasPrefix(tmpl.ID, BuiltinPrefix) || tmpl.Name == "" || tmpl.Content == "" || !tmpl.Builtin {
+			t.Errorf("invalid builtin template %#v", tmpl)
+		}
+		if seen[tmpl.ID] {
+			t.Errorf("duplicate template id %q", tmpl.ID)
+		}
+		seen[tmpl.ID] = true
+	}
+
+	if DailyPrompt("20261019") == "" {
+		t.Error("DailyPrompt() returned empty prompt")
+	}
+	if DailyPrompt("20261019") != DailyPrompt("20261019") {
+		t.Error("DailyPrompt() is not stable for the same date")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	if got := Expand("{{date}}\n본문", "20261019"); got != "2026년 10월 19일 월요일\n본문" {
+		t.Errorf("Expand() = %q", got)
+	}
+	if got := Expand("{{date}}본문", "bad"); got != "본문" {
+		t.Errorf("Expand() with invalid date = %q", got)
+	}
+	if got := Expand("본문", "20261019"); got != "본문" {
+		t.Errorf("Expand() without placeholder = %q", got)
+	}
+}
@@ -0,0 +1,35 @@
+package draft
+
+import "strings"
+
+// Merge는 저장된 내용과 내가 쓴 내용을 줄 단위로 합친 제안을 만든다.
+// 저장된 내용을 그대로 두고, 내 내용에만 있는 줄을 원래 순서대로 뒤에 덧붙인다.
+func Merge(saved, mine string) string {
+	saved = strings.TrimRight(saved, "\n")
+	if strings.TrimSpace(saved) == "" {
+		return mine
+	}
+
+	seen := make(map[string]struct{})
+	for _, line := range strings.Split(saved, "\n") {
+		seen[strings.TrimSpace(line)] = struct{}{}
+	}
+
+	var extra []string
+	for _, line := range strings.Split(mine, "\n") {
+		key := strings.TrimSpace(line)
+		if key == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		extra = append(extra, line)
+	}
+
+	if len(extra) == 0 {
+		return saved
+	}
+	return saved + "\n\n" + strings.Join(extra, "\n")
+}
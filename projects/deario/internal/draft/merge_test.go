@@ -0,0 +1,25 @@
+package draft
+
+import "testing"
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name  string
+		saved string
+		mine  string
+		want  string
+	}{
+		{name: "empty saved", saved: "", mine: "새로 쓴 내용", want: "새로 쓴 내용"},
+		{name: "identical", saved: "아침\n점심", mine: "아침\n점심", want: "아침\n점심"},
+		{name: "mine appends", saved: "아침\n점심", mine: "아침\n저녁", want: "아침\n점심\n\n저녁"},
+		{name: "whitespace ignored", saved: "아침 ", mine: " 아침\n\n밤", want: "아침 \n\n밤"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Merge(tt.saved, tt.mine); got != tt.want {
+				t.Errorf("Merge() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	aiclient "simple-server/internal/ai"
+	"simple-server/projects/deario/db"
+
+	"github.com/robfig/cron/v3"
+	"maragu.dev/goqite"
+	"maragu.dev/goqite/jobs"
+)
+
+// backfillLimit은 한 번의 보충 작업에서 넣는 일기 수다.
+const backfillLimit = 200
+
+var embeddingQ *goqite.Queue
+var embeddingQOnce sync.Once
+var errEmbeddingQ error
+
+type embedMessage struct {
+	UID  string `json:"uid"`
+	Date string `json:"date"`
+}
+
+func InitEmbeddingQueue() error {
+	embeddingQOnce.Do(func() {
+		embeddingDB, err := db.GetDB(false)
+		if err != nil {
+			errEmbeddingQ = fmt.Errorf("임베딩 큐 데이터베이스 연결 실패: %w", err)
+			return
+		}
+
+		embeddingQ = goqite.New(goqite.NewOpts{
+			DB:   embeddingDB,
+			Name: "diary-embedding",
+		})
+	})
+
+	return errEmbeddingQ
+}
+
+// Enqueue는 일기를 다시 임베딩하는 작업을 넣는다.
+// 임베딩은 부가 기능이므로 실패해도 오류를 남기기만 하고 호출한 쪽 작업은 계속한다.
+func Enqueue(ctx context.Context, uid, date string) {
+	if err := InitEmbeddingQueue(); err != nil {
+		slog.Error("임베딩 큐 초기화 실패", "error", err)
+		return
+	}
+
+	b, _ := json.Marshal(embedMessage{UID: uid, Date: date})
+	if _, err := jobs.Create(ctx, embeddingQ, "embed", goqite.Message{Body: b}); err != nil {
+		slog.Error("임베딩 작업 등록 실패", "uid", uid, "date", date, "error", err)
+	}
+}
+
+// DiaryEmbeddingJob은 큐에 쌓인 일기를 임베딩해 저장한다.
+func DiaryEmbeddingJob() {
+	if err := InitEmbeddingQueue(); err != nil {
+		slog.Error("임베딩 큐 초기화 실패", "error", err)
+		return
+	}
+
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	r := jobs.NewRunner(jobs.NewRunnerOpts{
+		Limit:        2,
+		Log:          slog.Default(),
+		PollInterval: 1 * time.Second,
+		Queue:        embeddingQ,
+	})
+
+	embedder := aiclient.DefaultEmbedder()
+	r.Register("embed", func(ctx context.Context, m []byte) error {
+		var msg embedMessage
+		if err := json.Unmarshal(m, &msg); err != nil {
+			slog.Error("임베딩 작업 해독 실패", "error", err)
+			return nil
+		}
+		if err := Index(ctx, queries, embedder, msg.UID, msg.Date); err != nil {
+			slog.Error("일기 임베딩 실패", "uid", msg.UID, "date", msg.Date, "error", err)
+			return err
+		}
+		return nil
+	})
+
+	r.Start(context.Background())
+}
+
+// BackfillEmbeddingsCron은 임베딩이 없거나 일기보다 오래된 일기를 찾아 작업을 넣는다.
+// 기능을 켜기 전에 쓴 일기와 임베딩 모델을 바꾼 뒤의 일기도 여기서 채운다.
+func BackfillEmbeddingsCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@every 10m", func() {
+		ctx := context.Background()
+		rows, err := queries.ListDiariesMissingEmbedding(ctx, db.ListDiariesMissingEmbeddingParams{
+			Model: aiclient.DefaultEmbedder().Model(),
+			Limit: backfillLimit,
+		})
+		if err != nil {
+			slog.Error("임베딩 대상 조회 실패", "error", err)
+			return
+		}
+		for _, row := range rows {
+			Enqueue(ctx, row.Uid, row.Date)
+		}
+		if len(rows) > 0 {
+			slog.Info("임베딩 보충 작업 등록", "count", len(rows))
+		}
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
@@ -0,0 +1,164 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	aiclient "simple-server/internal/ai"
+	"simple-server/projects/deario/db"
+)
+
+const (
+	// RelatedLimit은 일기 화면의 비슷한 일기 패널에 보여줄 수다.
+	RelatedLimit = 5
+	// SearchLimit은 의미 검색 결과 수다. 키워드 검색과 같다.
+	SearchLimit = 20
+
+	// minScore보다 유사도가 낮은 일기는 관련 없다고 보고 뺀다.
+	minScore = 0.2
+	// maxEmbedRunes는 임베딩할 때 쓰는 본문 앞부분 글자 수다.
+	maxEmbedRunes = 4000
+)
+
+// Match는 유사도 순으로 고른 일기다.
+type Match struct {
+	Date    string
+	Content string
+	Mood    string
+	Score   float32
+}
+
+// Index는 일기 본문을 임베딩해 저장한다. 본문이 그대로면 API를 다시 부르지 않고 갱신 시각만 바꾼다.
+// 본문이 비었거나 일기가 없으면 임베딩을 지운다.
+func Index(ctx context.Context, queries *db.Queries, e aiclient.Embedder, uid, date string) error {
+	diary, err := queries.GetDiary(ctx, db.GetDiaryParams{Date: date, Uid: uid})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("일기 조회 실패: %w", err)
+	}
+	if diary.Content == "" {
+		return queries.DeleteDiaryEmbedding(ctx, diary.ID)
+	}
+
+	hash := contentHash(diary.Content)
+	params := db.UpsertDiaryEmbeddingParams{
+		DiaryID:     diary.ID,
+		Uid:         uid,
+		Date:        diary.Date,
+		Model:       e.Model(),
+		ContentHash: hash,
+	}
+
+	existing, err := queries.GetDiaryEmbedding(ctx, diary.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("임베딩 조회 실패: %w", err)
+	}
+	if err == nil && existing.Model == params.Model && existing.ContentHash == hash {
+		params.Vector = existing.Vector
+		return queries.UpsertDiaryEmbedding(ctx, params)
+	}
+
+	vectors, err := e.Embed(ctx, aiclient.EmbedDocument, truncate(diary.Content, maxEmbedRunes))
+	if err != nil {
+		return err
+	}
+	params.Vector = aiclient.EncodeVector(vectors[0])
+	return queries.UpsertDiaryEmbedding(ctx, params)
+}
+
+// Related는 date 일기와 비슷한 다른 일기를 유사도 순으로 반환한다.
+// 아직 임베딩하지 않았거나 임베딩이 본문보다 오래됐으면 빈 목록을 반환한다. 임베딩은 저장 작업과 보충 작업이 채운다.
+func Related(ctx context.Context, queries *db.Queries, e aiclient.Embedder, uid, date string, k int) ([]Match, error) {
+	diary, err := queries.GetDiary(ctx, db.GetDiaryParams{Date: date, Uid: uid})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := queries.GetDiaryEmbedding(ctx, diary.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if existing.Model != e.Model() || existing.ContentHash != contentHash(diary.Content) {
+		return nil, nil
+	}
+
+	vector, err := aiclient.DecodeVector(existing.Vector)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := queries.ListDiaryEmbeddings(ctx, db.ListDiaryEmbeddingsParams{Uid: uid, Model: e.Model()})
+	if err != nil {
+		return nil, err
+	}
+	return rank(vector, rows, date, k), nil
+}
+
+// Search는 검색어와 뜻이 비슷한 일기를 유사도 순으로 반환한다.
+func Search(ctx context.Context, queries *db.Queries, e aiclient.Embedder, uid, q string, k int) ([]Match, error) {
+	vectors, err := e.Embed(ctx, aiclient.EmbedQuery, q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := queries.ListDiaryEmbeddings(ctx, db.ListDiaryEmbeddingsParams{Uid: uid, Model: e.Model()})
+	if err != nil {
+		return nil, err
+	}
+	return rank(vectors[0], rows, "", k), nil
+}
+
+// rank는 query와의 코사인 유사도가 minScore 이상인 일기를 높은 순으로 k개까지 고른다.
+// 유사도가 같으면 최근 일기가 앞선다. exclude 날짜의 일기는 뺀다.
+func rank(query []float32, rows []db.ListDiaryEmbeddingsRow, exclude string, k int) []Match {
+	var matches []Match
+	for _, row := range rows {
+		if row.Date == exclude {
+			continue
+		}
+		vector, err := aiclient.DecodeVector(row.Vector)
+		if err != nil {
+			continue
+		}
+		score := aiclient.Cosine(query, vector)
+		if score < minScore {
+			continue
+		}
+		matches = append(matches, Match{Date: row.Date, Content: row.Content, Mood: row.Mood, Score: score})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Date > matches[j].Date
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,94 @@
+package embedding
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	aiclient "simple-server/internal/ai"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/db/dbtest"
+)
+
+func TestRank(t *testing.T) {
+	rows := []db.ListDiaryEmbeddingsRow{
+		{Date: "20261018", Vector: aiclient.EncodeVector([]float32{1, 0})},
+		{Date: "20261017", Vector: aiclient.EncodeVector([]float32{0.6, 0.8})},
+		{Date: "20261016", Vector: aiclient.EncodeVector([]float32{0, 1})},
+		{Date: "20261015", Vector: aiclient.EncodeVector([]float32{0.6, 0.8})},
+		{Date: "20261014", Vector: []byte{1, 2, 3}},
+	}
+
+	got := rank([]float32{1, 0}, rows, "20261018", 5)
+	want := []string{"20261017", "20261015"}
+	if len(got) != len(want) {
+		t.Fatalf("rank() = %+v, want dates %v", got, want)
+	}
+	for i, m := range got {
+		if m.Date != want[i] {
+			t.Errorf("rank()[%d] = %s, want %s", i, m.Date, want[i])
+		}
+	}
+
+	if got := rank([]float32{1, 0}, rows, "", 1); len(got) != 1 || got[0].Date != "20261018" {
+		t.Errorf("rank() with k=1 = %+v", got)
+	}
+}
+
+func TestIndexRelatedSearch(t *testing.T) {
+	ctx := context.Background()
+	queries := dbtest.New(t)
+	e := aiclient.LocalEmbedder{Dim: 128}
+	uid := "u1"
+
+	diaries := map[string]string{
+		"20261001": "회사 프로젝트 마감 때문에 너무 지쳤다",
+		"20261002": "회사 프로젝트 회의가 길어서 지쳤다",
+		"20261003": "바다에 가서 수영했다",
+	}
+	for date, content := range diaries {
+		if _, err := queries.UpsertDiaryContent(ctx, db.UpsertDiaryContentParams{Uid: uid, Content: content, Date: date}); err != nil {
+			t.Fatal(err)
+		}
+		if err := Index(ctx, queries, e, uid, date); err != nil {
+			t.Fatalf("Index(%s) error = %v", date, err)
+		}
+	}
+
+	related, err := Related(ctx, queries, e, uid, "20261001", RelatedLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(related) == 0 || related[0].Date != "20261002" {
+		t.Errorf("Related() = %+v, want 20261002 first", related)
+	}
+	for _, m := range related {
+		if m.Date == "20261001" {
+			t.Error("Related() should not include the diary itself")
+		}
+	}
+
+	found, err := Search(ctx, queries, e, uid, "프로젝트 때문에 지쳤어", SearchLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) < 2 || found[0].Date == "20261003" {
+		t.Errorf("Search() = %+v", found)
+	}
+
+	// 다른 모델로 만든 임베딩은 비교하지 않는다.
+	if got, _ := Related(ctx, queries, aiclient.LocalEmbedder{Dim: 64}, uid, "20261001", RelatedLimit); len(got) != 0 {
+		t.Errorf("Related() with other model = %+v", got)
+	}
+
+	if _, err := queries.UpsertDiaryContent(ctx, db.UpsertDiaryContentParams{Uid: uid, Content: "", Date: "20261003"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Index(ctx, queries, e, uid, "20261003"); err != nil {
+		t.Fatal(err)
+	}
+	diary, _ := queries.GetDiary(ctx, db.GetDiaryParams{Date: "20261003", Uid: uid})
+	if _, err := queries.GetDiaryEmbedding(ctx, diary.ID); err != sql.ErrNoRows {
+		t.Errorf("embedding of emptied diary should be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package habit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+)
+
+// Streak은 연속 작성 일수를 담는다.
+type Streak struct {
+	Current int
+	Longest int
+	// WrittenToday는 사용자 시간대 기준 오늘 일기를 작성했는지 나타낸다.
+	WrittenToday bool
+}
+
+// Summary는 작성 습관 요약 정보를 담는다.
+type Summary struct {
+	Today        string
+	Streak       Streak
+	Total        int
+	MonthlyGoal  int
+	MonthlyCount int
+	Achievements []Achievement
+}
+
+// Achievement는 사용자에게 표시할 배지 정보를 담는다.
+type Achievement struct {
+	Code     string
+	Title    string
+	Icon     string
+	Achieved bool
+	Date     string
+}
+
+// achievementRule은 배지 획득 조건을 정의한다.
+type achievementRule struct {
+	code    string
+	title   string
+	icon    string
+	reached func(s Summary) bool
+}
+
+var achievementRules = []achievementRule{
+	{code: "first_diary", title: "첫 일기", icon: "edit_note", reached: func(s Summary) bool { return s.Total >= 1 }},
+	{code: "streak_7", title: "7일 연속", icon: "local_fire_department", reached: func(s Summary) bool { return s.Streak.Longest >= 7 }},
+	{code: "streak_30", title: "30일 연속", icon: "whatshot", reached: func(s Summary) bool { return s.Streak.Longest >= 30 }},
+	{code: "streak_100", title: "100일 연속", icon: "military_tech", reached: func(s Summary) bool { return s.Streak.Longest >= 100 }},
+	{code: "total_100", title: "일기 100편", icon: "auto_stories", reached: func(s Summary) bool { return s.Total >= 100 }},
+	{code: "total_365", title: "일기 365편", icon: "workspace_premium", reached: func(s Summary) bool { return s.Total >= 365 }},
+	{code: "monthly_goal", title: "월간 목표 달성", icon: "flag", reached: func(s Summary) bool {
+		return s.MonthlyGoal > 0 && s.MonthlyCount >= s.MonthlyGoal
+	}},
+}
+
+// ComputeStreak은 내림차순으로 정렬된 작성 날짜로 현재/최장 연속 일수를 계산한다.
+// 오늘 아직 작성하지 않았더라도 어제까지 이어졌다면 현재 연속 기록은 유지된 것으로 본다.
+func ComputeStreak(dates []string, today string) Streak {
+	var streak Streak
+	if len(dates) == 0 {
+		return streak
+	}
+
+	todayTime, err := time.Parse(dateutil.DateFormatYYYYMMDD, today)
+	if err != nil {
+		return streak
+	}
+
+	var prev time.Time
+	run := 0
+	inCurrent := true
+	for _, date := range dates {
+		t, err := time.Parse(dateutil.DateFormatYYYYMMDD, date)
+		if err != nil || t.After(todayTime) {
+			continue
+		}
+		if !prev.IsZero() && prev.Equal(t) {
+			continue
+		}
+
+		switch {
+		case prev.IsZero():
+			run = 1
+			inCurrent = todayTime.Sub(t) <= 24*time.Hour
+		case prev.AddDate(0, 0, -1).Equal(t):
+			run++
+		default:
+			run = 1
+			inCurrent = false
+		}
+
+		if inCurrent {
+			streak.Current = run
+		}
+		if t.Equal(todayTime) {
+			streak.WrittenToday = true
+		}
+		if run > streak.Longest {
+			streak.Longest = run
+		}
+		prev = t
+	}
+
+	return streak
+}
+
+// LoadSummary는 사용자 설정 시간대 기준으로 작성 습관 요약을 계산한다.
+func LoadSummary(ctx context.Context, queries *db.Queries, uid string) (Summary, error) {
+	setting, err := queries.GetUserSetting(ctx, uid)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Summary{}, fmt.Errorf("사용자 설정 조회 실패: %w", err)
+	}
+
+	today := deariodate.TodayIn(setting.Timezone)
+	dates, err := queries.ListDiaryWrittenDates(ctx, uid)
+	if err != nil {
+		return Summary{}, fmt.Errorf("작성 날짜 조회 실패: %w", err)
+	}
+
+	monthStart := today[:6] + "01"
+	monthlyCount, err := queries.CountDiaryBetween(ctx, db.CountDiaryBetweenParams{
+		Uid:       uid,
+		StartDate: monthStart,
+		EndDate:   today[:6] + "31",
+	})
+	if err != nil {
+		return Summary{}, fmt.Errorf("월간 작성 수 조회 실패: %w", err)
+	}
+
+	summary := Summary{
+		Today:        today,
+		Streak:       ComputeStreak(dates, today),
+		Total:        len(dates),
+		MonthlyGoal:  int(setting.MonthlyGoal),
+		MonthlyCount: int(monthlyCount),
+	}
+
+	stored, err := queries.ListUserAchievements(ctx, uid)
+	if err != nil {
+		return Summary{}, fmt.Errorf("배지 조회 실패: %w", err)
+	}
+	summary.Achievements = mergeAchievements(stored)
+
+	return summary, nil
+}
+
+// RefreshAchievements는 새로 달성한 배지를 저장하고 요약을 반환한다.
+func RefreshAchievements(ctx context.Context, queries *db.Queries, uid string) (Summary, error) {
+	summary, err := LoadSummary(ctx, queries, uid)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	for i, rule := range achievementRules {
+		if summary.Achievements[i].Achieved || !rule.reached(summary) {
+			continue
+		}
+		if err := queries.InsertUserAchievement(ctx, db.InsertUserAchievementParams{
+			Uid:          uid,
+			Code:         rule.code,
+			AchievedDate: summary.Today,
+		}); err != nil {
+			return Summary{}, fmt.Errorf("배지 저장 실패: %w", err)
+		}
+		summary.Achievements[i].Achieved = true
+		summary.Achievements[i].Date = summary.Today
+	}
+
+	return summary, nil
+}
+
+// GoalPercent는 월간 목표 달성률을 0~100 사이로 반환한다.
+func (s Summary) GoalPercent() int {
+	if s.MonthlyGoal <= 0 {
+		return 0
+	}
+	percent := s.MonthlyCount * 100 / s.MonthlyGoal
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+// mergeAchievements는 배지 정의 순서대로 저장된 획득 정보를 합친다.
+func mergeAchievements(stored []db.UserAchievement) []Achievement {
+	achievedDates := make(map[string]string, len(stored))
+	for _, a := range stored {
+		achievedDates[a.Code] = a.AchievedDate
+	}
+
+	result := make([]Achievement, 0, len(achievementRules))
+	for _, rule := range achievementRules {
+		date, ok := achievedDates[rule.code]
+		result = append(result, Achievement{
+			Code:     rule.code,
+			Title:    rule.title,
+			Icon:     rule.icon,
+			Achieved: ok,
+			Date:     date,
+		})
+	}
+	return result
+}
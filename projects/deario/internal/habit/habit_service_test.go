@@ -0,0 +1,57 @@
+package habit
+
+import "testing"
+
+func TestComputeStreak(t *testing.T) {
+	tests := []struct {
+		name  string
+		dates []string
+		today string
+		want  Streak
+	}{
+		{
+			name:  "empty",
+			dates: nil,
+			today: "20250110",
+			want:  Streak{},
+		},
+		{
+			name:  "written today",
+			dates: []string{"20250110", "20250109", "20250108"},
+			today: "20250110",
+			want:  Streak{Current: 3, Longest: 3, WrittenToday: true},
+		},
+		{
+			name:  "not yet written today keeps streak",
+			dates: []string{"20250109", "20250108"},
+			today: "20250110",
+			want:  Streak{Current: 2, Longest: 2},
+		},
+		{
+			name:  "broken streak",
+			dates: []string{"20250107", "20250106", "20250105", "20250104"},
+			today: "20250110",
+			want:  Streak{Current: 0, Longest: 4},
+		},
+		{
+			name:  "longest in the past",
+			dates: []string{"20250110", "20250101", "20241231", "20241230"},
+			today: "20250110",
+			want:  Streak{Current: 1, Longest: 3, WrittenToday: true},
+		},
+		{
+			name:  "future and duplicated dates ignored",
+			dates: []string{"20250111", "20250110", "20250110", "20250109"},
+			today: "20250110",
+			want:  Streak{Current: 2, Longest: 2, WrittenToday: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeStreak(tt.dates, tt.today); got != tt.want {
+				t.Fatalf("ComputeStreak() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	aiclient "simple-server/internal/ai"
 	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/webhook"
 	"strings"
 	"sync"
 	"time"
@@ -115,21 +116,10 @@ func GenerateAIReportJob() {
 		uid := string(m)
 		slog.Info("AI 리포트 생성 작업을 시작합니다.", "uid", uid)
 
-		var allDiaries []db.Diary
-		// 한 페이지에 7개의 일기를 가져오므로, 5페이지를 조회하여 최대 35개의 최근 일기를 가져옴
-		for page := 1; page <= 5; page++ {
-			// sqlc의 ListDiarys는 page 파라미터를 interface{}로 받으므로 page를 직접 전달
-			diaries, err := queries.ListDiarys(ctx, db.ListDiarysParams{Uid: uid, Column2: int64(page)})
-			if err != nil {
-				slog.Error("일기 목록 조회 실패", "uid", uid, "page", page, "error", err)
-				// 한 페이지 실패 시 다음 페이지 시도
-				continue
-			}
-			if len(diaries) == 0 {
-				// 더 이상 가져올 일기가 없으면 중단
-				break
-			}
-			allDiaries = append(allDiaries, diaries...)
+		// 최근 날짜 순으로 최대 35개의 일기를 가져옴
+		allDiaries, err := queries.ListDiarys(ctx, db.ListDiarysParams{Uid: uid, PageSize: 35})
+		if err != nil {
+			return fmt.Errorf("일기 목록 조회 실패: %w", err)
 		}
 
 		if len(allDiaries) == 0 {
@@ -155,6 +145,7 @@ func GenerateAIReportJob() {
 		// 생성된 리포트는 일단 로그로 출력합니다.
 		// TODO: 향후 이 부분에 이메일 발송 또는 DB 저장 로직을 추가할 수 있습니다.
 		slog.Info("AI 리포트 생성 성공", "uid", uid, "report", report)
+		webhook.Emit(ctx, queries, uid, webhook.EventReportGenerated, webhook.ReportData{Report: report, DiaryCount: len(allDiaries)})
 
 		return nil
 	})
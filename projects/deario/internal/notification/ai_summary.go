@@ -0,0 +1,117 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+)
+
+// SummaryPeriod는 정기 AI 요약의 주기다.
+type SummaryPeriod string
+
+const (
+	SummaryWeekly  SummaryPeriod = "weekly"
+	SummaryMonthly SummaryPeriod = "monthly"
+)
+
+// summaryTime은 사용자 시간대 기준 정기 요약을 만드는 시각이다.
+// 주간 요약은 월요일, 월간 요약은 매월 1일에 지난 기간을 요약한다.
+const summaryTime = "08:00"
+
+// summaryMinEntries는 요약을 만들기 위한 최소 일기 수다. 이보다 적으면 건너뛴다.
+var summaryMinEntries = map[SummaryPeriod]int{
+	SummaryWeekly:  3,
+	SummaryMonthly: 8,
+}
+
+// Label은 화면과 알림에 쓰는 주기 이름이다.
+func (p SummaryPeriod) Label() string {
+	switch p {
+	case SummaryWeekly:
+		return "주간"
+	case SummaryMonthly:
+		return "월간"
+	default:
+		return string(p)
+	}
+}
+
+// dueSummaryPeriods는 사용자 시간대의 local 시각에 만들어야 할 요약 주기를 반환한다.
+func dueSummaryPeriods(local time.Time, weekly, monthly bool) []SummaryPeriod {
+	if local.Format("15:04") != summaryTime {
+		return nil
+	}
+
+	var periods []SummaryPeriod
+	if weekly && local.Weekday() == time.Monday {
+		periods = append(periods, SummaryWeekly)
+	}
+	if monthly && local.Day() == 1 {
+		periods = append(periods, SummaryMonthly)
+	}
+	return periods
+}
+
+// summaryRange는 local 날짜 직전에 끝난 주(월~일) 또는 달의 시작일과 끝일을 YYYYMMDD로 반환한다.
+func summaryRange(period SummaryPeriod, local time.Time) (string, string) {
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	var start, end time.Time
+	switch period {
+	case SummaryMonthly:
+		first := day.AddDate(0, 0, 1-day.Day())
+		start, end = first.AddDate(0, -1, 0), first.AddDate(0, 0, -1)
+	default:
+		monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		start, end = monday.AddDate(0, 0, -7), monday.AddDate(0, 0, -1)
+	}
+	return start.Format(dateutil.DateFormatYYYYMMDD), end.Format(dateutil.DateFormatYYYYMMDD)
+}
+
+// buildSummaryPrompt는 기간 안의 일기로 정기 요약 프롬프트를 만든다.
+func buildSummaryPrompt(period SummaryPeriod, startDate, endDate string, diaries []db.ListDiariesInRangeRow) string {
+	var entries strings.Builder
+	for _, diary := range diaries {
+		if strings.TrimSpace(diary.Content) == "" {
+			continue
+		}
+		fmt.Fprintf(&entries, "날짜: %s\n기분: %s\n내용: %s\n---\n", diary.Date, diary.Mood, diary.Content)
+	}
+	if entries.Len() == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(`당신은 사용자의 일기를 함께 읽어주는 다정한 친구 '디어'입니다. 아래는 사용자가 %s부터 %s까지 쓴 일기입니다.
+이 기간을 돌아보는 %s 요약을 친근한 존댓말과 마크다운으로 작성해주세요. 일기에 없는 내용은 지어내지 말고, 진단하거나 평가하지 마세요.
+기분은 1(아주 좋음)부터 5(아주 나쁨)까지의 값이며 0은 기록하지 않은 날입니다.
+
+**일기:**
+---
+%s
+---
+
+**작성 구조:**
+
+### 한 줄 요약
+(이 기간을 한 문장으로 정리해주세요.)
+
+#### 마음의 흐름
+(기분과 감정이 어떻게 흘러갔는지 2~3문장으로 짚어주세요.)
+
+#### 자주 떠올린 것들
+(자주 등장한 사람, 일, 고민을 3개 이내의 목록으로 정리해주세요.)
+
+#### 다음 %s을 위한 한마디
+(스스로를 칭찬할 점과 작게 실천해볼 만한 제안을 한두 문장으로 전해주세요.)`,
+		dateutil.MustFormatDateKor(startDate), dateutil.MustFormatDateKor(endDate), period.Label(), entries.String(), periodNoun(period))
+}
+
+func periodNoun(period SummaryPeriod) string {
+	if period == SummaryMonthly {
+		return "한 달"
+	}
+	return "한 주"
+}
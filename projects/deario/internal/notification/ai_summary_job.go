@@ -0,0 +1,202 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	aiclient "simple-server/internal/ai"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/internal/webhook"
+
+	"github.com/robfig/cron/v3"
+	"maragu.dev/goqite"
+	"maragu.dev/goqite/jobs"
+)
+
+var summaryQ *goqite.Queue
+var summaryQOnce sync.Once
+var errSummaryQ error
+
+type summaryMessage struct {
+	UID       string        `json:"uid"`
+	Period    SummaryPeriod `json:"period"`
+	StartDate string        `json:"startDate"`
+	EndDate   string        `json:"endDate"`
+}
+
+func InitAISummaryQueue() error {
+	summaryQOnce.Do(func() {
+		summaryDB, err := db.GetDB(false)
+		if err != nil {
+			errSummaryQ = fmt.Errorf("AI 요약 큐 데이터베이스 연결 실패: %w", err)
+			return
+		}
+
+		summaryQ = goqite.New(goqite.NewOpts{
+			DB:   summaryDB,
+			Name: "ai-summary",
+		})
+	})
+
+	return errSummaryQ
+}
+
+// AISummaryCron은 정기 요약을 켠 사용자의 시간대에 맞춰 지난 주, 지난 달 요약 작업을 넣는다.
+func AISummaryCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@every 1m", func() {
+		ctx := context.Background()
+		now := time.Now()
+
+		targets, err := queries.ListSummaryTargets(ctx)
+		if err != nil {
+			slog.Error("AI 요약 대상 조회 실패", "error", err)
+			return
+		}
+
+		for _, target := range targets {
+			local := now.In(deariodate.Location(target.Timezone))
+			for _, period := range dueSummaryPeriods(local, target.WeeklySummary == 1, target.MonthlySummary == 1) {
+				start, end := summaryRange(period, local)
+				if err := enqueueAISummary(ctx, summaryMessage{
+					UID:       target.Uid,
+					Period:    period,
+					StartDate: start,
+					EndDate:   end,
+				}); err != nil {
+					slog.Error("AI 요약 작업 등록 실패", "uid", target.Uid, "period", period, "error", err)
+				}
+			}
+		}
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
+
+func enqueueAISummary(ctx context.Context, msg summaryMessage) error {
+	if err := InitAISummaryQueue(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = jobs.Create(ctx, summaryQ, "ai-summary", goqite.Message{Body: b})
+	return err
+}
+
+// GenerateAISummaryJob은 기간 안의 일기를 날짜 범위로 조회해 요약을 만들고 저장한 뒤 푸시로 알린다.
+// 이미 만든 기간이거나 일기가 summaryMinEntries보다 적으면 건너뛴다.
+func GenerateAISummaryJob() {
+	if err := InitAISummaryQueue(); err != nil {
+		slog.Error("AI 요약 큐 초기화 실패", "error", err)
+		return
+	}
+
+	r := jobs.NewRunner(jobs.NewRunnerOpts{
+		Limit:        1,
+		Log:          slog.Default(),
+		PollInterval: 1 * time.Second,
+		Extend:       5 * time.Minute,
+		Queue:        summaryQ,
+	})
+
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 객체 생성 실패", "error", err)
+		return
+	}
+
+	r.Register("ai-summary", func(ctx context.Context, m []byte) error {
+		var msg summaryMessage
+		if err := json.Unmarshal(m, &msg); err != nil {
+			slog.Error("AI 요약 작업 해독 실패", "error", err)
+			return nil
+		}
+
+		count, err := queries.CountAISummariesForPeriod(ctx, db.CountAISummariesForPeriodParams{
+			Uid:       msg.UID,
+			Period:    string(msg.Period),
+			StartDate: msg.StartDate,
+		})
+		if err != nil {
+			return fmt.Errorf("AI 요약 조회 실패: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+
+		diaries, err := queries.ListDiariesInRange(ctx, db.ListDiariesInRangeParams{
+			Uid:       msg.UID,
+			StartDate: msg.StartDate,
+			EndDate:   msg.EndDate,
+		})
+		if err != nil {
+			return fmt.Errorf("일기 조회 실패: %w", err)
+		}
+		if len(diaries) < summaryMinEntries[msg.Period] {
+			slog.Info("일기가 적어 AI 요약을 건너뜁니다.", "uid", msg.UID, "period", msg.Period, "diary_count", len(diaries))
+			return nil
+		}
+
+		prompt := buildSummaryPrompt(msg.Period, msg.StartDate, msg.EndDate, diaries)
+		if prompt == "" {
+			return nil
+		}
+		content, err := aiclient.Request(ctx, prompt, "gemini-2.5-pro")
+		if err != nil {
+			slog.Error("AI 요약 생성 실패", "uid", msg.UID, "period", msg.Period, "error", err)
+			return err
+		}
+
+		if _, err := queries.UpsertAISummary(ctx, db.UpsertAISummaryParams{
+			Uid:        msg.UID,
+			Period:     string(msg.Period),
+			StartDate:  msg.StartDate,
+			EndDate:    msg.EndDate,
+			Content:    content,
+			DiaryCount: int64(len(diaries)),
+		}); err != nil {
+			return fmt.Errorf("AI 요약 저장 실패: %w", err)
+		}
+		slog.Info("AI 요약 생성 성공", "uid", msg.UID, "period", msg.Period, "diary_count", len(diaries))
+		webhook.Emit(ctx, queries, msg.UID, webhook.EventReportGenerated, webhook.ReportData{Report: content, DiaryCount: len(diaries)})
+
+		pushSummary(ctx, queries, msg)
+		return nil
+	})
+
+	r.Start(context.Background())
+}
+
+// pushSummary는 요약이 만들어졌다고 알린다. 알림을 끈 사용자에게는 보내지 않는다.
+func pushSummary(ctx context.Context, queries *db.Queries, msg summaryMessage) {
+	setting, err := queries.GetUserSetting(ctx, msg.UID)
+	if err != nil || setting.IsPush != 1 || setting.PushToken == "" {
+		return
+	}
+	if err := InitPushQueue(); err != nil {
+		slog.Error("푸시 큐 초기화 실패", "error", err)
+		return
+	}
+
+	b, _ := json.Marshal(Payload{
+		Title: fmt.Sprintf("%s 일기 요약", msg.Period.Label()),
+		Body:  fmt.Sprintf("지난 %s의 일기 요약이 도착했어요. 통계 화면에서 확인해보세요.", periodNoun(msg.Period)),
+		Token: setting.PushToken,
+	})
+	if _, err := jobs.Create(ctx, pushQ, "send", goqite.Message{Body: b}); err != nil {
+		slog.Error("푸시 발송 실패", "error", err)
+	}
+}
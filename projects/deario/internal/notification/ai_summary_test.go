@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"simple-server/projects/deario/db"
+)
+
+func TestDueSummaryPeriods(t *testing.T) {
+	kst := time.FixedZone("KST", 9*60*60)
+	tests := []struct {
+		name    string
+		local   time.Time
+		weekly  bool
+		monthly bool
+		want    []SummaryPeriod
+	}{
+		{name: "monday morning", local: time.Date(2026, 10, 19, 8, 0, 0, 0, kst), weekly: true, monthly: true, want: []SummaryPeriod{SummaryWeekly}},
+		{name: "monday other minute", local: time.Date(2026, 10, 19, 8, 1, 0, 0, kst), weekly: true, monthly: true, want: nil},
+		{name: "weekly off", local: time.Date(2026, 10, 19, 8, 0, 0, 0, kst), weekly: false, monthly: true, want: nil},
+		{name: "first of month", local: time.Date(2026, 11, 1, 8, 0, 0, 0, kst), weekly: true, monthly: true, want: []SummaryPeriod{SummaryMonthly}},
+		{name: "monday first of month", local: time.Date(2027, 2, 1, 8, 0, 0, 0, kst), weekly: true, monthly: true, want: []SummaryPeriod{SummaryWeekly, SummaryMonthly}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dueSummaryPeriods(tt.local, tt.weekly, tt.monthly); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dueSummaryPeriods() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		period    SummaryPeriod
+		local     time.Time
+		wantStart string
+		wantEnd   string
+	}{
+		{name: "weekly on monday", period: SummaryWeekly, local: time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC), wantStart: "20261012", wantEnd: "20261018"},
+		{name: "weekly on sunday", period: SummaryWeekly, local: time.Date(2026, 10, 25, 8, 0, 0, 0, time.UTC), wantStart: "20261012", wantEnd: "20261018"},
+		{name: "weekly across year", period: SummaryWeekly, local: time.Date(2027, 1, 4, 8, 0, 0, 0, time.UTC), wantStart: "20261228", wantEnd: "20270103"},
+		{name: "monthly", period: SummaryMonthly, local: time.Date(2026, 11, 1, 8, 0, 0, 0, time.UTC), wantStart: "20261001", wantEnd: "20261031"},
+		{name: "monthly february", period: SummaryMonthly, local: time.Date(2028, 3, 1, 8, 0, 0, 0, time.UTC), wantStart: "20280201", wantEnd: "20280229"},
+		{name: "monthly january", period: SummaryMonthly, local: time.Date(2027, 1, 1, 8, 0, 0, 0, time.UTC), wantStart: "20261201", wantEnd: "20261231"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := summaryRange(tt.period, tt.local)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("summaryRange() = %s~%s, want %s~%s", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestBuildSummaryPrompt(t *testing.T) {
+	if got := buildSummaryPrompt(SummaryWeekly, "20261012", "20261018", []db.ListDiariesInRangeRow{{Date: "20261012", Content: "  "}}); got != "" {
+		t.Errorf("buildSummaryPrompt() with blank diaries = %q, want empty", got)
+	}
+
+	got := buildSummaryPrompt(SummaryMonthly, "20261001", "20261031", []db.ListDiariesInRangeRow{{Date: "20261003", Content: "산책을 했다", Mood: "2"}})
+	for _, want := range []string{"2026년 10월 01일", "2026년 10월 31일", "월간", "산책을 했다", "한 달"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("buildSummaryPrompt() missing %q", want)
+		}
+	}
+}
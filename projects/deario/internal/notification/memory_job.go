@@ -0,0 +1,80 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+
+	"github.com/robfig/cron/v3"
+	"maragu.dev/goqite"
+	"maragu.dev/goqite/jobs"
+)
+
+// memoryPushTime은 사용자 시간대 기준 추억 알림 시각이다.
+const memoryPushTime = "09:00"
+
+// MemoryPushCron은 지난 해 같은 날 작성한 일기가 있는 사용자에게 추억 알림을 보낸다.
+func MemoryPushCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@every 1m", func() {
+		ctx := context.Background()
+		now := time.Now()
+
+		targets, err := queries.ListMemoryPushTargets(ctx)
+		if err != nil {
+			slog.Error("추억 알림 대상 조회 실패", "error", err)
+			return
+		}
+
+		for _, target := range targets {
+			if now.In(deariodate.Location(target.Timezone)).Format("15:04") != memoryPushTime {
+				continue
+			}
+
+			today := deariodate.TodayIn(target.Timezone)
+			monthDay, extraMonthDay := deariodate.MonthDayKeys(today)
+			memories, err := queries.ListDiariesOnThisDay(ctx, db.ListDiariesOnThisDayParams{
+				Uid:           target.Uid,
+				MonthDay:      sql.NullString{String: monthDay, Valid: true},
+				ExtraMonthDay: sql.NullString{String: extraMonthDay, Valid: true},
+				BeforeDate:    today[:4] + "0101",
+			})
+			if err != nil {
+				slog.Error("추억 일기 조회 실패", "uid", target.Uid, "error", err)
+				continue
+			}
+			if len(memories) == 0 {
+				continue
+			}
+
+			if err := InitPushQueue(); err != nil {
+				slog.Error("푸시 큐 초기화 실패", "error", err)
+				return
+			}
+
+			payload := Payload{
+				Title: "지난 해 오늘",
+				Body:  fmt.Sprintf("지난 해 오늘 작성한 일기가 %d편 있어요. 그날의 기록을 다시 읽어볼까요?", len(memories)),
+				Token: target.PushToken,
+			}
+			b, _ := json.Marshal(payload)
+
+			if _, err := jobs.Create(ctx, pushQ, "send", goqite.Message{Body: b}); err != nil {
+				slog.Error("푸시 발송 실패", "error", err)
+			}
+		}
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
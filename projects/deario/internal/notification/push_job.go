@@ -44,6 +44,20 @@ func InitPushQueue() error {
 	return errPushQ
 }
 
+// EnqueuePush는 푸시 한 건을 발송 큐에 넣는다.
+func EnqueuePush(ctx context.Context, payload Payload) error {
+	if err := InitPushQueue(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = jobs.Create(ctx, pushQ, "send", goqite.Message{Body: b})
+	return err
+}
+
 func PushSendCron(c *cron.Cron) {
 	queries, err := db.GetQueries(false)
 	if err != nil {
@@ -0,0 +1,79 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/internal/habit"
+
+	"github.com/robfig/cron/v3"
+	"maragu.dev/goqite"
+	"maragu.dev/goqite/jobs"
+)
+
+// streakNudgeTime은 사용자 시간대 기준 연속 기록 알림 시각이다.
+const streakNudgeTime = "21:00"
+
+// StreakNudgeCron은 오늘 아직 작성하지 않아 연속 기록이 끊기려는 사용자에게 알림을 보낸다.
+func StreakNudgeCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@every 1m", func() {
+		ctx := context.Background()
+		now := time.Now()
+
+		targets, err := queries.ListStreakNudgeTargets(ctx)
+		if err != nil {
+			slog.Error("연속 기록 알림 대상 조회 실패", "error", err)
+			return
+		}
+
+		for _, target := range targets {
+			localNow := now.In(deariodate.Location(target.Timezone))
+			if localNow.Format("15:04") != streakNudgeTime {
+				continue
+			}
+
+			dates, err := queries.ListDiaryWrittenDates(ctx, target.Uid)
+			if err != nil {
+				slog.Error("작성 날짜 조회 실패", "uid", target.Uid, "error", err)
+				continue
+			}
+			streak := habit.ComputeStreak(dates, deariodate.TodayIn(target.Timezone))
+			if streak.WrittenToday || streak.Current < 2 {
+				continue
+			}
+
+			if err := InitPushQueue(); err != nil {
+				slog.Error("푸시 큐 초기화 실패", "error", err)
+				return
+			}
+
+			payload := Payload{
+				Title: "연속 기록 알림",
+				Body:  streakNudgeBody(streak.Current),
+				Token: target.PushToken,
+			}
+			b, _ := json.Marshal(payload)
+
+			if _, err := jobs.Create(ctx, pushQ, "send", goqite.Message{Body: b}); err != nil {
+				slog.Error("푸시 발송 실패", "error", err)
+			}
+		}
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
+
+func streakNudgeBody(current int) string {
+	return fmt.Sprintf("지금까지 %d일 연속으로 기록했어요. 오늘 한 줄만 남겨도 이어갈 수 있어요!", current)
+}
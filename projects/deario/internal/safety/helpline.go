@@ -0,0 +1,79 @@
+package safety
+
+import "strings"
+
+// Helpline은 위기 상황에서 안내하는 상담 연락처다.
+type Helpline struct {
+	Name        string
+	Number      string
+	Description string
+}
+
+var helplinesByCountry = map[string][]Helpline{
+	"KR": {
+		{Name: "자살예방상담전화", Number: "109", Description: "24시간 무료 상담"},
+		{Name: "정신건강위기상담전화", Number: "1577-0199", Description: "24시간 정신건강 상담"},
+		{Name: "청소년상담전화", Number: "1388", Description: "청소년 고민 상담"},
+		{Name: "긴급 신고", Number: "112 · 119", Description: "위급한 상황일 때"},
+	},
+	"JP": {
+		{Name: "いのちの電話", Number: "0570-783-556", Description: "자살 예방 상담"},
+		{Name: "よりそいホットライン", Number: "0120-279-338", Description: "24시간 무료 상담"},
+		{Name: "긴급 신고", Number: "110 · 119", Description: "위급한 상황일 때"},
+	},
+	"US": {
+		{Name: "988 Suicide & Crisis Lifeline", Number: "988", Description: "전화 또는 문자, 24시간"},
+		{Name: "긴급 신고", Number: "911", Description: "위급한 상황일 때"},
+	},
+	"GB": {
+		{Name: "Samaritans", Number: "116 123", Description: "24시간 무료 상담"},
+		{Name: "긴급 신고", Number: "999", Description: "위급한 상황일 때"},
+	},
+	"DE": {
+		{Name: "TelefonSeelsorge", Number: "0800 111 0 111", Description: "24시간 무료 상담"},
+		{Name: "긴급 신고", Number: "112", Description: "위급한 상황일 때"},
+	},
+	"SG": {
+		{Name: "Samaritans of Singapore", Number: "1767", Description: "24시간 상담"},
+		{Name: "긴급 신고", Number: "995", Description: "위급한 상황일 때"},
+	},
+	"AU": {
+		{Name: "Lifeline", Number: "13 11 14", Description: "24시간 상담"},
+		{Name: "긴급 신고", Number: "000", Description: "위급한 상황일 때"},
+	},
+}
+
+// fallbackHelplines는 시간대로 나라를 알 수 없을 때 안내한다.
+var fallbackHelplines = []Helpline{
+	{Name: "Find A Helpline", Number: "findahelpline.com", Description: "나라별 무료 상담 연락처 찾기"},
+	{Name: "긴급 신고", Number: "현지 긴급 번호", Description: "위급한 상황일 때"},
+}
+
+var countryByTimezone = map[string]string{
+	"Asia/Seoul":          "KR",
+	"Asia/Tokyo":          "JP",
+	"Asia/Singapore":      "SG",
+	"Europe/London":       "GB",
+	"Europe/Berlin":       "DE",
+	"America/New_York":    "US",
+	"America/Chicago":     "US",
+	"America/Denver":      "US",
+	"America/Phoenix":     "US",
+	"America/Los_Angeles": "US",
+	"America/Anchorage":   "US",
+	"Pacific/Honolulu":    "US",
+}
+
+// Helplines는 사용자 시간대로 나라를 짐작해 상담 연락처를 반환한다. 시간대가 없으면 한국 연락처를 쓴다.
+func Helplines(timezone string) []Helpline {
+	if timezone == "" {
+		return helplinesByCountry["KR"]
+	}
+	if country, ok := countryByTimezone[timezone]; ok {
+		return helplinesByCountry[country]
+	}
+	if strings.HasPrefix(timezone, "Australia/") {
+		return helplinesByCountry["AU"]
+	}
+	return fallbackHelplines
+}
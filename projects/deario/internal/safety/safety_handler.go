@@ -0,0 +1,42 @@
+package safety
+
+import (
+	"context"
+
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HelplinesPanel은 사용자 시간대에 맞는 상담 연락처 목록을 렌더링한다.
+func HelplinesPanel(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	return components.SafetyHelplines(HelplineItems(UserHelplines(ctx, queries, uid))).Render(ctx, c.Response().Writer)
+}
+
+// UserHelplines는 사용자 설정의 시간대로 상담 연락처를 고른다. 설정이 없으면 한국 연락처를 쓴다.
+func UserHelplines(ctx context.Context, queries *db.Queries, uid string) []Helpline {
+	setting, _ := queries.GetUserSetting(ctx, uid)
+	return Helplines(setting.Timezone)
+}
+
+// HelplineItems는 상담 연락처를 화면에 쓰는 형태로 바꾼다.
+func HelplineItems(helplines []Helpline) []components.SafetyHelpline {
+	items := make([]components.SafetyHelpline, 0, len(helplines))
+	for _, h := range helplines {
+		items = append(items, components.SafetyHelpline(h))
+	}
+	return items
+}
@@ -0,0 +1,219 @@
+package safety
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	aiclient "simple-server/internal/ai"
+	"simple-server/internal/config"
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+)
+
+// Level은 글에서 감지한 위기 표현의 정도다.
+type Level string
+
+const (
+	LevelNone    Level = "none"
+	LevelConcern Level = "concern"
+	LevelCrisis  Level = "crisis"
+)
+
+// Source는 감지를 실행한 곳이다. 운영 통계를 나눠 보는 데 쓴다.
+type Source string
+
+const (
+	SourceSave     Source = "save"
+	SourceFeedback Source = "feedback"
+	SourceChat     Source = "chat"
+)
+
+// EventName은 저장한 일기에서 위기 표현을 감지했을 때 HX-Trigger로 보내는 이벤트다.
+const EventName = "deario-safety"
+
+// crisisTerms는 자해나 자살을 직접 떠올리게 하는 표현이다. 띄어쓰기를 지운 소문자로 적는다.
+var crisisTerms = []string{
+	"죽고싶", "죽고만싶", "죽어버리고싶", "죽는게낫", "죽을까생각", "자살", "극단적선택", "목숨을끊", "목숨끊",
+	"스스로목숨", "살고싶지않", "살기싫", "살아서뭐해", "살이유가없", "사라지고싶", "없어지고싶", "세상을떠나고싶",
+	"삶을끝내", "생을마감", "자해", "손목을긋", "손목긋", "유서", "뛰어내리고싶", "뛰어내릴까",
+	"suicide", "killmyself", "wanttodie", "selfharm", "endmylife",
+}
+
+// concernTerms는 위기까지는 아니어도 마음이 많이 지쳐 있음을 보여주는 표현이다.
+var concernTerms = []string{
+	"우울", "공허", "무기력", "희망이없", "버틸수없", "버티기힘들", "견딜수없", "아무도없", "나같은건",
+	"쓸모없", "외롭", "외로워", "숨막혀", "숨이막혀", "잠을못자", "다포기", "포기하고싶",
+	"depressed", "hopeless", "worthless",
+}
+
+// excludedTerms는 위기 표현과 글자가 겹치지만 뜻이 다른 관용 표현이다. 비교 전에 지운다.
+var excludedTerms = []string{
+	"자살골", "자살특공", "웃겨죽", "웃겨서죽", "배고파죽", "더워죽", "추워죽", "졸려죽", "죽을만큼맛있", "귀여워죽",
+}
+
+// Assess는 규칙 사전으로 글의 위기 표현을 판단한다. 띄어쓰기와 문장 부호는 무시한다.
+func Assess(content string) Level {
+	text := normalize(content)
+	for _, term := range excludedTerms {
+		text = strings.ReplaceAll(text, term, "")
+	}
+	for _, term := range crisisTerms {
+		if strings.Contains(text, term) {
+			return LevelCrisis
+		}
+	}
+	for _, term := range concernTerms {
+		if strings.Contains(text, term) {
+			return LevelConcern
+		}
+	}
+	return LevelNone
+}
+
+func normalize(content string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(content) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Classify는 규칙 사전으로 먼저 판단하고, 애매한 concern 단계이면서 SAFETY_AI_CHECK=true면 AI에게 한 번 더 묻는다.
+// 규칙이 crisis로 본 글은 AI 답과 상관없이 crisis로 둔다. AI 요청이 실패하면 규칙 판단을 그대로 쓴다.
+// 자동 저장으로 같은 글을 여러 번 확인하므로 AI 답은 캐시해 둔다.
+func Classify(ctx context.Context, content string) Level {
+	level := Assess(content)
+	if level != LevelConcern || config.GetEnv("SAFETY_AI_CHECK") != "true" {
+		return level
+	}
+
+	answer, err := aiclient.CachedRequest(ctx, fmt.Sprintf(`아래 글에 자해나 자살을 생각하는 표현이 있는지 판단해줘.
+자해나 자살 생각이 드러나면 crisis, 힘든 마음만 드러나면 concern, 둘 다 아니면 none 중 한 단어로만 답해줘.
+
+글:
+%s`, content))
+	if err != nil {
+		slog.Warn("위기 표현 AI 확인 실패", "error", err)
+		return level
+	}
+	return parseAICheck(answer, level)
+}
+
+func parseAICheck(answer string, fallback Level) Level {
+	switch Level(strings.ToLower(strings.Trim(strings.TrimSpace(answer), ".`'\""))) {
+	case LevelCrisis:
+		return LevelCrisis
+	case LevelConcern:
+		return LevelConcern
+	case LevelNone:
+		return LevelNone
+	default:
+		return fallback
+	}
+}
+
+// PromptGuidance는 일기요정 프롬프트 끝에 덧붙일 안내다. 감지한 것이 없으면 빈 문자열이다.
+// 위기 단계면 요청한 응답 방식보다 이 안내를 먼저 따르게 한다.
+func PromptGuidance(level Level, helplines []Helpline) string {
+	switch level {
+	case LevelCrisis:
+		var lines []string
+		for _, h := range helplines {
+			lines = append(lines, fmt.Sprintf("%s %s", h.Name, h.Number))
+		}
+		return fmt.Sprintf(`
+중요: 사용자가 지금 매우 힘든 상태일 수 있어. 요청한 응답 방식보다 아래를 먼저 지켜줘.
+- 판단하거나 충고하지 말고, 지금 느끼는 마음을 있는 그대로 받아줘
+- 혼자 감당하지 않아도 된다고 말하고, 믿을 수 있는 사람이나 전문 상담에 연락해보길 부드럽게 권해줘
+- 연락처가 필요하면 다음만 안내해줘: %s
+- 방법이나 수단, 농담, 가벼운 제안은 절대 말하지 마
+`, strings.Join(lines, ", "))
+	case LevelConcern:
+		return `
+참고: 사용자가 많이 지쳐 있을 수 있어. 조언보다 공감을 먼저 건네고, 스스로를 탓하지 않도록 부드럽게 말해줘.
+`
+	default:
+		return ""
+	}
+}
+
+var (
+	eventCounts = expvar.NewMap("safety_event_count")
+
+	recordedMu  sync.Mutex
+	recordedDay string
+	recorded    = make(map[string]bool)
+)
+
+// Record는 위기 표현을 감지한 횟수를 날짜, 출처, 단계별로 센다. 누가 썼는지는 남기지 않는다.
+// 자동 저장처럼 같은 사용자가 하루에 여러 번 보내도 출처와 단계마다 한 번만 센다.
+func Record(ctx context.Context, queries *db.Queries, source Source, level Level, uid string) {
+	if level == LevelNone {
+		return
+	}
+
+	day := time.Now().UTC().Format(dateutil.DateFormatYYYYMMDD)
+	if !firstToday(day, uid+"\x00"+string(source)+"\x00"+string(level)) {
+		return
+	}
+
+	eventCounts.Add(string(source)+"."+string(level), 1)
+	if err := queries.IncrementSafetyEvent(ctx, db.IncrementSafetyEventParams{
+		Day:    day,
+		Source: string(source),
+		Level:  string(level),
+	}); err != nil {
+		slog.Error("위기 표현 통계 저장 실패", "error", err)
+	}
+}
+
+// firstToday는 오늘 처음 보는 key인지 확인한다. key는 해시로만 들고 있다가 날짜가 바뀌면 비운다.
+func firstToday(day, key string) bool {
+	sum := sha256.Sum256([]byte(key))
+	hashed := hex.EncodeToString(sum[:])
+
+	recordedMu.Lock()
+	defer recordedMu.Unlock()
+	if recordedDay != day {
+		recordedDay = day
+		recorded = make(map[string]bool)
+	}
+	if recorded[hashed] {
+		return false
+	}
+	recorded[hashed] = true
+	return true
+}
+
+// PublishEventCounts는 최근 30일의 위기 표현 감지 횟수를 /debug/vars의 safety_events로 보여준다.
+func PublishEventCounts() {
+	expvar.Publish("safety_events", expvar.Func(func() any {
+		queries, err := db.GetQueries(false)
+		if err != nil {
+			return nil
+		}
+		since := time.Now().UTC().AddDate(0, 0, -30).Format(dateutil.DateFormatYYYYMMDD)
+		rows, err := queries.ListSafetyEvents(context.Background(), since)
+		if err != nil {
+			return nil
+		}
+		out := make(map[string]map[string]int64)
+		for _, row := range rows {
+			if out[row.Day] == nil {
+				out[row.Day] = make(map[string]int64)
+			}
+			out[row.Day][row.Source+"."+row.Level] = row.Count
+		}
+		return out
+	}))
+}
@@ -0,0 +1,94 @@
+package safety
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssess(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Level
+	}{
+		{name: "plain", content: "오늘은 친구와 카페에 갔다.", want: LevelNone},
+		{name: "crisis", content: "요즘은 그냥 죽고 싶다는 생각만 든다", want: LevelCrisis},
+		{name: "crisis spacing", content: "사라지고 싶어. 정말로.", want: LevelCrisis},
+		{name: "crisis punctuation", content: "살...기 싫다", want: LevelCrisis},
+		{name: "english", content: "I want to die", want: LevelCrisis},
+		{name: "concern", content: "하루 종일 무기력하고 외롭다", want: LevelConcern},
+		{name: "idiom", content: "영화가 웃겨 죽는 줄 알았다", want: LevelNone},
+		{name: "sports idiom", content: "축구 보다가 자살골 나와서 속상했다", want: LevelNone},
+		{name: "idiom with crisis", content: "배고파 죽겠다. 그래도 살기 싫다", want: LevelCrisis},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Assess(tt.content); got != tt.want {
+				t.Errorf("Assess(%q) = %s, want %s", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAICheck(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   Level
+	}{
+		{answer: "crisis", want: LevelCrisis},
+		{answer: " None.\n", want: LevelNone},
+		{answer: "`concern`", want: LevelConcern},
+		{answer: "잘 모르겠어요", want: LevelConcern},
+	}
+	for _, tt := range tests {
+		if got := parseAICheck(tt.answer, LevelConcern); got != tt.want {
+			t.Errorf("parseAICheck(%q) = %s, want %s", tt.answer, got, tt.want)
+		}
+	}
+}
+
+func TestPromptGuidance(t *testing.T) {
+	if got := PromptGuidance(LevelNone, nil); got != "" {
+		t.Errorf("PromptGuidance(none) = %q", got)
+	}
+	crisis := PromptGuidance(LevelCrisis, Helplines("Asia/Seoul"))
+	if !strings.Contains(crisis, "자살예방상담전화 109") {
+		t.Errorf("PromptGuidance(crisis) should include helplines: %q", crisis)
+	}
+	if PromptGuidance(LevelConcern, nil) == "" {
+		t.Error("PromptGuidance(concern) should not be empty")
+	}
+}
+
+func TestHelplines(t *testing.T) {
+	tests := []struct {
+		timezone string
+		want     string
+	}{
+		{timezone: "", want: "109"},
+		{timezone: "Asia/Seoul", want: "109"},
+		{timezone: "America/Los_Angeles", want: "988"},
+		{timezone: "Australia/Sydney", want: "13 11 14"},
+		{timezone: "Asia/Shanghai", want: "findahelpline.com"},
+	}
+	for _, tt := range tests {
+		if got := Helplines(tt.timezone); len(got) == 0 || got[0].Number != tt.want {
+			t.Errorf("Helplines(%q) = %+v, want first number %s", tt.timezone, got, tt.want)
+		}
+	}
+}
+
+func TestFirstToday(t *testing.T) {
+	if !firstToday("20261019", "a") {
+		t.Error("first call should be true")
+	}
+	if firstToday("20261019", "a") {
+		t.Error("repeated call on the same day should be false")
+	}
+	if !firstToday("20261019", "b") {
+		t.Error("other key should be true")
+	}
+	if !firstToday("20261020", "a") {
+		t.Error("next day should be true")
+	}
+}
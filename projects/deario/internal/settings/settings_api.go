@@ -0,0 +1,150 @@
+package settings
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"simple-server/internal/validate"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/apitoken"
+
+	"github.com/labstack/echo/v4"
+)
+
+type settingsResponse struct {
+	IsPush      int64  `json:"is_push"`
+	PushTime    string `json:"push_time"`
+	RandomRange int64  `json:"random_range"`
+	MonthlyGoal int64  `json:"monthly_goal"`
+	MemoryPush  int64  `json:"memory_push"`
+	Timezone    string `json:"timezone"`
+	// WeeklySummary, MonthlySummary는 정기 AI 요약 수신 여부다.
+	WeeklySummary  int64 `json:"weekly_summary"`
+	MonthlySummary int64 `json:"monthly_summary"`
+	KeepVoiceAudio int64 `json:"keep_voice_audio"`
+	// AppLockForceLogout은 PIN을 거듭 틀렸을 때 모든 기기에서 로그아웃시킬지 여부다.
+	AppLockForceLogout int64 `json:"app_lock_force_logout"`
+}
+
+// patchSettingsDTO는 API로 바꿀 설정 항목이다. 보내지 않은 항목은 저장된 값을 그대로 둔다.
+// 새 설정이 생겨도 그 항목을 모르는 예전 클라이언트가 값을 기본값으로 되돌리지 않게 모든 항목을 포인터로 받는다.
+type patchSettingsDTO struct {
+	IsPush             *int64  `json:"is_push" validate:"omitempty,oneof=0 1" message:"알림 설정 값이 올바르지 않습니다."`
+	PushTime           *string `json:"push_time" validate:"omitempty,datetime=15:04" message:"알림 시간이 올바르지 않습니다."`
+	RandomRange        *int64  `json:"random_range" validate:"omitempty,min=0,max=3650" message:"랜덤일자 범위가 올바르지 않습니다."`
+	MonthlyGoal        *int64  `json:"monthly_goal" validate:"omitempty,min=0,max=31" message:"월간 목표는 0~31 사이로 입력해주세요."`
+	MemoryPush         *int64  `json:"memory_push" validate:"omitempty,oneof=0 1" message:"추억 알림 설정 값이 올바르지 않습니다."`
+	Timezone           *string `json:"timezone" validate:"omitempty,timezone" message:"시간대가 올바르지 않습니다."`
+	WeeklySummary      *int64  `json:"weekly_summary" validate:"omitempty,oneof=0 1" message:"주간 요약 설정 값이 올바르지 않습니다."`
+	MonthlySummary     *int64  `json:"monthly_summary" validate:"omitempty,oneof=0 1" message:"월간 요약 설정 값이 올바르지 않습니다."`
+	KeepVoiceAudio     *int64  `json:"keep_voice_audio" validate:"omitempty,oneof=0 1" message:"음성 원본 보관 설정 값이 올바르지 않습니다."`
+	AppLockForceLogout *int64  `json:"app_lock_force_logout" validate:"omitempty,oneof=0 1" message:"강제 로그아웃 설정 값이 올바르지 않습니다."`
+}
+
+// apply는 저장된 설정에 보낸 항목만 덮어써 저장할 설정 전체를 만든다.
+func (p patchSettingsDTO) apply(s db.UserSetting) updateSettingsDTO {
+	pick := func(v *int64, current int64) int64 {
+		if v != nil {
+			return *v
+		}
+		return current
+	}
+	dto := updateSettingsDTO{
+		IsPush:             pick(p.IsPush, s.IsPush),
+		PushTime:           s.PushTime,
+		RandomRange:        &s.RandomRange,
+		MonthlyGoal:        &s.MonthlyGoal,
+		MemoryPush:         pick(p.MemoryPush, s.MemoryPush),
+		Timezone:           s.Timezone,
+		WeeklySummary:      pick(p.WeeklySummary, s.WeeklySummary),
+		MonthlySummary:     pick(p.MonthlySummary, s.MonthlySummary),
+		KeepVoiceAudio:     pick(p.KeepVoiceAudio, s.KeepVoiceAudio),
+		AppLockForceLogout: pick(p.AppLockForceLogout, s.AppLockForceLogout),
+	}
+	if p.PushTime != nil {
+		dto.PushTime = *p.PushTime
+	}
+	if p.RandomRange != nil {
+		dto.RandomRange = p.RandomRange
+	}
+	if p.MonthlyGoal != nil {
+		dto.MonthlyGoal = p.MonthlyGoal
+	}
+	if p.Timezone != nil {
+		dto.Timezone = *p.Timezone
+	}
+	return dto
+}
+
+// APIGetSettings는 토큰 소유자의 설정을 JSON으로 반환한다.
+func APIGetSettings(c echo.Context) error {
+	uid, err := apitoken.UID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	return renderSettingsJSON(c, queries, uid)
+}
+
+// APIUpdateSettings는 JSON 본문에 있는 설정 항목만 바꾼다. 빠진 항목은 저장된 값을 유지한다.
+func APIUpdateSettings(c echo.Context) error {
+	uid, err := apitoken.UID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto patchSettingsDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	setting, err := queries.GetUserSetting(ctx, uid)
+	if errors.Is(err, sql.ErrNoRows) {
+		setting = defaultUserSetting(uid)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "사용자 설정을 가져오지 못했습니다.")
+	}
+
+	if err := saveSettings(ctx, queries, uid, dto.apply(setting)); err != nil {
+		return err
+	}
+
+	return renderSettingsJSON(c, queries, uid)
+}
+
+func renderSettingsJSON(c echo.Context, queries *db.Queries, uid string) error {
+	setting, err := queries.GetUserSetting(c.Request().Context(), uid)
+	if errors.Is(err, sql.ErrNoRows) {
+		setting = defaultUserSetting(uid)
+	} else if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "사용자 설정을 가져오지 못했습니다.")
+	}
+
+	return c.JSON(http.StatusOK, settingsResponse{
+		IsPush:             setting.IsPush,
+		PushTime:           setting.PushTime,
+		RandomRange:        setting.RandomRange,
+		MonthlyGoal:        setting.MonthlyGoal,
+		MemoryPush:         setting.MemoryPush,
+		Timezone:           setting.Timezone,
+		WeeklySummary:      setting.WeeklySummary,
+		MonthlySummary:     setting.MonthlySummary,
+		KeepVoiceAudio:     setting.KeepVoiceAudio,
+		AppLockForceLogout: setting.AppLockForceLogout,
+	})
+}
@@ -0,0 +1,35 @@
+package settings
+
+import (
+	"testing"
+
+	"simple-server/projects/deario/db"
+)
+
+func TestPatchSettingsApply(t *testing.T) {
+	saved := db.UserSetting{
+		Uid:                "user-1",
+		IsPush:             1,
+		PushTime:           "21:00",
+		RandomRange:        100,
+		MonthlyGoal:        20,
+		MemoryPush:         1,
+		Timezone:           "Asia/Tokyo",
+		WeeklySummary:      1,
+		KeepVoiceAudio:     1,
+		AppLockForceLogout: 1,
+	}
+	off := int64(0)
+	pushTime := "08:30"
+
+	got := patchSettingsDTO{IsPush: &off, PushTime: &pushTime}.apply(saved)
+
+	if got.IsPush != 0 || got.PushTime != "08:30" {
+		t.Errorf("보낸 항목 = %d %q, want 0 \"08:30\"", got.IsPush, got.PushTime)
+	}
+	// 보내지 않은 항목은 저장된 값을 유지해야 한다.
+	if *got.RandomRange != 100 || *got.MonthlyGoal != 20 || got.MemoryPush != 1 || got.Timezone != "Asia/Tokyo" ||
+		got.WeeklySummary != 1 || got.MonthlySummary != 0 || got.KeepVoiceAudio != 1 || got.AppLockForceLogout != 1 {
+		t.Errorf("보내지 않은 항목이 바뀌었다: %+v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package settings
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"net/http"
@@ -17,6 +18,16 @@ type updateSettingsDTO struct {
 	IsPush      int64  `form:"is_push" json:"is_push" validate:"oneof=0 1" message:"알림 설정 값이 올바르지 않습니다."`
 	PushTime    string `form:"push_time" json:"push_time" validate:"omitempty,datetime=15:04" message:"알림 시간이 올바르지 않습니다."`
 	RandomRange *int64 `form:"random_range" json:"random_range" validate:"omitempty,min=0,max=3650" message:"랜덤일자 범위가 올바르지 않습니다."`
+	MonthlyGoal *int64 `form:"monthly_goal" json:"monthly_goal" validate:"omitempty,min=0,max=31" message:"월간 목표는 0~31 사이로 입력해주세요."`
+	MemoryPush  int64  `form:"memory_push" json:"memory_push" validate:"oneof=0 1" message:"추억 알림 설정 값이 올바르지 않습니다."`
+	Timezone    string `form:"timezone" json:"timezone" validate:"omitempty,timezone" message:"시간대가 올바르지 않습니다."`
+	// WeeklySummary, MonthlySummary는 정기 AI 요약 수신 여부다.
+	WeeklySummary  int64 `form:"weekly_summary" json:"weekly_summary" validate:"oneof=0 1" message:"주간 요약 설정 값이 올바르지 않습니다."`
+	MonthlySummary int64 `form:"monthly_summary" json:"monthly_summary" validate:"oneof=0 1" message:"월간 요약 설정 값이 올바르지 않습니다."`
+	// KeepVoiceAudio는 음성 일기의 녹음 원본을 보관할지 여부다.
+	KeepVoiceAudio int64 `form:"keep_voice_audio" json:"keep_voice_audio" validate:"oneof=0 1" message:"음성 원본 보관 설정 값이 올바르지 않습니다."`
+	// AppLockForceLogout은 PIN을 거듭 틀렸을 때 모든 기기에서 로그아웃시킬지 여부다.
+	AppLockForceLogout int64 `form:"app_lock_force_logout" json:"app_lock_force_logout" validate:"oneof=0 1" message:"강제 로그아웃 설정 값이 올바르지 않습니다."`
 }
 
 func defaultUserSetting(uid string) db.UserSetting {
@@ -25,6 +36,7 @@ func defaultUserSetting(uid string) db.UserSetting {
 		IsPush:      0,
 		PushTime:    "",
 		RandomRange: 365,
+		Timezone:    "Asia/Seoul",
 	}
 }
 
@@ -80,12 +92,33 @@ func UpdateSettings(c echo.Context) error {
 		return err
 	}
 
+	if err := saveSettings(c.Request().Context(), queries, uid, dto); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// saveSettings는 설정 화면과 API에서 받은 설정을 한 트랜잭션으로 저장한다.
+// 중간에 실패하면 일부 항목만 바뀐 채로 남지 않는다.
+func saveSettings(ctx context.Context, queries *db.Queries, uid string, dto updateSettingsDTO) error {
+	conn, err := db.GetDB()
+	if err != nil {
+		return err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "사용자 설정 저장 실패")
+	}
+	defer func() { _ = tx.Rollback() }()
+	queries = queries.WithTx(tx)
+
 	randomRange := int64(365)
 	if dto.RandomRange != nil {
 		randomRange = *dto.RandomRange
 	}
 
-	if err := queries.UpsertUserSetting(c.Request().Context(), db.UpsertUserSettingParams{
+	if err := queries.UpsertUserSetting(ctx, db.UpsertUserSettingParams{
 		Uid:         uid,
 		IsPush:      dto.IsPush,
 		PushTime:    dto.PushTime,
@@ -94,5 +127,54 @@ func UpdateSettings(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "사용자 설정 저장 실패")
 	}
 
-	return c.NoContent(http.StatusNoContent)
+	monthlyGoal := int64(0)
+	if dto.MonthlyGoal != nil {
+		monthlyGoal = *dto.MonthlyGoal
+	}
+	timezone := dto.Timezone
+	if timezone == "" {
+		timezone = "Asia/Seoul"
+	}
+
+	if err := queries.UpdateWritingGoal(ctx, db.UpdateWritingGoalParams{
+		MonthlyGoal: monthlyGoal,
+		Timezone:    timezone,
+		Uid:         uid,
+	}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "작성 목표 저장 실패")
+	}
+
+	if err := queries.UpdateMemoryPush(ctx, db.UpdateMemoryPushParams{
+		MemoryPush: dto.MemoryPush,
+		Uid:        uid,
+	}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "추억 알림 설정 저장 실패")
+	}
+
+	if err := queries.UpdateSummarySettings(ctx, db.UpdateSummarySettingsParams{
+		WeeklySummary:  dto.WeeklySummary,
+		MonthlySummary: dto.MonthlySummary,
+		Uid:            uid,
+	}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "AI 요약 설정 저장 실패")
+	}
+
+	if err := queries.UpdateVoiceSettings(ctx, db.UpdateVoiceSettingsParams{
+		KeepVoiceAudio: dto.KeepVoiceAudio,
+		Uid:            uid,
+	}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "음성 일기 설정 저장 실패")
+	}
+
+	if err := queries.UpdateAppLockForceLogout(ctx, db.UpdateAppLockForceLogoutParams{
+		AppLockForceLogout: dto.AppLockForceLogout,
+		Uid:                uid,
+	}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "앱 잠금 설정 저장 실패")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "사용자 설정 저장 실패")
+	}
+	return nil
 }
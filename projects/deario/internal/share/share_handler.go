@@ -0,0 +1,378 @@
+package share
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"simple-server/internal/validate"
+	"simple-server/pkg/util/authutil"
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/views/components"
+	"simple-server/projects/deario/views/pages"
+
+	"github.com/labstack/echo/v4"
+	"github.com/robfig/cron/v3"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// shareRetention은 만료되거나 폐기된 공유 링크 기록을 남겨 두는 기간이다.
+const shareRetention = 30 * 24 * time.Hour
+
+type createShareDTO struct {
+	Date          string `form:"date" validate:"required,len=8,numeric" message:"날짜 형식이 올바르지 않습니다."`
+	ExpiresDays   int    `form:"expires_days" validate:"required" message:"유효 기간을 골라주세요."`
+	PIN           string `form:"pin" validate:"omitempty,numeric,min=4,max=8" message:"PIN은 숫자 4~8자리로 입력해주세요."`
+	IncludeAI     string `form:"include_ai"`
+	IncludeImages string `form:"include_images"`
+}
+
+type unlockShareDTO struct {
+	PIN string `form:"pin" validate:"required,numeric,min=4,max=8" message:"PIN은 숫자 4~8자리로 입력해주세요."`
+}
+
+// ShareForm은 일기 화면의 공유 대화상자에 들어갈 링크 생성 폼을 렌더링한다.
+func ShareForm(c echo.Context) error {
+	date := c.QueryParam("date")
+	if date == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "날짜가 필요합니다.")
+	}
+	return components.DiaryShareForm(date, ExpiryDays, DefaultExpiryDays).Render(c.Request().Context(), c.Response().Writer)
+}
+
+// CreateShare는 일기 한 편을 읽기 전용으로 볼 수 있는 공유 링크를 만든다.
+func CreateShare(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto createShareDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+	if !ValidExpiryDays(dto.ExpiresDays) {
+		return echo.NewHTTPError(http.StatusBadRequest, "유효 기간이 올바르지 않습니다.")
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if _, err := queries.GetDiary(ctx, db.GetDiaryParams{Date: dto.Date, Uid: uid}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "저장된 일기가 있어야 공유할 수 있습니다.")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "일기를 가져오지 못했습니다.")
+	}
+
+	now := time.Now()
+	count, err := queries.CountActiveDiaryShares(ctx, db.CountActiveDiarySharesParams{
+		Uid: uid,
+		Now: now.UTC().Format(dateutil.DateFormatISOTime),
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "공유 링크 목록을 가져오지 못했습니다.")
+	}
+	if count >= MaxActiveShares {
+		return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("공유 링크는 최대 %d개까지 열어 둘 수 있습니다. 쓰지 않는 링크를 설정에서 폐기해주세요.", MaxActiveShares))
+	}
+
+	var pinHash string
+	if dto.PIN != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(dto.PIN), bcrypt.DefaultCost)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, "PIN 저장 준비에 실패했습니다.")
+		}
+		pinHash = string(hashed)
+	}
+
+	s, err := queries.CreateDiaryShare(ctx, db.CreateDiaryShareParams{
+		Uid:           uid,
+		Date:          dto.Date,
+		PinHash:       pinHash,
+		IncludeAi:     boolToInt(dto.IncludeAI == "1"),
+		IncludeImages: boolToInt(dto.IncludeImages == "1"),
+		Expires:       ExpiresAt(now, dto.ExpiresDays),
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "공유 링크를 만들지 못했습니다.")
+	}
+
+	setting, _ := queries.GetUserSetting(ctx, uid)
+	expires := deariodate.LocalTime(s.Expires, deariodate.Location(setting.Timezone))
+	return components.ShareCreated(shareURL(c, s), expires).Render(ctx, c.Response().Writer)
+}
+
+// SharesPanel은 설정 화면의 공유 링크 목록을 렌더링한다.
+func SharesPanel(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	return renderShares(c, queries, uid)
+}
+
+// RevokeShare는 공유 링크를 폐기한다. 폐기한 링크는 다시 열 수 없다.
+func RevokeShare(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	count, err := queries.RevokeDiaryShare(c.Request().Context(), db.RevokeDiaryShareParams{ID: c.Param("id"), Uid: uid})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "공유 링크 폐기에 실패했습니다.")
+	}
+	if count == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "공유 링크를 찾을 수 없습니다.")
+	}
+
+	return renderShares(c, queries, uid)
+}
+
+// SharedDiaryPage는 공유 링크로 들어온 방문자에게 읽기 전용 일기를 보여준다.
+// 로그인 없이 열리는 페이지이므로 링크가 올바르지 않거나 만료, 폐기된 경우 모두 같은 404로 응답한다.
+// PIN이 걸린 링크는 PIN 입력 폼만 보여주고, 조회 수는 일기를 실제로 보여줄 때만 센다.
+func SharedDiaryPage(c echo.Context) error {
+	setPublicHeaders(c)
+	token := c.Param("token")
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	s, err := loadShare(ctx, queries, token)
+	if err != nil {
+		return err
+	}
+	if s.PinHash != "" {
+		return pages.SharedDiary(components.SharedDiaryPINForm(token)).Render(ctx, c.Response().Writer)
+	}
+
+	view, err := sharedDiaryView(ctx, queries, s)
+	if err != nil {
+		return err
+	}
+	return pages.SharedDiary(components.SharedDiary(view)).Render(ctx, c.Response().Writer)
+}
+
+// UnlockSharedDiary는 공유 링크의 PIN을 확인하고 일기를 보여준다.
+// MaxPINFailures번 연속으로 틀리면 PINLockWindow 동안 링크를 잠그고, 모두 MaxPINAttempts번 틀리면 링크를 폐기한다.
+func UnlockSharedDiary(c echo.Context) error {
+	setPublicHeaders(c)
+	token := c.Param("token")
+
+	var dto unlockShareDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	s, err := loadShare(ctx, queries, token)
+	if err != nil {
+		return err
+	}
+	if s.PinHash == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "PIN이 필요 없는 링크입니다.")
+	}
+
+	now := time.Now()
+	attempt, ok, err := ReservePIN(ctx, queries, s.ID, now)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "PIN을 확인하지 못했습니다.")
+	}
+	if !ok {
+		return pinRefused(ctx, queries, s.ID, now)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(s.PinHash), []byte(dto.PIN)) != nil {
+		if attempt.PinAttempts >= MaxPINAttempts {
+			if _, err := queries.RevokeDiaryShare(ctx, db.RevokeDiaryShareParams{ID: s.ID, Uid: s.Uid}); err != nil {
+				slog.Error("공유 링크 폐기 실패", "share", s.ID, "error", err)
+			}
+			return errSharePINExhausted
+		}
+		if attempt.LockedUntil != "" {
+			return echo.NewHTTPError(http.StatusTooManyRequests, fmt.Sprintf("PIN을 여러 번 틀렸습니다. %d분 후 다시 시도해주세요.", int(PINLockWindow.Minutes())))
+		}
+		return echo.NewHTTPError(http.StatusUnauthorized, "PIN이 올바르지 않습니다.")
+	}
+
+	view, err := sharedDiaryView(ctx, queries, s)
+	if err != nil {
+		return err
+	}
+	return components.SharedDiary(view).Render(ctx, c.Response().Writer)
+}
+
+// PruneSharesCron은 만료되거나 폐기된 지 오래된 공유 링크를 매일 정리한다.
+func PruneSharesCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@daily", func() {
+		before := time.Now().UTC().Add(-shareRetention).Format(dateutil.DateFormatISOTime)
+		count, err := queries.PruneDiaryShares(context.Background(), before)
+		if err != nil {
+			slog.Error("공유 링크 정리 실패", "error", err)
+			return
+		}
+		slog.Info("공유 링크 정리", "count", count)
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
+
+var (
+	errShareNotFound     = echo.NewHTTPError(http.StatusNotFound, "공유 링크를 찾을 수 없거나 만료되었습니다.")
+	errSharePINExhausted = echo.NewHTTPError(http.StatusGone, "PIN을 너무 여러 번 틀려 링크를 막았습니다. 공유한 사람에게 새 링크를 요청해주세요.")
+)
+
+// pinRefused는 PIN 시도를 예약하지 못한 이유를 다시 읽어 알려준다.
+func pinRefused(ctx context.Context, queries *db.Queries, id string, now time.Time) error {
+	s, err := queries.GetDiaryShare(ctx, id)
+	if err != nil {
+		return errShareNotFound
+	}
+	if s.PinAttempts >= MaxPINAttempts {
+		return errSharePINExhausted
+	}
+	if s.Revoked != "" {
+		return errShareNotFound
+	}
+	minutes := int(LockRemaining(s, now).Minutes()) + 1
+	return echo.NewHTTPError(http.StatusTooManyRequests, fmt.Sprintf("PIN을 여러 번 틀렸습니다. %d분 후 다시 시도해주세요.", minutes))
+}
+
+// loadShare는 토큰의 서명과 유효 기간을 확인하고 공유 정보를 반환한다.
+func loadShare(ctx context.Context, queries *db.Queries, token string) (db.DiaryShare, error) {
+	id, err := ParseToken(token)
+	if err != nil {
+		return db.DiaryShare{}, errShareNotFound
+	}
+	s, err := queries.GetDiaryShare(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.DiaryShare{}, errShareNotFound
+		}
+		return db.DiaryShare{}, echo.NewHTTPError(http.StatusInternalServerError, "공유 링크를 확인하지 못했습니다.")
+	}
+	if !Verify(secretKey(), token, s) || !Active(s, time.Now()) {
+		return db.DiaryShare{}, errShareNotFound
+	}
+	return s, nil
+}
+
+// sharedDiaryView는 공유 설정에 맞춰 보여줄 일기 내용을 고르고 조회 수를 기록한다.
+func sharedDiaryView(ctx context.Context, queries *db.Queries, s db.DiaryShare) (components.SharedDiaryView, error) {
+	d, err := queries.GetDiary(ctx, db.GetDiaryParams{Date: s.Date, Uid: s.Uid})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return components.SharedDiaryView{}, errShareNotFound
+		}
+		return components.SharedDiaryView{}, echo.NewHTTPError(http.StatusInternalServerError, "일기를 가져오지 못했습니다.")
+	}
+
+	view := components.SharedDiaryView{Date: d.Date, Content: d.Content, Mood: d.Mood}
+	if s.IncludeAi == 1 {
+		view.AIFeedback = d.AiFeedback
+		view.AIImage = d.AiImage
+	}
+	if s.IncludeImages == 1 {
+		for _, u := range []string{d.ImageUrl1, d.ImageUrl2, d.ImageUrl3} {
+			if u != "" {
+				view.Images = append(view.Images, u)
+			}
+		}
+	}
+
+	if err := queries.RecordDiaryShareView(ctx, s.ID); err != nil {
+		slog.Error("공유 링크 조회 기록 실패", "share", s.ID, "error", err)
+	}
+	return view, nil
+}
+
+func renderShares(c echo.Context, queries *db.Queries, uid string) error {
+	ctx := c.Request().Context()
+	shares, err := queries.ListActiveDiaryShares(ctx, db.ListActiveDiarySharesParams{
+		Uid: uid,
+		Now: time.Now().UTC().Format(dateutil.DateFormatISOTime),
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "공유 링크 목록을 가져오지 못했습니다.")
+	}
+
+	setting, _ := queries.GetUserSetting(ctx, uid)
+	loc := deariodate.Location(setting.Timezone)
+	items := make([]components.ShareItem, 0, len(shares))
+	for _, s := range shares {
+		items = append(items, components.ShareItem{
+			ID:            s.ID,
+			Date:          s.Date,
+			URL:           shareURL(c, s),
+			HasPIN:        s.PinHash != "",
+			IncludeAI:     s.IncludeAi == 1,
+			IncludeImages: s.IncludeImages == 1,
+			ViewCount:     s.ViewCount,
+			LastViewed:    deariodate.LocalTime(s.LastViewed, loc),
+			Expires:       deariodate.LocalTime(s.Expires, loc),
+		})
+	}
+
+	return components.Shares(items).Render(ctx, c.Response().Writer)
+}
+
+// shareURL은 공유 링크의 전체 주소다. 토큰은 서명으로 다시 만들 수 있어 DB에 저장하지 않는다.
+func shareURL(c echo.Context, s db.DiaryShare) string {
+	return c.Scheme() + "://" + c.Request().Host + "/share/" + Sign(secretKey(), s.ID, s.Expires)
+}
+
+// setPublicHeaders는 공유 페이지가 검색 엔진, 리퍼러, 캐시로 새어 나가지 않도록 막는다.
+func setPublicHeaders(c echo.Context) {
+	h := c.Response().Header()
+	h.Set("X-Robots-Tag", "noindex, nofollow")
+	h.Set("Referrer-Policy", "no-referrer")
+	h.Set("Cache-Control", "no-store")
+}
+
+func boolToInt(v bool) int64 {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package share
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"simple-server/internal/config"
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+)
+
+const (
+	// MaxActiveShares는 사용자당 동시에 열어 둘 수 있는 공유 링크 수다.
+	MaxActiveShares = 20
+	// DefaultExpiryDays는 공유 링크의 기본 유효 기간이다.
+	DefaultExpiryDays = 7
+	// MaxPINFailures는 링크를 잠그기 전까지 허용하는 연속 PIN 실패 횟수다.
+	MaxPINFailures = 5
+	// PINLockWindow는 PIN을 여러 번 틀렸을 때 링크를 잠그는 시간이다.
+	PINLockWindow = 15 * time.Minute
+	// MaxPINAttempts는 PIN을 맞히기 전까지 허용하는 총 시도 횟수다. 다 쓰면 링크를 폐기한다.
+	// 잠금만으로는 하루 480번씩 시도해 네 자리 PIN을 유효 기간 안에 모두 맞혀 볼 수 있다.
+	MaxPINAttempts = 20
+)
+
+// ExpiryDays는 공유 링크를 만들 때 고를 수 있는 유효 기간(일)이다.
+var ExpiryDays = []int{1, 7, 30}
+
+var ErrInvalidToken = errors.New("공유 링크가 올바르지 않습니다")
+
+// secretKey는 링크 서명 키다. 따로 설정하지 않으면 세션 비밀 값을 쓴다.
+func secretKey() []byte {
+	return []byte(config.GetEnvOrDefault("SHARE_SECRET", config.GetEnv("SESSION_SECRET")))
+}
+
+// Sign은 공유 id와 만료 시각을 서명한 링크 토큰을 만든다.
+// 만료 시각을 함께 서명하므로 토큰만 바꿔서는 유효 기간을 늘릴 수 없다.
+func Sign(key []byte, id, expires string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + expires))
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:18])
+}
+
+// ParseToken은 토큰에서 공유 id를 꺼낸다. 서명은 DB에서 공유 정보를 읽은 뒤 Verify로 확인한다.
+func ParseToken(token string) (string, error) {
+	id, sig, ok := strings.Cut(token, ".")
+	if !ok || id == "" || sig == "" || strings.Contains(sig, ".") {
+		return "", ErrInvalidToken
+	}
+	return id, nil
+}
+
+// Verify는 토큰이 공유 정보로 만든 서명과 같은지 확인한다.
+func Verify(key []byte, token string, s db.DiaryShare) bool {
+	return hmac.Equal([]byte(token), []byte(Sign(key, s.ID, s.Expires)))
+}
+
+// ValidExpiryDays는 고를 수 있는 유효 기간인지 확인한다.
+func ValidExpiryDays(days int) bool {
+	return slices.Contains(ExpiryDays, days)
+}
+
+// ExpiresAt은 지금부터 days일 뒤의 만료 시각을 DB 형식(UTC)으로 반환한다.
+func ExpiresAt(now time.Time, days int) string {
+	return now.UTC().AddDate(0, 0, days).Format(dateutil.DateFormatISOTime)
+}
+
+// Active는 링크가 폐기되지 않았고 만료되지 않았는지 확인한다.
+func Active(s db.DiaryShare, now time.Time) bool {
+	if s.Revoked != "" {
+		return false
+	}
+	expires, err := time.ParseInLocation(dateutil.DateFormatISOTime, s.Expires, time.UTC)
+	return err == nil && now.Before(expires)
+}
+
+// LockRemaining은 PIN을 여러 번 틀려 잠긴 링크의 남은 잠금 시간이다.
+func LockRemaining(s db.DiaryShare, now time.Time) time.Duration {
+	lockedUntil, err := time.ParseInLocation(dateutil.DateFormatISOTime, s.LockedUntil, time.UTC)
+	if err != nil || !now.Before(lockedUntil) {
+		return 0
+	}
+	return lockedUntil.Sub(now)
+}
+
+// ReservePIN은 PIN을 비교하기 전에 시도 한 번을 실패로 먼저 기록한다.
+// 한 문장으로 세고 잠그므로 동시에 들어온 요청도 MaxPINFailures번째 시도가 예약되는 순간 링크가 잠긴다.
+// 잠겨 있거나 시도를 다 써서 예약하지 못하면 false를 반환한다. PIN이 맞으면 조회 기록이 횟수를 되돌린다.
+func ReservePIN(ctx context.Context, queries *db.Queries, id string, now time.Time) (db.DiaryShare, bool, error) {
+	s, err := queries.ReserveDiarySharePIN(ctx, db.ReserveDiarySharePINParams{
+		MaxFailures: MaxPINFailures,
+		LockUntil:   now.UTC().Add(PINLockWindow).Format(dateutil.DateFormatISOTime),
+		ID:          id,
+		Now:         now.UTC().Format(dateutil.DateFormatISOTime),
+		MaxAttempts: MaxPINAttempts,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.DiaryShare{}, false, nil
+	}
+	if err != nil {
+		return db.DiaryShare{}, false, err
+	}
+	return s, true, nil
+}
@@ -0,0 +1,200 @@
+package share
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/db/dbtest"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	key := []byte("secret")
+	s := db.DiaryShare{ID: "s123", Expires: "2026-10-26 12:00:00"}
+	token := Sign(key, s.ID, s.Expires)
+	tampered := []byte(token)
+	tampered[len(tampered)-1] ^= 1
+
+	id, err := ParseToken(token)
+	if err != nil || id != s.ID {
+		t.Fatalf("ParseToken() = %q, %v", id, err)
+	}
+	if !Verify(key, token, s) {
+		t.Error("Verify() rejected a valid token")
+	}
+
+	tests := []struct {
+		name  string
+		key   []byte
+		token string
+		share db.DiaryShare
+	}{
+		{name: "wrong key", key: []byte("other"), token: token, share: s},
+		{name: "tampered signature", key: key, token: string(tampered), share: s},
+		{name: "extended expiry", key: key, token: token, share: db.DiaryShare{ID: s.ID, Expires: "2027-10-26 12:00:00"}},
+		{name: "other share", key: key, token: token, share: db.DiaryShare{ID: "s456", Expires: s.Expires}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if Verify(tt.key, tt.token, tt.share) {
+				t.Error("Verify() accepted an invalid token")
+			}
+		})
+	}
+}
+
+func TestParseTokenInvalid(t *testing.T) {
+	for _, token := range []string{"", "s123", "s123.", ".sig", "s123.sig.extra"} {
+		if _, err := ParseToken(token); err == nil {
+			t.Errorf("ParseToken(%q) expected error", token)
+		}
+	}
+}
+
+func TestActive(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		share db.DiaryShare
+		want  bool
+	}{
+		{name: "active", share: db.DiaryShare{Expires: ExpiresAt(now, 7)}, want: true},
+		{name: "expired", share: db.DiaryShare{Expires: "2026-10-19 11:59:59"}, want: false},
+		{name: "revoked", share: db.DiaryShare{Expires: ExpiresAt(now, 7), Revoked: "2026-10-19 10:00:00"}, want: false},
+		{name: "invalid expiry", share: db.DiaryShare{Expires: "bad"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Active(tt.share, now); got != tt.want {
+				t.Errorf("Active() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func createShare(t *testing.T, queries *db.Queries, now time.Time) db.DiaryShare {
+	t.Helper()
+	s, err := queries.CreateDiaryShare(context.Background(), db.CreateDiaryShareParams{
+		Uid:     "user-1",
+		Date:    "20261019",
+		PinHash: "hash",
+		Expires: ExpiresAt(now, 30),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestReservePIN(t *testing.T) {
+	ctx := context.Background()
+	queries := dbtest.New(t)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	s := createShare(t, queries, now)
+
+	for i := 1; i < MaxPINFailures; i++ {
+		got, ok, err := ReservePIN(ctx, queries, s.ID, now)
+		if err != nil || !ok || got.PinFailures != int64(i) || got.LockedUntil != "" {
+			t.Fatalf("시도 %d = %+v, %v, %v", i, got, ok, err)
+		}
+	}
+	got, ok, err := ReservePIN(ctx, queries, s.ID, now)
+	if err != nil || !ok || got.PinFailures != 0 || got.LockedUntil != "2026-10-19 12:15:00" {
+		t.Fatalf("잠금 시도 = %+v, %v, %v", got, ok, err)
+	}
+	if got := LockRemaining(got, now); got != PINLockWindow {
+		t.Errorf("LockRemaining() = %v, want %v", got, PINLockWindow)
+	}
+	if _, ok, _ := ReservePIN(ctx, queries, s.ID, now.Add(time.Minute)); ok {
+		t.Fatal("잠긴 링크에 시도가 예약됨")
+	}
+
+	// 잠금이 풀려도 총 시도 횟수는 이어서 센다.
+	later := now.Add(PINLockWindow)
+	for i := MaxPINFailures + 1; i <= MaxPINAttempts; i++ {
+		got, ok, err = ReservePIN(ctx, queries, s.ID, later)
+		if err != nil || !ok {
+			t.Fatalf("시도 %d = %v, %v", i, ok, err)
+		}
+		if got.LockedUntil != "" {
+			later = later.Add(PINLockWindow)
+		}
+	}
+	if got.PinAttempts != MaxPINAttempts {
+		t.Fatalf("pin_attempts = %d, want %d", got.PinAttempts, MaxPINAttempts)
+	}
+	if _, ok, _ := ReservePIN(ctx, queries, s.ID, later.Add(PINLockWindow)); ok {
+		t.Fatal("시도를 다 쓴 링크에 시도가 예약됨")
+	}
+
+	// PIN을 맞히면 횟수를 처음부터 센다.
+	if err := queries.RecordDiaryShareView(ctx, s.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := ReservePIN(ctx, queries, s.ID, later); err != nil || !ok || got.PinFailures != 1 || got.PinAttempts != 1 {
+		t.Fatalf("맞힌 뒤 시도 = %+v, %v, %v", got, ok, err)
+	}
+}
+
+func TestReservePINConcurrent(t *testing.T) {
+	ctx := context.Background()
+	queries := dbtest.New(t)
+	now := time.Now()
+	s := createShare(t, queries, now)
+
+	// 한꺼번에 들어온 요청도 잠금까지 MaxPINFailures번만 PIN을 비교할 수 있어야 한다.
+	const workers = 20
+	var reserved atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := ReservePIN(ctx, queries, s.ID, now)
+			if err != nil {
+				t.Error(err)
+			}
+			if ok {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := reserved.Load(); got != MaxPINFailures {
+		t.Errorf("예약된 시도 = %d, want %d", got, MaxPINFailures)
+	}
+	got, err := queries.GetDiaryShare(ctx, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if LockRemaining(got, now) <= 0 || got.PinAttempts != MaxPINFailures {
+		t.Errorf("공유 링크 = %+v", got)
+	}
+}
+
+func TestLockRemaining(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	if got := LockRemaining(db.DiaryShare{LockedUntil: "2026-10-19 12:15:00"}, now.Add(PINLockWindow)); got != 0 {
+		t.Errorf("LockRemaining() after window = %v, want 0", got)
+	}
+	if got := LockRemaining(db.DiaryShare{}, now); got != 0 {
+		t.Errorf("LockRemaining() without lock = %v, want 0", got)
+	}
+}
+
+func TestValidExpiryDays(t *testing.T) {
+	for _, days := range ExpiryDays {
+		if !ValidExpiryDays(days) {
+			t.Errorf("ValidExpiryDays(%d) = false", days)
+		}
+	}
+	for _, days := range []int{0, -1, 2, 365} {
+		if ValidExpiryDays(days) {
+			t.Errorf("ValidExpiryDays(%d) = true", days)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package stats
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"simple-server/pkg/util/dateutil"
+	"simple-server/pkg/util/stringutil"
+)
+
+// Range는 통계 조회 범위를 나타낸다.
+type Range string
+
+const (
+	RangeWeek  Range = "week"
+	RangeMonth Range = "month"
+	RangeYear  Range = "year"
+	RangeAll   Range = "all"
+)
+
+var weekdayLabels = [7]string{"일", "월", "화", "수", "목", "금", "토"}
+
+// Entry는 통계 계산에 사용하는 일기 한 편의 정보다.
+type Entry struct {
+	Date    string
+	Content string
+	Mood    string
+	// Created는 사용자 시간대로 변환된 작성 시각이며, 알 수 없으면 zero 값이다.
+	Created time.Time
+}
+
+// Bucket은 일별 또는 월별 집계 결과를 담는다.
+type Bucket struct {
+	Key        string `json:"key"`
+	DiaryCount int    `json:"diaryCount"`
+	WordCount  int    `json:"wordCount"`
+	Moods      [5]int `json:"moods"`
+}
+
+// Summary는 조회 범위 전체의 작성량 요약이다.
+type Summary struct {
+	TotalDiaries int     `json:"totalDiaries"`
+	TotalWords   int     `json:"totalWords"`
+	AvgWords     float64 `json:"avgWords"`
+	AvgLength    float64 `json:"avgLength"`
+}
+
+// WeekdayMood는 요일별 기분 분포를 담는다.
+type WeekdayMood struct {
+	Weekday string `json:"weekday"`
+	Moods   [5]int `json:"moods"`
+}
+
+// Data는 통계 페이지에 내려주는 전체 데이터다.
+type Data struct {
+	Range            Range         `json:"range"`
+	StartDate        string        `json:"startDate"`
+	EndDate          string        `json:"endDate"`
+	Daily            bool          `json:"daily"`
+	Buckets          []Bucket      `json:"buckets"`
+	Summary          Summary       `json:"summary"`
+	HourDistribution [24]int       `json:"hourDistribution"`
+	MoodByWeekday    []WeekdayMood `json:"moodByWeekday"`
+}
+
+// HeatmapCell은 연간 히트맵의 하루 칸이다.
+type HeatmapCell struct {
+	Date    string
+	Level   int
+	Words   int
+	InYear  bool
+	Written bool
+}
+
+// ParseRange는 조회 범위 문자열을 해석하고, 알 수 없는 값이면 false를 반환한다.
+func ParseRange(value string) (Range, bool) {
+	switch Range(value) {
+	case "":
+		return RangeYear, true
+	case RangeWeek, RangeMonth, RangeYear, RangeAll:
+		return Range(value), true
+	default:
+		return "", false
+	}
+}
+
+// Period는 조회 범위의 시작일과 종료일을 YYYYMMDD 형식으로 반환한다.
+// firstDate는 전체 기간 조회 시 사용하는 첫 작성일이며 비어 있으면 오늘부터 계산한다.
+func Period(r Range, today, firstDate string) (string, string) {
+	switch r {
+	case RangeWeek:
+		return dateutil.MustAddDaysToDate(today, -6), today
+	case RangeMonth:
+		return dateutil.MustAddDaysToDate(today, -29), today
+	case RangeAll:
+		if firstDate == "" || firstDate > today {
+			return today, today
+		}
+		return firstDate, today
+	default:
+		t, err := time.Parse(dateutil.DateFormatYYYYMMDD, today)
+		if err != nil {
+			return today, today
+		}
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -11, 0)
+		return start.Format(dateutil.DateFormatYYYYMMDD), today
+	}
+}
+
+// Build는 조회 범위의 일기 목록으로 통계 데이터를 계산한다.
+// 주간/월간은 일별, 연간/전체는 월별로 묶으며 데이터가 없는 구간도 0으로 채운다.
+func Build(r Range, start, end string, entries []Entry) Data {
+	data := Data{
+		Range:     r,
+		StartDate: start,
+		EndDate:   end,
+		Daily:     r == RangeWeek || r == RangeMonth,
+	}
+	data.Buckets = emptyBuckets(start, end, data.Daily)
+	index := make(map[string]int, len(data.Buckets))
+	for i, b := range data.Buckets {
+		index[b.Key] = i
+	}
+
+	data.MoodByWeekday = make([]WeekdayMood, len(weekdayLabels))
+	for i, label := range weekdayLabels {
+		data.MoodByWeekday[i].Weekday = label
+	}
+
+	totalLength := 0
+	for _, e := range entries {
+		key := e.Date
+		if !data.Daily && len(key) >= 6 {
+			key = key[:6]
+		}
+		words := stringutil.CountWords(e.Content)
+		mood := moodIndex(e.Mood)
+
+		if i, ok := index[key]; ok {
+			data.Buckets[i].DiaryCount++
+			data.Buckets[i].WordCount += words
+			if mood >= 0 {
+				data.Buckets[i].Moods[mood]++
+			}
+		}
+
+		if mood >= 0 {
+			if weekday, err := dateutil.GetWeekday(e.Date); err == nil {
+				data.MoodByWeekday[weekday].Moods[mood]++
+			}
+		}
+		if !e.Created.IsZero() {
+			data.HourDistribution[e.Created.Hour()]++
+		}
+
+		data.Summary.TotalDiaries++
+		data.Summary.TotalWords += words
+		totalLength += utf8.RuneCountInString(e.Content)
+	}
+
+	if data.Summary.TotalDiaries > 0 {
+		n := float64(data.Summary.TotalDiaries)
+		data.Summary.AvgWords = float64(data.Summary.TotalWords) / n
+		data.Summary.AvgLength = float64(totalLength) / n
+	}
+
+	return data
+}
+
+// Heatmap은 한 해의 작성 기록을 일요일부터 시작하는 주 단위 열로 배치한다.
+// 앞뒤로 다른 해에 속한 칸은 InYear가 false다.
+func Heatmap(year int, entries []Entry) [][]HeatmapCell {
+	words := make(map[string]int, len(entries))
+	for _, e := range entries {
+		words[e.Date] = stringutil.CountWords(e.Content)
+	}
+
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	day := first.AddDate(0, 0, -int(first.Weekday()))
+
+	var weeks [][]HeatmapCell
+	for !day.After(last) {
+		week := make([]HeatmapCell, 0, 7)
+		for range 7 {
+			date := day.Format(dateutil.DateFormatYYYYMMDD)
+			count, written := words[date]
+			cell := HeatmapCell{
+				Date:    date,
+				InYear:  day.Year() == year,
+				Written: written,
+				Words:   count,
+			}
+			if written {
+				cell.Level = heatmapLevel(count)
+			}
+			week = append(week, cell)
+			day = day.AddDate(0, 0, 1)
+		}
+		weeks = append(weeks, week)
+	}
+	return weeks
+}
+
+// heatmapLevel은 단어 수에 따라 1~4단계 색상 강도를 반환한다.
+func heatmapLevel(words int) int {
+	switch {
+	case words >= 150:
+		return 4
+	case words >= 80:
+		return 3
+	case words >= 30:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// emptyBuckets는 시작일부터 종료일까지 빈 집계 구간을 만든다.
+func emptyBuckets(start, end string, daily bool) []Bucket {
+	from, err := time.Parse(dateutil.DateFormatYYYYMMDD, start)
+	if err != nil {
+		return nil
+	}
+	to, err := time.Parse(dateutil.DateFormatYYYYMMDD, end)
+	if err != nil {
+		return nil
+	}
+
+	var buckets []Bucket
+	if daily {
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			buckets = append(buckets, Bucket{Key: d.Format(dateutil.DateFormatYYYYMMDD)})
+		}
+		return buckets
+	}
+
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for !month.After(to) {
+		buckets = append(buckets, Bucket{Key: month.Format("200601")})
+		month = month.AddDate(0, 1, 0)
+	}
+	return buckets
+}
+
+// moodIndex는 "1"~"5" 기분 값을 0부터 시작하는 인덱스로 바꾼다.
+func moodIndex(mood string) int {
+	if len(mood) != 1 || mood[0] < '1' || mood[0] > '5' {
+		return -1
+	}
+	return int(mood[0] - '1')
+}
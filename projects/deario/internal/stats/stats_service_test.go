@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		r         Range
+		today     string
+		firstDate string
+		wantStart string
+		wantEnd   string
+	}{
+		{name: "week", r: RangeWeek, today: "20250110", wantStart: "20250104", wantEnd: "20250110"},
+		{name: "month", r: RangeMonth, today: "20250310", wantStart: "20250209", wantEnd: "20250310"},
+		{name: "year", r: RangeYear, today: "20250310", wantStart: "20240401", wantEnd: "20250310"},
+		{name: "all", r: RangeAll, today: "20250310", firstDate: "20230105", wantStart: "20230105", wantEnd: "20250310"},
+		{name: "all without diary", r: RangeAll, today: "20250310", wantStart: "20250310", wantEnd: "20250310"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := Period(tt.r, tt.today, tt.firstDate)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("Period() = %s~%s, want %s~%s", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	created := time.Date(2025, 1, 9, 22, 30, 0, 0, time.UTC)
+	entries := []Entry{
+		{Date: "20250105", Content: "하나 둘 셋", Mood: "1"},
+		{Date: "20250109", Content: "오늘은 비", Mood: "5", Created: created},
+	}
+
+	data := Build(RangeWeek, "20250104", "20250110", entries)
+
+	if len(data.Buckets) != 7 {
+		t.Fatalf("len(Buckets) = %d, want 7", len(data.Buckets))
+	}
+	if b := data.Buckets[1]; b.Key != "20250105" || b.DiaryCount != 1 || b.WordCount != 3 || b.Moods[0] != 1 {
+		t.Errorf("Buckets[1] = %+v", b)
+	}
+	if data.Summary.TotalDiaries != 2 || data.Summary.TotalWords != 5 || data.Summary.AvgWords != 2.5 {
+		t.Errorf("Summary = %+v", data.Summary)
+	}
+	if data.HourDistribution[22] != 1 {
+		t.Errorf("HourDistribution[22] = %d, want 1", data.HourDistribution[22])
+	}
+	// 2025-01-05는 일요일, 2025-01-09는 목요일이다.
+	if data.MoodByWeekday[0].Moods[0] != 1 || data.MoodByWeekday[4].Moods[4] != 1 {
+		t.Errorf("MoodByWeekday = %+v", data.MoodByWeekday)
+	}
+
+	monthly := Build(RangeYear, "20240201", "20250110", entries)
+	if len(monthly.Buckets) != 12 || monthly.Buckets[11].DiaryCount != 2 {
+		t.Errorf("monthly Buckets = %+v", monthly.Buckets)
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	weeks := Heatmap(2025, []Entry{{Date: "20250101", Content: "짧은 일기"}})
+
+	// 2025-01-01은 수요일이므로 첫 주의 앞 세 칸은 지난해다.
+	first := weeks[0]
+	if first[0].InYear || first[2].InYear || !first[3].InYear {
+		t.Errorf("first week = %+v", first)
+	}
+	if !first[3].Written || first[3].Level != 1 {
+		t.Errorf("2025-01-01 cell = %+v", first[3])
+	}
+	last := weeks[len(weeks)-1]
+	if last[len(last)-1].Date < "20251231" {
+		t.Errorf("last cell = %s, want on or after 20251231", last[len(last)-1].Date)
+	}
+}
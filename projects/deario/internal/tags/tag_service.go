@@ -0,0 +1,110 @@
+package tags
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"simple-server/projects/deario/db"
+)
+
+const (
+	maxTagLen       = 30
+	maxTagsPerDiary = 20
+)
+
+// hashtagPattern은 앞 글자가 단어/URL/HTML 엔티티의 일부가 아닌 #태그를 찾는다.
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_]+)`)
+
+// Extract는 일기 내용에서 해시태그를 중복 없이 소문자로 추출한다.
+// 숫자로만 이루어졌거나 너무 긴 태그는 제외한다.
+func Extract(content string) []string {
+	var result []string
+	seen := make(map[string]struct{})
+	for _, m := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		name, ok := Normalize(m[1])
+		if !ok {
+			continue
+		}
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+		result = append(result, name)
+		if len(result) == maxTagsPerDiary {
+			break
+		}
+	}
+	return result
+}
+
+// Normalize는 사용자가 입력한 태그에서 #을 떼고 소문자로 바꾼 뒤 유효성을 검사한다.
+func Normalize(value string) (string, bool) {
+	name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), "#"))
+	if name == "" || utf8.RuneCountInString(name) > maxTagLen {
+		return "", false
+	}
+
+	allDigits := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_' {
+			return "", false
+		}
+		if !unicode.IsDigit(r) {
+			allDigits = false
+		}
+	}
+	if allDigits {
+		return "", false
+	}
+	return name, true
+}
+
+// likeEscaper는 LIKE 패턴에서 특수 문자로 쓰이는 글자 앞에 \를 붙인다.
+// sqlc가 ESCAPE 절을 읽지 못하므로 쿼리에서는 like(pattern, value, '\') 함수로 이스케이프 문자를 지정한다.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike는 value를 LIKE 패턴 안에서 글자 그대로 비교되도록 바꾼다.
+func EscapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// ReplaceInContent는 내용 속 #from 태그를 #to로 바꾼다. 대소문자는 구분하지 않는다.
+func ReplaceInContent(content, from, to string) string {
+	var b strings.Builder
+	last := 0
+	for _, idx := range hashtagPattern.FindAllStringSubmatchIndex(content, -1) {
+		start, end := idx[2], idx[3]
+		if strings.ToLower(content[start:end]) != from {
+			continue
+		}
+		b.WriteString(content[last:start])
+		b.WriteString(to)
+		last = end
+	}
+	if last == 0 {
+		return content
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// SyncDiary는 일기 내용에서 추출한 태그로 diary_tag를 다시 채운다.
+func SyncDiary(ctx context.Context, queries *db.Queries, diaryID, uid, content string) error {
+	if err := queries.DeleteDiaryTags(ctx, diaryID); err != nil {
+		return fmt.Errorf("태그 삭제 실패: %w", err)
+	}
+	for _, name := range Extract(content) {
+		if err := queries.InsertDiaryTag(ctx, db.InsertDiaryTagParams{
+			DiaryID: diaryID,
+			Uid:     uid,
+			Tag:     name,
+		}); err != nil {
+			return fmt.Errorf("태그 저장 실패: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package tags
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/db/dbtest"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "none", content: "오늘은 평범한 하루", want: nil},
+		{name: "korean and english", content: "#산책 다녀옴 #Walk", want: []string{"산책", "walk"}},
+		{name: "dedupe case insensitive", content: "#운동 #운동 #Go #go", want: []string{"운동", "go"}},
+		{name: "markdown heading", content: "# 제목\n## 소제목", want: nil},
+		{name: "inline hash and url", content: "C#공부 https://a.com/#top &#123;", want: nil},
+		{name: "digits only", content: "#1 #2번", want: []string{"2번"}},
+		{name: "punctuation boundary", content: "(#여행), #맛집!", want: []string{"여행", "맛집"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Extract(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceInContent(t *testing.T) {
+	got := ReplaceInContent("#운동 하고 #운동장 갔다 #운동", "운동", "헬스")
+	want := "#헬스 하고 #운동장 갔다 #헬스"
+	if got != want {
+		t.Errorf("ReplaceInContent() = %q, want %q", got, want)
+	}
+}
+
+func TestSuggestUserTagsEscapesPrefix(t *testing.T) {
+	ctx := context.Background()
+	queries := dbtest.New(t)
+	for i, tag := range []string{"a_b", "axb", "a%b", `a\b`} {
+		if err := queries.InsertDiaryTag(ctx, db.InsertDiaryTagParams{
+			DiaryID: fmt.Sprint("diary-", i),
+			Uid:     "user-1",
+			Tag:     tag,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string][]string{
+		"a_": {"a_b"},
+		"a%": {"a%b"},
+		`a\`: {`a\b`},
+		"a":  {"a%b", `a\b`, "a_b", "axb"},
+	}
+	for prefix, want := range tests {
+		got, err := queries.SuggestUserTags(ctx, db.SuggestUserTagsParams{
+			Uid:    "user-1",
+			Prefix: sql.NullString{String: EscapeLike(prefix), Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SuggestUserTags(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+}
@@ -0,0 +1,500 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// MaxAudioDuration은 받아쓸 수 있는 녹음 길이다.
+	MaxAudioDuration = 2 * time.Hour
+	// MinAudioDuration보다 짧은 녹음은 말소리가 없는 것으로 보고 받지 않는다.
+	MinAudioDuration = time.Second
+
+	mimeWebM = "audio/webm"
+	mimeOgg  = "audio/ogg"
+	mimeMP4  = "audio/mp4"
+	mimeWAV  = "audio/wav"
+	mimeMP3  = "audio/mpeg"
+)
+
+var (
+	errUnsupportedAudio = echo.NewHTTPError(http.StatusUnsupportedMediaType, "지원하지 않는 음성 형식입니다. webm, ogg, mp4, wav, mp3 녹음만 받아쓸 수 있습니다.")
+	errCorruptAudio     = echo.NewHTTPError(http.StatusUnprocessableEntity, "음성 파일이 손상되어 읽을 수 없습니다.")
+	errAudioTooLong     = echo.NewHTTPError(http.StatusRequestEntityTooLarge, "녹음은 2시간까지 받아쓸 수 있습니다.")
+	errAudioTooShort    = echo.NewHTTPError(http.StatusUnprocessableEntity, "녹음이 너무 짧습니다.")
+)
+
+// audioInfo는 올라온 녹음을 직접 읽어 알아낸 형식과 길이다.
+// 길이를 알 수 없는 녹음(조각으로 녹음한 webm, fragmented mp4)은 Duration이 0이고 크기 제한만 받는다.
+type audioInfo struct {
+	MIMEType string
+	Duration time.Duration
+}
+
+// sniffAudio는 파일 앞부분의 시그니처로 음성 형식을 판별한다. 클라이언트가 알려준 형식은 믿지 않는다.
+func sniffAudio(head []byte) (string, bool) {
+	switch {
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return mimeWebM, true
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return mimeOgg, true
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		return mimeMP4, true
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return mimeWAV, true
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return mimeMP3, true
+	case len(head) >= 4:
+		if _, ok := parseMP3Frame(head); ok {
+			return mimeMP3, true
+		}
+	}
+	return "", false
+}
+
+// inspectAudio는 임시 파일의 형식을 판별하고 길이를 잰다.
+func inspectAudio(path string) (audioInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return audioInfo{}, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return audioInfo{}, err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return audioInfo{}, err
+	}
+	mimeType, ok := sniffAudio(head[:n])
+	if !ok {
+		return audioInfo{}, errUnsupportedAudio
+	}
+
+	r := io.NewSectionReader(f, 0, stat.Size())
+	info := audioInfo{MIMEType: mimeType}
+	switch mimeType {
+	case mimeWAV:
+		format, dataSize, err := readWAVHeader(r)
+		if err != nil {
+			return info, errCorruptAudio
+		}
+		if dataSize < 0 {
+			// 녹음하면서 올린 wav는 헤더에 길이가 없으므로 파일 끝까지를 데이터로 본다.
+			offset, _ := r.Seek(0, io.SeekCurrent)
+			dataSize = stat.Size() - offset
+		}
+		info.Duration = format.duration(dataSize)
+	case mimeWebM:
+		info.Duration = webmDuration(r, stat.Size())
+	case mimeOgg:
+		info.Duration = oggDuration(r, stat.Size())
+	case mimeMP4:
+		info.Duration = mp4Duration(r, stat.Size())
+	case mimeMP3:
+		info.Duration = mp3Duration(r, stat.Size())
+	}
+	return info, nil
+}
+
+// checkAudio는 녹음 길이 제한을 확인한다.
+func checkAudio(info audioInfo) error {
+	switch {
+	case info.Duration > MaxAudioDuration:
+		return errAudioTooLong
+	case info.Duration > 0 && info.Duration < MinAudioDuration:
+		return errAudioTooShort
+	}
+	return nil
+}
+
+func seconds(v float64) time.Duration {
+	if v <= 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return time.Duration(v * float64(time.Second))
+}
+
+// webm(EBML) 요소 ID
+const (
+	idSegment     = 0x18538067
+	idInfo        = 0x1549A966
+	idCluster     = 0x1F43B675
+	idTimescale   = 0x2AD7B1
+	idDuration    = 0x4489
+	idTimecode    = 0xE7
+	idSimpleBlock = 0xA3
+	idBlockGroup  = 0xA0
+	idBlock       = 0xA1
+)
+
+// webmTailSize는 webm 길이를 어림할 때 파일 끝에서 읽는 크기다. 128kbps로도 1분 넘게 담긴다.
+const webmTailSize = 1 << 20
+
+// webmDuration은 Segment > Info의 Duration을 읽는다.
+// MediaRecorder는 녹음이 끝나기 전에 헤더를 쓰므로 Duration이 없다. 그럴 때는 마지막 Cluster의 블록 시각으로 길이를 어림한다.
+func webmDuration(r io.ReaderAt, size int64) time.Duration {
+	scale, duration := webmInfo(r)
+	if duration <= 0 {
+		ticks, ok := webmLastTimecode(r, size)
+		if !ok {
+			return 0
+		}
+		duration = float64(ticks)
+	}
+	return seconds(duration * float64(scale) / 1e9)
+}
+
+// webmInfo는 파일 앞쪽에서 TimecodeScale과 Duration을 읽는다.
+func webmInfo(r io.ReaderAt) (uint64, float64) {
+	buf := make([]byte, 64<<10)
+	n, _ := r.ReadAt(buf, 0)
+	buf = buf[:n]
+
+	scale := uint64(1000000)
+	duration := 0.0
+	for pos := 0; pos < len(buf); {
+		id, idLen := readVint(buf[pos:], true)
+		if idLen == 0 {
+			break
+		}
+		size, sizeLen := readVint(buf[pos+idLen:], false)
+		if sizeLen == 0 {
+			break
+		}
+		pos += idLen + sizeLen
+		unknown := size == 1<<(7*sizeLen)-1
+
+		switch id {
+		case idSegment, idInfo:
+			// 안으로 들어가 자식 요소를 읽는다.
+			continue
+		case idCluster:
+			return scale, duration
+		case idTimescale:
+			if pos+int(size) <= len(buf) && size <= 8 {
+				scale = readUint(buf[pos : pos+int(size)])
+			}
+		case idDuration:
+			if pos+int(size) <= len(buf) {
+				switch size {
+				case 4:
+					duration = float64(math.Float32frombits(binary.BigEndian.Uint32(buf[pos:])))
+				case 8:
+					duration = math.Float64frombits(binary.BigEndian.Uint64(buf[pos:]))
+				}
+			}
+		}
+		if unknown {
+			break
+		}
+		pos += int(size)
+	}
+	return scale, duration
+}
+
+// webmLastTimecode는 파일 끝에 있는 마지막 Cluster의 시각에 그 안 블록의 가장 늦은 상대 시각을 더한다.
+// 음성 데이터에 Cluster ID와 같은 바이트가 나올 수 있으므로 바로 뒤에 Timecode가 오는 것만 Cluster로 본다.
+func webmLastTimecode(r io.ReaderAt, size int64) (int64, bool) {
+	tailSize := min(size, webmTailSize)
+	tail := make([]byte, tailSize)
+	n, _ := r.ReadAt(tail, size-tailSize)
+	tail = tail[:n]
+
+	clusterID := []byte{0x1F, 0x43, 0xB6, 0x75}
+	for end := len(tail); end > 0; {
+		i := bytes.LastIndex(tail[:end], clusterID)
+		if i < 0 {
+			return 0, false
+		}
+		end = i
+		if ticks, ok := clusterEnd(tail[i+len(clusterID):]); ok {
+			return ticks, true
+		}
+	}
+	return 0, false
+}
+
+// clusterEnd는 Cluster 크기 바로 뒤부터 읽어 Cluster 시각과 마지막 블록 시각의 합을 구한다.
+func clusterEnd(b []byte) (int64, bool) {
+	_, sizeLen := readVint(b, false)
+	if sizeLen == 0 {
+		return 0, false
+	}
+	pos := sizeLen
+
+	id, idLen := readVint(b[pos:], true)
+	if id != idTimecode {
+		return 0, false
+	}
+	size, n := readVint(b[pos+idLen:], false)
+	if n == 0 || size > 8 || pos+idLen+n+int(size) > len(b) {
+		return 0, false
+	}
+	pos += idLen + n
+	cluster := int64(readUint(b[pos : pos+int(size)]))
+	pos += int(size)
+
+	last := int64(0)
+	for pos < len(b) {
+		id, idLen := readVint(b[pos:], true)
+		size, n := readVint(b[pos+idLen:], false)
+		if idLen == 0 || n == 0 {
+			break
+		}
+		pos += idLen + n
+		switch id {
+		case idBlockGroup:
+			// 안으로 들어가 Block을 읽는다.
+			continue
+		case idSimpleBlock, idBlock:
+			// 블록은 트랙 번호 뒤에 Cluster 기준 상대 시각(int16)이 온다.
+			_, track := readVint(b[pos:], false)
+			if track > 0 && pos+track+2 <= len(b) {
+				last = max(last, int64(int16(binary.BigEndian.Uint16(b[pos+track:]))))
+			}
+		}
+		if pos+int(size) > len(b) {
+			break
+		}
+		pos += int(size)
+	}
+	return cluster + last, true
+}
+
+// readVint는 EBML 가변 길이 정수를 읽는다. ID는 길이 표시 비트를 남기고 크기는 뗀다.
+func readVint(b []byte, keepMarker bool) (uint64, int) {
+	if len(b) == 0 || b[0] == 0 {
+		return 0, 0
+	}
+	length := 1
+	for mask := byte(0x80); b[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 || len(b) < length {
+		return 0, 0
+	}
+	v := uint64(b[0])
+	if !keepMarker {
+		v &= uint64(0xFF >> length)
+	}
+	for i := 1; i < length; i++ {
+		v = v<<8 | uint64(b[i])
+	}
+	return v, length
+}
+
+func readUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+// oggDuration은 마지막 페이지의 granule position을 샘플 수로 보고 길이를 구한다.
+func oggDuration(r io.ReaderAt, size int64) time.Duration {
+	head := make([]byte, 512)
+	n, _ := r.ReadAt(head, 0)
+	head = head[:n]
+	if len(head) < 27 {
+		return 0
+	}
+	segments := int(head[26])
+	body := 27 + segments
+	if len(head) < body+16 {
+		return 0
+	}
+
+	var rate, preSkip float64
+	packet := head[body:]
+	switch {
+	case bytes.HasPrefix(packet, []byte("OpusHead")):
+		rate = 48000
+		preSkip = float64(binary.LittleEndian.Uint16(packet[10:12]))
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		rate = float64(binary.LittleEndian.Uint32(packet[12:16]))
+	default:
+		return 0
+	}
+	if rate == 0 {
+		return 0
+	}
+
+	tailSize := min(size, 64<<10)
+	tail := make([]byte, tailSize)
+	n, _ = r.ReadAt(tail, size-tailSize)
+	tail = tail[:n]
+	i := bytes.LastIndex(tail, []byte("OggS"))
+	if i < 0 || i+14 > len(tail) {
+		return 0
+	}
+	granule := int64(binary.LittleEndian.Uint64(tail[i+6 : i+14]))
+	return seconds((float64(granule) - preSkip) / rate)
+}
+
+// mp4Duration은 moov > mvhd의 길이를 읽는다. 조각으로 녹음한 mp4는 0으로 적혀 있다.
+func mp4Duration(r io.ReaderAt, size int64) time.Duration {
+	moov, moovSize, ok := findBox(r, 0, size, "moov")
+	if !ok {
+		return 0
+	}
+	mvhd, mvhdSize, ok := findBox(r, moov, moovSize, "mvhd")
+	if !ok || mvhdSize < 20 {
+		return 0
+	}
+
+	b := make([]byte, min(mvhdSize, 32))
+	if _, err := r.ReadAt(b, mvhd); err != nil && !errors.Is(err, io.EOF) {
+		return 0
+	}
+	var timescale uint32
+	var duration uint64
+	if b[0] == 1 {
+		if len(b) < 32 {
+			return 0
+		}
+		timescale = binary.BigEndian.Uint32(b[20:24])
+		duration = binary.BigEndian.Uint64(b[24:32])
+	} else {
+		timescale = binary.BigEndian.Uint32(b[12:16])
+		duration = uint64(binary.BigEndian.Uint32(b[16:20]))
+	}
+	if timescale == 0 || duration == math.MaxUint32 || duration == math.MaxUint64 {
+		return 0
+	}
+	return seconds(float64(duration) / float64(timescale))
+}
+
+// findBox는 [start, start+size) 안에서 이름이 name인 박스를 찾아 본문 위치와 크기를 반환한다.
+func findBox(r io.ReaderAt, start, size int64, name string) (int64, int64, bool) {
+	header := make([]byte, 16)
+	for pos, end := start, start+size; pos+8 <= end; {
+		if _, err := r.ReadAt(header[:8], pos); err != nil {
+			return 0, 0, false
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		headerLen := int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = end - pos
+		case 1:
+			if _, err := r.ReadAt(header[8:16], pos+8); err != nil {
+				return 0, 0, false
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerLen = 16
+		}
+		if boxSize < headerLen || pos+boxSize > end {
+			return 0, 0, false
+		}
+		if string(header[4:8]) == name {
+			return pos + headerLen, boxSize - headerLen, true
+		}
+		pos += boxSize
+	}
+	return 0, 0, false
+}
+
+type mp3Frame struct {
+	bitrate         int
+	sampleRate      int
+	samplesPerFrame int
+	sideInfo        int
+}
+
+var (
+	mp3Bitrates1 = [...]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}
+	mp3Bitrates2 = [...]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}
+	mp3Rates     = [...]int{44100, 48000, 32000}
+)
+
+// parseMP3Frame은 MPEG Layer III 프레임 헤더를 읽는다. ADTS(AAC) 헤더는 layer 값이 0이라 걸러진다.
+func parseMP3Frame(b []byte) (mp3Frame, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+	version := (b[1] >> 3) & 3
+	layer := (b[1] >> 1) & 3
+	bitrateIndex := b[2] >> 4
+	rateIndex := (b[2] >> 2) & 3
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	mono := b[3]>>6 == 3
+	frame := mp3Frame{sampleRate: mp3Rates[rateIndex]}
+	if version == 3 {
+		frame.bitrate = mp3Bitrates1[bitrateIndex] * 1000
+		frame.samplesPerFrame = 1152
+		frame.sideInfo = 32
+		if mono {
+			frame.sideInfo = 17
+		}
+	} else {
+		frame.bitrate = mp3Bitrates2[bitrateIndex] * 1000
+		frame.samplesPerFrame = 576
+		frame.sampleRate /= 2
+		if version == 0 {
+			frame.sampleRate /= 2
+		}
+		frame.sideInfo = 17
+		if mono {
+			frame.sideInfo = 9
+		}
+	}
+	return frame, true
+}
+
+// mp3Duration은 Xing/Info 헤더의 프레임 수로, 없으면 첫 프레임 비트레이트로 길이를 어림한다.
+func mp3Duration(r io.ReaderAt, size int64) time.Duration {
+	buf := make([]byte, 64<<10)
+	n, _ := r.ReadAt(buf, 0)
+	buf = buf[:n]
+
+	start := 0
+	if bytes.HasPrefix(buf, []byte("ID3")) && len(buf) >= 10 {
+		tagSize := int(buf[6]&0x7F)<<21 | int(buf[7]&0x7F)<<14 | int(buf[8]&0x7F)<<7 | int(buf[9]&0x7F)
+		start = 10 + tagSize
+		if buf[5]&0x10 != 0 {
+			start += 10
+		}
+		if start > len(buf) {
+			// 태그가 커서 첫 프레임까지 읽지 못했다. 태그를 뺀 크기만 다시 읽는다.
+			rest := make([]byte, 4<<10)
+			n, _ := r.ReadAt(rest, int64(start))
+			buf, start = rest[:n], 0
+			size -= int64(10 + tagSize)
+		}
+	}
+
+	for i := start; i+4 <= len(buf); i++ {
+		frame, ok := parseMP3Frame(buf[i:])
+		if !ok {
+			continue
+		}
+		xing := i + 4 + frame.sideInfo
+		if xing+12 <= len(buf) {
+			tag := string(buf[xing : xing+4])
+			flags := binary.BigEndian.Uint32(buf[xing+4 : xing+8])
+			if (tag == "Xing" || tag == "Info") && flags&1 != 0 {
+				frames := binary.BigEndian.Uint32(buf[xing+8 : xing+12])
+				return seconds(float64(frames) * float64(frame.samplesPerFrame) / float64(frame.sampleRate))
+			}
+		}
+		return seconds(float64(size-int64(i)) * 8 / float64(frame.bitrate))
+	}
+	return 0
+}
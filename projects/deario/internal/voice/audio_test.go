@@ -0,0 +1,259 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// makeWAV는 440Hz 사인파(진폭 0.5)를 담은 wav를 만든다. streaming이면 녹음 중인 wav처럼 크기 자리를 비운다.
+func makeWAV(format uint16, channels, rate, bits int, d time.Duration, streaming bool) []byte {
+	frames := int(d * time.Duration(rate) / time.Second)
+	width := bits / 8
+	data := make([]byte, frames*channels*width)
+	for i := range frames {
+		v := 0.5 * math.Sin(2*math.Pi*440*float64(i)/float64(rate))
+		for ch := range channels {
+			b := data[(i*channels+ch)*width:]
+			switch {
+			case format == wavFormatFloat:
+				binary.LittleEndian.PutUint32(b, math.Float32bits(float32(v)))
+			case bits == 16:
+				binary.LittleEndian.PutUint16(b, uint16(int16(v*math.MaxInt16)))
+			case bits == 24:
+				s := int32(v * (1<<23 - 1))
+				b[0], b[1], b[2] = byte(s), byte(s>>8), byte(s>>16)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	riffSize, dataSize := uint32(36+len(data)), uint32(len(data))
+	if streaming {
+		riffSize, dataSize = wavUnknownSize, wavUnknownSize
+	}
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, riffSize)
+	buf.WriteString("WAVE")
+	// 형식과 상관없는 청크는 건너뛰어야 한다.
+	buf.WriteString("LIST")
+	binary.Write(&buf, binary.LittleEndian, uint32(3))
+	buf.Write([]byte{1, 2, 3, 0})
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, format)
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(rate))
+	binary.Write(&buf, binary.LittleEndian, uint32(rate*channels*width))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels*width))
+	binary.Write(&buf, binary.LittleEndian, uint16(bits))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, dataSize)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func box(name string, body ...[]byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(bytes.Join(body, nil))))
+	b = append(b, name...)
+	return append(b, bytes.Join(body, nil)...)
+}
+
+func oggPage(granule uint64, packet []byte) []byte {
+	b := []byte("OggS\x00\x02")
+	b = binary.LittleEndian.AppendUint64(b, granule)
+	b = append(b, make([]byte, 12)...)
+	b = append(b, 1, byte(len(packet)))
+	return append(b, packet...)
+}
+
+// webmCluster는 MediaRecorder처럼 크기를 모르는 Cluster에 SimpleBlock을 담는다.
+func webmCluster(timecode uint32, payload []byte, blocks ...int16) []byte {
+	b := []byte{0x1F, 0x43, 0xB6, 0x75, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xE7, 0x84}
+	b = binary.BigEndian.AppendUint32(b, timecode)
+	for _, rel := range blocks {
+		block := binary.BigEndian.AppendUint16([]byte{0x81}, uint16(rel))
+		block = append(append(block, 0x80), payload...)
+		b = append(b, 0xA3, 0x40|byte(len(block)>>8), byte(len(block)))
+		b = append(b, block...)
+	}
+	return b
+}
+
+func writeTemp(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audio")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSniffAudio(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{"webm", []byte{0x1A, 0x45, 0xDF, 0xA3, 0x9F}, mimeWebM},
+		{"ogg", []byte("OggS\x00\x02"), mimeOgg},
+		{"mp4", []byte("\x00\x00\x00\x1cftypM4A "), mimeMP4},
+		{"wav", []byte("RIFF\x24\x00\x00\x00WAVE"), mimeWAV},
+		{"mp3 id3", []byte("ID3\x04\x00"), mimeMP3},
+		{"mp3 frame", []byte{0xFF, 0xFB, 0x90, 0x64}, mimeMP3},
+		{"aac adts", []byte{0xFF, 0xF1, 0x50, 0x80}, ""},
+		{"avi", []byte("RIFF\x24\x00\x00\x00AVI "), ""},
+		{"html", []byte("<!doctype html>"), ""},
+		{"빈 파일", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sniffAudio(tt.head)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("sniffAudio = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectAudio(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:16], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:20], 90500)
+
+	opusHead := append([]byte("OpusHead\x01\x01"), 0x38, 0x01, 0x80, 0xBB, 0, 0, 0, 0, 0)
+
+	ebmlInfo := []byte{
+		0x2A, 0xD7, 0xB1, 0x83, 0x0F, 0x42, 0x40, // TimecodeScale 1000000
+		0x44, 0x89, 0x88, // Duration float64
+	}
+	ebmlInfo = binary.BigEndian.AppendUint64(ebmlInfo, math.Float64bits(5000))
+	webm := []byte{0x1A, 0x45, 0xDF, 0xA3, 0x84, 0x42, 0x82, 0x81, 0x77}
+	webm = append(webm, 0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+	webm = append(webm, 0x15, 0x49, 0xA9, 0x66, 0x80|byte(len(ebmlInfo)))
+	webm = append(webm, ebmlInfo...)
+	webm = append(webm, 0x1F, 0x43, 0xB6, 0x75, 0x80)
+
+	// MediaRecorder는 Info에 Duration을 쓰지 않으므로 마지막 Cluster로 길이를 어림한다.
+	// 음성 데이터에 Cluster ID와 같은 바이트가 섞여 있어도 속지 않아야 한다.
+	payload := []byte{0x1F, 0x43, 0xB6, 0x75, 0x80, 0x00}
+	recorder := []byte{0x1A, 0x45, 0xDF, 0xA3, 0x84, 0x42, 0x82, 0x81, 0x77}
+	recorder = append(recorder, 0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+	recorder = append(recorder, 0x15, 0x49, 0xA9, 0x66, 0x87, 0x2A, 0xD7, 0xB1, 0x83, 0x0F, 0x42, 0x40)
+	recorder = append(recorder, webmCluster(0, payload, 0, 20, 40)...)
+	recorder = append(recorder, webmCluster(7_230_000, payload, 0, 20, 1000)...)
+
+	mp3 := make([]byte, 16000)
+	copy(mp3, []byte{0xFF, 0xFB, 0x90, 0x64})
+
+	tests := []struct {
+		name     string
+		data     []byte
+		mimeType string
+		duration time.Duration
+	}{
+		{"wav", makeWAV(wavFormatPCM, 2, 48000, 16, 2*time.Second, false), mimeWAV, 2 * time.Second},
+		{"녹음 중인 wav", makeWAV(wavFormatPCM, 1, 44100, 16, 3*time.Second, true), mimeWAV, 3 * time.Second},
+		{"mp4", append(box("ftyp", []byte("M4A \x00\x00\x00\x00")), box("moov", box("mvhd", mvhd))...), mimeMP4, 90500 * time.Millisecond},
+		{"조각 mp4", append(box("ftyp", []byte("iso5\x00\x00\x00\x00")), box("moof")...), mimeMP4, 0},
+		{"ogg opus", append(oggPage(0, opusHead), oggPage(48000*10+312, []byte{0})...), mimeOgg, 10 * time.Second},
+		{"webm", webm, mimeWebM, 5 * time.Second},
+		{"MediaRecorder webm", recorder, mimeWebM, 7231 * time.Second},
+		{"mp3", mp3, mimeMP3, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := inspectAudio(writeTemp(t, tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.MIMEType != tt.mimeType || info.Duration != tt.duration {
+				t.Errorf("inspectAudio = %+v, want %s %s", info, tt.mimeType, tt.duration)
+			}
+		})
+	}
+
+	t.Run("지원하지 않는 형식", func(t *testing.T) {
+		if _, err := inspectAudio(writeTemp(t, []byte("<!doctype html><html></html>"))); !errors.Is(err, errUnsupportedAudio) {
+			t.Fatalf("err = %v, want errUnsupportedAudio", err)
+		}
+	})
+	t.Run("손상된 wav", func(t *testing.T) {
+		data := makeWAV(wavFormatPCM, 1, 16000, 16, time.Second, false)
+		binary.LittleEndian.PutUint16(data[32:34], 2) // ADPCM
+		if _, err := inspectAudio(writeTemp(t, data)); !errors.Is(err, errCorruptAudio) {
+			t.Fatalf("err = %v, want errCorruptAudio", err)
+		}
+	})
+}
+
+func TestCheckAudio(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     error
+	}{
+		{0, nil},
+		{time.Minute, nil},
+		{MaxAudioDuration + time.Second, errAudioTooLong},
+		{500 * time.Millisecond, errAudioTooShort},
+	}
+	for _, tt := range tests {
+		if err := checkAudio(audioInfo{Duration: tt.duration}); !errors.Is(err, tt.want) {
+			t.Errorf("checkAudio(%s) = %v, want %v", tt.duration, err, tt.want)
+		}
+	}
+}
+
+func TestNormalizeWAV(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"48kHz 스테레오 24bit 녹음 중", makeWAV(wavFormatPCM, 2, 48000, 24, time.Second, true)},
+		{"44.1kHz 모노 float", makeWAV(wavFormatFloat, 1, 44100, 32, time.Second, false)},
+		{"8kHz 모노 16bit", makeWAV(wavFormatPCM, 1, 8000, 16, time.Second, false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.wav")
+			f, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			duration, err := normalizeWAV(bytes.NewReader(tt.data), f)
+			f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if duration < 999*time.Millisecond || duration > 1001*time.Millisecond {
+				t.Errorf("duration = %s, want 1s", duration)
+			}
+
+			out, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			format, dataSize, err := readWAVHeader(bytes.NewReader(out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := wavFormat{format: wavFormatPCM, channels: 1, sampleRate: normalizedRate, blockAlign: 2, bits: 16}
+			if format != want || dataSize != int64(len(out)-44) {
+				t.Fatalf("header = %+v, %d", format, dataSize)
+			}
+
+			peak := 0.0
+			for i := 44; i+2 <= len(out); i += 2 {
+				peak = max(peak, math.Abs(float64(int16(binary.LittleEndian.Uint16(out[i:])))/math.MaxInt16))
+			}
+			if peak < 0.45 || peak > 0.55 {
+				t.Errorf("peak = %.3f, want about 0.5", peak)
+			}
+		})
+	}
+}
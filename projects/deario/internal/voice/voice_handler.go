@@ -0,0 +1,304 @@
+package voice
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errVoiceNoteNotFound = echo.NewHTTPError(http.StatusNotFound, "음성 일기를 찾을 수 없습니다.")
+	errNothingToQueue    = echo.NewHTTPError(http.StatusConflict, "받아쓸 녹음이 없거나 이미 처리 중입니다.")
+)
+
+type uploadStatus struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress int64  `json:"progress"`
+	Offset   int64  `json:"offset"`
+	Text     string `json:"text,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func statusOf(note db.VoiceNote) uploadStatus {
+	return uploadStatus{
+		ID:       note.ID,
+		Status:   note.Status,
+		Progress: note.Progress,
+		Offset:   note.Size,
+		Text:     note.Transcript,
+		Error:    note.Error,
+	}
+}
+
+// CreateUpload는 음성 일기 업로드를 시작한다. 원본 보관 여부는 사용자 설정을 따른다.
+func CreateUpload(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	date, err := deariodate.NormalizeRequired(c.FormValue("date"))
+	if err != nil {
+		return err
+	}
+	// 알려준 형식은 참고만 한다. 업로드를 마치면 파일을 직접 읽어 형식을 정한다.
+	mimeType := ""
+	if raw := c.FormValue("mime_type"); raw != "" {
+		var ok bool
+		if mimeType, ok = normalizeMIMEType(raw); !ok {
+			return echo.NewHTTPError(http.StatusBadRequest, "음성 파일 형식이 올바르지 않습니다.")
+		}
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	keepAudio := int64(0)
+	setting, err := queries.GetUserSetting(ctx, uid)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusInternalServerError, "사용자 설정을 가져오지 못했습니다.")
+	}
+	if err == nil {
+		keepAudio = setting.KeepVoiceAudio
+	}
+
+	note, err := queries.CreateVoiceNote(ctx, db.CreateVoiceNoteParams{
+		Uid:       uid,
+		Date:      date,
+		MimeType:  mimeType,
+		KeepAudio: keepAudio,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "음성 일기 업로드를 시작하지 못했습니다.")
+	}
+	return c.JSON(http.StatusCreated, statusOf(note))
+}
+
+// UploadStatus는 업로드와 받아쓰기 진행 상태를 반환한다. 끊긴 업로드는 offset부터 이어서 올린다.
+func UploadStatus(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	note, err := loadNote(c, queries, uid)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, statusOf(note))
+}
+
+// AppendUpload는 Upload-Offset 헤더 위치에 요청 본문 조각을 이어 붙인다.
+func AppendUpload(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	offset, err := strconv.ParseInt(c.Request().Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "업로드 위치가 올바르지 않습니다.")
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	note, err := loadNote(c, queries, uid)
+	if err != nil {
+		return err
+	}
+
+	size, err := AppendChunk(c.Request().Context(), queries, note, offset, c.Request().Body)
+	if err != nil {
+		return internalError(err, "녹음을 저장하지 못했습니다.", "id", note.ID)
+	}
+	note.Size = size
+	return c.JSON(http.StatusOK, statusOf(note))
+}
+
+// CompleteUpload는 업로드를 마치고 받아쓰기 작업을 넣는다.
+func CompleteUpload(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	note, err := loadNote(c, queries, uid)
+	if err != nil {
+		return err
+	}
+
+	if note.Status != statusUploading || note.Size == 0 {
+		return errNothingToQueue
+	}
+	info, err := inspectUpload(ctx, queries, note)
+	if err != nil {
+		return internalError(err, "녹음을 확인하지 못했습니다.", "id", note.ID)
+	}
+
+	rows, err := queries.QueueVoiceNote(ctx, db.QueueVoiceNoteParams{
+		MimeType: info.MIMEType,
+		ID:       note.ID,
+		Uid:      uid,
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "음성 인식을 시작하지 못했습니다.")
+	}
+	if rows == 0 {
+		return errNothingToQueue
+	}
+	if err := Enqueue(ctx, note.ID); err != nil {
+		slog.Error("음성 일기 작업 등록 실패", "id", note.ID, "error", err)
+		if err := queries.FailVoiceNote(ctx, db.FailVoiceNoteParams{Error: "음성 인식을 시작하지 못했습니다.", ID: note.ID}); err != nil {
+			slog.Error("음성 일기 실패 기록 실패", "id", note.ID, "error", err)
+		}
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "음성 인식을 시작하지 못했습니다.")
+	}
+
+	note.Status = statusQueued
+	return c.JSON(http.StatusAccepted, statusOf(note))
+}
+
+// VoiceNotesPanel은 날짜 일기에 보관한 녹음을 보여준다.
+func VoiceNotesPanel(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	date, err := deariodate.NormalizeWithDefault(c.QueryParam("date"))
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	return renderPanel(c, queries, uid, date)
+}
+
+// VoiceAudio는 보관한 녹음 원본을 내려준다.
+func VoiceAudio(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	note, err := loadNote(c, queries, uid)
+	if err != nil {
+		return err
+	}
+	if note.Object == "" {
+		return errVoiceNoteNotFound
+	}
+
+	r, err := defaultStorage.Open(c.Request().Context(), note.Object)
+	if err != nil {
+		slog.Error("음성 원본 열기 실패", "id", note.ID, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "녹음 원본을 불러오지 못했습니다.")
+	}
+	defer r.Close()
+	c.Response().Header().Set("Cache-Control", "private, max-age=3600")
+	return c.Stream(http.StatusOK, note.MimeType, r)
+}
+
+// DeleteVoiceNote는 보관한 녹음을 지운다. 받아쓴 글은 일기에 남는다.
+func DeleteVoiceNote(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	note, err := loadNote(c, queries, uid)
+	if err != nil {
+		return err
+	}
+	if note.Object != "" {
+		if err := defaultStorage.Delete(ctx, note.Object); err != nil {
+			slog.Error("음성 원본 삭제 실패", "id", note.ID, "error", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "녹음 원본을 삭제하지 못했습니다.")
+		}
+	}
+	if _, err := queries.DeleteVoiceNote(ctx, db.DeleteVoiceNoteParams{ID: note.ID, Uid: uid}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "음성 일기 삭제에 실패했습니다.")
+	}
+
+	return renderPanel(c, queries, uid, note.Date)
+}
+
+func loadNote(c echo.Context, queries *db.Queries, uid string) (db.VoiceNote, error) {
+	note, err := queries.GetVoiceNote(c.Request().Context(), db.GetVoiceNoteParams{ID: c.Param("id"), Uid: uid})
+	if errors.Is(err, sql.ErrNoRows) {
+		return note, errVoiceNoteNotFound
+	}
+	if err != nil {
+		return note, echo.NewHTTPError(http.StatusInternalServerError, "음성 일기를 가져오지 못했습니다.")
+	}
+	return note, nil
+}
+
+// internalError는 사용자에게 보여줄 HTTPError는 그대로 두고, 나머지 오류는 기록한 뒤 message로 감싼다.
+func internalError(err error, message string, args ...any) error {
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he
+	}
+	slog.Error(message, append(args, "error", err)...)
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
+
+func renderPanel(c echo.Context, queries *db.Queries, uid, date string) error {
+	notes, err := queries.ListVoiceNotes(c.Request().Context(), db.ListVoiceNotesParams{Uid: uid, Date: date})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "음성 일기를 가져오지 못했습니다.")
+	}
+
+	items := make([]components.VoiceNoteItem, 0, len(notes))
+	for _, n := range notes {
+		items = append(items, components.VoiceNoteItem{
+			ID:         n.ID,
+			Transcript: n.Transcript,
+			HasAudio:   n.Object != "",
+		})
+	}
+	return components.VoiceNotesPanel(date, items).Render(c.Request().Context(), c.Response().Writer)
+}
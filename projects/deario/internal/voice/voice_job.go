@@ -0,0 +1,116 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+
+	"github.com/robfig/cron/v3"
+	"maragu.dev/goqite"
+	"maragu.dev/goqite/jobs"
+)
+
+// staleAfter가 지나도록 끝나지 않은 업로드와 원본도 받아쓴 글도 없는 녹음은 정리한다.
+// 받아쓴 글은 녹음한 화면을 닫아도 음성 일기 목록에서 다시 볼 수 있게 남긴다.
+const staleAfter = 24 * time.Hour
+
+var voiceQ *goqite.Queue
+var voiceQOnce sync.Once
+var errVoiceQ error
+
+func InitVoiceQueue() error {
+	voiceQOnce.Do(func() {
+		voiceDB, err := db.GetDB(false)
+		if err != nil {
+			errVoiceQ = fmt.Errorf("음성 일기 큐 데이터베이스 연결 실패: %w", err)
+			return
+		}
+
+		voiceQ = goqite.New(goqite.NewOpts{
+			DB:   voiceDB,
+			Name: "voice-transcribe",
+		})
+	})
+
+	return errVoiceQ
+}
+
+// Enqueue는 녹음 받아쓰기 작업을 넣는다.
+func Enqueue(ctx context.Context, id string) error {
+	if err := InitVoiceQueue(); err != nil {
+		return err
+	}
+	_, err := jobs.Create(ctx, voiceQ, "transcribe", goqite.Message{Body: []byte(id)})
+	return err
+}
+
+// VoiceTranscribeJob은 큐에 쌓인 녹음을 받아쓴다. 긴 녹음이 많을 수 있어 한 번에 하나씩 처리한다.
+func VoiceTranscribeJob() {
+	if err := InitVoiceQueue(); err != nil {
+		slog.Error("음성 일기 큐 초기화 실패", "error", err)
+		return
+	}
+
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	r := jobs.NewRunner(jobs.NewRunnerOpts{
+		Limit:        1,
+		Log:          slog.Default(),
+		PollInterval: 1 * time.Second,
+		Queue:        voiceQ,
+	})
+
+	r.Register("transcribe", func(ctx context.Context, m []byte) error {
+		return Transcribe(ctx, queries, defaultStorage, string(m))
+	})
+
+	r.Start(context.Background())
+}
+
+// PruneVoiceNotesCron은 끊긴 업로드의 임시 파일과 원본을 보관하지 않는 녹음 기록을 정리한다.
+func PruneVoiceNotesCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@hourly", func() {
+		ctx := context.Background()
+		before := time.Now().UTC().Add(-staleAfter).Format(dateutil.DateFormatISOTime)
+		rows, err := queries.ListPrunableVoiceNotes(ctx, before)
+		if err != nil {
+			slog.Error("음성 일기 정리 대상 조회 실패", "error", err)
+			return
+		}
+		for _, row := range rows {
+			if err := os.Remove(spoolPath(row.ID)); err != nil && !os.IsNotExist(err) {
+				slog.Warn("음성 임시 파일 삭제 실패", "id", row.ID, "error", err)
+			}
+			if row.Object != "" {
+				if err := defaultStorage.Delete(ctx, row.Object); err != nil {
+					slog.Error("음성 파일 삭제 실패", "id", row.ID, "error", err)
+					continue
+				}
+			}
+			if err := queries.DeleteVoiceNoteByID(ctx, row.ID); err != nil {
+				slog.Error("음성 일기 정리 실패", "id", row.ID, "error", err)
+			}
+		}
+		if len(rows) > 0 {
+			slog.Info("음성 일기 정리", "count", len(rows))
+		}
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
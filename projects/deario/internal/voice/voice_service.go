@@ -0,0 +1,377 @@
+package voice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	aiclient "simple-server/internal/ai"
+	"simple-server/internal/config"
+	"simple-server/projects/deario/db"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// MaxChunkSize는 한 번에 올릴 수 있는 조각 크기다. 공통 BodyLimit(5M)보다 작게 둔다.
+	MaxChunkSize = 2 << 20
+	// MaxAudioSize는 녹음 한 건의 최대 크기다. 32kbps opus 기준으로 2시간 남짓이다.
+	MaxAudioSize = 100 << 20
+
+	statusUploading    = "uploading"
+	statusQueued       = "queued"
+	statusTranscribing = "transcribing"
+	statusDone         = "done"
+	statusFailed       = "failed"
+)
+
+var (
+	errOffsetMismatch = echo.NewHTTPError(http.StatusConflict, "업로드 위치가 맞지 않습니다. 다시 이어서 올려주세요.")
+	errChunkTooLarge  = echo.NewHTTPError(http.StatusRequestEntityTooLarge, "한 번에 올릴 수 있는 크기를 넘었습니다.")
+	errAudioTooLarge  = echo.NewHTTPError(http.StatusRequestEntityTooLarge, "녹음이 너무 깁니다.")
+)
+
+// spoolDir은 받아쓰기 전까지 올라온 조각을 이어 붙여 두는 곳이다.
+func spoolDir() string {
+	return config.GetEnvOrDefault("VOICE_SPOOL_DIR", filepath.Join(os.TempDir(), "deario-voice"))
+}
+
+func spoolPath(id string) string {
+	return filepath.Join(spoolDir(), id)
+}
+
+// normalizeMIMEType은 "audio/webm;codecs=opus"처럼 붙은 옵션을 떼고 음성 형식인지 확인한다.
+func normalizeMIMEType(raw string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(raw)
+	if err != nil || !strings.HasPrefix(mediaType, "audio/") {
+		return "", false
+	}
+	return mediaType, true
+}
+
+func extension(mimeType string) string {
+	switch mimeType {
+	case "audio/webm":
+		return ".webm"
+	case "audio/ogg":
+		return ".ogg"
+	case "audio/mp4", "audio/x-m4a", "audio/aac":
+		return ".m4a"
+	case "audio/mpeg":
+		return ".mp3"
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		return ".wav"
+	default:
+		return ".bin"
+	}
+}
+
+// objectName은 원본 음성을 보관할 스토리지 경로다.
+func objectName(note db.VoiceNote) string {
+	return fmt.Sprintf("voice/%s/%s/%s%s", note.Uid, note.Date, note.ID, extension(note.MimeType))
+}
+
+// noteLock은 녹음 하나의 조각 쓰기 잠금이다. refs는 잠금을 잡았거나 기다리는 요청 수다.
+type noteLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+var (
+	appendMu    sync.Mutex
+	appendLocks = make(map[string]*noteLock)
+)
+
+// lockNote는 같은 녹음의 조각이 동시에 쓰이지 않게 막는다.
+// 기다리는 요청이 남아 있는 동안 잠금을 지우면 다음 요청이 새 잠금을 만들어 함께 쓰게 되므로, 마지막 요청이 풀 때만 지운다.
+func lockNote(id string) func() {
+	appendMu.Lock()
+	l, ok := appendLocks[id]
+	if !ok {
+		l = &noteLock{}
+		appendLocks[id] = l
+	}
+	l.refs++
+	appendMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		appendMu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(appendLocks, id)
+		}
+		appendMu.Unlock()
+	}
+}
+
+// AppendChunk는 offset 위치에 조각을 이어 붙이고 새 크기를 반환한다.
+// offset이 지금까지 받은 크기와 다르면 errOffsetMismatch를 반환한다. 클라이언트는 상태를 다시 받아 이어서 올린다.
+// 지난 요청이 중간에 끊겨 파일에만 남은 뒷부분은 잘라내고 다시 쓴다.
+func AppendChunk(ctx context.Context, queries *db.Queries, note db.VoiceNote, offset int64, r io.Reader) (int64, error) {
+	unlock := lockNote(note.ID)
+	defer unlock()
+
+	current, err := queries.GetVoiceNote(ctx, db.GetVoiceNoteParams{ID: note.ID, Uid: note.Uid})
+	if err != nil {
+		return 0, err
+	}
+	if current.Status != statusUploading || current.Size != offset {
+		return current.Size, errOffsetMismatch
+	}
+
+	if err := os.MkdirAll(spoolDir(), 0o700); err != nil {
+		return offset, fmt.Errorf("음성 임시 폴더 생성 실패: %w", err)
+	}
+	f, err := os.OpenFile(spoolPath(note.ID), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return offset, fmt.Errorf("음성 임시 파일 열기 실패: %w", err)
+	}
+	defer f.Close()
+	if err := f.Truncate(offset); err != nil {
+		return offset, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	n, err := io.Copy(f, io.LimitReader(r, MaxChunkSize+1))
+	if err != nil {
+		return offset, fmt.Errorf("음성 조각 저장 실패: %w", err)
+	}
+	if n > MaxChunkSize {
+		return offset, errChunkTooLarge
+	}
+	if offset+n > MaxAudioSize {
+		return offset, errAudioTooLarge
+	}
+	// 첫 조각에서 형식을 확인해 두면 긴 녹음을 다 올린 뒤에야 거절하는 일이 없다.
+	if offset == 0 {
+		head := make([]byte, 512)
+		m, _ := f.ReadAt(head, 0)
+		if _, ok := sniffAudio(head[:m]); !ok && m >= 12 {
+			return offset, errUnsupportedAudio
+		}
+	}
+
+	rows, err := queries.AppendVoiceNoteChunk(ctx, db.AppendVoiceNoteChunkParams{
+		NewSize: offset + n,
+		ID:      note.ID,
+		Uid:     note.Uid,
+		Offset:  offset,
+	})
+	if err != nil {
+		return offset, err
+	}
+	if rows == 0 {
+		return offset, errOffsetMismatch
+	}
+	return offset + n, nil
+}
+
+// inspectUpload는 다 올라온 녹음의 형식과 길이를 확인한다.
+// 받을 수 없는 녹음이면 failed로 두고 임시 파일을 지운 뒤 사용자에게 보여줄 오류를 반환한다.
+func inspectUpload(ctx context.Context, queries *db.Queries, note db.VoiceNote) (audioInfo, error) {
+	info, err := inspectAudio(spoolPath(note.ID))
+	if err == nil {
+		err = checkAudio(info)
+	}
+	var he *echo.HTTPError
+	if !errors.As(err, &he) {
+		return info, err
+	}
+
+	slog.Warn("음성 일기 업로드 거절", "id", note.ID, "mime", info.MIMEType, "duration", info.Duration, "error", he.Message)
+	if err := queries.FailVoiceNote(ctx, db.FailVoiceNoteParams{Error: fmt.Sprint(he.Message), ID: note.ID}); err != nil {
+		return info, err
+	}
+	if err := os.Remove(spoolPath(note.ID)); err != nil && !os.IsNotExist(err) {
+		slog.Warn("음성 임시 파일 삭제 실패", "id", note.ID, "error", err)
+	}
+	return info, he
+}
+
+// transcribeFile은 테스트에서 AI 요청을 바꿔 끼우기 위한 것이다.
+var transcribeFile = aiclient.TranscribeAudioFile
+
+// Transcribe는 올라온 녹음을 받아쓰고 결과를 저장한다. 원본 보관을 켠 녹음은 스토리지에 올린다.
+// 받아쓰기에 실패하면 녹음을 failed로 두고 nil을 반환한다. 사용자가 다시 녹음하면 되므로 작업을 재시도하지 않는다.
+// 상태 저장처럼 잠깐의 오류는 그대로 반환해 작업을 재시도하므로, 임시 파일은 done이나 failed로 끝났을 때만 지운다.
+func Transcribe(ctx context.Context, queries *db.Queries, store Storage, id string) (err error) {
+	note, err := queries.GetVoiceNoteByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("음성 일기 조회 실패: %w", err)
+	}
+	if note.Status != statusQueued && note.Status != statusTranscribing {
+		return nil
+	}
+	path := spoolPath(note.ID)
+	defer func() {
+		if err == nil {
+			os.Remove(spoolPath(note.ID))
+		}
+	}()
+
+	if err := setProgress(ctx, queries, note.ID, 10); err != nil {
+		return err
+	}
+
+	// 길이를 알면 긴 녹음을 구간으로 나눠 받아쓴다. 길이를 모르면 한 번에 받아쓴다.
+	var duration time.Duration
+	if note.MimeType == mimeWAV {
+		normalized, d, err := normalizeFile(path)
+		if err != nil {
+			return fail(ctx, queries, note, "음성 파일을 변환하지 못했습니다.", err)
+		}
+		defer os.Remove(normalized)
+		path = normalized
+		duration = d
+	} else if info, err := inspectAudio(path); err == nil {
+		duration = info.Duration
+	}
+
+	object := ""
+	if note.KeepAudio == 1 {
+		object = objectName(note)
+		if err := putFile(ctx, store, object, note.MimeType, path); err != nil {
+			return fail(ctx, queries, note, "음성 원본을 저장하지 못했습니다.", err)
+		}
+		if err := setProgress(ctx, queries, note.ID, 30); err != nil {
+			return err
+		}
+	}
+
+	text, err := transcribeFile(ctx, path, note.MimeType, duration, func(done, total int) {
+		if err := setProgress(ctx, queries, note.ID, segmentProgress(done, total)); err != nil {
+			slog.Warn("음성 일기 진행률 저장 실패", "id", note.ID, "error", err)
+		}
+	})
+	if err != nil {
+		if object != "" {
+			if err := store.Delete(ctx, object); err != nil {
+				slog.Warn("음성 원본 삭제 실패", "id", note.ID, "error", err)
+			}
+		}
+		return fail(ctx, queries, note, failureMessage(err), err)
+	}
+
+	return queries.FinishVoiceNote(ctx, db.FinishVoiceNoteParams{
+		Transcript: CleanTranscript(text),
+		Object:     object,
+		ID:         note.ID,
+	})
+}
+
+// segmentProgress는 구간을 나눠 받아쓸 때의 진행률이다. 저장 단계 뒤인 30%부터 95%까지 구간 수에 맞춰 올린다.
+func segmentProgress(done, total int) int64 {
+	return 30 + int64(65*done/total)
+}
+
+// normalizeFile은 wav 녹음을 16kHz 모노 PCM으로 바꾼 파일을 옆에 만들고 그 경로와 길이를 반환한다.
+func normalizeFile(path string) (string, time.Duration, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path + ".wav")
+	if err != nil {
+		return "", 0, err
+	}
+	duration, err := normalizeWAV(src, dst)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", 0, err
+	}
+	if duration == 0 {
+		os.Remove(dst.Name())
+		return "", 0, errAudioTooShort
+	}
+	return dst.Name(), duration, nil
+}
+
+func setProgress(ctx context.Context, queries *db.Queries, id string, progress int64) error {
+	return queries.UpdateVoiceNoteProgress(ctx, db.UpdateVoiceNoteProgressParams{
+		Status:   statusTranscribing,
+		Progress: progress,
+		ID:       id,
+	})
+}
+
+func putFile(ctx context.Context, store Storage, object, mimeType, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return store.Put(ctx, object, mimeType, f)
+}
+
+func fail(ctx context.Context, queries *db.Queries, note db.VoiceNote, message string, cause error) error {
+	slog.Error("음성 일기 받아쓰기 실패", "id", note.ID, "uid", note.Uid, "error", cause)
+	return queries.FailVoiceNote(ctx, db.FailVoiceNoteParams{Error: message, ID: note.ID})
+}
+
+// failureMessage는 AI 오류를 사용자에게 보여줄 문구로 바꾼다.
+func failureMessage(err error) string {
+	var he *echo.HTTPError
+	if errors.As(aiclient.HTTPError(err), &he) {
+		if message, ok := he.Message.(string); ok {
+			return message
+		}
+	}
+	return "음성 인식에 실패했습니다."
+}
+
+var (
+	speakerLabelPattern = regexp.MustCompile(`^(?i:(?:화자|발화자|speaker)\s*[0-9A-Za-z가-힣]{0,3}\s*[:：]\s*)`)
+	timestampPattern    = regexp.MustCompile(`^[\[(]?\d{1,2}:\d{2}(?::\d{2})?[\])]?\s*`)
+	spacePattern        = regexp.MustCompile(`[ \t\x{00A0}]+`)
+)
+
+// CleanTranscript는 받아쓴 글을 일기 문장으로 다듬는다.
+// 모델이 붙인 화자 표시와 시간 표시를 지우고, 공백과 빈 줄을 정리하고, 문장 부호 없이 끝난 줄에 마침표를 찍는다.
+func CleanTranscript(text string) string {
+	var paragraphs []string
+	blank := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		line = timestampPattern.ReplaceAllString(line, "")
+		line = speakerLabelPattern.ReplaceAllString(line, "")
+		line = strings.TrimSpace(spacePattern.ReplaceAllString(line, " "))
+		if line == "" {
+			blank = len(paragraphs) > 0
+			continue
+		}
+		if !endsWithPunctuation(line) {
+			line += "."
+		}
+		if blank {
+			paragraphs = append(paragraphs, "")
+			blank = false
+		}
+		paragraphs = append(paragraphs, line)
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+func endsWithPunctuation(line string) bool {
+	r, _ := utf8.DecodeLastRuneInString(line)
+	return strings.ContainsRune(".!?~\"')…”’", r)
+}
@@ -0,0 +1,478 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/db/dbtest"
+)
+
+func TestCleanTranscript(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"마침표 추가", "오늘은 산책을 했다", "오늘은 산책을 했다."},
+		{"문장 부호 유지", "정말 좋았어!", "정말 좋았어!"},
+		{"화자 표시 제거", "화자 1: 오늘 날씨가 맑았다", "오늘 날씨가 맑았다."},
+		{"영문 화자 표시 제거", "Speaker A: 점심은 국수", "점심은 국수."},
+		{"시간 표시 제거", "[00:12] 저녁에 친구를 만났다.", "저녁에 친구를 만났다."},
+		{"공백 정리", "  아침에   커피를\t마셨다  ", "아침에 커피를 마셨다."},
+		{"문단 유지", "첫 문단\n\n\n둘째 문단", "첫 문단.\n\n둘째 문단."},
+		{"앞뒤 빈 줄 제거", "\n\n내용\n\n", "내용."},
+		{"빈 입력", "  \n ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanTranscript(tt.in); got != tt.want {
+				t.Errorf("CleanTranscript(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeMIMEType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"audio/webm;codecs=opus", "audio/webm", true},
+		{"audio/mp4", "audio/mp4", true},
+		{"video/webm", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := normalizeMIMEType(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("normalizeMIMEType(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAppendChunk(t *testing.T) {
+	t.Setenv("VOICE_SPOOL_DIR", t.TempDir())
+	ctx := context.Background()
+	queries := dbtest.New(t)
+	note := createNote(t, queries, 0)
+
+	size, err := AppendChunk(ctx, queries, note, 0, strings.NewReader("hello "))
+	if err != nil || size != 6 {
+		t.Fatalf("첫 조각 = %d, %v", size, err)
+	}
+
+	// 서버가 받은 크기와 다른 위치에서 올리면 거절한다.
+	if _, err := AppendChunk(ctx, queries, note, 3, strings.NewReader("x")); !errors.Is(err, errOffsetMismatch) {
+		t.Fatalf("위치가 다른 조각 오류 = %v, want errOffsetMismatch", err)
+	}
+
+	// 지난 요청이 파일에만 남긴 뒷부분은 잘라내고 이어 쓴다.
+	f, err := os.OpenFile(spoolPath(note.ID), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("garbage")
+	f.Close()
+
+	size, err = AppendChunk(ctx, queries, note, 6, strings.NewReader("world"))
+	if err != nil || size != 11 {
+		t.Fatalf("이어 올린 조각 = %d, %v", size, err)
+	}
+	b, err := os.ReadFile(spoolPath(note.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello world" {
+		t.Fatalf("임시 파일 = %q", b)
+	}
+
+	if _, err := AppendChunk(ctx, queries, note, 11, bytes.NewReader(make([]byte, MaxChunkSize+1))); !errors.Is(err, errChunkTooLarge) {
+		t.Fatalf("큰 조각 오류 = %v, want errChunkTooLarge", err)
+	}
+	got, err := queries.GetVoiceNoteByID(ctx, note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Size != 11 {
+		t.Fatalf("거절된 조각 뒤 크기 = %d, want 11", got.Size)
+	}
+}
+
+func TestAppendChunkConcurrent(t *testing.T) {
+	t.Setenv("VOICE_SPOOL_DIR", t.TempDir())
+	ctx := context.Background()
+	queries := dbtest.New(t)
+	note := createNote(t, queries, 0)
+
+	// 같은 위치에 동시에 올린 조각 중 하나만 받아들이고, 파일에도 그 조각만 남아야 한다.
+	const workers = 8
+	chunks := make([][]byte, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		chunks[i] = append([]byte{0x1A, 0x45, 0xDF, 0xA3}, bytes.Repeat([]byte{byte('a' + i)}, 64<<10)...)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = AppendChunk(ctx, queries, note, 0, bytes.NewReader(chunks[i]))
+		}()
+	}
+	wg.Wait()
+
+	winner := -1
+	for i, err := range errs {
+		switch {
+		case err == nil && winner < 0:
+			winner = i
+		case err == nil:
+			t.Fatalf("조각 %d와 %d가 모두 받아들여짐", winner, i)
+		case !errors.Is(err, errOffsetMismatch):
+			t.Fatalf("조각 %d 오류 = %v", i, err)
+		}
+	}
+	if winner < 0 {
+		t.Fatal("받아들여진 조각이 없음")
+	}
+	b, err := os.ReadFile(spoolPath(note.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, chunks[winner]) {
+		t.Fatalf("임시 파일이 받아들인 조각과 다름 (%d bytes)", len(b))
+	}
+
+	appendMu.Lock()
+	defer appendMu.Unlock()
+	if len(appendLocks) != 0 {
+		t.Fatalf("남은 잠금 = %d", len(appendLocks))
+	}
+}
+
+func TestAppendChunkRejectsUnsupportedFormat(t *testing.T) {
+	t.Setenv("VOICE_SPOOL_DIR", t.TempDir())
+	queries := dbtest.New(t)
+	note := createNote(t, queries, 0)
+
+	_, err := AppendChunk(context.Background(), queries, note, 0, strings.NewReader("<!doctype html><html></html>"))
+	if !errors.Is(err, errUnsupportedAudio) {
+		t.Fatalf("err = %v, want errUnsupportedAudio", err)
+	}
+}
+
+func TestInspectUpload(t *testing.T) {
+	t.Setenv("VOICE_SPOOL_DIR", t.TempDir())
+	ctx := context.Background()
+	queries := dbtest.New(t)
+
+	t.Run("형식은 파일에서 정한다", func(t *testing.T) {
+		note := createNote(t, queries, 0)
+		wav := makeWAV(wavFormatPCM, 1, 16000, 16, 2*time.Second, true)
+		if _, err := AppendChunk(ctx, queries, note, 0, bytes.NewReader(wav)); err != nil {
+			t.Fatal(err)
+		}
+		info, err := inspectUpload(ctx, queries, note)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.MIMEType != mimeWAV || info.Duration != 2*time.Second {
+			t.Fatalf("info = %+v", info)
+		}
+	})
+
+	t.Run("짧은 녹음은 거절", func(t *testing.T) {
+		note := createNote(t, queries, 0)
+		wav := makeWAV(wavFormatPCM, 1, 16000, 16, 200*time.Millisecond, false)
+		if _, err := AppendChunk(ctx, queries, note, 0, bytes.NewReader(wav)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := inspectUpload(ctx, queries, note); !errors.Is(err, errAudioTooShort) {
+			t.Fatalf("err = %v, want errAudioTooShort", err)
+		}
+		got, err := queries.GetVoiceNoteByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != statusFailed || got.Error != errAudioTooShort.Message {
+			t.Fatalf("결과 = %+v", got)
+		}
+		if _, err := os.Stat(spoolPath(note.ID)); !os.IsNotExist(err) {
+			t.Fatalf("임시 파일이 남아 있음: %v", err)
+		}
+	})
+}
+
+type fakeStorage struct {
+	objects map[string][]byte
+}
+
+func (s *fakeStorage) Put(_ context.Context, object, _ string, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.objects[object] = b
+	return nil
+}
+
+func (s *fakeStorage) Open(_ context.Context, object string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(s.objects[object])), nil
+}
+
+func (s *fakeStorage) Delete(_ context.Context, object string) error {
+	delete(s.objects, object)
+	return nil
+}
+
+func TestTranscribe(t *testing.T) {
+	t.Setenv("VOICE_SPOOL_DIR", t.TempDir())
+	ctx := context.Background()
+	queries := dbtest.New(t)
+
+	orig := transcribeFile
+	t.Cleanup(func() { transcribeFile = orig })
+	var transcribeErr error
+	transcribeFile = func(_ context.Context, path, mimeType string, _ time.Duration, _ func(int, int)) (string, error) {
+		if mimeType != "audio/webm" {
+			t.Errorf("mimeType = %q", mimeType)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("임시 파일 없음: %v", err)
+		}
+		return "화자 1: 오늘은 비가 왔다", transcribeErr
+	}
+
+	t.Run("원본 보관", func(t *testing.T) {
+		store := &fakeStorage{objects: map[string][]byte{}}
+		note := uploadNote(t, queries, 1)
+
+		if err := Transcribe(ctx, queries, store, note.ID); err != nil {
+			t.Fatal(err)
+		}
+		got, err := queries.GetVoiceNoteByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != statusDone || got.Progress != 100 || got.Transcript != "오늘은 비가 왔다." {
+			t.Fatalf("결과 = %+v", got)
+		}
+		if got.Object != objectName(note) || string(store.objects[got.Object]) != "audio" {
+			t.Fatalf("보관한 원본 = %q, %v", got.Object, store.objects)
+		}
+		if _, err := os.Stat(spoolPath(note.ID)); !os.IsNotExist(err) {
+			t.Fatalf("임시 파일이 남아 있음: %v", err)
+		}
+	})
+
+	t.Run("wav는 변환해서 받아쓴다", func(t *testing.T) {
+		stub := transcribeFile
+		t.Cleanup(func() { transcribeFile = stub })
+		transcribeFile = func(_ context.Context, path, mimeType string, _ time.Duration, _ func(int, int)) (string, error) {
+			f, err := os.Open(path)
+			if err != nil {
+				return "", err
+			}
+			defer f.Close()
+			format, _, err := readWAVHeader(f)
+			if err != nil || format.sampleRate != normalizedRate || format.channels != 1 {
+				t.Errorf("변환된 wav = %+v, %v", format, err)
+			}
+			return "변환 완료", nil
+		}
+
+		store := &fakeStorage{objects: map[string][]byte{}}
+		note := createNote(t, queries, 1)
+		wav := makeWAV(wavFormatPCM, 2, 48000, 16, time.Second, true)
+		if _, err := AppendChunk(ctx, queries, note, 0, bytes.NewReader(wav)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := queries.QueueVoiceNote(ctx, db.QueueVoiceNoteParams{MimeType: mimeWAV, ID: note.ID, Uid: note.Uid}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := Transcribe(ctx, queries, store, note.ID); err != nil {
+			t.Fatal(err)
+		}
+		got, err := queries.GetVoiceNoteByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != statusDone || len(store.objects[got.Object]) != 44+normalizedRate*2 {
+			t.Fatalf("결과 = %+v, 보관한 원본 %d bytes", got, len(store.objects[got.Object]))
+		}
+		if _, err := os.Stat(spoolPath(note.ID) + ".wav"); !os.IsNotExist(err) {
+			t.Fatalf("변환 파일이 남아 있음: %v", err)
+		}
+	})
+
+	t.Run("긴 녹음은 구간마다 진행률을 올린다", func(t *testing.T) {
+		stub := transcribeFile
+		t.Cleanup(func() { transcribeFile = stub })
+		store := &fakeStorage{objects: map[string][]byte{}}
+		note := createNote(t, queries, 0)
+		wav := makeWAV(wavFormatPCM, 1, 8000, 16, 11*time.Minute, true)
+		for offset := 0; offset < len(wav); offset += MaxChunkSize {
+			chunk := wav[offset:min(offset+MaxChunkSize, len(wav))]
+			if _, err := AppendChunk(ctx, queries, note, int64(offset), bytes.NewReader(chunk)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := queries.QueueVoiceNote(ctx, db.QueueVoiceNoteParams{MimeType: mimeWAV, ID: note.ID, Uid: note.Uid}); err != nil {
+			t.Fatal(err)
+		}
+
+		var progress []int64
+		transcribeFile = func(_ context.Context, _, _ string, duration time.Duration, report func(int, int)) (string, error) {
+			// 변환한 wav의 길이를 넘긴다. 리샘플링으로 마지막 샘플 하나 정도는 달라질 수 있다.
+			if duration < 11*time.Minute-time.Millisecond || duration > 11*time.Minute {
+				t.Errorf("duration = %v", duration)
+			}
+			for i := 1; i <= 3; i++ {
+				report(i, 3)
+				got, err := queries.GetVoiceNoteByID(ctx, note.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				progress = append(progress, got.Progress)
+			}
+			return "긴 녹음", nil
+		}
+
+		if err := Transcribe(ctx, queries, store, note.ID); err != nil {
+			t.Fatal(err)
+		}
+		if want := []int64{51, 73, 95}; !slices.Equal(progress, want) {
+			t.Errorf("진행률 = %v, want %v", progress, want)
+		}
+	})
+
+	t.Run("재시도할 오류면 임시 파일을 남긴다", func(t *testing.T) {
+		stub := transcribeFile
+		t.Cleanup(func() { transcribeFile = stub })
+		store := &fakeStorage{objects: map[string][]byte{}}
+		note := uploadNote(t, queries, 0)
+
+		// 받아쓰기 뒤 결과 저장이 취소되면 작업이 다시 돌 때 같은 파일을 읽어야 한다.
+		cctx, cancel := context.WithCancel(ctx)
+		transcribeFile = func(_ context.Context, path, mimeType string, _ time.Duration, _ func(int, int)) (string, error) {
+			cancel()
+			return "오늘은 맑았다", nil
+		}
+		if err := Transcribe(cctx, queries, store, note.ID); err == nil {
+			t.Fatal("취소된 저장이 성공으로 끝남")
+		}
+		if _, err := os.Stat(spoolPath(note.ID)); err != nil {
+			t.Fatalf("재시도 전에 임시 파일이 지워짐: %v", err)
+		}
+
+		transcribeFile = stub
+		if err := Transcribe(ctx, queries, store, note.ID); err != nil {
+			t.Fatal(err)
+		}
+		got, err := queries.GetVoiceNoteByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != statusDone {
+			t.Fatalf("재시도 결과 = %+v", got)
+		}
+		if _, err := os.Stat(spoolPath(note.ID)); !os.IsNotExist(err) {
+			t.Fatalf("임시 파일이 남아 있음: %v", err)
+		}
+	})
+
+	t.Run("실패하면 원본 삭제", func(t *testing.T) {
+		transcribeErr = errors.New("boom")
+		t.Cleanup(func() { transcribeErr = nil })
+		store := &fakeStorage{objects: map[string][]byte{}}
+		note := uploadNote(t, queries, 1)
+
+		if err := Transcribe(ctx, queries, store, note.ID); err != nil {
+			t.Fatal(err)
+		}
+		got, err := queries.GetVoiceNoteByID(ctx, note.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != statusFailed || got.Error == "" || got.Object != "" {
+			t.Fatalf("결과 = %+v", got)
+		}
+		if len(store.objects) != 0 {
+			t.Fatalf("남은 원본 = %v", store.objects)
+		}
+	})
+}
+
+func TestListVoiceNotes(t *testing.T) {
+	ctx := context.Background()
+	queries := dbtest.New(t)
+
+	// 원본을 보관하지 않아도 받아쓴 글이 있으면 목록에 남는다.
+	withText := createNote(t, queries, 0)
+	if err := queries.FinishVoiceNote(ctx, db.FinishVoiceNoteParams{Transcript: "오늘은 맑았다.", ID: withText.ID}); err != nil {
+		t.Fatal(err)
+	}
+	empty := createNote(t, queries, 0)
+	if err := queries.FinishVoiceNote(ctx, db.FinishVoiceNoteParams{ID: empty.ID}); err != nil {
+		t.Fatal(err)
+	}
+	createNote(t, queries, 0)
+
+	notes, err := queries.ListVoiceNotes(ctx, db.ListVoiceNotesParams{Uid: "user-1", Date: "20261019"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes[0].ID != withText.ID {
+		t.Fatalf("목록 = %+v", notes)
+	}
+
+	rows, err := queries.ListPrunableVoiceNotes(ctx, "9999-12-31 00:00:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if row.ID == withText.ID {
+			t.Fatal("받아쓴 글이 있는 녹음을 정리 대상으로 봄")
+		}
+	}
+	if len(rows) != 2 {
+		t.Fatalf("정리 대상 = %d, want 2", len(rows))
+	}
+}
+
+func createNote(t *testing.T, queries *db.Queries, keepAudio int64) db.VoiceNote {
+	t.Helper()
+	note, err := queries.CreateVoiceNote(context.Background(), db.CreateVoiceNoteParams{
+		Uid:       "user-1",
+		Date:      "20261019",
+		MimeType:  "audio/webm",
+		KeepAudio: keepAudio,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return note
+}
+
+// uploadNote는 조각을 올리고 받아쓰기 대기 상태로 둔 녹음을 만든다.
+func uploadNote(t *testing.T, queries *db.Queries, keepAudio int64) db.VoiceNote {
+	t.Helper()
+	ctx := context.Background()
+	note := createNote(t, queries, keepAudio)
+	if _, err := AppendChunk(ctx, queries, note, 0, strings.NewReader("audio")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queries.QueueVoiceNote(ctx, db.QueueVoiceNoteParams{MimeType: note.MimeType, ID: note.ID, Uid: note.Uid}); err != nil {
+		t.Fatal(err)
+	}
+	return note
+}
@@ -0,0 +1,99 @@
+package voice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"simple-server/internal/middleware"
+	"simple-server/projects/deario/internal/diary"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// Storage는 음성 원본을 보관하는 곳이다.
+type Storage interface {
+	Put(ctx context.Context, object, contentType string, r io.Reader) error
+	Open(ctx context.Context, object string) (io.ReadCloser, error)
+	Delete(ctx context.Context, object string) error
+}
+
+// firebaseStorage는 일기 이미지와 같은 파이어베이스 버킷에 음성 원본을 둔다.
+type firebaseStorage struct{}
+
+var defaultStorage Storage = firebaseStorage{}
+
+func (firebaseStorage) bucket(ctx context.Context) (*storage.BucketHandle, error) {
+	client, err := middleware.App.Storage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("스토리지 클라이언트 생성 실패: %w", err)
+	}
+	b, err := client.Bucket(diary.StorageBucket())
+	if err != nil {
+		return nil, fmt.Errorf("버킷 가져오기 실패: %w", err)
+	}
+	return b, nil
+}
+
+func (s firebaseStorage) Put(ctx context.Context, object, contentType string, r io.Reader) error {
+	b, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	w := b.Object(object).NewWriter(ctx)
+	w.ContentType = contentType
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return fmt.Errorf("음성 파일 업로드 실패: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("음성 파일 업로드 실패: %w", err)
+	}
+	return nil
+}
+
+func (s firebaseStorage) Open(ctx context.Context, object string) (io.ReadCloser, error) {
+	b, err := s.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.Object(object).NewReader(ctx)
+}
+
+func (s firebaseStorage) Delete(ctx context.Context, object string) error {
+	b, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	if err := b.Object(object).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("음성 파일 삭제 실패: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserAudio는 사용자가 보관한 음성 원본을 모두 지우고 지운 개수를 반환한다.
+func DeleteUserAudio(ctx context.Context, uid string) (int, error) {
+	b, err := firebaseStorage{}.bucket(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	it := b.Objects(ctx, &storage.Query{Prefix: "voice/" + uid + "/"})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("음성 파일 조회 실패: %w", err)
+		}
+		if err := b.Object(attrs.Name).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+			return deleted, fmt.Errorf("음성 파일 삭제 실패: %w", err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,261 @@
+package voice
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	// normalizedRate는 받아쓰기에 보내는 wav의 샘플레이트다. 말소리는 16kHz면 충분하고 크기가 1/3로 준다.
+	normalizedRate = 16000
+
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+
+	// wavUnknownSize는 녹음하면서 쓴 wav가 크기 자리에 넣어 두는 값이다.
+	wavUnknownSize = 0xFFFFFFFF
+)
+
+var errWAVFormat = errors.New("지원하지 않는 wav 형식")
+
+type wavFormat struct {
+	format     uint16
+	channels   int
+	sampleRate int
+	blockAlign int
+	bits       int
+}
+
+func (f wavFormat) duration(dataSize int64) time.Duration {
+	if f.blockAlign == 0 || f.sampleRate == 0 {
+		return 0
+	}
+	frames := dataSize / int64(f.blockAlign)
+	return time.Duration(frames) * time.Second / time.Duration(f.sampleRate)
+}
+
+// readWAVHeader는 RIFF 헤더와 fmt 청크를 읽고 data 청크 시작까지 넘긴다.
+// data 크기를 알 수 없으면(0 또는 0xFFFFFFFF) -1을 반환한다.
+func readWAVHeader(r io.Reader) (wavFormat, int64, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return wavFormat{}, 0, err
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return wavFormat{}, 0, errWAVFormat
+	}
+
+	var format wavFormat
+	hasFormat := false
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return wavFormat{}, 0, err
+		}
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+
+		switch string(header[0:4]) {
+		case "fmt ":
+			if size < 16 || size > 64 {
+				return wavFormat{}, 0, errWAVFormat
+			}
+			b := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return wavFormat{}, 0, err
+			}
+			format = wavFormat{
+				format:     binary.LittleEndian.Uint16(b[0:2]),
+				channels:   int(binary.LittleEndian.Uint16(b[2:4])),
+				sampleRate: int(binary.LittleEndian.Uint32(b[4:8])),
+				blockAlign: int(binary.LittleEndian.Uint16(b[12:14])),
+				bits:       int(binary.LittleEndian.Uint16(b[14:16])),
+			}
+			if format.format == wavFormatExtensible && size >= 40 {
+				// WAVE_FORMAT_EXTENSIBLE은 SubFormat GUID 앞 2바이트가 실제 형식이다.
+				format.format = binary.LittleEndian.Uint16(b[24:26])
+			}
+			if err := format.validate(); err != nil {
+				return wavFormat{}, 0, err
+			}
+			hasFormat = true
+		case "data":
+			if !hasFormat {
+				return wavFormat{}, 0, errWAVFormat
+			}
+			if size == 0 || size == wavUnknownSize {
+				return format, -1, nil
+			}
+			return format, size, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return wavFormat{}, 0, err
+			}
+		}
+	}
+}
+
+func (f wavFormat) validate() error {
+	if f.channels < 1 || f.channels > 8 || f.sampleRate < 8000 || f.sampleRate > 192000 {
+		return errWAVFormat
+	}
+	switch {
+	case f.format == wavFormatPCM && (f.bits == 8 || f.bits == 16 || f.bits == 24 || f.bits == 32):
+	case f.format == wavFormatFloat && (f.bits == 32 || f.bits == 64):
+	default:
+		return fmt.Errorf("%w: format=%d bits=%d", errWAVFormat, f.format, f.bits)
+	}
+	if f.blockAlign != f.channels*f.bits/8 {
+		return errWAVFormat
+	}
+	return nil
+}
+
+// sample은 한 프레임의 채널을 평균 내 -1~1 사이 모노 샘플로 바꾼다.
+func (f wavFormat) sample(frame []byte) float64 {
+	width := f.bits / 8
+	sum := 0.0
+	for ch := range f.channels {
+		b := frame[ch*width : (ch+1)*width]
+		switch {
+		case f.format == wavFormatFloat && f.bits == 32:
+			sum += float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		case f.format == wavFormatFloat:
+			sum += math.Float64frombits(binary.LittleEndian.Uint64(b))
+		case f.bits == 8:
+			sum += (float64(b[0]) - 128) / 128
+		case f.bits == 16:
+			sum += float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+		case f.bits == 24:
+			v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+			sum += float64(v) / (1 << 23)
+		case f.bits == 32:
+			sum += float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+		}
+	}
+	return sum / float64(f.channels)
+}
+
+// normalizeWAV는 여러 형식의 wav를 16kHz 모노 16bit PCM wav로 바꾸고 길이를 반환한다.
+// iOS Safari처럼 MediaRecorder로 녹음하지 못하는 브라우저는 PCM을 그대로 올리므로
+// 헤더의 크기가 비어 있고 샘플레이트도 기기마다 다르다. 받아쓰기 전에 한 형식으로 맞춘다.
+func normalizeWAV(r io.Reader, w io.WriteSeeker) (time.Duration, error) {
+	br := bufio.NewReader(r)
+	format, dataSize, err := readWAVHeader(br)
+	if err != nil {
+		return 0, err
+	}
+	var data io.Reader = br
+	if dataSize >= 0 {
+		data = io.LimitReader(br, dataSize)
+	}
+
+	if _, err := w.Write(wavHeader(0)); err != nil {
+		return 0, err
+	}
+	bw := bufio.NewWriter(w)
+	out := make([]byte, 2)
+	written := int64(0)
+	rs := newResampler(format.sampleRate, normalizedRate, func(v float64) error {
+		v = max(-1, min(1, v))
+		binary.LittleEndian.PutUint16(out, uint16(int16(math.Round(v*math.MaxInt16))))
+		written++
+		_, err := bw.Write(out)
+		return err
+	})
+
+	frame := make([]byte, format.blockAlign)
+	for {
+		if _, err := io.ReadFull(data, frame); err != nil {
+			// 녹음이 끊겨 마지막 프레임이 덜 들어온 경우는 버린다.
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return 0, err
+		}
+		if err := rs.push(format.sample(frame)); err != nil {
+			return 0, err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+
+	if _, err := w.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(wavHeader(written * 2)); err != nil {
+		return 0, err
+	}
+	return time.Duration(written) * time.Second / normalizedRate, nil
+}
+
+// wavHeader는 16kHz 모노 16bit PCM wav 헤더를 만든다.
+func wavHeader(dataSize int64) []byte {
+	b := make([]byte, 44)
+	copy(b[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(b[4:8], uint32(36+dataSize))
+	copy(b[8:12], "WAVE")
+	copy(b[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(b[16:20], 16)
+	binary.LittleEndian.PutUint16(b[20:22], wavFormatPCM)
+	binary.LittleEndian.PutUint16(b[22:24], 1)
+	binary.LittleEndian.PutUint32(b[24:28], normalizedRate)
+	binary.LittleEndian.PutUint32(b[28:32], normalizedRate*2)
+	binary.LittleEndian.PutUint16(b[32:34], 2)
+	binary.LittleEndian.PutUint16(b[34:36], 16)
+	copy(b[36:40], "data")
+	binary.LittleEndian.PutUint32(b[40:44], uint32(dataSize))
+	return b
+}
+
+// resampler는 선형 보간으로 샘플레이트를 바꾼다.
+// 낮출 때는 접힘 잡음을 줄이려고 비율만큼 이동 평균을 먼저 낸다.
+type resampler struct {
+	step   float64
+	t      float64
+	n      int
+	prev   float64
+	window []float64
+	sum    float64
+	emit   func(float64) error
+}
+
+func newResampler(from, to int, emit func(float64) error) *resampler {
+	r := &resampler{step: float64(from) / float64(to), emit: emit}
+	if width := int(math.Round(r.step)); width > 1 {
+		r.window = make([]float64, 0, width)
+	}
+	return r
+}
+
+func (r *resampler) push(x float64) error {
+	if r.window != nil {
+		if len(r.window) == cap(r.window) {
+			r.sum -= r.window[0]
+			r.window = append(r.window[:0], r.window[1:]...)
+		}
+		r.window = append(r.window, x)
+		r.sum += x
+		x = r.sum / float64(len(r.window))
+	}
+
+	if r.n == 0 {
+		r.prev = x
+	}
+	for r.t <= float64(r.n) {
+		frac := r.t - float64(r.n-1)
+		if err := r.emit(r.prev + (x-r.prev)*frac); err != nil {
+			return err
+		}
+		r.t += r.step
+	}
+	r.prev = x
+	r.n++
+	return nil
+}
@@ -0,0 +1,281 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"simple-server/internal/config"
+	"simple-server/internal/validate"
+	"simple-server/pkg/util/authutil"
+	"simple-server/projects/deario/db"
+	"simple-server/projects/deario/internal/deariodate"
+	"simple-server/projects/deario/views/components"
+
+	"github.com/labstack/echo/v4"
+)
+
+type createWebhookDTO struct {
+	URL    string   `form:"url" validate:"required,max=500" message:"주소는 1~500자로 입력해주세요."`
+	Events []string `form:"events" validate:"required,min=1" message:"이벤트를 하나 이상 골라주세요."`
+}
+
+// WebhooksPanel은 설정 화면의 웹훅 목록을 렌더링한다.
+func WebhooksPanel(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	return renderWebhooks(c, queries, uid, "")
+}
+
+// CreateWebhook은 웹훅을 등록하고 서명 비밀 값을 한 번만 보여준다.
+// 개발 환경에서만 로컬 테스트를 위해 http 주소를 허용한다.
+func CreateWebhook(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	var dto createWebhookDTO
+	if err := c.Bind(&dto); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "요청 본문이 올바르지 않습니다.")
+	}
+	if err := c.Validate(&dto); err != nil {
+		return validate.HTTPError(err, &dto)
+	}
+
+	target, err := ValidateURL(dto.URL, config.IsDevEnv())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	events, err := ParseEvents(dto.Events)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "이벤트 값이 올바르지 않습니다.")
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	count, err := queries.CountWebhooks(c.Request().Context(), uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "웹훅 목록을 가져오지 못했습니다.")
+	}
+	if count >= MaxWebhooksPerUser {
+		return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("웹훅은 최대 %d개까지 등록할 수 있습니다.", MaxWebhooksPerUser))
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "웹훅 등록에 실패했습니다.")
+	}
+	if _, err := queries.CreateWebhook(c.Request().Context(), db.CreateWebhookParams{
+		Uid:    uid,
+		Url:    target,
+		Secret: secret,
+		Events: JoinEvents(events),
+	}); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "웹훅 등록에 실패했습니다.")
+	}
+
+	return renderWebhooks(c, queries, uid, secret)
+}
+
+// DeleteWebhook은 웹훅과 전송 기록을 삭제한다.
+func DeleteWebhook(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	count, err := queries.DeleteWebhook(c.Request().Context(), db.DeleteWebhookParams{ID: c.Param("id"), Uid: uid})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "웹훅 삭제에 실패했습니다.")
+	}
+	if count == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "웹훅을 찾을 수 없습니다.")
+	}
+
+	return renderWebhooks(c, queries, uid, "")
+}
+
+// EnableWebhook은 자동으로 꺼진 웹훅을 다시 켜고 실패 횟수를 초기화한다.
+func EnableWebhook(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	count, err := queries.EnableWebhook(c.Request().Context(), db.EnableWebhookParams{ID: c.Param("id"), Uid: uid})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "웹훅을 켜지 못했습니다.")
+	}
+	if count == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "웹훅을 찾을 수 없습니다.")
+	}
+
+	return renderWebhooks(c, queries, uid, "")
+}
+
+// TestWebhook은 테스트 이벤트를 보내고 전송 기록을 렌더링한다.
+func TestWebhook(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	hook, err := ownWebhook(c.Request().Context(), queries, uid, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if hook.Enabled == 0 {
+		return echo.NewHTTPError(http.StatusConflict, "꺼진 웹훅입니다. 다시 켠 뒤 보내주세요.")
+	}
+	if err := SendPing(c.Request().Context(), queries, hook); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "테스트 이벤트를 보내지 못했습니다.")
+	}
+
+	return renderDeliveries(c, queries, uid, hook.ID)
+}
+
+// WebhookDeliveries는 웹훅의 최근 전송 기록을 렌더링한다.
+func WebhookDeliveries(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	hook, err := ownWebhook(c.Request().Context(), queries, uid, c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return renderDeliveries(c, queries, uid, hook.ID)
+}
+
+// ReplayDelivery는 지난 전송을 같은 본문으로 다시 보낸다.
+func ReplayDelivery(c echo.Context) error {
+	uid, err := authutil.SessionUID(c)
+	if err != nil {
+		return err
+	}
+
+	queries, err := db.GetQueries()
+	if err != nil {
+		return err
+	}
+
+	d, err := Replay(c.Request().Context(), queries, uid, c.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return echo.NewHTTPError(http.StatusNotFound, "전송 기록을 찾을 수 없습니다.")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "다시 보내지 못했습니다.")
+	}
+
+	return renderDeliveries(c, queries, uid, d.WebhookID)
+}
+
+func ownWebhook(ctx context.Context, queries *db.Queries, uid, id string) (db.Webhook, error) {
+	hook, err := queries.GetWebhook(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && hook.Uid != uid) {
+		return db.Webhook{}, echo.NewHTTPError(http.StatusNotFound, "웹훅을 찾을 수 없습니다.")
+	}
+	if err != nil {
+		return db.Webhook{}, echo.NewHTTPError(http.StatusInternalServerError, "웹훅을 가져오지 못했습니다.")
+	}
+	return hook, nil
+}
+
+func renderWebhooks(c echo.Context, queries *db.Queries, uid, secret string) error {
+	ctx := c.Request().Context()
+	hooks, err := queries.ListWebhooks(ctx, uid)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "웹훅 목록을 가져오지 못했습니다.")
+	}
+
+	setting, _ := queries.GetUserSetting(ctx, uid)
+	loc := deariodate.Location(setting.Timezone)
+
+	panel := components.WebhookPanel{
+		Webhooks:  make([]components.WebhookItem, 0, len(hooks)),
+		Secret:    secret,
+		CanCreate: len(hooks) < MaxWebhooksPerUser,
+	}
+	for _, info := range AllEvents {
+		panel.Events = append(panel.Events, components.WebhookEventOption{Value: string(info.Event), Label: info.Label})
+	}
+	for _, h := range hooks {
+		item := components.WebhookItem{
+			ID:             h.ID,
+			URL:            h.Url,
+			Enabled:        h.Enabled == 1,
+			FailureCount:   h.FailureCount,
+			DisabledReason: h.DisabledReason,
+			Created:        deariodate.LocalTime(h.Created.String, loc),
+		}
+		for _, e := range splitEvents(h.Events) {
+			item.Events = append(item.Events, e.Label())
+		}
+		panel.Webhooks = append(panel.Webhooks, item)
+	}
+
+	return components.Webhooks(panel).Render(ctx, c.Response().Writer)
+}
+
+func renderDeliveries(c echo.Context, queries *db.Queries, uid, webhookID string) error {
+	ctx := c.Request().Context()
+	rows, err := queries.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{WebhookID: webhookID, Uid: uid})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "전송 기록을 가져오지 못했습니다.")
+	}
+
+	setting, _ := queries.GetUserSetting(ctx, uid)
+	loc := deariodate.Location(setting.Timezone)
+
+	items := make([]components.WebhookDeliveryItem, 0, len(rows))
+	for _, d := range rows {
+		items = append(items, components.WebhookDeliveryItem{
+			ID:             d.ID,
+			Event:          Event(d.Event).Label(),
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			ResponseStatus: d.ResponseStatus,
+			Error:          d.Error,
+			NextAttempt:    deariodate.LocalTime(d.NextAttempt, loc),
+			Created:        deariodate.LocalTime(d.Created.String, loc),
+		})
+	}
+
+	return components.WebhookDeliveries(webhookID, items).Render(ctx, c.Response().Writer)
+}
@@ -0,0 +1,337 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"simple-server/internal/config"
+	"simple-server/pkg/util/dateutil"
+	"simple-server/projects/deario/db"
+
+	"github.com/robfig/cron/v3"
+	"maragu.dev/goqite"
+	"maragu.dev/goqite/jobs"
+)
+
+const (
+	statusPending = "pending"
+	statusSuccess = "success"
+	statusFailed  = "failed"
+
+	// deliveryRetention은 전송 기록을 남겨 두는 기간이다.
+	deliveryRetention = 30 * 24 * time.Hour
+	// maxErrorLen은 전송 기록에 남길 오류 메시지 길이다.
+	maxErrorLen = 300
+)
+
+var errBlockedAddress = errors.New("내부망 주소로는 보낼 수 없습니다")
+
+var webhookQ *goqite.Queue
+var webhookQOnce sync.Once
+var errWebhookQ error
+
+// httpClient는 리다이렉트를 따라가지 않고, 내부망 주소로 연결하지 않는다.
+// 주소 검사를 연결 시점에 하므로 DNS 응답이 바뀌어도 우회할 수 없다.
+var httpClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: guardDial,
+		}).DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+func guardDial(_, address string, _ syscall.RawConn) error {
+	if config.IsDevEnv() {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+func InitWebhookQueue() error {
+	webhookQOnce.Do(func() {
+		webhookDB, err := db.GetDB(false)
+		if err != nil {
+			errWebhookQ = fmt.Errorf("웹훅 큐 데이터베이스 연결 실패: %w", err)
+			return
+		}
+
+		webhookQ = goqite.New(goqite.NewOpts{
+			DB:      webhookDB,
+			Name:    "webhook",
+			Timeout: 30 * time.Second,
+		})
+	})
+
+	return errWebhookQ
+}
+
+// Emit는 이벤트를 구독한 사용자의 웹훅마다 전송 기록을 만들고 큐에 넣는다.
+// 웹훅은 부가 기능이므로 실패해도 오류를 남기기만 하고 호출한 쪽 작업은 계속한다.
+func Emit(ctx context.Context, queries *db.Queries, uid string, event Event, data any) {
+	ctx = context.WithoutCancel(ctx)
+	hooks, err := queries.ListWebhooksForEvent(ctx, db.ListWebhooksForEventParams{
+		Uid:   uid,
+		Event: sql.NullString{String: string(event), Valid: true},
+	})
+	if err != nil {
+		slog.Error("웹훅 조회 실패", "uid", uid, "event", event, "error", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	payload, err := newPayload(event, data)
+	if err != nil {
+		slog.Error("웹훅 본문 생성 실패", "uid", uid, "event", event, "error", err)
+		return
+	}
+	for _, hook := range hooks {
+		if _, err := createDelivery(ctx, queries, hook, event, payload); err != nil {
+			slog.Error("웹훅 전송 등록 실패", "webhook", hook.ID, "event", event, "error", err)
+		}
+	}
+}
+
+// SendPing은 설정 화면에서 주소와 서명을 확인할 수 있도록 테스트 이벤트를 보낸다.
+func SendPing(ctx context.Context, queries *db.Queries, hook db.Webhook) error {
+	payload, err := newPayload(EventPing, map[string]string{"message": "Deario 웹훅 테스트입니다."})
+	if err != nil {
+		return err
+	}
+	_, err = createDelivery(ctx, queries, hook, EventPing, payload)
+	return err
+}
+
+// Replay는 지난 전송을 같은 본문으로 다시 보낸다. 본문의 이벤트 id가 같으므로 받는 쪽에서 중복을 거를 수 있다.
+func Replay(ctx context.Context, queries *db.Queries, uid, deliveryID string) (db.WebhookDelivery, error) {
+	d, err := queries.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+	if d.Uid != uid {
+		return db.WebhookDelivery{}, sql.ErrNoRows
+	}
+	hook, err := queries.GetWebhook(ctx, d.WebhookID)
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+	return createDelivery(ctx, queries, hook, Event(d.Event), []byte(d.Payload))
+}
+
+func newPayload(event Event, data any) ([]byte, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{
+		ID:      "evt_" + hex.EncodeToString(id),
+		Event:   event,
+		Created: time.Now().UTC().Truncate(time.Second),
+		Data:    data,
+	})
+}
+
+func createDelivery(ctx context.Context, queries *db.Queries, hook db.Webhook, event Event, payload []byte) (db.WebhookDelivery, error) {
+	if err := InitWebhookQueue(); err != nil {
+		return db.WebhookDelivery{}, err
+	}
+
+	d, err := queries.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+		WebhookID: hook.ID,
+		Uid:       hook.Uid,
+		Event:     string(event),
+		Payload:   string(payload),
+	})
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+	if _, err := jobs.Create(ctx, webhookQ, "deliver", goqite.Message{Body: []byte(d.ID)}); err != nil {
+		return db.WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+// WebhookDeliverJob은 큐에 쌓인 웹훅 전송을 처리한다.
+// 실패하면 Backoff만큼 기다렸다 다시 큐에 넣고, MaxAttempts번 모두 실패하면 전송을 포기한다.
+func WebhookDeliverJob() {
+	if err := InitWebhookQueue(); err != nil {
+		slog.Error("웹훅 큐 초기화 실패", "error", err)
+		return
+	}
+
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	r := jobs.NewRunner(jobs.NewRunnerOpts{
+		Limit:        2,
+		Log:          slog.Default(),
+		PollInterval: 1 * time.Second,
+		Queue:        webhookQ,
+	})
+
+	r.Register("deliver", func(ctx context.Context, m []byte) error {
+		return deliver(ctx, queries, string(m))
+	})
+
+	r.Start(context.Background())
+}
+
+func deliver(ctx context.Context, queries *db.Queries, deliveryID string) error {
+	d, err := queries.GetWebhookDelivery(ctx, deliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if d.Status != statusPending {
+		return nil
+	}
+
+	hook, err := queries.GetWebhook(ctx, d.WebhookID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	attempt := d.Attempts + 1
+	update := db.UpdateWebhookDeliveryParams{ID: d.ID, Attempts: attempt}
+	if hook.Enabled == 0 {
+		update.Attempts = d.Attempts
+		update.Status = statusFailed
+		update.Error = "웹훅이 꺼져 있어 보내지 않았습니다."
+		return queries.UpdateWebhookDelivery(ctx, update)
+	}
+
+	update.ResponseStatus, err = send(ctx, hook, d)
+	if err == nil {
+		update.Status = statusSuccess
+		if err := queries.UpdateWebhookDelivery(ctx, update); err != nil {
+			return err
+		}
+		return queries.ResetWebhookFailures(ctx, hook.ID)
+	}
+
+	update.Error = truncate(err.Error(), maxErrorLen)
+	if attempt < MaxAttempts {
+		delay := Backoff(int(attempt))
+		update.Status = statusPending
+		update.NextAttempt = time.Now().UTC().Add(delay).Format(dateutil.DateFormatISOTime)
+		if err := queries.UpdateWebhookDelivery(ctx, update); err != nil {
+			return err
+		}
+		_, err := jobs.Create(ctx, webhookQ, "deliver", goqite.Message{Body: []byte(d.ID), Delay: delay})
+		return err
+	}
+
+	update.Status = statusFailed
+	if err := queries.UpdateWebhookDelivery(ctx, update); err != nil {
+		return err
+	}
+	failures, err := queries.RecordWebhookFailure(ctx, hook.ID)
+	if err != nil {
+		return err
+	}
+	if failures >= DisableThreshold {
+		slog.Warn("웹훅 자동 비활성화", "webhook", hook.ID, "failures", failures)
+		return queries.DisableWebhook(ctx, db.DisableWebhookParams{
+			ID:             hook.ID,
+			DisabledReason: fmt.Sprintf("전송이 %d번 연속 실패해 자동으로 껐습니다.", failures),
+		})
+	}
+	return nil
+}
+
+// send는 서명한 본문을 웹훅 주소로 보내고 응답 코드를 반환한다. 2xx가 아니면 오류다.
+func send(ctx context.Context, hook db.Webhook, d db.WebhookDelivery) (int64, error) {
+	body := []byte(d.Payload)
+	now := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Deario-Webhook/1.0")
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, d.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, now, body))
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4<<10))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return int64(res.StatusCode), fmt.Errorf("응답 코드 %d", res.StatusCode)
+	}
+	return int64(res.StatusCode), nil
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+// PruneDeliveriesCron은 매일 보존 기간이 지난 전송 기록을 지운다.
+func PruneDeliveriesCron(c *cron.Cron) {
+	queries, err := db.GetQueries(false)
+	if err != nil {
+		slog.Error("쿼리 로드 실패", "error", err)
+		return
+	}
+
+	if _, err := c.AddFunc("@daily", func() {
+		before := time.Now().UTC().Add(-deliveryRetention).Format(dateutil.DateFormatISOTime)
+		count, err := queries.PruneWebhookDeliveries(context.Background(), before)
+		if err != nil {
+			slog.Error("웹훅 전송 기록 정리 실패", "error", err)
+			return
+		}
+		slog.Info("웹훅 전송 기록 정리", "count", count)
+	}); err != nil {
+		slog.Error("스케줄 등록 실패", "error", err)
+	}
+}
@@ -0,0 +1,247 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxWebhooksPerUser는 사용자당 등록할 수 있는 웹훅 수의 상한이다.
+	MaxWebhooksPerUser = 5
+	// MaxAttempts는 전송 하나를 포기하기 전까지 시도하는 횟수다.
+	MaxAttempts = 6
+	// DisableThreshold는 웹훅을 자동으로 끄기 전까지 연속으로 실패한 전송 수다.
+	DisableThreshold = 5
+
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// 전송 요청에 붙는 헤더다. 받는 쪽은 SignatureHeader를 TimestampHeader와 본문으로 검증한다.
+const (
+	EventHeader     = "X-Deario-Event"
+	DeliveryHeader  = "X-Deario-Delivery"
+	TimestampHeader = "X-Deario-Timestamp"
+	SignatureHeader = "X-Deario-Signature"
+)
+
+// Event는 웹훅으로 알리는 일기 이벤트다.
+type Event string
+
+const (
+	EventDiarySaved      Event = "diary.saved"
+	EventMoodUpdated     Event = "mood.updated"
+	EventAIFeedbackSaved Event = "ai_feedback.saved"
+	EventReportGenerated Event = "report.generated"
+	// EventPing은 설정 화면의 테스트 전송에만 쓰며 구독 대상이 아니다.
+	EventPing Event = "ping"
+)
+
+// EventInfo는 설정 화면에 보여줄 이벤트 설명이다.
+type EventInfo struct {
+	Event Event
+	Label string
+}
+
+// AllEvents는 구독할 수 있는 이벤트를 화면에 보여줄 순서대로 나열한다.
+var AllEvents = []EventInfo{
+	{Event: EventDiarySaved, Label: "일기 저장"},
+	{Event: EventMoodUpdated, Label: "기분 변경"},
+	{Event: EventAIFeedbackSaved, Label: "일기요정 답변 저장"},
+	{Event: EventReportGenerated, Label: "AI 리포트 생성"},
+}
+
+// Envelope는 웹훅 요청 본문이다.
+type Envelope struct {
+	ID      string    `json:"id"`
+	Event   Event     `json:"event"`
+	Created time.Time `json:"created"`
+	Data    any       `json:"data"`
+}
+
+// DiaryData는 diary.saved 이벤트의 data다.
+type DiaryData struct {
+	Date    string `json:"date"`
+	Content string `json:"content"`
+	Mood    string `json:"mood"`
+	Version int64  `json:"version"`
+}
+
+// MoodData는 mood.updated 이벤트의 data다.
+type MoodData struct {
+	Date string `json:"date"`
+	Mood string `json:"mood"`
+}
+
+// AIFeedbackData는 ai_feedback.saved 이벤트의 data다.
+type AIFeedbackData struct {
+	Date       string `json:"date"`
+	AIFeedback string `json:"aiFeedback"`
+}
+
+// ReportData는 report.generated 이벤트의 data다.
+type ReportData struct {
+	Report     string `json:"report"`
+	DiaryCount int    `json:"diaryCount"`
+}
+
+// ParseEvents는 입력된 이벤트를 검증하고 중복을 없애 정해진 순서로 정렬한다.
+func ParseEvents(values []string) ([]Event, error) {
+	known := make(map[string]bool, len(AllEvents))
+	var events []Event
+	for _, info := range AllEvents {
+		known[string(info.Event)] = true
+		if slices.Contains(values, string(info.Event)) {
+			events = append(events, info.Event)
+		}
+	}
+	for _, v := range values {
+		if !known[v] {
+			return nil, fmt.Errorf("알 수 없는 이벤트: %s", v)
+		}
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("이벤트를 하나 이상 골라야 합니다")
+	}
+	return events, nil
+}
+
+// JoinEvents는 이벤트 목록을 저장용 문자열로 만든다.
+func JoinEvents(events []Event) string {
+	parts := make([]string, 0, len(events))
+	for _, e := range events {
+		parts = append(parts, string(e))
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitEvents는 저장된 이벤트 문자열을 목록으로 되돌린다.
+func splitEvents(s string) []Event {
+	fields := strings.Fields(s)
+	events := make([]Event, 0, len(fields))
+	for _, f := range fields {
+		events = append(events, Event(f))
+	}
+	return events
+}
+
+// Label은 이벤트의 한국어 이름을 반환한다.
+func (e Event) Label() string {
+	for _, info := range AllEvents {
+		if info.Event == e {
+			return info.Label
+		}
+	}
+	if e == EventPing {
+		return "테스트"
+	}
+	return string(e)
+}
+
+// GenerateSecret은 서명에 쓸 비밀 값을 만든다.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("비밀 값 생성 실패: %w", err)
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Sign은 "타임스탬프.본문"의 HMAC-SHA256 서명을 "sha256=<16진수>" 형식으로 반환한다.
+// 타임스탬프를 함께 서명해 받는 쪽이 오래된 요청의 재전송을 거를 수 있게 한다.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff는 attempt번째 시도가 실패한 뒤 다음 시도까지 기다릴 시간이다.
+// 30초부터 두 배씩 늘리되 1시간을 넘지 않는다.
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := baseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// ValidateURL은 웹훅 주소가 http(s) 절대 주소인지 확인한다.
+// allowInsecure가 false면 https만 허용한다.
+func ValidateURL(raw string, allowInsecure bool) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("주소 형식이 올바르지 않습니다")
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !allowInsecure {
+			return "", fmt.Errorf("https 주소만 사용할 수 있습니다")
+		}
+	default:
+		return "", fmt.Errorf("http(s) 주소만 사용할 수 있습니다")
+	}
+	if u.User != nil {
+		return "", fmt.Errorf("주소에 사용자 정보를 넣을 수 없습니다")
+	}
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// blockedPrefixes는 net/netip 분류에는 없지만 밖에서 닿을 수 없어 웹훅으로 쓸 수 없는 특수 용도 대역이다.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("100.64.0.0/10"),  // 통신사 NAT(CGNAT) 공유 대역
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF 프로토콜 할당
+	netip.MustParsePrefix("198.18.0.0/15"),  // 네트워크 장비 벤치마크
+	netip.MustParsePrefix("240.0.0.0/4"),    // 예약, 브로드캐스트
+	netip.MustParsePrefix("64:ff9b:1::/48"), // 사설 NAT64
+}
+
+// nat64Prefix는 IPv4 주소를 마지막 4바이트에 담는 잘 알려진 NAT64 대역이다.
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// IsPublicIP는 내부망이나 루프백이 아닌 공인 주소인지 확인한다.
+// 웹훅이 서버 내부 서비스로 요청을 보내는 데 쓰이지 않도록 전송 직전에 검사한다.
+// IPv4-mapped(::ffff:a.b.c.d)와 NAT64(64:ff9b::a.b.c.d) 주소는 안에 담긴 IPv4 주소로 검사한다.
+func IsPublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	if nat64Prefix.Contains(addr) {
+		b := addr.As16()
+		addr = netip.AddrFrom4([4]byte(b[12:]))
+	}
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE user_setting ADD COLUMN monthly_goal INTEGER DEFAULT 0 NOT NULL CHECK (monthly_goal BETWEEN 0 AND 31);
+
+ALTER TABLE user_setting ADD COLUMN timezone TEXT DEFAULT 'Asia/Seoul' NOT NULL;
+
+CREATE TABLE IF NOT EXISTS user_achievement (
+    id TEXT DEFAULT (
+        'r' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    uid TEXT DEFAULT '' NOT NULL,
+    code TEXT DEFAULT '' NOT NULL,
+    achieved_date TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_achievement_uid_code
+ON user_achievement (uid, code);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_achievement_uid_code;
+
+DROP TABLE user_achievement;
+
+ALTER TABLE user_setting DROP COLUMN monthly_goal;
+
+ALTER TABLE user_setting DROP COLUMN timezone;
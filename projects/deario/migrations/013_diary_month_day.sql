@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE diary ADD COLUMN month_day TEXT GENERATED ALWAYS AS (substr(date, 5, 4)) VIRTUAL;
+
+CREATE INDEX IF NOT EXISTS idx_diary_uid_month_day
+ON diary (uid, month_day);
+
+ALTER TABLE user_setting ADD COLUMN memory_push INTEGER DEFAULT 0 NOT NULL CHECK (memory_push IN (0, 1));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_diary_uid_month_day;
+
+ALTER TABLE diary DROP COLUMN month_day;
+
+ALTER TABLE user_setting DROP COLUMN memory_push;
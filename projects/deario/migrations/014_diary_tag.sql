@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS diary_tag (
+    diary_id TEXT NOT NULL REFERENCES diary (id) ON DELETE CASCADE,
+    uid TEXT DEFAULT '' NOT NULL,
+    tag TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (diary_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_diary_tag_uid_tag
+ON diary_tag (uid, tag);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_diary_tag_uid_tag;
+
+DROP TABLE diary_tag;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE diary ADD COLUMN version INTEGER DEFAULT 1 NOT NULL;
+
+CREATE TABLE IF NOT EXISTS diary_draft (
+    uid TEXT DEFAULT '' NOT NULL,
+    date TEXT DEFAULT '' NOT NULL,
+    content TEXT DEFAULT '' NOT NULL,
+    base_version INTEGER DEFAULT 0 NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (uid, date)
+);
+
+-- +goose Down
+DROP TABLE diary_draft;
+
+ALTER TABLE diary DROP COLUMN version;
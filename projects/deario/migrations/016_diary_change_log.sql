@@ -0,0 +1,52 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS diary_change (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    uid TEXT DEFAULT '' NOT NULL,
+    date TEXT DEFAULT '' NOT NULL,
+    op TEXT DEFAULT 'upsert' NOT NULL CHECK (op IN ('upsert', 'delete')),
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_diary_change_uid_seq
+ON diary_change (uid, seq);
+
+CREATE INDEX IF NOT EXISTS idx_diary_change_updated
+ON diary_change (updated);
+
+-- 기존 일기도 첫 동기화에서 내려받을 수 있도록 변경 기록을 채운다.
+INSERT INTO diary_change (uid, date, op)
+SELECT uid, date, 'upsert' FROM diary ORDER BY updated;
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_diary_change_insert AFTER INSERT ON diary
+BEGIN
+    INSERT INTO diary_change (uid, date, op) VALUES (NEW.uid, NEW.date, 'upsert');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_diary_change_update AFTER UPDATE ON diary
+BEGIN
+    INSERT INTO diary_change (uid, date, op) VALUES (NEW.uid, NEW.date, 'upsert');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_diary_change_delete AFTER DELETE ON diary
+BEGIN
+    INSERT INTO diary_change (uid, date, op) VALUES (OLD.uid, OLD.date, 'delete');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS trg_diary_change_delete;
+
+DROP TRIGGER IF EXISTS trg_diary_change_update;
+
+DROP TRIGGER IF EXISTS trg_diary_change_insert;
+
+DROP INDEX IF EXISTS idx_diary_change_updated;
+
+DROP INDEX IF EXISTS idx_diary_change_uid_seq;
+
+DROP TABLE diary_change;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS api_token (
+    id TEXT DEFAULT (
+        't' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    uid TEXT DEFAULT '' NOT NULL,
+    name TEXT DEFAULT '' NOT NULL,
+    prefix TEXT DEFAULT '' NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT DEFAULT '' NOT NULL,
+    rate_limit INTEGER DEFAULT 60 NOT NULL,
+    last_used TEXT DEFAULT '' NOT NULL,
+    revoked TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_token_uid
+ON api_token (uid, created);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_api_token_uid;
+
+DROP TABLE api_token;
@@ -0,0 +1,52 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS webhook (
+    id TEXT DEFAULT (
+        'w' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    uid TEXT DEFAULT '' NOT NULL,
+    url TEXT DEFAULT '' NOT NULL,
+    secret TEXT DEFAULT '' NOT NULL,
+    events TEXT DEFAULT '' NOT NULL,
+    enabled INTEGER DEFAULT 1 NOT NULL,
+    failure_count INTEGER DEFAULT 0 NOT NULL,
+    disabled_reason TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_uid
+ON webhook (uid);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id TEXT DEFAULT (
+        'd' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    webhook_id TEXT NOT NULL REFERENCES webhook (id) ON DELETE CASCADE,
+    uid TEXT DEFAULT '' NOT NULL,
+    event TEXT DEFAULT '' NOT NULL,
+    payload TEXT DEFAULT '' NOT NULL,
+    status TEXT DEFAULT 'pending' NOT NULL CHECK (status IN ('pending', 'success', 'failed')),
+    attempts INTEGER DEFAULT 0 NOT NULL,
+    response_status INTEGER DEFAULT 0 NOT NULL,
+    error TEXT DEFAULT '' NOT NULL,
+    next_attempt TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_webhook_created
+ON webhook_delivery (webhook_id, created);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_created
+ON webhook_delivery (created);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_webhook_delivery_created;
+
+DROP INDEX IF EXISTS idx_webhook_delivery_webhook_created;
+
+DROP TABLE webhook_delivery;
+
+DROP INDEX IF EXISTS idx_webhook_uid;
+
+DROP TABLE webhook;
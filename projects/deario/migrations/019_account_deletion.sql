@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS account_deletion (
+    uid TEXT PRIMARY KEY,
+    status TEXT DEFAULT 'scheduled' NOT NULL CHECK (status IN ('scheduled', 'purging')),
+    purge_after TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_deletion_status_purge_after
+ON account_deletion (status, purge_after);
+
+-- 탈퇴 기록은 사용자 데이터를 지운 뒤에도 남기므로 uid 대신 해시만 저장한다.
+CREATE TABLE IF NOT EXISTS account_audit (
+    id TEXT DEFAULT (
+        'a' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    uid_hash TEXT DEFAULT '' NOT NULL,
+    event TEXT DEFAULT '' NOT NULL CHECK (event IN ('requested', 'cancelled', 'purged', 'failed')),
+    detail TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_audit_uid_hash
+ON account_audit (uid_hash);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_account_audit_uid_hash;
+
+DROP TABLE account_audit;
+
+DROP INDEX IF EXISTS idx_account_deletion_status_purge_after;
+
+DROP TABLE account_deletion;
@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS diary_share (
+    id TEXT DEFAULT (
+        's' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    uid TEXT DEFAULT '' NOT NULL,
+    date TEXT DEFAULT '' NOT NULL,
+    pin_hash TEXT DEFAULT '' NOT NULL,
+    include_ai INTEGER DEFAULT 0 NOT NULL CHECK (include_ai IN (0, 1)),
+    include_images INTEGER DEFAULT 0 NOT NULL CHECK (include_images IN (0, 1)),
+    expires TEXT DEFAULT '' NOT NULL,
+    revoked TEXT DEFAULT '' NOT NULL,
+    view_count INTEGER DEFAULT 0 NOT NULL,
+    last_viewed TEXT DEFAULT '' NOT NULL,
+    pin_failures INTEGER DEFAULT 0 NOT NULL,
+    locked_until TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_diary_share_uid_created
+ON diary_share (uid, created);
+
+CREATE INDEX IF NOT EXISTS idx_diary_share_expires
+ON diary_share (expires);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_diary_share_expires;
+
+DROP INDEX IF EXISTS idx_diary_share_uid_created;
+
+DROP TABLE diary_share;
@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS diary_template (
+    id TEXT DEFAULT (
+        'p' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    uid TEXT DEFAULT '' NOT NULL,
+    name TEXT DEFAULT '' NOT NULL,
+    content TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_diary_template_uid_created
+ON diary_template (uid, created);
+
+ALTER TABLE user_setting ADD COLUMN auto_template TEXT DEFAULT '' NOT NULL;
+
+-- +goose Down
+ALTER TABLE user_setting DROP COLUMN auto_template;
+
+DROP INDEX IF EXISTS idx_diary_template_uid_created;
+
+DROP TABLE diary_template;
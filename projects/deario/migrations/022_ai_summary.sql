@@ -0,0 +1,30 @@
+-- +goose Up
+ALTER TABLE user_setting ADD COLUMN weekly_summary INTEGER DEFAULT 0 NOT NULL CHECK (weekly_summary IN (0, 1));
+
+ALTER TABLE user_setting ADD COLUMN monthly_summary INTEGER DEFAULT 0 NOT NULL CHECK (monthly_summary IN (0, 1));
+
+CREATE TABLE IF NOT EXISTS ai_summary (
+    id TEXT DEFAULT (
+        'm' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    uid TEXT DEFAULT '' NOT NULL,
+    period TEXT DEFAULT '' NOT NULL CHECK (period IN ('weekly', 'monthly')),
+    start_date TEXT DEFAULT '' NOT NULL,
+    end_date TEXT DEFAULT '' NOT NULL,
+    content TEXT DEFAULT '' NOT NULL,
+    diary_count INTEGER DEFAULT 0 NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ai_summary_uid_period_start
+ON ai_summary (uid, period, start_date);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_ai_summary_uid_period_start;
+
+DROP TABLE ai_summary;
+
+ALTER TABLE user_setting DROP COLUMN monthly_summary;
+
+ALTER TABLE user_setting DROP COLUMN weekly_summary;
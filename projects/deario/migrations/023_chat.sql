@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS chat_session (
+    id TEXT DEFAULT (
+        'c' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    uid TEXT DEFAULT '' NOT NULL,
+    title TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_session_uid_updated
+ON chat_session (uid, updated);
+
+CREATE TABLE IF NOT EXISTS chat_message (
+    id TEXT DEFAULT (
+        'g' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    session_id TEXT DEFAULT '' NOT NULL,
+    uid TEXT DEFAULT '' NOT NULL,
+    role TEXT DEFAULT '' NOT NULL CHECK (role IN ('user', 'model')),
+    content TEXT DEFAULT '' NOT NULL,
+    citations TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_message_session_created
+ON chat_message (session_id, created);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_chat_message_session_created;
+
+DROP TABLE chat_message;
+
+DROP INDEX IF EXISTS idx_chat_session_uid_updated;
+
+DROP TABLE chat_session;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS diary_embedding (
+    diary_id TEXT NOT NULL PRIMARY KEY,
+    uid TEXT DEFAULT '' NOT NULL,
+    date TEXT DEFAULT '' NOT NULL,
+    model TEXT DEFAULT '' NOT NULL,
+    content_hash TEXT DEFAULT '' NOT NULL,
+    vector BLOB NOT NULL,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_diary_embedding_uid_model
+ON diary_embedding (uid, model);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_diary_embedding_delete AFTER DELETE ON diary
+BEGIN
+    DELETE FROM diary_embedding WHERE diary_id = OLD.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS trg_diary_embedding_delete;
+
+DROP INDEX IF EXISTS idx_diary_embedding_uid_model;
+
+DROP TABLE diary_embedding;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- 위기 표현 감지 횟수를 날짜별로만 센다. 누가 썼는지는 남기지 않는다.
+CREATE TABLE IF NOT EXISTS safety_event (
+    day TEXT NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('save', 'feedback', 'chat')),
+    level TEXT NOT NULL CHECK (level IN ('concern', 'crisis')),
+    count INTEGER DEFAULT 0 NOT NULL,
+    PRIMARY KEY (day, source, level)
+);
+
+-- +goose Down
+DROP TABLE safety_event;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- 같은 입력이면 같은 답이어도 되는 AI 응답을 서버를 다시 띄워도 이어 쓰도록 남긴다.
+-- key는 모델, 프롬프트, 옵션의 해시라 프롬프트 원문은 남지 않는다.
+CREATE TABLE IF NOT EXISTS ai_cache (
+    key TEXT PRIMARY KEY NOT NULL,
+    value TEXT NOT NULL,
+    expires TEXT NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_cache_expires ON ai_cache (expires);
+
+-- +goose Down
+DROP TABLE ai_cache;
@@ -0,0 +1,37 @@
+-- +goose Up
+ALTER TABLE user_setting ADD COLUMN keep_voice_audio INTEGER DEFAULT 0 NOT NULL CHECK (keep_voice_audio IN (0, 1));
+
+-- 음성 일기 녹음 한 건이다. 조각으로 올린 뒤 받아쓰기 작업이 끝나면 transcript를 채운다.
+-- keep_audio면 원본을 스토리지 object에 남겨 일기 옆에서 다시 들을 수 있게 한다.
+CREATE TABLE IF NOT EXISTS voice_note (
+    id TEXT DEFAULT (
+        'v' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    uid TEXT DEFAULT '' NOT NULL,
+    date TEXT DEFAULT '' NOT NULL,
+    mime_type TEXT DEFAULT '' NOT NULL,
+    size INTEGER DEFAULT 0 NOT NULL,
+    status TEXT DEFAULT 'uploading' NOT NULL CHECK (status IN ('uploading', 'queued', 'transcribing', 'done', 'failed')),
+    progress INTEGER DEFAULT 0 NOT NULL,
+    keep_audio INTEGER DEFAULT 0 NOT NULL CHECK (keep_audio IN (0, 1)),
+    object TEXT DEFAULT '' NOT NULL,
+    transcript TEXT DEFAULT '' NOT NULL,
+    error TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_voice_note_uid_date
+ON voice_note (uid, date);
+
+CREATE INDEX IF NOT EXISTS idx_voice_note_status_updated
+ON voice_note (status, updated);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_voice_note_status_updated;
+
+DROP INDEX IF EXISTS idx_voice_note_uid_date;
+
+DROP TABLE voice_note;
+
+ALTER TABLE user_setting DROP COLUMN keep_voice_audio;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- 검사를 통과하지 못한 업로드 이미지다. 원본은 quarantine/ 경로로 옮겨 공개 주소로 받을 수 없게 하고
+-- 보관 기간 동안만 남겨 오탐을 확인할 수 있게 한다.
+CREATE TABLE IF NOT EXISTS image_quarantine (
+    id TEXT DEFAULT (
+        'q' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    uid TEXT DEFAULT '' NOT NULL,
+    source TEXT DEFAULT '' NOT NULL,
+    object TEXT DEFAULT '' NOT NULL,
+    reason TEXT DEFAULT '' NOT NULL,
+    size INTEGER DEFAULT 0 NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_quarantine_created ON image_quarantine (created);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_image_quarantine_created;
+
+DROP TABLE image_quarantine;
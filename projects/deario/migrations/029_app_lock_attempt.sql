@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE user_setting ADD COLUMN app_lock_force_logout INTEGER DEFAULT 0 NOT NULL CHECK (app_lock_force_logout IN (0, 1));
+
+-- sessions_revoked_at(유닉스 초)보다 먼저 만든 로그인 세션은 더 이상 받지 않는다.
+ALTER TABLE user_setting ADD COLUMN sessions_revoked_at INTEGER DEFAULT 0 NOT NULL;
+
+-- 앱 잠금 PIN 실패 기록이다. 서버를 다시 띄우거나 여러 대로 나눠 띄워도 같은 기록을 본다.
+-- failures는 이번 잠금까지 남은 연속 실패 수, lockouts는 지금까지 걸린 잠금 횟수로 잠금 시간을 늘리는 데 쓴다.
+CREATE TABLE IF NOT EXISTS app_lock_attempt (
+    uid TEXT NOT NULL PRIMARY KEY,
+    failures INTEGER DEFAULT 0 NOT NULL,
+    lockouts INTEGER DEFAULT 0 NOT NULL,
+    locked_until TEXT DEFAULT '' NOT NULL,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE app_lock_attempt;
+
+ALTER TABLE user_setting DROP COLUMN sessions_revoked_at;
+
+ALTER TABLE user_setting DROP COLUMN app_lock_force_logout;
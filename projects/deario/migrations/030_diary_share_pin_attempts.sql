@@ -0,0 +1,6 @@
+-- +goose Up
+-- pin_attempts는 마지막으로 PIN을 맞힌 뒤 틀린 총 횟수다. 잠금이 풀려도 줄지 않으며 상한에 닿으면 링크를 폐기한다.
+ALTER TABLE diary_share ADD COLUMN pin_attempts INTEGER DEFAULT 0 NOT NULL;
+
+-- +goose Down
+ALTER TABLE diary_share DROP COLUMN pin_attempts;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- 지운 일기의 마지막 버전이다. 같은 날짜에 일기를 다시 만들면 이 버전 다음부터 세어,
+-- 지우기 전 버전을 들고 있는 기기가 새로 만든 일기를 덮어쓰지 못하게 한다.
+CREATE TABLE IF NOT EXISTS diary_tombstone (
+    uid TEXT DEFAULT '' NOT NULL,
+    date TEXT DEFAULT '' NOT NULL,
+    version INTEGER DEFAULT 0 NOT NULL,
+    updated TEXT DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (uid, date)
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_diary_tombstone AFTER DELETE ON diary
+BEGIN
+    INSERT INTO diary_tombstone (uid, date, version) VALUES (OLD.uid, OLD.date, OLD.version)
+    ON CONFLICT (uid, date) DO UPDATE SET
+        version = MAX(diary_tombstone.version, excluded.version),
+        updated = CURRENT_TIMESTAMP;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS trg_diary_tombstone;
+
+DROP TABLE diary_tombstone;
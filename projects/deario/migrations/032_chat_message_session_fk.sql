@@ -0,0 +1,58 @@
+-- +goose Up
+-- 대화를 지우면 메시지도 함께 지워지도록 session_id에 외래 키를 건다.
+-- SQLite는 기존 컬럼에 외래 키를 추가할 수 없어서 테이블을 새로 만들어 옮긴다. 대화가 없는 메시지는 옮기지 않는다.
+CREATE TABLE chat_message_new (
+    id TEXT DEFAULT (
+        'g' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    session_id TEXT NOT NULL REFERENCES chat_session (id) ON DELETE CASCADE,
+    uid TEXT DEFAULT '' NOT NULL,
+    role TEXT DEFAULT '' NOT NULL CHECK (role IN ('user', 'model')),
+    content TEXT DEFAULT '' NOT NULL,
+    citations TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO
+    chat_message_new (id, session_id, uid, role, content, citations, created)
+SELECT id, session_id, uid, role, content, citations, created
+FROM chat_message
+WHERE session_id IN (SELECT id FROM chat_session)
+ORDER BY rowid;
+
+DROP INDEX IF EXISTS idx_chat_message_session_created;
+
+DROP TABLE chat_message;
+
+ALTER TABLE chat_message_new RENAME TO chat_message;
+
+CREATE INDEX IF NOT EXISTS idx_chat_message_session_created
+ON chat_message (session_id, created);
+
+-- +goose Down
+CREATE TABLE chat_message_old (
+    id TEXT DEFAULT (
+        'g' || LOWER(HEX(RANDOMBLOB(7)))
+    ) NOT NULL PRIMARY KEY,
+    session_id TEXT DEFAULT '' NOT NULL,
+    uid TEXT DEFAULT '' NOT NULL,
+    role TEXT DEFAULT '' NOT NULL CHECK (role IN ('user', 'model')),
+    content TEXT DEFAULT '' NOT NULL,
+    citations TEXT DEFAULT '' NOT NULL,
+    created TEXT DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO
+    chat_message_old (id, session_id, uid, role, content, citations, created)
+SELECT id, session_id, uid, role, content, citations, created
+FROM chat_message
+ORDER BY rowid;
+
+DROP INDEX IF EXISTS idx_chat_message_session_created;
+
+DROP TABLE chat_message;
+
+ALTER TABLE chat_message_old RENAME TO chat_message;
+
+CREATE INDEX IF NOT EXISTS idx_chat_message_session_created
+ON chat_message (session_id, created);
@@ -31,21 +31,40 @@ FROM
     diary
 WHERE
     uid = ?
+    AND (
+        date < sqlc.arg(cursor_date)
+        OR (
+            date = sqlc.arg(cursor_date)
+            AND id < sqlc.arg(cursor_id)
+        )
+        OR sqlc.arg(cursor_date) = ''
+    )
 ORDER BY
-    created desc
+    date DESC,
+    id DESC
 LIMIT
-    7
-OFFSET
-    ((? - 1) * 7);
+    sqlc.arg(page_size);
 
--- name: ListDiaryDatesByMonth :many
+-- name: ListDiaryCalendarDays :many
 SELECT
-    date
+    date,
+    mood,
+    content,
+    CAST(
+        image_url1 != ''
+        OR image_url2 != ''
+        OR image_url3 != '' AS INTEGER
+    ) AS has_image,
+    CAST(
+        ai_feedback != ''
+        OR ai_image != '' AS INTEGER
+    ) AS has_ai_feedback
 FROM
     diary
 WHERE
     uid = ?
-    AND substr (date, 1, 6) = ?
+    AND date >= sqlc.arg(start_date)
+    AND date <= sqlc.arg(end_date)
 ORDER BY
     date;
 
@@ -65,18 +84,69 @@ LIMIT
 
 -- name: UpsertDiaryContent :one
 INSERT INTO
-    diary (uid, content, date)
+    diary (uid, content, date, version)
 VALUES
-    (?, ?, ?) ON CONFLICT (uid, date) DO
+    (
+        sqlc.arg(uid),
+        sqlc.arg(content),
+        sqlc.arg(date),
+        COALESCE(
+            (
+                SELECT
+                    diary_tombstone.version
+                FROM
+                    diary_tombstone
+                WHERE
+                    diary_tombstone.uid = sqlc.arg(uid)
+                    AND diary_tombstone.date = sqlc.arg(date)
+            ),
+            0
+        ) + 1
+    ) ON CONFLICT (uid, date) DO
 UPDATE
 SET
     content = excluded.content,
+    version = diary.version + 1,
     updated = datetime ('now') RETURNING *;
 
+-- name: InsertDiaryContent :one
+INSERT INTO
+    diary (uid, content, date, version)
+VALUES
+    (
+        sqlc.arg(uid),
+        sqlc.arg(content),
+        sqlc.arg(date),
+        COALESCE(
+            (
+                SELECT
+                    diary_tombstone.version
+                FROM
+                    diary_tombstone
+                WHERE
+                    diary_tombstone.uid = sqlc.arg(uid)
+                    AND diary_tombstone.date = sqlc.arg(date)
+            ),
+            0
+        ) + 1
+    ) ON CONFLICT (uid, date) DO NOTHING RETURNING *;
+
+-- name: UpdateDiaryContentIfVersion :one
+UPDATE diary
+SET
+    content = ?,
+    version = version + 1,
+    updated = datetime ('now')
+WHERE
+    uid = ?
+    AND date = ?
+    AND version = sqlc.arg(base_version) RETURNING *;
+
 -- name: UpdateDiary :one
 UPDATE diary
 SET
     content = ?,
+    version = version + 1,
     updated = datetime ('now')
 WHERE
     id = ? RETURNING *;
@@ -86,6 +156,22 @@ DELETE FROM diary
 WHERE
     id = ?;
 
+-- name: DeleteDiaryIfVersion :execrows
+DELETE FROM diary
+WHERE
+    id = ?
+    AND version = ?;
+
+-- name: ClearDiaryContentIfVersion :one
+UPDATE diary
+SET
+    content = '',
+    version = version + 1,
+    updated = datetime ('now')
+WHERE
+    id = ?
+    AND version = ? RETURNING *;
+
 -- name: UpdateDiaryOfAiFeedback :exec
 UPDATE diary
 SET
@@ -165,7 +251,13 @@ FROM
 WHERE
     is_push = 1
     AND push_token != ''
-    AND push_time != '';
+    AND push_time != ''
+    AND uid NOT IN (
+        SELECT
+            uid
+        FROM
+            account_deletion
+    );
 
 -- name: GetUser :one
 SELECT
@@ -183,79 +275,1270 @@ INSERT INTO
 VALUES
     (?, ?, ?);
 
--- name: MonthlyDiaryCount :many
-WITH
-    monthly AS (
+-- name: ListDiaryStatRows :many
+SELECT
+    date,
+    content,
+    mood,
+    created
+FROM
+    diary
+WHERE
+    uid = ?
+    AND content != ''
+    AND date >= sqlc.arg(start_date)
+    AND date <= sqlc.arg(end_date)
+ORDER BY
+    date;
+
+-- name: GetFirstDiaryDate :one
+SELECT
+    CAST(COALESCE(MIN(date), '') AS TEXT) AS date
+FROM
+    diary
+WHERE
+    uid = ?
+    AND content != '';
+
+-- name: ListDiaryWrittenDates :many
+SELECT
+    date
+FROM
+    diary
+WHERE
+    uid = ?
+    AND content != ''
+ORDER BY
+    date DESC;
+
+-- name: CountDiaryBetween :one
+SELECT
+    COUNT(*)
+FROM
+    diary
+WHERE
+    uid = ?
+    AND content != ''
+    AND date >= sqlc.arg(start_date)
+    AND date <= sqlc.arg(end_date);
+
+-- name: UpdateWritingGoal :exec
+UPDATE user_setting
+SET
+    monthly_goal = ?,
+    timezone = ?,
+    updated = datetime ('now')
+WHERE
+    uid = ?;
+
+-- name: ListUserAchievements :many
+SELECT
+    *
+FROM
+    user_achievement
+WHERE
+    uid = ?
+ORDER BY
+    achieved_date,
+    created;
+
+-- name: InsertUserAchievement :exec
+INSERT INTO
+    user_achievement (uid, code, achieved_date)
+VALUES
+    (?, ?, ?) ON CONFLICT (uid, code) DO NOTHING;
+
+-- name: ListStreakNudgeTargets :many
+SELECT
+    uid,
+    push_token,
+    timezone
+FROM
+    user_setting
+WHERE
+    is_push = 1
+    AND push_token != ''
+    AND uid NOT IN (
         SELECT
-            substr (date, 1, 6) AS month,
-            COUNT(*) AS count
+            uid
         FROM
-            diary
-        WHERE
-            uid = ?
-        GROUP BY
-            substr (date, 1, 6)
-        ORDER BY
-            month DESC
-        LIMIT
-            6
+            account_deletion
+    );
+
+-- name: ListDiariesOnThisDay :many
+SELECT
+    date,
+    content,
+    mood
+FROM
+    diary
+WHERE
+    uid = ?
+    AND month_day IN (sqlc.arg(month_day), sqlc.arg(extra_month_day))
+    AND date < sqlc.arg(before_date)
+    AND content != ''
+ORDER BY
+    date DESC;
+
+-- name: UpdateMemoryPush :exec
+UPDATE user_setting
+SET
+    memory_push = ?,
+    updated = datetime ('now')
+WHERE
+    uid = ?;
+
+-- name: ListMemoryPushTargets :many
+SELECT
+    uid,
+    push_token,
+    timezone
+FROM
+    user_setting
+WHERE
+    is_push = 1
+    AND memory_push = 1
+    AND push_token != ''
+    AND uid NOT IN (
+        SELECT
+            uid
+        FROM
+            account_deletion
+    );
+
+-- name: DeleteDiaryTags :exec
+DELETE FROM diary_tag
+WHERE
+    diary_id = ?;
+
+-- name: InsertDiaryTag :exec
+INSERT INTO
+    diary_tag (diary_id, uid, tag)
+VALUES
+    (?, ?, ?) ON CONFLICT (diary_id, tag) DO NOTHING;
+
+-- name: ListUserTags :many
+SELECT
+    tag,
+    COUNT(*) AS count
+FROM
+    diary_tag
+WHERE
+    uid = ?
+GROUP BY
+    tag
+ORDER BY
+    count DESC,
+    tag;
+
+-- name: SuggestUserTags :many
+SELECT
+    tag
+FROM
+    diary_tag
+WHERE
+    uid = ?
+    AND like(sqlc.arg(prefix) || '%', tag, '\')
+GROUP BY
+    tag
+ORDER BY
+    COUNT(*) DESC,
+    tag
+LIMIT
+    10;
+
+-- name: ListDiarysByTag :many
+SELECT
+    diary.*
+FROM
+    diary
+    JOIN diary_tag ON diary_tag.diary_id = diary.id
+WHERE
+    diary.uid = ?
+    AND diary_tag.tag = ?
+    AND (
+        diary.date < sqlc.arg(cursor_date)
+        OR (
+            diary.date = sqlc.arg(cursor_date)
+            AND diary.id < sqlc.arg(cursor_id)
+        )
+        OR sqlc.arg(cursor_date) = ''
     )
+ORDER BY
+    diary.date DESC,
+    diary.id DESC
+LIMIT
+    sqlc.arg(page_size);
+
+-- name: SearchDiarysByTag :many
 SELECT
-    *
+    diary.date,
+    diary.content
 FROM
-    monthly
+    diary
+    JOIN diary_tag ON diary_tag.diary_id = diary.id
+WHERE
+    diary.uid = ?
+    AND diary_tag.tag = ?
+    AND diary.content LIKE '%' || sqlc.arg(keyword) || '%'
 ORDER BY
-    month;
+    diary.date DESC
+LIMIT
+    20;
+
+-- name: ListDiarysWithTag :many
+SELECT
+    diary.*
+FROM
+    diary
+    JOIN diary_tag ON diary_tag.diary_id = diary.id
+WHERE
+    diary.uid = ?
+    AND diary_tag.tag = ?;
+
+-- name: UpsertDiaryDraft :exec
+INSERT INTO
+    diary_draft (uid, date, content, base_version)
+VALUES
+    (?, ?, ?, ?) ON CONFLICT (uid, date) DO
+UPDATE
+SET
+    content = excluded.content,
+    base_version = excluded.base_version,
+    updated = datetime ('now');
+
+-- name: GetDiaryDraft :one
+SELECT
+    *
+FROM
+    diary_draft
+WHERE
+    uid = ?
+    AND date = ?;
 
--- name: MonthlyMoodCount :many
-WITH
-    monthly AS (
+-- name: DeleteDiaryDraft :exec
+DELETE FROM diary_draft
+WHERE
+    uid = ?
+    AND date = ?;
+
+-- name: ListDiaryChangesSince :many
+SELECT
+    changes.date,
+    CAST(changes.seq AS INTEGER) AS seq,
+    CAST(diary.id IS NOT NULL AS INTEGER) AS present,
+    CAST(COALESCE(diary.content, '') AS TEXT) AS content,
+    CAST(COALESCE(diary.mood, '0') AS TEXT) AS mood,
+    CAST(COALESCE(diary.version, 0) AS INTEGER) AS version,
+    CAST(COALESCE(diary.updated, '') AS TEXT) AS updated
+FROM
+    (
         SELECT
-            substr (date, 1, 6) AS month,
-            sum(
-                CASE
-                    WHEN mood = '1' THEN 1
-                    ELSE 0
-                END
-            ) AS mood1,
-            sum(
-                CASE
-                    WHEN mood = '2' THEN 1
-                    ELSE 0
-                END
-            ) AS mood2,
-            sum(
-                CASE
-                    WHEN mood = '3' THEN 1
-                    ELSE 0
-                END
-            ) AS mood3,
-            sum(
-                CASE
-                    WHEN mood = '4' THEN 1
-                    ELSE 0
-                END
-            ) AS mood4,
-            sum(
-                CASE
-                    WHEN mood = '5' THEN 1
-                    ELSE 0
-                END
-            ) AS mood5
+            diary_change.date,
+            MAX(diary_change.seq) AS seq
         FROM
-            diary
+            diary_change
         WHERE
-            uid = ?
+            diary_change.uid = sqlc.arg(uid)
+            AND diary_change.seq > sqlc.arg(since)
         GROUP BY
-            substr (date, 1, 6)
-        ORDER BY
-            month DESC
-        LIMIT
-            6
-    )
+            diary_change.date
+    ) AS changes
+    LEFT JOIN diary ON diary.uid = sqlc.arg(uid)
+    AND diary.date = changes.date
+ORDER BY
+    changes.seq
+LIMIT
+    sqlc.arg(page_size);
+
+-- name: GetLatestDiaryChangeSeq :one
+SELECT
+    CAST(COALESCE(MAX(seq), 0) AS INTEGER) AS seq
+FROM
+    diary_change
+WHERE
+    uid = ?;
+
+-- name: PruneDiaryChanges :execrows
+DELETE FROM diary_change
+WHERE
+    diary_change.updated < CAST(sqlc.arg(before) AS TEXT)
+    AND diary_change.seq NOT IN (
+        SELECT
+            MAX(latest.seq)
+        FROM
+            diary_change AS latest
+        GROUP BY
+            latest.uid,
+            latest.date
+    );
+
+-- name: CreateAPIToken :one
+INSERT INTO
+    api_token (uid, name, prefix, token_hash, scopes, rate_limit)
+VALUES
+    (?, ?, ?, ?, ?, ?)
+RETURNING
+    *;
+
+-- name: ListAPITokens :many
+SELECT
+    *
+FROM
+    api_token
+WHERE
+    uid = ?
+    AND revoked = ''
+ORDER BY
+    created DESC;
+
+-- name: CountAPITokens :one
+SELECT
+    COUNT(*)
+FROM
+    api_token
+WHERE
+    uid = ?
+    AND revoked = '';
+
+-- name: GetAPITokenByHash :one
+SELECT
+    *
+FROM
+    api_token
+WHERE
+    token_hash = ?
+    AND revoked = '';
+
+-- name: RevokeAPIToken :execrows
+UPDATE api_token
+SET
+    revoked = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND uid = ?
+    AND revoked = '';
+
+-- name: RevokeUserAPITokens :execrows
+UPDATE api_token
+SET
+    revoked = CURRENT_TIMESTAMP
+WHERE
+    uid = ?
+    AND revoked = '';
+
+-- name: TouchAPIToken :exec
+UPDATE api_token
+SET
+    last_used = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND last_used < DATETIME('now', '-1 minute');
+
+-- name: CreateWebhook :one
+INSERT INTO
+    webhook (uid, url, secret, events)
+VALUES
+    (?, ?, ?, ?)
+RETURNING
+    *;
+
+-- name: ListWebhooks :many
 SELECT
     *
 FROM
-    monthly
+    webhook
+WHERE
+    uid = ?
 ORDER BY
-    month;
+    created;
+
+-- name: CountWebhooks :one
+SELECT
+    COUNT(*)
+FROM
+    webhook
+WHERE
+    uid = ?;
+
+-- name: GetWebhook :one
+SELECT
+    *
+FROM
+    webhook
+WHERE
+    id = ?;
+
+-- name: ListWebhooksForEvent :many
+SELECT
+    *
+FROM
+    webhook
+WHERE
+    uid = sqlc.arg(uid)
+    AND enabled = 1
+    AND (' ' || events || ' ') LIKE '% ' || sqlc.arg(event) || ' %';
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhook
+WHERE
+    id = ?
+    AND uid = ?;
+
+-- name: EnableWebhook :execrows
+UPDATE webhook
+SET
+    enabled = 1,
+    failure_count = 0,
+    disabled_reason = '',
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND uid = ?;
+
+-- name: ResetWebhookFailures :exec
+UPDATE webhook
+SET
+    failure_count = 0,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND failure_count > 0;
+
+-- name: RecordWebhookFailure :one
+UPDATE webhook
+SET
+    failure_count = failure_count + 1,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+RETURNING
+    failure_count;
+
+-- name: DisableWebhook :exec
+UPDATE webhook
+SET
+    enabled = 0,
+    disabled_reason = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?;
+
+-- name: DisableUserWebhooks :exec
+UPDATE webhook
+SET
+    enabled = 0,
+    disabled_reason = sqlc.arg(reason),
+    updated = CURRENT_TIMESTAMP
+WHERE
+    uid = sqlc.arg(uid)
+    AND enabled = 1;
+
+-- name: EnableUserWebhooks :exec
+UPDATE webhook
+SET
+    enabled = 1,
+    failure_count = 0,
+    disabled_reason = '',
+    updated = CURRENT_TIMESTAMP
+WHERE
+    uid = sqlc.arg(uid)
+    AND enabled = 0
+    AND disabled_reason = sqlc.arg(reason);
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO
+    webhook_delivery (webhook_id, uid, event, payload)
+VALUES
+    (?, ?, ?, ?)
+RETURNING
+    *;
+
+-- name: GetWebhookDelivery :one
+SELECT
+    *
+FROM
+    webhook_delivery
+WHERE
+    id = ?;
+
+-- name: ListWebhookDeliveries :many
+SELECT
+    *
+FROM
+    webhook_delivery
+WHERE
+    webhook_id = ?
+    AND uid = ?
+ORDER BY
+    created DESC
+LIMIT
+    20;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_delivery
+SET
+    status = ?,
+    attempts = ?,
+    response_status = ?,
+    error = ?,
+    next_attempt = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?;
+
+-- name: PruneWebhookDeliveries :execrows
+DELETE FROM webhook_delivery
+WHERE
+    created < CAST(sqlc.arg(before) AS TEXT);
+
+-- name: ScheduleAccountDeletion :one
+INSERT INTO
+    account_deletion (uid, status, purge_after)
+VALUES
+    (?, 'scheduled', ?)
+ON CONFLICT (uid) DO UPDATE
+SET
+    status = 'scheduled',
+    purge_after = excluded.purge_after,
+    updated = CURRENT_TIMESTAMP
+RETURNING
+    *;
+
+-- name: GetAccountDeletion :one
+SELECT
+    *
+FROM
+    account_deletion
+WHERE
+    uid = ?;
+
+-- name: CancelAccountDeletion :execrows
+DELETE FROM account_deletion
+WHERE
+    uid = ?
+    AND status = 'scheduled';
+
+-- name: ListDueAccountDeletions :many
+SELECT
+    uid
+FROM
+    account_deletion
+WHERE
+    status = 'scheduled'
+    AND purge_after <= CAST(sqlc.arg(now) AS TEXT)
+ORDER BY
+    purge_after;
+
+-- name: MarkAccountDeletionPurging :execrows
+UPDATE account_deletion
+SET
+    status = 'purging',
+    updated = CURRENT_TIMESTAMP
+WHERE
+    uid = ?
+    AND status = 'scheduled';
+
+-- name: RescheduleAccountDeletion :exec
+UPDATE account_deletion
+SET
+    status = 'scheduled',
+    updated = CURRENT_TIMESTAMP
+WHERE
+    uid = ?;
+
+-- name: FinishAccountDeletion :exec
+DELETE FROM account_deletion
+WHERE
+    uid = ?;
+
+-- name: CreateAccountAudit :exec
+INSERT INTO
+    account_audit (uid_hash, event, detail)
+VALUES
+    (?, ?, ?);
+
+-- name: ListDiaryImageURLs :many
+SELECT
+    image_url1,
+    image_url2,
+    image_url3
+FROM
+    diary
+WHERE
+    uid = ?
+    AND (
+        image_url1 != ''
+        OR image_url2 != ''
+        OR image_url3 != ''
+    );
+
+-- name: ListAllDiaryImageURLs :many
+SELECT
+    image_url1,
+    image_url2,
+    image_url3
+FROM
+    diary
+WHERE
+    image_url1 != ''
+    OR image_url2 != ''
+    OR image_url3 != '';
+
+-- name: CreateDiaryShare :one
+INSERT INTO
+    diary_share (
+        uid,
+        date,
+        pin_hash,
+        include_ai,
+        include_images,
+        expires
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?)
+RETURNING
+    *;
+
+-- name: GetDiaryShare :one
+SELECT
+    *
+FROM
+    diary_share
+WHERE
+    id = ?;
+
+-- name: ListActiveDiaryShares :many
+SELECT
+    *
+FROM
+    diary_share
+WHERE
+    uid = sqlc.arg(uid)
+    AND revoked = ''
+    AND expires > CAST(sqlc.arg(now) AS TEXT)
+ORDER BY
+    created DESC;
+
+-- name: CountActiveDiaryShares :one
+SELECT
+    COUNT(*)
+FROM
+    diary_share
+WHERE
+    uid = sqlc.arg(uid)
+    AND revoked = ''
+    AND expires > CAST(sqlc.arg(now) AS TEXT);
+
+-- name: RevokeDiaryShare :execrows
+UPDATE diary_share
+SET
+    revoked = CURRENT_TIMESTAMP,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND uid = ?
+    AND revoked = '';
+
+-- name: RecordDiaryShareView :exec
+UPDATE diary_share
+SET
+    view_count = view_count + 1,
+    last_viewed = CURRENT_TIMESTAMP,
+    pin_failures = 0,
+    pin_attempts = 0,
+    locked_until = ''
+WHERE
+    id = ?;
+
+-- name: ReserveDiarySharePIN :one
+UPDATE diary_share
+SET
+    pin_failures = CASE
+        WHEN pin_failures + 1 >= sqlc.arg(max_failures) THEN 0
+        ELSE pin_failures + 1
+    END,
+    locked_until = CASE
+        WHEN pin_failures + 1 >= sqlc.arg(max_failures) THEN CAST(sqlc.arg(lock_until) AS TEXT)
+        ELSE ''
+    END,
+    pin_attempts = pin_attempts + 1
+WHERE
+    id = sqlc.arg(id)
+    AND revoked = ''
+    AND locked_until <= CAST(sqlc.arg(now) AS TEXT)
+    AND pin_attempts < sqlc.arg(max_attempts)
+RETURNING
+    *;
+
+-- name: PruneDiaryShares :execrows
+DELETE FROM diary_share
+WHERE
+    expires < CAST(sqlc.arg(before) AS TEXT)
+    OR (
+        revoked != ''
+        AND revoked < CAST(sqlc.arg(before) AS TEXT)
+    );
+
+-- name: CreateDiaryTemplate :one
+INSERT INTO
+    diary_template (uid, name, content)
+VALUES
+    (?, ?, ?)
+RETURNING
+    *;
+
+-- name: GetDiaryTemplate :one
+SELECT
+    *
+FROM
+    diary_template
+WHERE
+    id = ?
+    AND uid = ?;
+
+-- name: ListDiaryTemplates :many
+SELECT
+    *
+FROM
+    diary_template
+WHERE
+    uid = ?
+ORDER BY
+    created;
+
+-- name: CountDiaryTemplates :one
+SELECT
+    COUNT(*)
+FROM
+    diary_template
+WHERE
+    uid = ?;
+
+-- name: UpdateDiaryTemplate :execrows
+UPDATE diary_template
+SET
+    name = ?,
+    content = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND uid = ?;
+
+-- name: DeleteDiaryTemplate :execrows
+DELETE FROM diary_template
+WHERE
+    id = ?
+    AND uid = ?;
+
+-- name: UpdateAutoTemplate :exec
+INSERT INTO
+    user_setting (uid, auto_template)
+VALUES
+    (?, ?) ON CONFLICT (uid) DO
+UPDATE
+SET
+    auto_template = excluded.auto_template,
+    updated = datetime ('now');
+
+-- name: ClearAutoTemplate :exec
+UPDATE user_setting
+SET
+    auto_template = '',
+    updated = datetime ('now')
+WHERE
+    uid = ?
+    AND auto_template = ?;
+
+-- name: UpdateSummarySettings :exec
+UPDATE user_setting
+SET
+    weekly_summary = ?,
+    monthly_summary = ?,
+    updated = datetime ('now')
+WHERE
+    uid = ?;
+
+-- name: ListSummaryTargets :many
+SELECT
+    uid,
+    is_push,
+    push_token,
+    timezone,
+    weekly_summary,
+    monthly_summary
+FROM
+    user_setting
+WHERE
+    (
+        weekly_summary = 1
+        OR monthly_summary = 1
+    )
+    AND uid NOT IN (
+        SELECT
+            uid
+        FROM
+            account_deletion
+    );
+
+-- name: ListDiariesInRange :many
+SELECT
+    date,
+    content,
+    mood
+FROM
+    diary
+WHERE
+    uid = ?
+    AND content != ''
+    AND date >= sqlc.arg(start_date)
+    AND date <= sqlc.arg(end_date)
+ORDER BY
+    date;
+
+-- name: UpsertAISummary :one
+INSERT INTO
+    ai_summary (
+        uid,
+        period,
+        start_date,
+        end_date,
+        content,
+        diary_count
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?) ON CONFLICT (uid, period, start_date) DO
+UPDATE
+SET
+    end_date = excluded.end_date,
+    content = excluded.content,
+    diary_count = excluded.diary_count,
+    updated = CURRENT_TIMESTAMP
+RETURNING
+    *;
+
+-- name: CountAISummariesForPeriod :one
+SELECT
+    COUNT(*)
+FROM
+    ai_summary
+WHERE
+    uid = ?
+    AND period = ?
+    AND start_date = ?;
+
+-- name: ListAISummaries :many
+SELECT
+    *
+FROM
+    ai_summary
+WHERE
+    uid = ?
+ORDER BY
+    start_date DESC,
+    period
+LIMIT
+    ?;
+
+-- name: CreateChatSession :one
+INSERT INTO
+    chat_session (uid, title)
+VALUES
+    (?, ?)
+RETURNING
+    *;
+
+-- name: GetChatSession :one
+SELECT
+    *
+FROM
+    chat_session
+WHERE
+    id = ?
+    AND uid = ?;
+
+-- name: ListChatSessions :many
+SELECT
+    *
+FROM
+    chat_session
+WHERE
+    uid = ?
+ORDER BY
+    updated DESC
+LIMIT
+    ?;
+
+-- name: TouchChatSession :exec
+UPDATE chat_session
+SET
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND uid = ?;
+
+-- name: DeleteChatSession :execrows
+DELETE FROM chat_session
+WHERE
+    id = ?
+    AND uid = ?;
+
+-- name: CreateChatMessage :one
+INSERT INTO
+    chat_message (session_id, uid, role, content, citations)
+VALUES
+    (?, ?, ?, ?, ?)
+RETURNING
+    *;
+
+-- name: ListChatMessages :many
+SELECT
+    *
+FROM
+    chat_message
+WHERE
+    session_id = ?
+    AND uid = ?
+ORDER BY
+    created,
+    rowid;
+
+-- name: ListRecentChatDiaries :many
+SELECT
+    date,
+    content,
+    mood
+FROM
+    diary
+WHERE
+    uid = ?
+    AND content != ''
+ORDER BY
+    date DESC
+LIMIT
+    ?;
+
+-- name: GetDiaryEmbedding :one
+SELECT
+    *
+FROM
+    diary_embedding
+WHERE
+    diary_id = ?;
+
+-- name: UpsertDiaryEmbedding :exec
+INSERT INTO
+    diary_embedding (
+        diary_id,
+        uid,
+        date,
+        model,
+        content_hash,
+        vector
+    )
+VALUES
+    (?, ?, ?, ?, ?, ?) ON CONFLICT (diary_id) DO
+UPDATE
+SET
+    date = excluded.date,
+    model = excluded.model,
+    content_hash = excluded.content_hash,
+    vector = excluded.vector,
+    updated = datetime ('now');
+
+-- name: DeleteDiaryEmbedding :exec
+DELETE FROM diary_embedding
+WHERE
+    diary_id = ?;
+
+-- name: ListDiaryEmbeddings :many
+SELECT
+    diary.date,
+    diary.content,
+    diary.mood,
+    diary_embedding.vector
+FROM
+    diary_embedding
+    JOIN diary ON diary.id = diary_embedding.diary_id
+WHERE
+    diary_embedding.uid = ?
+    AND diary_embedding.model = ?
+    AND diary.content != ''
+ORDER BY
+    diary.date DESC;
+
+-- name: ListDiariesMissingEmbedding :many
+SELECT
+    diary.uid,
+    diary.date
+FROM
+    diary
+    LEFT JOIN diary_embedding ON diary_embedding.diary_id = diary.id
+    AND diary_embedding.model = sqlc.arg(model)
+WHERE
+    diary.content != ''
+    AND (
+        diary_embedding.diary_id IS NULL
+        OR diary_embedding.updated < diary.updated
+    )
+ORDER BY
+    diary.updated DESC
+LIMIT
+    ?;
+
+-- name: IncrementSafetyEvent :exec
+INSERT INTO
+    safety_event (day, source, level, count)
+VALUES
+    (?, ?, ?, 1) ON CONFLICT (day, source, level) DO
+UPDATE
+SET
+    count = count + 1;
+
+-- name: ListSafetyEvents :many
+SELECT
+    *
+FROM
+    safety_event
+WHERE
+    day >= ?
+ORDER BY
+    day DESC,
+    source,
+    level;
+
+-- name: GetAICache :one
+SELECT
+    value,
+    expires
+FROM
+    ai_cache
+WHERE
+    key = ?
+    AND expires > CAST(sqlc.arg(now) AS TEXT);
+
+-- name: UpsertAICache :exec
+INSERT INTO
+    ai_cache (key, value, expires)
+VALUES
+    (?, ?, ?) ON CONFLICT (key) DO
+UPDATE
+SET
+    value = excluded.value,
+    expires = excluded.expires;
+
+-- name: PruneAICache :execrows
+DELETE FROM ai_cache
+WHERE
+    expires <= CAST(sqlc.arg(now) AS TEXT);
+
+-- name: UpdateVoiceSettings :exec
+UPDATE user_setting
+SET
+    keep_voice_audio = ?,
+    updated = datetime ('now')
+WHERE
+    uid = ?;
+
+-- name: CreateVoiceNote :one
+INSERT INTO
+    voice_note (uid, date, mime_type, keep_audio)
+VALUES
+    (?, ?, ?, ?) RETURNING *;
+
+-- name: GetVoiceNote :one
+SELECT
+    *
+FROM
+    voice_note
+WHERE
+    id = ?
+    AND uid = ?;
+
+-- name: GetVoiceNoteByID :one
+SELECT
+    *
+FROM
+    voice_note
+WHERE
+    id = ?;
+
+-- name: AppendVoiceNoteChunk :execrows
+UPDATE voice_note
+SET
+    size = CAST(sqlc.arg(new_size) AS INTEGER),
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = sqlc.arg(id)
+    AND uid = sqlc.arg(uid)
+    AND status = 'uploading'
+    AND size = CAST(sqlc.arg(offset) AS INTEGER);
+
+-- name: QueueVoiceNote :execrows
+UPDATE voice_note
+SET
+    status = 'queued',
+    progress = 0,
+    mime_type = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?
+    AND uid = ?
+    AND status = 'uploading'
+    AND size > 0;
+
+-- name: UpdateVoiceNoteProgress :exec
+UPDATE voice_note
+SET
+    status = ?,
+    progress = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?;
+
+-- name: FinishVoiceNote :exec
+UPDATE voice_note
+SET
+    status = 'done',
+    progress = 100,
+    transcript = ?,
+    object = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?;
+
+-- name: FailVoiceNote :exec
+UPDATE voice_note
+SET
+    status = 'failed',
+    error = ?,
+    updated = CURRENT_TIMESTAMP
+WHERE
+    id = ?;
+
+-- name: ListVoiceNotes :many
+SELECT
+    *
+FROM
+    voice_note
+WHERE
+    uid = ?
+    AND date = ?
+    AND status = 'done'
+    AND (
+        object != ''
+        OR transcript != ''
+    )
+ORDER BY
+    created;
+
+-- name: DeleteVoiceNote :execrows
+DELETE FROM voice_note
+WHERE
+    id = ?
+    AND uid = ?;
+
+-- name: ListPrunableVoiceNotes :many
+SELECT
+    id,
+    object
+FROM
+    voice_note
+WHERE
+    updated < CAST(sqlc.arg(before) AS TEXT)
+    AND (
+        status != 'done'
+        OR (
+            object = ''
+            AND transcript = ''
+        )
+    );
+
+-- name: DeleteVoiceNoteByID :exec
+DELETE FROM voice_note
+WHERE
+    id = ?;
+
+-- name: CreateImageQuarantine :exec
+INSERT INTO
+    image_quarantine (uid, source, object, reason, size)
+VALUES
+    (?, ?, ?, ?, ?);
+
+-- name: ListExpiredImageQuarantine :many
+SELECT
+    id,
+    object
+FROM
+    image_quarantine
+WHERE
+    created < CAST(sqlc.arg(before) AS TEXT);
+
+-- name: DeleteImageQuarantine :exec
+DELETE FROM image_quarantine
+WHERE
+    id = ?;
+
+-- name: UpdateAppLockForceLogout :exec
+UPDATE user_setting
+SET
+    app_lock_force_logout = ?,
+    updated = datetime ('now')
+WHERE
+    uid = ?;
+
+-- name: RevokeUserSessions :exec
+UPDATE user_setting
+SET
+    sessions_revoked_at = ?,
+    updated = datetime ('now')
+WHERE
+    uid = ?;
+
+-- name: GetAppLockAttempt :one
+SELECT
+    *
+FROM
+    app_lock_attempt
+WHERE
+    uid = ?;
+
+-- name: DeleteStaleAppLockAttempt :exec
+DELETE FROM app_lock_attempt
+WHERE
+    uid = ?
+    AND updated < CAST(sqlc.arg(before) AS TEXT);
+
+-- name: CreateAppLockAttempt :exec
+INSERT INTO
+    app_lock_attempt (uid)
+VALUES
+    (?) ON CONFLICT (uid) DO NOTHING;
+
+-- name: ReserveAppLockAttempt :one
+UPDATE app_lock_attempt
+SET
+    failures = failures + 1,
+    updated = datetime ('now')
+WHERE
+    uid = sqlc.arg(uid)
+    AND locked_until <= CAST(sqlc.arg(now) AS TEXT)
+    AND failures < sqlc.arg(max_failures)
+RETURNING
+    *;
+
+-- name: LockAppLockAttempt :execrows
+UPDATE app_lock_attempt
+SET
+    failures = 0,
+    lockouts = lockouts + 1,
+    locked_until = CAST(sqlc.arg(locked_until) AS TEXT),
+    updated = datetime ('now')
+WHERE
+    uid = sqlc.arg(uid)
+    AND failures >= sqlc.arg(max_failures);
+
+-- name: DeleteAppLockAttempt :exec
+DELETE FROM app_lock_attempt
+WHERE
+    uid = ?;
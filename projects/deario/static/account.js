@@ -0,0 +1,55 @@
+;(function () {
+  const CONFIRM_TEXT = "탈퇴"
+
+  document.addEventListener("click", handleDeleteAccountClick)
+
+  function csrfToken() {
+    return (
+      document.cookie
+        .split("; ")
+        .find((value) => value.startsWith("_csrf="))
+        ?.split("=")[1] || ""
+    )
+  }
+
+  async function handleDeleteAccountClick(event) {
+    const trigger = event.target.closest?.("[data-deario-delete-account]")
+    if (!trigger) return
+
+    event.preventDefault()
+    const confirmInput = document.querySelector("[data-deario-delete-confirm]")
+    if (confirmInput?.value.trim() !== CONFIRM_TEXT) {
+      showError(`확인란에 "${CONFIRM_TEXT}"를 입력해주세요.`)
+      return
+    }
+
+    trigger.disabled = true
+    try {
+      // 오래 열어 둔 세션으로 탈퇴할 수 없도록 서버는 방금 로그인한 토큰만 받는다.
+      const token = await window.reauthenticateUser()
+      const response = await fetch("/account/delete", {
+        method: "POST",
+        headers: {
+          "Content-Type": "application/json",
+          "X-CSRF-Token": csrfToken(),
+        },
+        credentials: "same-origin",
+        body: JSON.stringify({ token }),
+      })
+      const data = await response.json().catch(() => ({}))
+      if (!response.ok) {
+        showError(data.message || "계정 삭제 요청에 실패했습니다.")
+        return
+      }
+
+      const purgeAfter = new Date(data.purgeAfter).toLocaleString()
+      await showInfo(`${purgeAfter}에 계정이 삭제됩니다. 그 전에 다시 로그인하면 취소할 수 있어요.`, 3000)
+      window.logoutUser?.()
+    } catch (err) {
+      console.error("계정 삭제 요청 실패:", err)
+      showError("다시 로그인하지 못했습니다. 잠시 후 다시 시도해주세요.")
+    } finally {
+      trigger.disabled = false
+    }
+  }
+})()
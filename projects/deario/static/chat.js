@@ -0,0 +1,29 @@
+;(function () {
+  const EXAMPLE_SELECTOR = "[data-deario-chat-example]"
+  const THREAD = "#chat-thread"
+
+  document.addEventListener("click", (event) => {
+    const example = event.target.closest?.(EXAMPLE_SELECTOR)
+    if (!example) return
+
+    const textarea = document.querySelector(`${THREAD} textarea[name='question']`)
+    if (!textarea) return
+    textarea.value = example.dataset.dearioChatExample || ""
+    textarea.focus()
+  })
+
+  document.addEventListener("keydown", (event) => {
+    const textarea = event.target.closest?.(`${THREAD} textarea[name='question']`)
+    if (!textarea || event.key !== "Enter" || !(event.ctrlKey || event.metaKey)) return
+
+    event.preventDefault()
+    textarea.form?.requestSubmit()
+  })
+
+  document.addEventListener("htmx:afterSwap", (event) => {
+    const path = event.detail.pathInfo?.requestPath || ""
+    if (!path.startsWith("/chat/thread") && path !== "/chat/messages") return
+
+    document.querySelector(`${THREAD} textarea[name='question']`)?.scrollIntoView({ block: "end" })
+  })
+})()
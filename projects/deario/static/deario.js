@@ -4,6 +4,12 @@
   const EMPTY_AI_FEEDBACK_HTML =
     '<div id="ai-feedback-markdown"></div><textarea name="ai-feedback" hidden></textarea>'
 
+  const DIARY_MERGE_DIALOG = "#diary-merge-dialog"
+  const SAFETY_DIALOG = "#safety-dialog"
+  const DRAFT_INTERVAL_MS = 15000
+
+  let diaryConflict = false
+
   const htmxAfterHandlers = {
     "ai-feedback:show": showAiFeedback,
     "ai-feedback:saved": onAiFeedbackSaved,
@@ -18,7 +24,11 @@
   document.addEventListener("htmx:afterRequest", handleDiarySaveRequest)
   document.addEventListener("htmx:afterOnLoad", handleHtmxAfterOnLoad)
   document.addEventListener("htmx:afterSwap", handleHtmxAfterSwap)
+  document.addEventListener("htmx:beforeRequest", handleDiaryBeforeRequest)
+  document.addEventListener("htmx:beforeSwap", handleDiaryConflictSwap)
+  document.addEventListener("deario-safety", handleSafetyEvent)
   document.addEventListener("DOMContentLoaded", initSwipeNavigation)
+  setInterval(saveDiaryDraft, DRAFT_INTERVAL_MS)
 
   function registerSaveStore() {
     Alpine.store("save", {
@@ -63,10 +73,65 @@
   function handleDiarySaveRequest(event) {
     const source = htmxSourceElement(event)
     if (
+      !(source instanceof Element) ||
+      !source.matches("[data-deario-diary-input]") ||
+      !isHtmxSuccessful(event)
+    ) {
+      return
+    }
+
+    const version = event.detail.xhr?.getResponseHeader("X-Diary-Version")
+    const versionInput = document.querySelector("[data-deario-diary-version]")
+    if (version !== null && version !== undefined && versionInput) {
+      versionInput.value = version
+    }
+    setDiarySaved(true)
+  }
+
+  function handleDiaryBeforeRequest(event) {
+    // 충돌을 해결하기 전에는 자동 저장을 멈추고 초안으로만 보관한다.
+    const source = htmxSourceElement(event)
+    if (
+      diaryConflict &&
       source instanceof Element &&
       source.matches("[data-deario-diary-input]")
     ) {
-      setDiarySaved(true)
+      event.preventDefault()
+    }
+  }
+
+  function handleDiaryConflictSwap(event) {
+    if (event.detail.xhr?.status !== 409) return
+
+    diaryConflict = true
+    event.detail.shouldSwap = true
+    event.detail.isError = false
+  }
+
+  // 자동 저장마다 뜨지 않도록 날짜마다 한 번만 안내한다. 글쓰기는 막지 않는다.
+  function handleSafetyEvent(event) {
+    const key = `deario-safety:${event.detail?.date || ""}`
+    if (sessionStorage.getItem(key)) return
+
+    sessionStorage.setItem(key, "1")
+    showModal(SAFETY_DIALOG)
+  }
+
+  async function saveDiaryDraft() {
+    if (!window.Alpine || Alpine.store("save")?.isOk !== false) return
+
+    const form = document.getElementById("diary")
+    const versionInput = form?.querySelector("[data-deario-diary-version]")
+    if (!form || !versionInput) return
+
+    try {
+      await fetch("/diary/draft", {
+        method: "POST",
+        headers: { "X-CSRF-Token": getCookie("_csrf") },
+        body: new FormData(form),
+      })
+    } catch (err) {
+      console.error(err)
     }
   }
 
@@ -93,6 +158,9 @@
 
   function handleHtmxAfterSwap(event) {
     const target = event.detail.target
+    if (target?.id === "diary-merge-content") {
+      showModal(DIARY_MERGE_DIALOG)
+    }
     if (
       target?.id === "diary-image-content" ||
       target?.querySelector?.("#diary-image-content")
@@ -134,10 +202,6 @@
   }
 
   function showAiFeedback() {
-    const mdEl = document.getElementById("ai-feedback-markdown")
-    if (mdEl && mdEl.textContent && window.marked) {
-      mdEl.innerHTML = marked.parse(mdEl.textContent)
-    }
     showModal(AI_FEEDBACK_DIALOG)
   }
 
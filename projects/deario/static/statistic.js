@@ -1,62 +1,119 @@
 ;(function () {
   const moodSets = [
-    { label: "😁", key: "mood1", color: "#ffeb3b" },
-    { label: "🙂", key: "mood2", color: "#8bc34a" },
-    { label: "😐", key: "mood3", color: "#03a9f4" },
-    { label: "😣", key: "mood4", color: "#ff9800" },
-    { label: "😭", key: "mood5", color: "#f44336" },
+    { label: "😁", color: "#ffeb3b" },
+    { label: "🙂", color: "#8bc34a" },
+    { label: "😐", color: "#03a9f4" },
+    { label: "😣", color: "#ff9800" },
+    { label: "😭", color: "#f44336" },
   ]
 
+  const charts = {}
+
   document.addEventListener("DOMContentLoaded", initStatisticPage)
 
-  async function initStatisticPage() {
-    const countChart = document.getElementById("countChart")
-    const moodChart = document.getElementById("moodStackChart")
-    if (!countChart || !moodChart) return
+  function initStatisticPage() {
+    const tabs = document.getElementById("stats-range")
+    if (!tabs) return
+
+    tabs.querySelectorAll("[data-range]").forEach((tab) => {
+      tab.addEventListener("click", () => {
+        tabs.querySelectorAll("[data-range]").forEach((el) => el.classList.remove("active"))
+        tab.classList.add("active")
+        loadStatistic(tab.dataset.range)
+      })
+    })
+
+    const active = tabs.querySelector("[data-range].active")
+    loadStatistic(active ? active.dataset.range : "year")
+  }
+
+  async function loadStatistic(range) {
+    const exportLink = document.getElementById("stats-export")
+    if (exportLink) exportLink.href = `/statistic/export.csv?range=${range}`
 
     try {
-      const data = await fetchStatisticData()
-      const labels = data.months.map(formatMonth)
+      const data = await fetchStatisticData(range)
+      const labels = data.buckets.map((b) => (data.daily ? formatDay(b.key) : formatMonth(b.key)))
 
-      renderDiaryCountChart(countChart, labels, data)
-      renderMoodStackChart(moodChart, labels, data)
+      renderSummary(data.summary)
+      renderChart("countChart", {
+        type: "bar",
+        data: {
+          labels,
+          datasets: [
+            {
+              label: "작성 수",
+              data: data.buckets.map((b) => b.diaryCount),
+              backgroundColor: "rgba(33,150,243,0.5)",
+            },
+          ],
+        },
+      })
+      renderChart("wordChart", {
+        type: "line",
+        data: {
+          labels,
+          datasets: [
+            {
+              label: "단어 수",
+              data: data.buckets.map((b) => b.wordCount),
+              borderColor: "#7e57c2",
+              backgroundColor: "rgba(126,87,194,0.2)",
+              fill: true,
+            },
+          ],
+        },
+      })
+      renderChart("moodStackChart", stackedMoodChart(labels, data.buckets))
+      renderChart("hourChart", {
+        type: "bar",
+        data: {
+          labels: data.hourDistribution.map((_, hour) => `${hour}시`),
+          datasets: [
+            {
+              label: "작성 수",
+              data: data.hourDistribution,
+              backgroundColor: "rgba(0,150,136,0.5)",
+            },
+          ],
+        },
+      })
+      renderChart(
+        "weekdayMoodChart",
+        stackedMoodChart(
+          data.moodByWeekday.map((w) => w.weekday),
+          data.moodByWeekday,
+        ),
+      )
     } catch (err) {
       console.error(err)
     }
   }
 
-  async function fetchStatisticData() {
-    const response = await fetch("/statistic/data")
+  async function fetchStatisticData(range) {
+    const response = await fetch(`/statistic/data?range=${encodeURIComponent(range)}`)
     if (!response.ok) {
       throw new Error("통계 데이터를 불러오지 못했습니다.")
     }
     return response.json()
   }
 
-  function renderDiaryCountChart(canvas, labels, data) {
-    new Chart(canvas.getContext("2d"), {
-      type: "bar",
-      data: {
-        labels,
-        datasets: [
-          {
-            label: "작성 수",
-            data: data.diaryCount,
-            backgroundColor: "rgba(33,150,243,0.5)",
-          },
-        ],
-      },
-    })
+  function renderChart(id, config) {
+    const canvas = document.getElementById(id)
+    if (!canvas) return
+
+    if (charts[id]) charts[id].destroy()
+    charts[id] = new Chart(canvas.getContext("2d"), config)
   }
 
-  function renderMoodStackChart(canvas, labels, data) {
-    new Chart(canvas.getContext("2d"), {
+  function stackedMoodChart(labels, rows) {
+    return {
       type: "bar",
       data: {
         labels,
-        datasets: moodSets.map((mood) => ({
+        datasets: moodSets.map((mood, i) => ({
           label: mood.label,
-          data: data[mood.key],
+          data: rows.map((row) => row.moods[i]),
           backgroundColor: mood.color,
         })),
       },
@@ -66,7 +123,31 @@
           y: { stacked: true, beginAtZero: true },
         },
       },
-    })
+    }
+  }
+
+  function renderSummary(summary) {
+    const container = document.getElementById("stats-summary")
+    if (!container) return
+
+    const items = [
+      ["auto_stories", `${summary.totalDiaries}편`],
+      ["text_fields", `${summary.totalWords.toLocaleString()}단어`],
+      ["short_text", `평균 ${Math.round(summary.avgWords)}단어`],
+      ["straighten", `평균 ${Math.round(summary.avgLength)}자`],
+    ]
+    container.replaceChildren(
+      ...items.map(([icon, text]) => {
+        const chip = document.createElement("div")
+        chip.className = "chip"
+        const i = document.createElement("i")
+        i.textContent = icon
+        const span = document.createElement("span")
+        span.textContent = text
+        chip.append(i, span)
+        return chip
+      }),
+    )
   }
 
   function formatMonth(monthStr) {
@@ -74,4 +155,10 @@
     const month = parseInt(monthStr.substring(4, 6), 10)
     return `${year}년 ${month}월`
   }
+
+  function formatDay(dateStr) {
+    const month = parseInt(dateStr.substring(4, 6), 10)
+    const day = parseInt(dateStr.substring(6, 8), 10)
+    return `${month}/${day}`
+  }
 })()
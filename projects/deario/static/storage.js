@@ -9,6 +9,7 @@ import {
   const PREVIEW_SELECTOR = "[data-deario-image-preview]"
   const UPLOAD_SELECTOR = "[data-deario-image-upload]"
   const PENDING_PREVIEW_SELECTOR = '[data-role="pending-preview"]'
+  const MAX_IMAGE_SIZE = 10 * 1024 * 1024
 
   document.addEventListener("change", handlePreviewChange)
   document.addEventListener("click", handleUploadClick)
@@ -57,6 +58,11 @@ import {
     }
 
     const file = input.files[0]
+    // 서버에서도 검사하지만 큰 파일은 올리기 전에 막는다.
+    if (file.size > MAX_IMAGE_SIZE) {
+      showError("이미지는 10MB까지 올릴 수 있습니다.")
+      return
+    }
     const uid = auth.currentUser.uid
     const uploadYMD = ymdKST(new Date())
     const diaryYMD = ymdKST(date)
@@ -0,0 +1,165 @@
+;(function () {
+  const INPUT_SELECTOR = "[data-deario-diary-input]"
+  const VERSION_SELECTOR = "[data-deario-diary-version]"
+  const OUTBOX_KEY = "deario:sync:outbox"
+  const TOKEN_KEY = "deario:sync:token"
+  const BATCH_SIZE = 100
+
+  let syncing = false
+
+  document.addEventListener("htmx:beforeRequest", queueWhenOffline)
+  document.addEventListener("htmx:sendError", queueFailedSave)
+  window.addEventListener("online", sync)
+  document.addEventListener("DOMContentLoaded", sync)
+
+  function queueWhenOffline(event) {
+    if (navigator.onLine || !isDiarySave(event)) return
+
+    event.preventDefault()
+    enqueue(event.detail.elt)
+  }
+
+  function queueFailedSave(event) {
+    if (isDiarySave(event)) enqueue(event.detail.elt)
+  }
+
+  function isDiarySave(event) {
+    const elt = event.detail?.elt
+    return elt instanceof Element && elt.matches(INPUT_SELECTOR)
+  }
+
+  function enqueue(textarea) {
+    const form = textarea.closest("form")
+    const date = form?.querySelector("input[name=date]")?.value
+    if (!date) return
+
+    const version = form.querySelector(VERSION_SELECTOR)?.value
+    const outbox = readOutbox()
+    const first = !outbox[date]
+    outbox[date] = {
+      date,
+      content: textarea.value,
+      deleted: textarea.value.trim() === "",
+      baseVersion: version === undefined ? null : Number(version),
+      clientUpdated: new Date().toISOString(),
+    }
+    writeOutbox(outbox)
+
+    if (first) showInfo("오프라인 상태예요. 연결되면 자동으로 저장할게요.")
+  }
+
+  async function sync() {
+    if (syncing || !navigator.onLine) return
+    syncing = true
+    try {
+      await push()
+      await pull()
+    } catch (err) {
+      console.error(err)
+    } finally {
+      syncing = false
+    }
+  }
+
+  async function push() {
+    const pending = Object.values(readOutbox())
+    for (let i = 0; i < pending.length; i += BATCH_SIZE) {
+      const batch = pending.slice(i, i + BATCH_SIZE)
+      const response = await fetch("/diary/sync", {
+        method: "POST",
+        headers: {
+          "Content-Type": "application/json",
+          "X-CSRF-Token": getCookie("_csrf"),
+        },
+        body: JSON.stringify({ changes: batch }),
+      })
+      if (!response.ok) throw new Error(`동기화 실패: ${response.status}`)
+
+      const { results } = await response.json()
+      const sent = Object.fromEntries(batch.map((change) => [change.date, change]))
+      const outbox = readOutbox()
+      for (const result of results) {
+        // 보내는 동안 다시 고친 날짜는 다음 동기화에서 올린다.
+        if (outbox[result.date]?.clientUpdated === sent[result.date]?.clientUpdated) {
+          delete outbox[result.date]
+        }
+        applyResult(result)
+      }
+      writeOutbox(outbox)
+    }
+  }
+
+  function applyResult(result) {
+    const form = currentForm(result.date)
+    if (!form) return
+
+    if (result.status === "conflict") {
+      htmx.ajax("GET", `/diary/draft/merge?date=${result.date}`, {
+        target: "#diary-merge-content",
+        swap: "innerHTML",
+      })
+      return
+    }
+    if (result.status === "applied" || result.status === "unchanged") {
+      setVersion(form, result.version)
+      Alpine.store("save")?.ok()
+    }
+  }
+
+  async function pull() {
+    let token = localStorage.getItem(TOKEN_KEY) || ""
+    for (;;) {
+      const response = await fetch(`/diary/sync?since=${encodeURIComponent(token)}`)
+      if (response.status === 410 && token) {
+        token = ""
+        continue
+      }
+      if (!response.ok) throw new Error(`동기화 실패: ${response.status}`)
+
+      const page = await response.json()
+      page.changes.forEach(applyChange)
+      token = page.token
+      localStorage.setItem(TOKEN_KEY, token)
+      if (!page.hasMore) return
+    }
+  }
+
+  function applyChange(change) {
+    const form = currentForm(change.date)
+    if (!form || readOutbox()[change.date]) return
+    // 저장하지 않은 편집이 있으면 덮어쓰지 않고 다음 저장의 버전 검사에 맡긴다.
+    if (Alpine.store("save")?.isOk === false) return
+
+    const versionInput = form.querySelector(VERSION_SELECTOR)
+    if (versionInput && Number(versionInput.value) === change.version) return
+
+    form.querySelector(INPUT_SELECTOR).value = change.deleted ? "" : change.content
+    setVersion(form, change.deleted ? 0 : change.version)
+  }
+
+  function currentForm(date) {
+    const form = document.getElementById("diary")
+    return form?.querySelector("input[name=date]")?.value === date ? form : null
+  }
+
+  function setVersion(form, version) {
+    const versionInput = form.querySelector(VERSION_SELECTOR)
+    if (versionInput) versionInput.value = version
+  }
+
+  function readOutbox() {
+    try {
+      return JSON.parse(localStorage.getItem(OUTBOX_KEY)) || {}
+    } catch {
+      return {}
+    }
+  }
+
+  function writeOutbox(outbox) {
+    if (Object.keys(outbox).length === 0) {
+      localStorage.removeItem(OUTBOX_KEY)
+      return
+    }
+    localStorage.setItem(OUTBOX_KEY, JSON.stringify(outbox))
+  }
+})()
@@ -0,0 +1,74 @@
+;(function () {
+  const INPUT_SELECTOR = "[data-deario-diary-input]"
+  const SUGGESTIONS_SELECTOR = "[data-deario-tag-suggestions]"
+  const TAG_BEFORE_CURSOR = /(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_]{1,30})$/u
+
+  let timer
+  let lastPrefix = ""
+
+  document.addEventListener("input", (event) => {
+    const textarea = event.target.closest?.(INPUT_SELECTOR)
+    if (!textarea) return
+
+    clearTimeout(timer)
+    timer = setTimeout(() => suggest(textarea), 200)
+  })
+
+  async function suggest(textarea) {
+    const container = document.querySelector(SUGGESTIONS_SELECTOR)
+    if (!container) return
+
+    const prefix = currentTagPrefix(textarea)
+    if (!prefix) {
+      lastPrefix = ""
+      container.replaceChildren()
+      return
+    }
+    if (prefix === lastPrefix) return
+    lastPrefix = prefix
+
+    try {
+      const response = await fetch(`/diary/tags/suggest?q=${encodeURIComponent(prefix)}`)
+      if (!response.ok) return
+
+      const names = await response.json()
+      if (prefix !== lastPrefix) return
+      renderSuggestions(container, textarea, names.filter((name) => name !== prefix.toLowerCase()))
+    } catch (err) {
+      console.error(err)
+    }
+  }
+
+  function currentTagPrefix(textarea) {
+    const before = textarea.value.slice(0, textarea.selectionStart)
+    return before.match(TAG_BEFORE_CURSOR)?.[1] || ""
+  }
+
+  function renderSuggestions(container, textarea, names) {
+    container.replaceChildren(
+      ...names.map((name) => {
+        const chip = document.createElement("button")
+        chip.type = "button"
+        chip.className = "chip small"
+        chip.textContent = `#${name}`
+        chip.addEventListener("click", () => applySuggestion(container, textarea, name))
+        return chip
+      }),
+    )
+  }
+
+  function applySuggestion(container, textarea, name) {
+    const cursor = textarea.selectionStart
+    const prefix = currentTagPrefix(textarea)
+    const start = cursor - prefix.length
+    const insert = `${name} `
+
+    textarea.value = textarea.value.slice(0, start) + insert + textarea.value.slice(cursor)
+    textarea.focus()
+    textarea.setSelectionRange(start + insert.length, start + insert.length)
+    textarea.dispatchEvent(new Event("input", { bubbles: true }))
+
+    lastPrefix = ""
+    container.replaceChildren()
+  }
+})()
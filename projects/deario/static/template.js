@@ -0,0 +1,27 @@
+;(function () {
+  const TEMPLATE_SELECTOR = "[data-deario-template]"
+  const DIALOG = "#diary-template-dialog"
+
+  document.addEventListener("click", (event) => {
+    const item = event.target.closest?.(TEMPLATE_SELECTOR)
+    if (!item) return
+
+    applyTemplate(item.dataset.dearioTemplate || "")
+  })
+
+  function applyTemplate(content) {
+    const textarea = document.querySelector("#diary textarea[name='content']")
+    if (!textarea || !content) return
+
+    if (textarea.value.trim()) {
+      if (!confirm("지금 쓴 내용 뒤에 템플릿을 붙일까요?")) return
+      textarea.value += "\n\n" + content
+    } else {
+      textarea.value = content
+    }
+
+    textarea.dispatchEvent(new Event("input", { bubbles: true }))
+    closeModal(DIALOG)
+    textarea.focus()
+  }
+})()
@@ -1,9 +1,13 @@
 ;(function () {
   const TOGGLE_SELECTOR = "[data-deario-voice-toggle]"
+  // 녹음을 5초 조각으로 나눠 올린다. 긴 녹음도 중간에 끊기면 받은 곳부터 이어서 올린다.
+  const TIMESLICE = 5000
+  const POLL_INTERVAL = 1500
+  const MAX_RETRY = 5
 
   let recorder
-  let chunks = []
   let micStream
+  let upload
 
   document.addEventListener("click", (event) => {
     const button = event.target.closest?.(TOGGLE_SELECTOR)
@@ -14,7 +18,11 @@
 
   async function toggleRecord(button) {
     if (recorder?.state === "recording") {
-      stopRecording(button)
+      stopRecording()
+      return
+    }
+    if (upload) {
+      showInfo("이전 녹음을 처리하고 있어요")
       return
     }
 
@@ -22,73 +30,262 @@
       micStream = await navigator.mediaDevices.getUserMedia({
         audio: { echoCancellation: true, noiseSuppression: true },
       })
-      startRecording(button)
     } catch {
       showInfo("마이크 접근 실패")
+      return
+    }
+
+    try {
+      await startRecording(button)
+    } catch {
+      releaseMic()
+      upload = null
+      showInfo("녹음을 시작하지 못했습니다")
     }
   }
 
-  function startRecording(button) {
-    recorder = new MediaRecorder(micStream, { mimeType: "audio/webm" })
-    chunks = []
+  async function startRecording(button) {
+    const mimeType = pickMimeType()
+    recorder = mimeType
+      ? new MediaRecorder(micStream, { mimeType })
+      : createWavRecorder(micStream)
+
+    const date =
+      document.querySelector("#diary input[name='date']")?.value || ""
+    const body = new URLSearchParams({
+      date,
+      mime_type: recorder.mimeType || mimeType,
+    })
+    const status = await request("/diary/voice/uploads", {
+      method: "POST",
+      body,
+    })
+
+    upload = {
+      id: status.id,
+      button,
+      offset: 0,
+      queue: [],
+      pumping: null,
+      stopped: false,
+    }
 
     recorder.ondataavailable = (event) => {
-      if (event.data.size > 0) {
-        chunks.push(event.data)
+      if (event.data.size > 0 && upload) {
+        upload.queue.push(event.data)
+        pump()
       }
     }
-    recorder.onstop = transcribeRecording
+    recorder.onstop = finishUpload
 
-    recorder.start()
-    setButtonRecording(button, true)
+    recorder.start(TIMESLICE)
+    setButton(button, "recording")
   }
 
-  function stopRecording(button) {
+  function stopRecording() {
     try {
       recorder.stop()
     } finally {
       releaseMic()
-      setButtonRecording(button, false)
+      setButton(upload?.button, "uploading")
     }
   }
 
-  async function transcribeRecording() {
-    try {
-      recorder?.requestData?.()
-    } catch {}
-
-    const formData = new FormData()
-    formData.append(
-      "audio",
-      new Blob(chunks, { type: "audio/webm" }),
-      "recording.webm",
-    )
+  // pump는 쌓인 조각을 순서대로 올린다. 실패하면 서버가 받은 위치를 다시 확인하고 이어서 올린다.
+  function pump() {
+    if (upload.pumping) return upload.pumping
 
+    upload.pumping = (async () => {
+      let retry = 0
+      while (upload.queue.length > 0) {
+        const blob = upload.queue[0]
+        try {
+          const status = await request(`/diary/voice/uploads/${upload.id}`, {
+            method: "PATCH",
+            headers: {
+              "Upload-Offset": String(upload.offset),
+              "Content-Type": "application/octet-stream",
+            },
+            body: blob,
+          })
+          upload.offset = status.offset
+          upload.queue.shift()
+          retry = 0
+        } catch (err) {
+          if (++retry > MAX_RETRY) throw err
+          await sleep(500 * retry)
+          await resync()
+        }
+      }
+    })().finally(() => {
+      if (upload) upload.pumping = null
+    })
+
+    return upload.pumping
+  }
+
+  // resync는 서버가 받은 크기를 기준으로 이미 올라간 조각을 큐에서 덜어낸다.
+  async function resync() {
+    const status = await request(`/diary/voice/uploads/${upload.id}`)
+    let received = status.offset - upload.offset
+    while (
+      received > 0 &&
+      upload.queue.length > 0 &&
+      upload.queue[0].size <= received
+    ) {
+      received -= upload.queue.shift().size
+    }
+    if (received > 0 && upload.queue.length > 0) {
+      upload.queue[0] = upload.queue[0].slice(received)
+    }
+    upload.offset = status.offset
+  }
+
+  async function finishUpload() {
+    const current = upload
+    recorder = null
     try {
-      const response = await fetch("/diary/transcribe", {
+      await pump()
+      await request(`/diary/voice/uploads/${current.id}/complete`, {
         method: "POST",
-        headers: { "X-CSRF-Token": getCookie("_csrf") },
-        body: formData,
       })
+      setButton(current.button, "transcribing", 0)
+      await waitTranscript(current)
+    } catch (err) {
+      showInfo(err?.message || "음성 인식 실패")
+    } finally {
+      setButton(current.button, "idle")
+      upload = null
+    }
+  }
 
-      if (!response.ok) {
-        showInfo("음성 인식 실패")
+  async function waitTranscript(current) {
+    for (;;) {
+      await sleep(POLL_INTERVAL)
+      const status = await request(`/diary/voice/uploads/${current.id}`)
+      if (status.status === "done") {
+        appendTranscribedText(status.text)
+        htmx.trigger(document.body, "deario-voice-updated")
         return
       }
+      if (status.status === "failed") {
+        throw new Error(status.error || "음성 인식 실패")
+      }
+      setButton(current.button, "transcribing", status.progress)
+    }
+  }
 
-      appendTranscribedText(await response.text())
-    } catch {
-      showInfo("음성 인식 실패")
-    } finally {
-      releaseMic()
-      recorder = null
-      chunks = []
+  async function request(url, options = {}) {
+    const response = await fetch(url, {
+      ...options,
+      headers: { "X-CSRF-Token": getCookie("_csrf"), ...options.headers },
+    })
+    const data = await response.json().catch(() => ({}))
+    if (!response.ok) {
+      throw new Error(data.message || "음성 업로드 실패")
     }
+    return data
+  }
+
+  function pickMimeType() {
+    // Safari의 audio/mp4는 조각으로 나눠 녹음하면 이어 붙인 파일이 깨지는 경우가 있어 wav로 녹음한다.
+    const types = [
+      "audio/webm;codecs=opus",
+      "audio/ogg;codecs=opus",
+      "audio/webm",
+    ]
+    if (typeof MediaRecorder === "undefined") return ""
+    return (
+      types.find((type) => MediaRecorder.isTypeSupported?.(type)) || ""
+    )
+  }
+
+  // createWavRecorder는 MediaRecorder가 없거나 쓸 만한 형식이 없는 브라우저에서
+  // 마이크 PCM을 16kHz 모노 wav로 조각내 MediaRecorder처럼 내보낸다.
+  // 녹음이 끝나기 전에 헤더를 보내므로 크기 자리는 비워 두고 서버가 변환하면서 채운다.
+  function createWavRecorder(stream) {
+    const RATE = 16000
+    const context = new (window.AudioContext || window.webkitAudioContext)()
+    const source = context.createMediaStreamSource(stream)
+    const processor = context.createScriptProcessor(4096, 1, 1)
+    const ratio = context.sampleRate / RATE
+    let pending = [wavHeader(RATE)]
+    let carry = 0
+    let timer
+
+    const wav = {
+      mimeType: "audio/wav",
+      state: "inactive",
+      stream,
+      ondataavailable: null,
+      onstop: null,
+      start(timeslice) {
+        source.connect(processor)
+        processor.connect(context.destination)
+        timer = setInterval(flush, timeslice)
+        wav.state = "recording"
+      },
+      stop() {
+        clearInterval(timer)
+        processor.disconnect()
+        source.disconnect()
+        context.close()
+        wav.state = "inactive"
+        flush()
+        wav.onstop?.()
+      },
+    }
+
+    processor.onaudioprocess = (event) => {
+      const input = event.inputBuffer.getChannelData(0)
+      const count = Math.floor((input.length - carry) / ratio)
+      const out = new Int16Array(count)
+      for (let i = 0; i < count; i++) {
+        // 비율만큼의 샘플을 평균 내 16kHz로 줄인다.
+        const start = Math.max(0, Math.floor(carry + i * ratio))
+        const end = Math.min(input.length, Math.floor(carry + (i + 1) * ratio))
+        let sum = 0
+        for (let j = start; j < end; j++) sum += input[j]
+        const v = Math.max(-1, Math.min(1, sum / Math.max(1, end - start)))
+        out[i] = v < 0 ? v * 0x8000 : v * 0x7fff
+      }
+      carry = carry + count * ratio - input.length
+      pending.push(out)
+    }
+
+    function flush() {
+      if (pending.length === 0) return
+      const data = new Blob(pending, { type: "audio/wav" })
+      pending = []
+      wav.ondataavailable?.({ data })
+    }
+
+    return wav
+  }
+
+  function wavHeader(rate) {
+    const view = new DataView(new ArrayBuffer(44))
+    const text = (offset, value) =>
+      [...value].forEach((c, i) => view.setUint8(offset + i, c.charCodeAt(0)))
+    text(0, "RIFF")
+    view.setUint32(4, 0xffffffff, true)
+    text(8, "WAVE")
+    text(12, "fmt ")
+    view.setUint32(16, 16, true)
+    view.setUint16(20, 1, true)
+    view.setUint16(22, 1, true)
+    view.setUint32(24, rate, true)
+    view.setUint32(28, rate * 2, true)
+    view.setUint16(32, 2, true)
+    view.setUint16(34, 16, true)
+    text(36, "data")
+    view.setUint32(40, 0xffffffff, true)
+    return view.buffer
   }
 
   function appendTranscribedText(text) {
     const textarea = document.querySelector("#diary textarea[name='content']")
-    if (!textarea) return
+    if (!textarea || !text) return
 
     textarea.value += (textarea.value ? "\n" : "") + text
     textarea.dispatchEvent(new Event("input", { bubbles: true }))
@@ -100,8 +297,27 @@
     micStream = null
   }
 
-  function setButtonRecording(button, recording) {
-    button.classList.toggle("primary", recording)
-    button.innerHTML = recording ? "<i>stop</i>" : "<i>mic</i>"
+  function setButton(button, state, progress) {
+    if (!button) return
+
+    button.classList.toggle("primary", state === "recording")
+    button.disabled = state === "uploading" || state === "transcribing"
+    switch (state) {
+      case "recording":
+        button.innerHTML = "<i>stop</i>"
+        break
+      case "uploading":
+        button.innerHTML = "<i>upload</i>"
+        break
+      case "transcribing":
+        button.innerHTML = `<i>hourglass_top</i><span>${progress ?? 0}%</span>`
+        break
+      default:
+        button.innerHTML = "<i>mic</i>"
+    }
+  }
+
+  function sleep(ms) {
+    return new Promise((resolve) => setTimeout(resolve, ms))
   }
 })()
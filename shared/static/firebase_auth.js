@@ -1,7 +1,13 @@
 import { initializeApp } from "https://www.gstatic.com/firebasejs/11.0.2/firebase-app.js"
 import {
+  EmailAuthProvider,
+  FacebookAuthProvider,
+  GithubAuthProvider,
+  GoogleAuthProvider,
   getAuth,
   onAuthStateChanged,
+  reauthenticateWithCredential,
+  reauthenticateWithPopup,
   signOut,
 } from "https://www.gstatic.com/firebasejs/11.0.2/firebase-auth.js"
 import {
@@ -35,6 +41,41 @@ window.logoutUser = async function () {
   }
 }
 
+// 계정 삭제처럼 민감한 작업 전에 다시 로그인하고 새 ID 토큰을 반환한다.
+// 팝업으로 다시 인증할 수 없는 로그인 방식은 토큰만 새로 받으며, 서버가 로그인 시각을 확인한다.
+window.reauthenticateUser = async function () {
+  await auth.authStateReady()
+  const user = auth.currentUser
+  if (!user) {
+    throw new Error("로그인이 필요합니다.")
+  }
+
+  switch (user.providerData[0]?.providerId) {
+    case "google.com":
+      await reauthenticateWithPopup(user, new GoogleAuthProvider())
+      break
+    case "github.com":
+      await reauthenticateWithPopup(user, new GithubAuthProvider())
+      break
+    case "facebook.com":
+      await reauthenticateWithPopup(user, new FacebookAuthProvider())
+      break
+    case "password": {
+      const password = prompt("비밀번호를 입력해주세요.")
+      if (!password) {
+        throw new Error("비밀번호 입력이 취소되었습니다.")
+      }
+      await reauthenticateWithCredential(
+        user,
+        EmailAuthProvider.credential(user.email, password),
+      )
+      break
+    }
+  }
+
+  return user.getIdToken(true)
+}
+
 // 2. onAuthStateChanged로 로그인 / 로그아웃 감지
 onAuthStateChanged(auth, (user) => {
   if (user) {